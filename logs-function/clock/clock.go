@@ -0,0 +1,98 @@
+// Package clock provides the forwarder's notion of the current time. The time source can be replaced
+// in tests, and the skew against New Relic observed from Log API responses can be corrected for.
+package clock
+
+import (
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// Clock skew handling modes.
+const (
+	SkewModeOff     = "off"     // SkewModeOff ignores clock skew.
+	SkewModeWarn    = "warn"    // SkewModeWarn reports significant skew as a batch attribute.
+	SkewModeCorrect = "correct" // SkewModeCorrect reports significant skew and shifts timestamps generated by the forwarder.
+)
+
+// Clock is a source of the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// holder gives every stored Clock the same concrete type, as required by atomic.Value.
+type holder struct{ clock Clock }
+
+var (
+	current atomic.Value // current holds the holder of the Clock in use.
+	skew    atomic.Int64 // skew holds the last observed New Relic minus local time, in nanoseconds.
+)
+
+func init() {
+	current.Store(holder{clock: systemClock{}})
+}
+
+// Set replaces the time source and returns a function restoring the previous one.
+func Set(c Clock) (restore func()) {
+	previous := current.Swap(holder{clock: c})
+	return func() { current.Store(previous) }
+}
+
+// Now returns the current time, corrected by the observed skew when correction is enabled and the skew is significant.
+func Now() time.Time {
+	now := current.Load().(holder).clock.Now()
+	if Mode() == SkewModeCorrect {
+		if s, significant := SignificantSkew(); significant {
+			return now.Add(s)
+		}
+	}
+	return now
+}
+
+// ObserveServerDate records the skew between a New Relic response Date header and the local time the response was received.
+func ObserveServerDate(serverDate time.Time, receivedAt time.Time) {
+	skew.Store(int64(serverDate.Sub(receivedAt)))
+}
+
+// Skew returns the last observed skew (New Relic minus local time).
+func Skew() time.Duration {
+	return time.Duration(skew.Load())
+}
+
+// SignificantSkew returns the observed skew and whether it exceeds the configured threshold.
+// Skew is never significant when the mode is off.
+func SignificantSkew() (time.Duration, bool) {
+	if Mode() == SkewModeOff {
+		return 0, false
+	}
+	s := Skew()
+	threshold := time.Duration(threshold()) * time.Second
+	return s, s > threshold || s < -threshold
+}
+
+// Mode returns the configured clock skew handling mode.
+func Mode() string {
+	switch mode := os.Getenv(common.ClockSkewMode); mode {
+	case SkewModeWarn, SkewModeCorrect:
+		return mode
+	default:
+		return SkewModeOff
+	}
+}
+
+// threshold returns the configured significant skew in seconds.
+func threshold() int {
+	if envThreshold := os.Getenv(common.ClockSkewThreshold); envThreshold != "" {
+		if parsed, err := strconv.Atoi(envThreshold); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return common.DefaultClockSkewThreshold
+}
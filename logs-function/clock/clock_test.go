@@ -0,0 +1,56 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedClock is a Clock always returning the same time.
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+// TestNowWithSkew tests skew detection and correction in each mode.
+func TestNowWithSkew(t *testing.T) {
+	local := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	restore := Set(fixedClock{now: local})
+	defer restore()
+	defer ObserveServerDate(local, local)
+
+	tests := []struct {
+		name                string
+		mode                string
+		serverOffset        time.Duration
+		expectedNow         time.Time
+		expectedSignificant bool
+	}{
+		{"off ignores skew", "", 5 * time.Minute, local, false},
+		{"warn reports but does not correct", SkewModeWarn, 5 * time.Minute, local, true},
+		{"correct shifts the time", SkewModeCorrect, 5 * time.Minute, local.Add(5 * time.Minute), true},
+		{"correct ignores skew under threshold", SkewModeCorrect, 30 * time.Second, local, false},
+		{"negative skew", SkewModeCorrect, -2 * time.Minute, local.Add(-2 * time.Minute), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(common.ClockSkewMode, tt.mode)
+			ObserveServerDate(local.Add(tt.serverOffset), local)
+
+			assert.Equal(t, tt.expectedNow, Now())
+			_, significant := SignificantSkew()
+			assert.Equal(t, tt.expectedSignificant, significant)
+		})
+	}
+}
+
+// TestSet tests replacing and restoring the time source.
+func TestSet(t *testing.T) {
+	fixed := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	restore := Set(fixedClock{now: fixed})
+	assert.Equal(t, fixed, Now())
+	restore()
+	assert.WithinDuration(t, time.Now(), Now(), time.Second)
+}
@@ -0,0 +1,58 @@
+// Command configschema renders the function configuration schema from the documented environment variable
+// constants of the given package directories. Every string constant holding an environment variable name
+// whose doc comment calls it an environment variable becomes a setting, described by that comment. The
+// default is read from the constant of the same name prefixed with Default, and the allowed values from a
+// constant group documented as "Modes of <VARIABLE>".
+//
+// Usage:
+//
+//	go run ./cmd/configschema -out common/config_schema.json common logger
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	out := flag.String("out", "", "path the schema is written to; standard output when empty")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "configschema: at least one package directory is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := render(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "configschema: %v\n", err)
+		os.Exit(1)
+	}
+	if *out == "" {
+		_, _ = os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "configschema: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// render returns the indented JSON schema of the settings declared in the package directories.
+func render(dirs []string) ([]byte, error) {
+	settings, err := collect(dirs)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(Schema{Settings: settings}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema is the machine-readable description of the function configuration.
+type Schema struct {
+	Settings []Setting `json:"settings"`
+}
+
+// Setting describes a single environment variable read by the function.
+type Setting struct {
+	Name        string      `json:"name"`              // Name is the environment variable name.
+	Constant    string      `json:"constant"`          // Constant is the Go constant holding the name.
+	Type        string      `json:"type"`              // Type is one of string, boolean, integer, number or duration.
+	Default     interface{} `json:"default,omitempty"` // Default is the value used when the variable is unset.
+	Allowed     []string    `json:"allowed,omitempty"` // Allowed lists the accepted values, when restricted.
+	Description string      `json:"description"`       // Description is the doc comment of the constant.
+}
+
+// envNamePattern matches environment variable names.
+var envNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+
+// durationUnits are the time package constants recognized in default values.
+var durationUnits = map[string]time.Duration{
+	"Nanosecond":  time.Nanosecond,
+	"Microsecond": time.Microsecond,
+	"Millisecond": time.Millisecond,
+	"Second":      time.Second,
+	"Minute":      time.Minute,
+	"Hour":        time.Hour,
+}
+
+// declaration is a constant declared in one of the scanned packages.
+type declaration struct {
+	pkg   string
+	name  string
+	expr  ast.Expr
+	doc   string
+	group string
+}
+
+// collect returns the settings declared in the package directories, sorted by name. A variable declared
+// in more than one package is described by the first directory declaring it.
+func collect(dirs []string) ([]Setting, error) {
+	seen := make(map[string]bool)
+	var settings []Setting
+	for _, dir := range dirs {
+		declarations, err := parseConstants(dir)
+		if err != nil {
+			return nil, err
+		}
+		pkgSettings, err := settingsOf(declarations)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+		for _, setting := range pkgSettings {
+			if !seen[setting.Name] {
+				seen[setting.Name] = true
+				settings = append(settings, setting)
+			}
+		}
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Name < settings[j].Name })
+	return settings, nil
+}
+
+// parseConstants returns the constants declared in the non-test Go files of dir.
+func parseConstants(dir string) ([]declaration, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	fset := token.NewFileSet()
+	var declarations []declaration
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			group := ""
+			if gen.Lparen.IsValid() {
+				group = docText(gen.Doc)
+			}
+			for _, spec := range gen.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				doc := docText(valueSpec.Doc)
+				if doc == "" {
+					doc = docText(valueSpec.Comment)
+				}
+				if doc == "" && !gen.Lparen.IsValid() {
+					doc = docText(gen.Doc)
+				}
+				for i, name := range valueSpec.Names {
+					if i < len(valueSpec.Values) {
+						declarations = append(declarations, declaration{
+							pkg: file.Name.Name, name: name.Name, expr: valueSpec.Values[i], doc: doc, group: group,
+						})
+					}
+				}
+			}
+		}
+	}
+	return declarations, nil
+}
+
+// settingsOf returns the settings among the constants of a package.
+func settingsOf(declarations []declaration) ([]Setting, error) {
+	byName := make(map[string]declaration, len(declarations))
+	for _, decl := range declarations {
+		byName[decl.name] = decl
+	}
+	eval := evaluator{constants: byName}
+
+	allowed := make(map[string][]string)
+	for _, decl := range declarations {
+		target, ok := strings.CutPrefix(decl.group, "Modes of ")
+		if !ok {
+			continue
+		}
+		value, _, err := eval.eval(decl.expr)
+		if err != nil {
+			return nil, err
+		}
+		if value.Kind() == constant.String {
+			target = strings.TrimSuffix(target, ".")
+			allowed[target] = append(allowed[target], constant.StringVal(value))
+		}
+	}
+
+	var settings []Setting
+	for _, decl := range declarations {
+		value, _, err := eval.eval(decl.expr)
+		if err != nil || value.Kind() != constant.String {
+			continue
+		}
+		name := constant.StringVal(value)
+		if !envNamePattern.MatchString(name) || !strings.Contains(decl.doc, "environment variable") {
+			continue
+		}
+
+		setting := Setting{
+			Name:        name,
+			Constant:    decl.pkg + "." + decl.name,
+			Type:        "string",
+			Allowed:     allowed[name],
+			Description: decl.doc,
+		}
+		if setting.Allowed == nil {
+			setting.Allowed = allowed[decl.name]
+		}
+		if strings.Contains(decl.doc, `"true"`) {
+			setting.Type = "boolean"
+		}
+		if def, ok := byName["Default"+decl.name]; ok {
+			value, duration, err := eval.eval(def.expr)
+			if err != nil {
+				return nil, fmt.Errorf("default of %s: %w", decl.name, err)
+			}
+			setting.Type, setting.Default = defaultValue(value, duration, strings.HasSuffix(name, "_SECONDS"))
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// defaultValue returns the setting type and JSON value of a default. Durations of variables given in
+// seconds are rendered as numbers of seconds.
+func defaultValue(value constant.Value, duration bool, seconds bool) (string, interface{}) {
+	switch {
+	case duration && seconds:
+		nanos, _ := constant.Int64Val(constant.ToInt(value))
+		return "number", time.Duration(nanos).Seconds()
+	case duration:
+		nanos, _ := constant.Int64Val(constant.ToInt(value))
+		return "duration", time.Duration(nanos).String()
+	case value.Kind() == constant.Int:
+		n, _ := constant.Int64Val(value)
+		return "integer", n
+	case value.Kind() == constant.Float:
+		f, _ := constant.Float64Val(value)
+		return "number", f
+	case value.Kind() == constant.Bool:
+		return "boolean", constant.BoolVal(value)
+	default:
+		return "string", constant.StringVal(value)
+	}
+}
+
+// evaluator evaluates constant expressions referring to the constants of one package and the time units.
+type evaluator struct {
+	constants map[string]declaration
+}
+
+// eval returns the value of the expression and whether it is a time.Duration.
+func (e evaluator) eval(expr ast.Expr) (constant.Value, bool, error) {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		return constant.MakeFromLiteral(expr.Value, expr.Kind, 0), false, nil
+	case *ast.ParenExpr:
+		return e.eval(expr.X)
+	case *ast.Ident:
+		if decl, ok := e.constants[expr.Name]; ok {
+			return e.eval(decl.expr)
+		}
+		return nil, false, fmt.Errorf("unknown constant %s", expr.Name)
+	case *ast.SelectorExpr:
+		if pkg, ok := expr.X.(*ast.Ident); ok && pkg.Name == "time" {
+			if unit, ok := durationUnits[expr.Sel.Name]; ok {
+				return constant.MakeInt64(int64(unit)), true, nil
+			}
+		}
+		return nil, false, fmt.Errorf("unsupported selector %s", expr.Sel.Name)
+	case *ast.CallExpr:
+		if fun, ok := expr.Fun.(*ast.SelectorExpr); ok && fun.Sel.Name == "Duration" && len(expr.Args) == 1 {
+			value, _, err := e.eval(expr.Args[0])
+			return value, true, err
+		}
+		return nil, false, fmt.Errorf("unsupported call")
+	case *ast.BinaryExpr:
+		x, xDuration, err := e.eval(expr.X)
+		if err != nil {
+			return nil, false, err
+		}
+		y, yDuration, err := e.eval(expr.Y)
+		if err != nil {
+			return nil, false, err
+		}
+		duration := xDuration || yDuration
+		switch expr.Op {
+		case token.SHL, token.SHR:
+			shift, _ := constant.Uint64Val(y)
+			return constant.Shift(x, expr.Op, uint(shift)), duration, nil
+		case token.QUO:
+			if x.Kind() == constant.Int && y.Kind() == constant.Int {
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y), duration, nil
+			}
+		}
+		return constant.BinaryOp(x, expr.Op, y), duration, nil
+	}
+	return nil, false, fmt.Errorf("unsupported expression %T", expr)
+}
+
+// docText returns the comment as a single line.
+func docText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
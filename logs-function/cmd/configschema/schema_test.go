@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSchemaUpToDate tests that the committed schema matches the documented constants; run go generate ./common when it fails.
+func TestSchemaUpToDate(t *testing.T) {
+	rendered, err := render([]string{"../../common", "../../logger"})
+	assert.NoError(t, err)
+
+	committed, err := os.ReadFile("../../common/config_schema.json")
+	assert.NoError(t, err)
+	assert.Equal(t, string(committed), string(rendered))
+}
+
+// TestCollect tests how settings, defaults and allowed values are read from constants.
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	source := `package sample
+
+import "time"
+
+// Mode is the name of the environment variable selecting the mode.
+const Mode = "SAMPLE_MODE"
+
+// Modes of SAMPLE_MODE.
+const (
+	ModeFast = "fast"
+	ModeSafe = "safe"
+)
+
+// Timeout is the name of the environment variable for the request timeout in seconds.
+const Timeout = "SAMPLE_TIMEOUT_SECONDS"
+
+// DefaultTimeout is the default request timeout.
+const DefaultTimeout = 2 * time.Minute
+
+// Size is the name of the environment variable for the batch size.
+const Size = "SAMPLE_SIZE"
+
+// DefaultSize is the default batch size.
+const DefaultSize = 4 << 10
+
+// Enabled is the name of the environment variable that, when "true", enables the sample.
+const Enabled = "SAMPLE_ENABLED"
+
+// Attribute is a record attribute, not a setting.
+const Attribute = "sample.attribute"
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "sample.go"), []byte(source), 0o644))
+
+	settings, err := collect([]string{dir})
+	assert.NoError(t, err)
+
+	byName := make(map[string]Setting, len(settings))
+	for _, setting := range settings {
+		byName[setting.Name] = setting
+	}
+	assert.Len(t, settings, 4)
+	assert.Equal(t, []string{"fast", "safe"}, byName["SAMPLE_MODE"].Allowed)
+	assert.Equal(t, "number", byName["SAMPLE_TIMEOUT_SECONDS"].Type)
+	assert.Equal(t, 120.0, byName["SAMPLE_TIMEOUT_SECONDS"].Default)
+	assert.Equal(t, int64(4096), byName["SAMPLE_SIZE"].Default)
+	assert.Equal(t, "boolean", byName["SAMPLE_ENABLED"].Type)
+	assert.Equal(t, "sample.Mode", byName["SAMPLE_MODE"].Constant)
+}
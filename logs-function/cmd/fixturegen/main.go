@@ -0,0 +1,118 @@
+// Command fixturegen turns an archived Connector Hub payload into a sanitized test fixture.
+// It scrubs OCIDs and PII deterministically, writes the fixture to the output directory and
+// renders a skeleton golden file with the batches the pipeline currently produces for it.
+//
+// Usage:
+//
+//	go run ./cmd/fixturegen -in payload.json -out testdata -name audit_events
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/loggroup"
+	"github.com/newrelic/oci-log-integration/logs-function/unmarshal"
+)
+
+func main() {
+	in := flag.String("in", "", "path of the archived Connector Hub payload")
+	out := flag.String("out", "testdata", "directory the fixture and golden file are written to")
+	name := flag.String("name", "", "fixture name; defaults to the input file name")
+	flag.Parse()
+
+	if *in == "" {
+		fmt.Fprintln(os.Stderr, "fixturegen: -in is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *name == "" {
+		*name = trimExtension(filepath.Base(*in))
+	}
+
+	if err := run(*in, *out, *name); err != nil {
+		fmt.Fprintf(os.Stderr, "fixturegen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run writes <name>.json with the scrubbed payload and <name>.golden.json with the resulting batches.
+func run(in string, out string, name string) error {
+	payload, err := os.ReadFile(in)
+	if err != nil {
+		return fmt.Errorf("failed to read payload: %w", err)
+	}
+
+	fixture, err := indent(scrub(payload))
+	if err != nil {
+		return fmt.Errorf("payload is not valid JSON: %w", err)
+	}
+
+	golden, err := render(fixture)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	fixturePath := filepath.Join(out, name+".json")
+	goldenPath := filepath.Join(out, name+".golden.json")
+	if err := os.WriteFile(fixturePath, fixture, 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	if err := os.WriteFile(goldenPath, golden, 0o644); err != nil {
+		return fmt.Errorf("failed to write golden file: %w", err)
+	}
+
+	fmt.Printf("wrote %s and %s\n", fixturePath, goldenPath)
+	return nil
+}
+
+// render runs the fixture through the pipeline and returns the produced batches as indented JSON.
+func render(fixture []byte) ([]byte, error) {
+	event := unmarshal.Event{}
+	if err := event.Unmarshal(context.Background(), bytes.NewReader(fixture)); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fixture: %w", err)
+	}
+
+	channel := make(chan common.DetailedLogsBatch, common.MessageChannelSize)
+	var batches []common.DetailedLogsBatch
+	done := make(chan struct{})
+	go func() {
+		for batch := range channel {
+			batches = append(batches, batch)
+		}
+		close(done)
+	}()
+	loggroup.ProcessLogs(event.OCILoggingEvent, channel)
+	close(channel)
+	<-done
+
+	golden, err := json.MarshalIndent(batches, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode golden output: %w", err)
+	}
+	return append(golden, '\n'), nil
+}
+
+// indent validates and pretty-prints a JSON document.
+func indent(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, payload, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// trimExtension removes the file extension from name.
+func trimExtension(name string) string {
+	return name[:len(name)-len(filepath.Ext(name))]
+}
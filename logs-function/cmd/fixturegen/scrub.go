@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+)
+
+var (
+	// ocidPattern matches OCIDs: ocid1.<resource type>.<realm>.[region].<unique id>.
+	ocidPattern = regexp.MustCompile(`ocid1\.([a-z0-9]+)\.([a-z0-9]+)\.([a-z0-9-]*)\.([a-z0-9]+)`)
+	// ipv4Pattern matches dotted IPv4 addresses.
+	ipv4Pattern = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	// emailPattern matches e-mail addresses.
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// scrub replaces OCIDs, IPv4 addresses and e-mail addresses in the payload with deterministic
+// stand-ins derived from a hash of the original value. The same input always yields the same
+// fixture, and values that were equal stay equal so correlations in the payload survive.
+func scrub(payload []byte) []byte {
+	payload = ocidPattern.ReplaceAllFunc(payload, func(match []byte) []byte {
+		parts := ocidPattern.FindSubmatch(match)
+		return []byte(fmt.Sprintf("ocid1.%s.%s.%s.%s", parts[1], parts[2], parts[3], digest(match)[:32]))
+	})
+	payload = emailPattern.ReplaceAllFunc(payload, func(match []byte) []byte {
+		return []byte(fmt.Sprintf("user-%s@example.com", digest(match)[:8]))
+	})
+	payload = ipv4Pattern.ReplaceAllFunc(payload, func(match []byte) []byte {
+		sum := sha256.Sum256(match)
+		return []byte(fmt.Sprintf("10.%d.%d.%d", sum[0], sum[1], sum[2]))
+	})
+	return payload
+}
+
+// digest returns the hex-encoded SHA-256 of value.
+func digest(value []byte) string {
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestScrub tests that OCIDs and PII are replaced deterministically.
+func TestScrub(t *testing.T) {
+	payload := []byte(`[{"compartmentId":"ocid1.compartment.oc1..aaaaaaaabbbb","instance":"ocid1.instance.oc1.iad.anuwcljt1234",` +
+		`"again":"ocid1.compartment.oc1..aaaaaaaabbbb","ip":"192.168.1.20","user":"alice@corp.com"}]`)
+
+	scrubbed := string(scrub(payload))
+
+	assert.NotContains(t, scrubbed, "aaaaaaaabbbb")
+	assert.NotContains(t, scrubbed, "anuwcljt1234")
+	assert.NotContains(t, scrubbed, "192.168.1.20")
+	assert.NotContains(t, scrubbed, "alice@corp.com")
+	assert.Regexp(t, `"compartmentId":"ocid1\.compartment\.oc1\.\.[0-9a-f]{32}"`, scrubbed)
+	assert.Regexp(t, `"instance":"ocid1\.instance\.oc1\.iad\.[0-9a-f]{32}"`, scrubbed)
+	assert.Regexp(t, `"ip":"10\.\d+\.\d+\.\d+"`, scrubbed)
+	assert.Regexp(t, `"user":"user-[0-9a-f]{8}@example\.com"`, scrubbed)
+
+	assert.Equal(t, scrubbed, string(scrub(payload)), "Scrubbing should be deterministic")
+	assert.Equal(t, 2, strings.Count(scrubbed, ocidPattern.FindAllString(scrubbed, 1)[0]), "Equal OCIDs should stay equal")
+}
+
+// TestRun tests that the fixture and golden files are written.
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "payload.json")
+	assert.NoError(t, os.WriteFile(in, []byte(`[{"message":"hello","oracle":{"compartmentid":"ocid1.compartment.oc1..abc"}}]`), 0o644))
+
+	assert.NoError(t, run(in, filepath.Join(dir, "testdata"), "hello"))
+
+	fixture, err := os.ReadFile(filepath.Join(dir, "testdata", "hello.json"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(fixture), "..abc")
+
+	golden, err := os.ReadFile(filepath.Join(dir, "testdata", "hello.golden.json"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(golden), `"instrumentation.provider": "oci"`)
+	assert.Contains(t, string(golden), `"message": "hello"`)
+}
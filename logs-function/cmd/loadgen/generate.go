@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// words is the vocabulary synthetic messages are built from.
+var words = []string{"request", "completed", "user", "timeout", "GET", "POST", "/api/v1/orders", "status=200",
+	"status=503", "latency_ms=12", "cache", "miss", "retrying", "connection", "reset", "ok"}
+
+// generatePayload builds a Connector Hub payload of count custom-log records whose messages are
+// messageSize bytes long. The same seed always yields the same payload.
+func generatePayload(count int, messageSize int, seed int64) ([]byte, error) {
+	rng := rand.New(rand.NewSource(seed))
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	records := make([]map[string]interface{}, count)
+	for i := range records {
+		timestamp := base.Add(time.Duration(i) * time.Millisecond).Format(time.RFC3339Nano)
+		records[i] = map[string]interface{}{
+			"data": map[string]interface{}{
+				"message": syntheticMessage(rng, messageSize),
+			},
+			"id": fmt.Sprintf("loadgen-%08d", i),
+			"oracle": map[string]interface{}{
+				"compartmentid": "ocid1.compartment.oc1..loadgen",
+				"ingestedtime":  timestamp,
+				"loggroupid":    "ocid1.loggroup.oc1.iad.loadgen",
+				"logid":         "ocid1.log.oc1.iad.loadgen",
+				"tenantid":      "ocid1.tenancy.oc1..loadgen",
+			},
+			"source":      "loadgen",
+			"specversion": "1.0",
+			"time":        timestamp,
+			"type":        "com.oraclecloud.logging.custom.loadgen",
+		}
+	}
+	return json.Marshal(records)
+}
+
+// syntheticMessage returns a message of exactly size bytes made of random words.
+func syntheticMessage(rng *rand.Rand, size int) string {
+	var b strings.Builder
+	b.Grow(size + 16)
+	for b.Len() < size {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(words[rng.Intn(len(words))])
+	}
+	return b.String()[:size]
+}
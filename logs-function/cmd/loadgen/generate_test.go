@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/unmarshal"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGeneratePayload tests that payloads are deterministic and have the requested shape.
+func TestGeneratePayload(t *testing.T) {
+	payload, err := generatePayload(3, 100, 7)
+	assert.NoError(t, err)
+
+	again, err := generatePayload(3, 100, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, again)
+
+	event := unmarshal.Event{}
+	assert.NoError(t, event.Unmarshal(context.Background(), bytes.NewReader(payload)))
+	assert.Len(t, event.OCILoggingEvent, 3)
+	for _, record := range event.OCILoggingEvent {
+		assert.Len(t, record["data"].(map[string]interface{})["message"], 100)
+	}
+}
+
+// TestRun tests that every generated record reaches the mock sink.
+func TestRun(t *testing.T) {
+	payload, err := generatePayload(500, 256, 1)
+	assert.NoError(t, err)
+
+	result := run(payload, 3, 2, 0)
+	assert.Equal(t, int64(1500), result.records)
+	assert.Equal(t, int64(3), result.batches)
+	assert.Contains(t, result.String(), "records forwarded:  1500")
+}
@@ -0,0 +1,188 @@
+// Command loadgen measures forwarder throughput on synthetic Connector Hub payloads.
+// It generates payloads of the requested record count and message size, runs them through the
+// same unmarshal, transform, batching and worker pool stages as the function, posting to a mock
+// sink instead of New Relic, and reports throughput and memory usage.
+//
+// Usage:
+//
+//	go run ./cmd/loadgen -records 5000 -size 1024 -invocations 20 -workers 6 -latency 50ms
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/loggroup"
+	"github.com/newrelic/oci-log-integration/logs-function/unmarshal"
+	"github.com/newrelic/oci-log-integration/logs-function/util"
+)
+
+func main() {
+	records := flag.Int("records", 1000, "number of records per payload")
+	size := flag.Int("size", 512, "message size in bytes of each record")
+	invocations := flag.Int("invocations", 10, "number of payloads processed")
+	workers := flag.Int("workers", util.MaxWorkers(), "maximum number of concurrent workers")
+	latency := flag.Duration("latency", 0, "simulated Log API latency per batch")
+	seed := flag.Int64("seed", 1, "seed of the synthetic payload")
+	flag.Parse()
+
+	if *records <= 0 || *size <= 0 || *invocations <= 0 || *workers <= 0 {
+		fmt.Fprintln(os.Stderr, "loadgen: -records, -size, -invocations and -workers must be positive")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	payload, err := generatePayload(*records, *size, *seed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: failed to generate payload: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Print(run(payload, *invocations, *workers, *latency))
+}
+
+// mockSink counts the batches it receives, optionally sleeping to simulate Log API latency.
+type mockSink struct {
+	latency time.Duration
+	batches atomic.Int64
+	entries atomic.Int64
+}
+
+// CreateLogEntry records the batch.
+func (s *mockSink) CreateLogEntry(logEntry interface{}) error {
+	if s.latency > 0 {
+		time.Sleep(s.latency)
+	}
+	s.batches.Add(1)
+	for _, detailedLog := range logEntry.(common.DetailedLogsBatch) {
+		s.entries.Add(int64(len(detailedLog.Entries)))
+	}
+	return nil
+}
+
+// report summarizes a load run.
+type report struct {
+	invocations  int
+	payloadBytes int
+	records      int64
+	batches      int64
+	elapsed      time.Duration
+	slowest      time.Duration
+	totalAlloc   uint64
+	peakHeap     uint64
+	numGC        uint32
+}
+
+// String formats the report for the terminal.
+func (r report) String() string {
+	seconds := r.elapsed.Seconds()
+	megabytes := float64(r.payloadBytes*r.invocations) / (1024 * 1024)
+	return fmt.Sprintf("invocations:        %d\n"+
+		"payload size:       %d bytes\n"+
+		"records forwarded:  %d\n"+
+		"batches posted:     %d\n"+
+		"elapsed:            %v\n"+
+		"slowest invocation: %v\n"+
+		"throughput:         %.0f records/s, %.2f MB/s\n"+
+		"allocated:          %.2f MB (%.0f bytes/record)\n"+
+		"peak heap:          %.2f MB\n"+
+		"gc cycles:          %d\n",
+		r.invocations, r.payloadBytes, r.records, r.batches, r.elapsed.Round(time.Millisecond),
+		r.slowest.Round(time.Millisecond), float64(r.records)/seconds, megabytes/seconds,
+		float64(r.totalAlloc)/(1024*1024), float64(r.totalAlloc)/float64(max(r.records, 1)),
+		float64(r.peakHeap)/(1024*1024), r.numGC)
+}
+
+// run processes the payload invocations times through a warm worker pool, one invocation after another
+// as a single function container would, and reports the results.
+func run(payload []byte, invocations int, workers int, latency time.Duration) report {
+	sink := &mockSink{latency: latency}
+	pool := util.NewWorkerPool(workers, common.MessageChannelSize)
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	var peakHeap atomic.Uint64
+	stopSampling := sampleHeap(&peakHeap)
+
+	var slowest time.Duration
+	start := time.Now()
+	for i := 0; i < invocations; i++ {
+		invocationStart := time.Now()
+		invoke(payload, pool, sink, workers)
+		slowest = max(slowest, time.Since(invocationStart))
+	}
+	elapsed := time.Since(start)
+	stopSampling()
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	return report{
+		invocations:  invocations,
+		payloadBytes: len(payload),
+		records:      sink.entries.Load(),
+		batches:      sink.batches.Load(),
+		elapsed:      elapsed,
+		slowest:      slowest,
+		totalAlloc:   after.TotalAlloc - before.TotalAlloc,
+		peakHeap:     max(peakHeap.Load(), after.HeapInuse),
+		numGC:        after.NumGC - before.NumGC,
+	}
+}
+
+// invoke mirrors the function handler for a single payload.
+func invoke(payload []byte, pool *util.WorkerPool, sink util.NewRelicClientAPI, maxWorkers int) {
+	event := unmarshal.Event{}
+	if err := event.Unmarshal(context.Background(), bytes.NewReader(payload)); err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: failed to unmarshal payload: %v\n", err)
+		os.Exit(1)
+	}
+
+	channel := make(chan common.DetailedLogsBatch, common.MessageChannelSize)
+	dispatched := make(chan struct{})
+	workers := util.WorkerCount(event.PayloadSize, len(event.OCILoggingEvent), maxWorkers)
+	go func() {
+		pool.Dispatch(context.Background(), channel, sink, workers)
+		close(dispatched)
+	}()
+
+	loggroup.ProcessLogs(event.OCILoggingEvent, channel)
+	close(channel)
+	<-dispatched
+}
+
+// sampleHeap records the peak heap in use every few milliseconds until the returned function is called.
+func sampleHeap(peak *atomic.Uint64) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		var stats runtime.MemStats
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				runtime.ReadMemStats(&stats)
+				if stats.HeapInuse > peak.Load() {
+					peak.Store(stats.HeapInuse)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
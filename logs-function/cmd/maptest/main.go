@@ -0,0 +1,72 @@
+// Command maptest checks a mapping and routing configuration against sample records and their expected outputs,
+// so that declarative configurations can be tested in CI without deploying the function. The configuration is a
+// file of NAME=value settings, as accepted by profilediff, applied on top of the current environment. The cases
+// file is a JSON array of cases, each holding a record as delivered by Connector Hub and the expected result:
+//
+//	[
+//	  {
+//	    "name": "warn level is converted",
+//	    "record": {"type": "com.oraclecloud.logging.custom.app", "data": {"message": "hi", "level": "WARN"}},
+//	    "expect": {"level": "warn", "severity.number": 13, "data": {"message": "hi"}},
+//	    "account": "security"
+//	  },
+//	  {"name": "probes are dropped", "record": {"data": {"message": "GET /healthz"}}, "dropped": true}
+//	]
+//
+// Expected fields are matched against the sent record merged with the attributes of its batch; nested objects
+// match when their expected fields do, and a null value expects the field to be absent. The command prints one
+// line per case and exits with status 1 when a case fails.
+//
+// Usage:
+//
+//	go run ./cmd/maptest -config mapping.env -cases cases.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/newrelic/oci-log-integration/logs-function/config"
+)
+
+func main() {
+	configFile := flag.String("config", "", "path of the settings file under test; the current environment when empty")
+	casesFile := flag.String("cases", "", "path of the JSON test cases")
+	flag.Parse()
+
+	if *casesFile == "" {
+		fmt.Fprintln(os.Stderr, "maptest: -cases is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var settings map[string]string
+	if *configFile != "" {
+		var err error
+		if settings, err = config.LoadEnvFile(*configFile); err != nil {
+			fmt.Fprintf(os.Stderr, "maptest: failed to read config: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	data, err := os.ReadFile(*casesFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "maptest: failed to read cases: %v\n", err)
+		os.Exit(2)
+	}
+	var cases []Case
+	if err := json.Unmarshal(data, &cases); err != nil {
+		fmt.Fprintf(os.Stderr, "maptest: invalid cases: %v\n", err)
+		os.Exit(2)
+	}
+
+	results, err := run(settings, cases)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "maptest: %v\n", err)
+		os.Exit(2)
+	}
+	if failed := report(os.Stdout, results); failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/loggroup"
+	"github.com/newrelic/oci-log-integration/logs-function/routing"
+	"github.com/newrelic/oci-log-integration/logs-function/transform"
+	"github.com/newrelic/oci-log-integration/logs-function/unmarshal"
+)
+
+// Case is a sample record and the output the configuration under test is expected to produce for it.
+type Case struct {
+	Name    string                 `json:"name"`
+	Record  json.RawMessage        `json:"record"`            // Record is the record as delivered by Connector Hub.
+	Expect  map[string]interface{} `json:"expect,omitempty"`  // Expect holds the expected fields of the sent record; null expects a field to be absent.
+	Account string                 `json:"account,omitempty"` // Account, when set, is the expected alias of the account route.
+	Dropped bool                   `json:"dropped,omitempty"` // Dropped expects the record not to be sent.
+}
+
+// Result is the outcome of a case.
+type Result struct {
+	Name     string
+	Failures []string // Failures describes each mismatch, and is empty when the case passed.
+}
+
+// run processes the record of each case with the settings applied and checks the outputs.
+// It returns an error when the settings cannot be used, e.g. because of an invalid routing table.
+func run(settings map[string]string, cases []Case) ([]Result, error) {
+	restore := overlayEnv(settings)
+	defer restore()
+	transform.ReloadProfiles()
+	defer transform.ReloadProfiles()
+	routes, err := routing.LoadRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(cases))
+	for i, c := range cases {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("case %d", i)
+		}
+		results = append(results, Result{Name: name, Failures: check(c, routes)})
+	}
+	return results, nil
+}
+
+// check processes the record of a case and returns the mismatches with its expectations.
+func check(c Case, routes []routing.Route) []string {
+	sent, err := process(c.Record, routes)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	switch {
+	case c.Dropped && sent == nil:
+		return nil
+	case c.Dropped:
+		return []string{"record was sent, want dropped"}
+	case sent == nil:
+		return []string{"record was dropped"}
+	}
+
+	var failures []string
+	if c.Account != "" {
+		account, _ := sent[common.AccountAliasAttribute].(string)
+		if account == "" {
+			account = routing.DefaultAlias
+		}
+		if account != c.Account {
+			failures = append(failures, fmt.Sprintf("account: got %q, want %q", account, c.Account))
+		}
+	}
+	return append(failures, match("", normalize(c.Expect), sent)...)
+}
+
+// process runs a record through the pipeline and returns the sent record merged with the attributes of its batch,
+// or nil when the record was dropped.
+func process(record json.RawMessage, routes []routing.Route) (map[string]interface{}, error) {
+	event := unmarshal.Event{}
+	if err := event.Unmarshal(context.Background(), bytes.NewReader(append(append([]byte("["), record...), ']'))); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal record: %w", err)
+	}
+
+	channel := make(chan common.DetailedLogsBatch, common.MessageChannelSize)
+	loggroup.ProcessInvocation(loggroup.Invocation{Records: event.OCILoggingEvent, Routes: routes}, channel)
+	close(channel)
+
+	var sent map[string]interface{}
+	for batch := range channel {
+		for _, logs := range batch {
+			for _, entry := range logs.Entries {
+				sent = map[string]interface{}{}
+				for key, value := range logs.CommonData.Attributes {
+					sent[key] = value
+				}
+				for key, value := range entry {
+					sent[key] = value
+				}
+			}
+		}
+	}
+	if sent == nil {
+		return nil, nil
+	}
+	return normalize(sent).(map[string]interface{}), nil
+}
+
+// match returns the mismatches between the expected fields and the actual object, naming fields by dotted path.
+func match(path string, expected interface{}, actual map[string]interface{}) []string {
+	fields, _ := expected.(map[string]interface{})
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var failures []string
+	for _, key := range keys {
+		want := fields[key]
+		got, present := actual[key]
+		fieldPath := strings.TrimPrefix(path+"."+key, ".")
+		nestedWant, wantObject := want.(map[string]interface{})
+		nestedGot, gotObject := got.(map[string]interface{})
+		switch {
+		case want == nil && present:
+			failures = append(failures, fmt.Sprintf("%s: got %s, want absent", fieldPath, encode(got)))
+		case want == nil:
+		case !present:
+			failures = append(failures, fmt.Sprintf("%s: absent, want %s", fieldPath, encode(want)))
+		case wantObject && gotObject:
+			failures = append(failures, match(fieldPath, nestedWant, nestedGot)...)
+		case !reflect.DeepEqual(want, got):
+			failures = append(failures, fmt.Sprintf("%s: got %s, want %s", fieldPath, encode(got), encode(want)))
+		}
+	}
+	return failures
+}
+
+// report prints a line per case, followed by the mismatches of failed cases and a summary, and returns the
+// number of failed cases.
+func report(w io.Writer, results []Result) int {
+	failed := 0
+	for _, result := range results {
+		if len(result.Failures) == 0 {
+			fmt.Fprintf(w, "PASS  %s\n", result.Name)
+			continue
+		}
+		failed++
+		fmt.Fprintf(w, "FAIL  %s\n", result.Name)
+		for _, failure := range result.Failures {
+			fmt.Fprintf(w, "      %s\n", failure)
+		}
+	}
+	fmt.Fprintf(w, "%d passed, %d failed\n", len(results)-failed, failed)
+	return failed
+}
+
+// overlayEnv sets the settings in the environment and returns a function restoring the previous values.
+func overlayEnv(settings map[string]string) (restore func()) {
+	previous := map[string]*string{}
+	for key, value := range settings {
+		if old, ok := os.LookupEnv(key); ok {
+			previous[key] = &old
+		} else {
+			previous[key] = nil
+		}
+		_ = os.Setenv(key, value)
+	}
+	return func() {
+		for key, old := range previous {
+			if old == nil {
+				_ = os.Unsetenv(key)
+			} else {
+				_ = os.Setenv(key, *old)
+			}
+		}
+	}
+}
+
+// normalize returns the value as it would be sent, so that e.g. an int and an equal float64 compare equal.
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+// encode returns the JSON form of a value for failure messages.
+func encode(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// settings routes the records of a compartment to a second account and converts severities.
+var settings = map[string]string{
+	common.SeverityConversion: "true",
+	common.EmptyMessagePolicy: "*=drop",
+	common.AccountRoutes:      `[{"alias":"security","secretOcid":"ocid1.vaultsecret.oc1..b","compartments":["ocid1.compartment.oc1..sec"]}]`,
+}
+
+// cases are the test cases of settings, as read from a cases file.
+const cases = `[
+	{"name": "converted", "record": {"type": "com.oraclecloud.logging.custom.app", "data": {"message": "hi", "level": "WARN"}},
+	 "expect": {"level": "warn", "severity.number": 13, "extra": null}, "account": "default"},
+	{"name": "routed", "record": {"oracle": {"compartmentid": "ocid1.compartment.oc1..sec"}, "data": {"message": "hi"}},
+	 "account": "security"},
+	{"name": "dropped", "record": {"data": {"status": 200}}, "dropped": true},
+	{"name": "wrong", "record": {"oracle": {"compartmentid": "ocid1.compartment.oc1..sec"}, "data": {"message": "hi", "level": "INFO"}},
+	 "expect": {"level": "warn", "severity.number": 9, "data": {"message": null}}, "account": "default"},
+	{"name": "unexpectedly sent", "record": {"data": {"message": "hi"}}, "dropped": true},
+	{"name": "unexpectedly dropped", "record": {"data": {"status": 200}}, "expect": {"status": 200}}
+]`
+
+// TestRun tests that cases pass when the outputs match and that each mismatch is reported.
+func TestRun(t *testing.T) {
+	var parsed []Case
+	assert.NoError(t, json.Unmarshal([]byte(cases), &parsed))
+
+	results, err := run(settings, parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, []Result{
+		{Name: "converted"},
+		{Name: "routed"},
+		{Name: "dropped"},
+		{Name: "wrong", Failures: []string{
+			`account: got "security", want "default"`,
+			`data.message: got "hi", want absent`,
+			`level: got "info", want "warn"`,
+		}},
+		{Name: "unexpectedly sent", Failures: []string{"record was sent, want dropped"}},
+		{Name: "unexpectedly dropped", Failures: []string{"record was dropped"}},
+	}, results)
+	_, set := os.LookupEnv(common.AccountRoutes)
+	assert.False(t, set)
+}
+
+// TestRunInvalidRoutes tests that an invalid routing table fails the run rather than its cases.
+func TestRunInvalidRoutes(t *testing.T) {
+	_, err := run(map[string]string{common.AccountRoutes: `[{"alias":"default"}]`}, nil)
+	assert.ErrorContains(t, err, common.AccountRoutes)
+}
+
+// TestReport tests the printed results and the number of failed cases.
+func TestReport(t *testing.T) {
+	var out bytes.Buffer
+	failed := report(&out, []Result{{Name: "a"}, {Name: "b", Failures: []string{"level: absent, want \"warn\""}}})
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, "PASS  a\nFAIL  b\n      level: absent, want \"warn\"\n1 passed, 1 failed\n", out.String())
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/config"
+	"github.com/newrelic/oci-log-integration/logs-function/loggroup"
+	"github.com/newrelic/oci-log-integration/logs-function/transform"
+	"github.com/newrelic/oci-log-integration/logs-function/unmarshal"
+)
+
+// presetPrefix introduces a side naming a built-in preset.
+const presetPrefix = "preset:"
+
+// Kinds of record changes.
+const (
+	ChangeModified = "modified" // ChangeModified is a record sent by both sides with different fields.
+	ChangeRemoved  = "removed"  // ChangeRemoved is a record sent by side a only.
+	ChangeAdded    = "added"    // ChangeAdded is a record sent by side b only.
+)
+
+// ignoredFields lists the fields that differ between any two configurations by design.
+var ignoredFields = map[string]bool{
+	common.LineageAttribute:          true,
+	common.TransformProfileAttribute: true,
+}
+
+// Diff is the structured difference between the records sent with two configurations.
+type Diff struct {
+	A       string   `json:"a"`       // A is the first configuration.
+	B       string   `json:"b"`       // B is the second configuration.
+	Records Counts   `json:"records"` // Records holds the number of records sent with each configuration.
+	Changes []Change `json:"changes"` // Changes lists the records that differ, by payload position.
+}
+
+// Counts holds a number for each side.
+type Counts struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+// Change is the difference in one record of the payload.
+type Change struct {
+	Sequence int64         `json:"sequence"`         // Sequence is the position of the record in the payload.
+	Kind     string        `json:"kind"`             // Kind is one of the Change kinds.
+	Fields   []FieldChange `json:"fields,omitempty"` // Fields lists the differing fields of a modified record.
+}
+
+// FieldChange is the difference in one field of a record, absent on a side when nil.
+type FieldChange struct {
+	Path string      `json:"path"`
+	A    interface{} `json:"a,omitempty"`
+	B    interface{} `json:"b,omitempty"`
+}
+
+// run processes the payload with both configurations and returns the diff of the sent records.
+func run(payload []byte, a string, b string) (Diff, error) {
+	recordsA, err := process(payload, a)
+	if err != nil {
+		return Diff{}, fmt.Errorf("side a: %w", err)
+	}
+	recordsB, err := process(payload, b)
+	if err != nil {
+		return Diff{}, fmt.Errorf("side b: %w", err)
+	}
+	return Diff{
+		A:       a,
+		B:       b,
+		Records: Counts{A: len(recordsA), B: len(recordsB)},
+		Changes: compare(recordsA, recordsB),
+	}, nil
+}
+
+// process runs the payload through the pipeline with the configuration of the side and returns the sent records,
+// flattened with the attributes of their batch, by payload position.
+func process(payload []byte, side string) (map[int64]map[string]interface{}, error) {
+	profile, settings, err := resolveSide(side)
+	if err != nil {
+		return nil, err
+	}
+	restore := overlayEnv(settings)
+	defer restore()
+	transform.ReloadProfiles()
+	defer transform.ReloadProfiles()
+
+	event := unmarshal.Event{}
+	if err := event.Unmarshal(context.Background(), bytes.NewReader(payload)); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	if event.EventType != unmarshal.OCI_LOGGING {
+		return nil, fmt.Errorf("unsupported payload of type %s", event.EventType)
+	}
+
+	channel := make(chan common.DetailedLogsBatch, common.MessageChannelSize)
+	records := map[int64]map[string]interface{}{}
+	done := make(chan struct{})
+	go func() {
+		for batch := range channel {
+			for _, logs := range batch {
+				for _, entry := range logs.Entries {
+					record := flatten(logs.CommonData.Attributes, entry)
+					records[sequence(entry)] = record
+				}
+			}
+		}
+		close(done)
+	}()
+	loggroup.ProcessInvocation(loggroup.Invocation{Records: event.OCILoggingEvent, Profile: profile}, channel)
+	close(channel)
+	<-done
+	return records, nil
+}
+
+// resolveSide returns the transform profile and the settings of a side.
+func resolveSide(side string) (string, map[string]string, error) {
+	switch {
+	case side == transform.ProfileStable || side == transform.ProfileCanary:
+		return side, nil, nil
+	case strings.HasPrefix(side, presetPrefix):
+		settings, err := config.LoadPreset(strings.TrimPrefix(side, presetPrefix))
+		return transform.ProfileStable, settings, err
+	default:
+		settings, err := config.LoadEnvFile(side)
+		return transform.ProfileStable, settings, err
+	}
+}
+
+// overlayEnv sets the settings in the environment and returns a function restoring the previous values.
+func overlayEnv(settings map[string]string) (restore func()) {
+	previous := map[string]*string{}
+	for key, value := range settings {
+		if old, ok := os.LookupEnv(key); ok {
+			previous[key] = &old
+		} else {
+			previous[key] = nil
+		}
+		_ = os.Setenv(key, value)
+	}
+	return func() {
+		for key, old := range previous {
+			if old == nil {
+				_ = os.Unsetenv(key)
+			} else {
+				_ = os.Setenv(key, *old)
+			}
+		}
+	}
+}
+
+// sequence returns the payload position of a sent record.
+func sequence(entry map[string]interface{}) int64 {
+	switch seq := entry[common.SequenceAttribute].(type) {
+	case int64:
+		return seq
+	case int:
+		return int64(seq)
+	case float64:
+		return int64(seq)
+	}
+	return -1
+}
+
+// flatten merges the batch attributes and the record, as New Relic stores them, into a map keyed by dotted path.
+// Record fields override batch attributes.
+func flatten(attributes common.LogAttributes, entry map[string]interface{}) map[string]interface{} {
+	flat := map[string]interface{}{}
+	for key, value := range attributes {
+		flattenValue(flat, key, value)
+	}
+	for key, value := range entry {
+		flattenValue(flat, key, value)
+	}
+	delete(flat, common.SequenceAttribute)
+	for key := range ignoredFields {
+		delete(flat, key)
+	}
+	return flat
+}
+
+// flattenValue adds value to flat under path, descending into objects.
+func flattenValue(flat map[string]interface{}, path string, value interface{}) {
+	nested, ok := value.(map[string]interface{})
+	if !ok || len(nested) == 0 {
+		flat[path] = normalize(value)
+		return
+	}
+	for key, child := range nested {
+		flattenValue(flat, path+"."+key, child)
+	}
+}
+
+// normalize returns the value as it would be sent, so that e.g. an int and an equal float64 compare equal.
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+// compare returns the changes between the records of both sides, ordered by payload position.
+func compare(a, b map[int64]map[string]interface{}) []Change {
+	sequences := map[int64]bool{}
+	for seq := range a {
+		sequences[seq] = true
+	}
+	for seq := range b {
+		sequences[seq] = true
+	}
+	ordered := make([]int64, 0, len(sequences))
+	for seq := range sequences {
+		ordered = append(ordered, seq)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i] < ordered[j] })
+
+	changes := []Change{}
+	for _, seq := range ordered {
+		recordA, inA := a[seq]
+		recordB, inB := b[seq]
+		switch {
+		case !inB:
+			changes = append(changes, Change{Sequence: seq, Kind: ChangeRemoved})
+		case !inA:
+			changes = append(changes, Change{Sequence: seq, Kind: ChangeAdded})
+		default:
+			if fields := compareFields(recordA, recordB); len(fields) > 0 {
+				changes = append(changes, Change{Sequence: seq, Kind: ChangeModified, Fields: fields})
+			}
+		}
+	}
+	return changes
+}
+
+// compareFields returns the differing fields of two flattened records, ordered by path.
+func compareFields(a, b map[string]interface{}) []FieldChange {
+	paths := map[string]bool{}
+	for path := range a {
+		paths[path] = true
+	}
+	for path := range b {
+		paths[path] = true
+	}
+	var fields []FieldChange
+	for path := range paths {
+		valueA, inA := a[path]
+		valueB, inB := b[path]
+		if inA == inB && reflect.DeepEqual(valueA, valueB) {
+			continue
+		}
+		fields = append(fields, FieldChange{Path: path, A: valueA, B: valueB})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Path < fields[j].Path })
+	return fields
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// payload is a captured payload of two records, the second without a message.
+const payload = `[
+	{"id":"a","type":"com.oraclecloud.logging.custom.app","data":{"message":"hello","level":"WARN"}},
+	{"id":"b","type":"com.oraclecloud.logging.custom.app","data":{"status":200}}
+]`
+
+// TestRunSettingsFiles tests the diff between two settings files.
+func TestRunSettingsFiles(t *testing.T) {
+	dir := t.TempDir()
+	current := filepath.Join(dir, "current.env")
+	proposed := filepath.Join(dir, "proposed.env")
+	assert.NoError(t, os.WriteFile(current, []byte("# current\n"), 0o644))
+	assert.NoError(t, os.WriteFile(proposed, []byte("SEVERITY_CONVERSION=true\nEMPTY_MESSAGE_POLICY=*=drop\n"), 0o644))
+
+	diff, err := run([]byte(payload), current, proposed)
+	assert.NoError(t, err)
+	assert.Equal(t, Counts{A: 2, B: 1}, diff.Records)
+	assert.Equal(t, []Change{
+		{Sequence: 0, Kind: ChangeModified, Fields: []FieldChange{
+			{Path: common.LevelAttribute, B: "warn"},
+			{Path: common.SeverityNumberAttribute, B: 13.0},
+		}},
+		{Sequence: 1, Kind: ChangeRemoved},
+	}, diff.Changes)
+	_, set := os.LookupEnv(common.SeverityConversion)
+	assert.False(t, set)
+}
+
+// TestRunProfiles tests that identical stable and canary profiles produce no changes.
+func TestRunProfiles(t *testing.T) {
+	diff, err := run([]byte(payload), "stable", "canary")
+	assert.NoError(t, err)
+	assert.Equal(t, Counts{A: 2, B: 2}, diff.Records)
+	assert.Empty(t, diff.Changes)
+}
+
+// TestRunInvalidSide tests that unknown presets and missing files are reported.
+func TestRunInvalidSide(t *testing.T) {
+	_, err := run([]byte(payload), "preset:unknown", "stable")
+	assert.ErrorContains(t, err, "side a")
+	_, err = run([]byte(payload), "stable", filepath.Join(t.TempDir(), "missing.env"))
+	assert.ErrorContains(t, err, "side b")
+}
@@ -0,0 +1,49 @@
+// Command profilediff runs a captured Connector Hub payload through two transform configurations and prints a
+// structured diff of the records the pipeline would send to New Relic, so configuration changes can be reviewed
+// before rollout. Each side is the stable or canary profile of the current environment, a built-in preset, or a
+// file of NAME=value settings, applied on top of the current environment.
+//
+// Usage:
+//
+//	go run ./cmd/profilediff -in payload.json -a stable -b canary
+//	go run ./cmd/profilediff -in payload.json -a current.env -b proposed.env
+//	go run ./cmd/profilediff -in payload.json -a stable -b preset:minimal
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	in := flag.String("in", "", "path of the captured Connector Hub payload")
+	a := flag.String("a", "stable", `first configuration: "stable", "canary", "preset:<name>" or a settings file`)
+	b := flag.String("b", "canary", `second configuration: "stable", "canary", "preset:<name>" or a settings file`)
+	flag.Parse()
+
+	if *in == "" {
+		fmt.Fprintln(os.Stderr, "profilediff: -in is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	payload, err := os.ReadFile(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profilediff: failed to read payload: %v\n", err)
+		os.Exit(1)
+	}
+	diff, err := run(payload, *a, *b)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profilediff: %v\n", err)
+		os.Exit(1)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(diff); err != nil {
+		fmt.Fprintf(os.Stderr, "profilediff: failed to encode diff: %v\n", err)
+		os.Exit(1)
+	}
+}
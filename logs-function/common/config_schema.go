@@ -0,0 +1,12 @@
+package common
+
+import _ "embed"
+
+//go:generate go run ../cmd/configschema -out config_schema.json . ../logger
+
+// ConfigSchema is the JSON description of every environment variable read by the function: its name, type,
+// default, allowed values and description. It is generated from the documented constants, so that external
+// tooling can validate function configurations before they are deployed.
+//
+//go:embed config_schema.json
+var ConfigSchema []byte
@@ -1,24 +1,32 @@
 // Package common provides common constants structs and variables.
 package common
 
+import "time"
+
 // InstrumentationProvider is a parameter necessary for Entity Synthesis at New Relic.
 const InstrumentationProvider = "oci"
 
 // InstrumentationName is a parameter necessary for Entity Synthesis at New Relic.
 const InstrumentationName = "log-function"
 
-// SecretOCID is the environment variable name for the OCI secret OCID.
+// SecretOCID is the environment variable name for the OCI secret OCID. The secret holds the Ingest - License key
+// either as is or, for a secret that is a JSON object, as its licenseKey field.
 const SecretOCID = "SECRET_OCID"
 
-// VaultRegion is the environment variable name for the OCI vault region.
+// VaultRegion is the environment variable name for the OCI vault region. The region of the function is used when it is
+// unset.
 const VaultRegion = "VAULT_REGION"
 
 // NumberOfWorkers defines the number of concurrent worker goroutines for processing log batches.
 const NumberOfWorkers = 6
 
-// NewRelicRegion is the name of the environment variable for the New Relic region.
+// NewRelicRegion is the name of the environment variable for the New Relic region: US, EU, or "auto" to use the region
+// closest to the OCI region of the function.
 const NewRelicRegion = "NEW_RELIC_REGION"
 
+// NewRelicRegionAuto is the NEW_RELIC_REGION value selecting the New Relic region from the OCI region of the function.
+const NewRelicRegionAuto = "auto"
+
 // DebugEnabled is the name of the environment variable for enabling debug mode.
 const DebugEnabled = "DEBUG_ENABLED"
 
@@ -28,12 +36,625 @@ const ClientTTL = "CLIENT_TTL"
 // DefaultClientTTL is the default TTL for the NewRelic client cache in seconds (10 minutes = 600 seconds).
 const DefaultClientTTL = 600
 
+// LicenseKeyGracePeriod is the name of the environment variable for how many seconds after it was last fetched a
+// cached license key keeps being used while OCI Vault is unreachable, instead of failing every invocation during a
+// Vault outage. Set it to 0 to fail as soon as a refresh fails.
+const LicenseKeyGracePeriod = "LICENSE_KEY_GRACE_PERIOD_SECONDS"
+
+// DefaultLicenseKeyGracePeriod is the default license key grace period in seconds (1 hour).
+const DefaultLicenseKeyGracePeriod = 3600
+
 // MaxPayloadSize is the maximum size of a payload.
 // Reference: https://docs.newrelic.com/docs/logs/log-api/introduction-log-api/#limits
 const MaxPayloadSize = 1 * 1024 * 1024 // 1 mb
 
-// Secret field names
+// MaxAttributes is the maximum number of attributes New Relic keeps on a log, counting nested fields and the
+// common attributes of its batch.
+// Reference: https://docs.newrelic.com/docs/logs/log-api/introduction-log-api/#limits
+const MaxAttributes = 255
+
+// LicenseKey is the field of a JSON object secret holding the license key.
 const LicenseKey = "licenseKey"
 
 // Message channel size
 const MessageChannelSize = 10
+
+// ServiceNameRules is the name of the environment variable holding the ordered, comma-separated
+// list of rules used to derive the service.name attribute (e.g. "tag:app,resource,logGroup,subject").
+const ServiceNameRules = "SERVICE_NAME_RULES"
+
+// ServiceNameDefault is the name of the environment variable for the service.name used when no rule matches.
+const ServiceNameDefault = "SERVICE_NAME_DEFAULT"
+
+// ServiceNameAttribute is the New Relic attribute used by the Logs UI to group records by service.
+const ServiceNameAttribute = "service.name"
+
+// SourceCategoryAttribute is the attribute classifying each record as audit, network, application, security or platform.
+const SourceCategoryAttribute = "source.category"
+
+// LogSubjectAttribute is the attribute holding the CloudEvent subject of the record, the log object path of custom logs.
+const LogSubjectAttribute = "log.subject"
+
+// Base64FieldPolicy is the name of the environment variable selecting how large base64-encoded
+// field values are handled: keep (default), drop, hash or truncate.
+const Base64FieldPolicy = "BASE64_FIELD_POLICY"
+
+// Base64FieldMaxBytes is the name of the environment variable for the size above which a base64 value is subject to the policy.
+const Base64FieldMaxBytes = "BASE64_FIELD_MAX_BYTES"
+
+// DefaultBase64FieldMaxBytes is the default size threshold for base64 field values (4 KB).
+const DefaultBase64FieldMaxBytes = 4 * 1024
+
+// CompartmentAllowlist is the name of the environment variable holding the comma-separated compartment OCIDs
+// whose records are forwarded. When set, records from any other compartment are dropped.
+const CompartmentAllowlist = "COMPARTMENT_ALLOWLIST"
+
+// CompartmentDenylist is the name of the environment variable holding the comma-separated compartment OCIDs whose records are dropped.
+const CompartmentDenylist = "COMPARTMENT_DENYLIST"
+
+// AccountRoutes is the name of the environment variable holding the JSON routing table that sends records
+// to additional New Relic accounts, e.g. [{"alias":"sec","secretOcid":"ocid1.vaultsecret...","compartments":["ocid1.compartment..."]}].
+// Routes match compartments, log groups or, with "subjects", glob patterns of the subject of custom logs. Records matching no route are forwarded with the license key referenced by SecretOCID.
+const AccountRoutes = "ACCOUNT_ROUTES"
+
+// AccountAliasAttribute is the common attribute identifying the account route a batch was sent through.
+const AccountAliasAttribute = "newrelic.source.accountAlias"
+
+// ConnectionWarmUp is the name of the environment variable for opening the Log API connection during container startup.
+const ConnectionWarmUp = "CONNECTION_WARMUP"
+
+// PrefetchSecrets is the name of the environment variable that, when "true", fetches the license key and creates the
+// New Relic client in the background when the container starts, before the first payload arrives.
+const PrefetchSecrets = "PREFETCH_SECRETS"
+
+// StartupConnectivityCheck is the name of the environment variable that, when "true", opens the Log API connection
+// during startup as a startup check, failing startup when New Relic cannot be reached, instead of warming it up in
+// the background as CONNECTION_WARMUP does.
+const StartupConnectivityCheck = "STARTUP_CONNECTIVITY_CHECK"
+
+// StartupReportFile is the name of the environment variable for the path the JSON startup report is written to,
+// for orchestration probes. A failed startup also writes the report to stderr.
+const StartupReportFile = "STARTUP_REPORT_FILE"
+
+// ConnectionWarmUpTimeout is the maximum time spent warming up the Log API connection.
+const ConnectionWarmUpTimeout = 10 * time.Second
+
+// MaxWorkers is the name of the environment variable bounding the number of concurrent workers, which defaults to NumberOfWorkers.
+const MaxWorkers = "MAX_WORKERS"
+
+// ClockSkewMode is the name of the environment variable selecting how clock skew against New Relic is handled:
+// off (default), warn (stamp ClockSkewAttribute on batches) or correct (also shift forwarder-generated timestamps).
+const ClockSkewMode = "CLOCK_SKEW_MODE"
+
+// ClockSkewThreshold is the name of the environment variable for the skew, in seconds, considered significant.
+const ClockSkewThreshold = "CLOCK_SKEW_THRESHOLD_SECONDS"
+
+// DefaultClockSkewThreshold is the default significant clock skew in seconds.
+const DefaultClockSkewThreshold = 60
+
+// ClockSkewAttribute is the common attribute carrying the detected skew in seconds (New Relic minus function clock).
+const ClockSkewAttribute = "forwarder.clockSkewSeconds"
+
+// CanaryPercent is the name of the environment variable for the percentage of records processed with the canary
+// transform profile. The canary profile reads each transform setting from its CanaryPrefix-prefixed variable
+// (e.g. CANARY_SERVICE_NAME_RULES), falling back to the regular value.
+const CanaryPercent = "CANARY_PERCENT"
+
+// CanaryPrefix is the prefix of the environment variables configuring the canary transform profile.
+const CanaryPrefix = "CANARY_"
+
+// TransformProfileAttribute is the record attribute naming the transform profile applied when a canary is configured.
+const TransformProfileAttribute = "forwarder.transformProfile"
+
+// AuditProfile is the name of the environment variable selecting the transform profile for _Audit logs. Set it to
+// "strict" to set logtype, normalize the identity fields of the principal and caller and remove credential-bearing
+// HTTP headers.
+const AuditProfile = "AUDIT_PROFILE"
+
+// AuditHeaderAllowlist is the name of the environment variable holding the comma-separated, case-insensitive names
+// of the request and response headers kept on _Audit records. Every other header is dropped and counted in
+// AuditDroppedHeadersAttribute. Set it to "*" to keep all headers.
+const AuditHeaderAllowlist = "AUDIT_HEADER_ALLOWLIST"
+
+// DefaultAuditHeaderAllowlist is the default audit header allowlist.
+const DefaultAuditHeaderAllowlist = "user-agent,opc-request-id,content-type"
+
+// AuditDroppedHeadersAttribute is the record attribute holding the number of headers dropped from an _Audit record.
+const AuditDroppedHeadersAttribute = "audit.droppedHeaders"
+
+// RawMessagePassthrough is the name of the environment variable that, when "true", forwards each record's original
+// bytes as its message without decoding and re-encoding it, for byte-for-byte fidelity in compliance archives.
+const RawMessagePassthrough = "RAW_MESSAGE_PASSTHROUGH"
+
+// MessageLengthLimits is the name of the environment variable holding comma-separated per-source message length limits
+// as <type prefix>=<bytes>[:<strategy>], matched in order against the record type, with "*" matching any source and
+// "subject:<glob>" matching the subject of custom logs, e.g. "com.oraclecloud.vcn.flowlogs=4096,*=32768:headtail". Strategies are head (default), tail and headtail.
+const MessageLengthLimits = "MESSAGE_LENGTH_LIMITS"
+
+// EmptyMessagePolicy is the name of the environment variable holding comma-separated per-source treatments of records
+// whose message, read from the record or its data, is missing or blank, as <type prefix>=<policy>, matched in order
+// against the record type, with "*" matching any source and "subject:<glob>" the subject of custom logs,
+// e.g. "com.oraclecloud.audit=source,*=keys". Policies are keep
+// (default), drop, keys (message synthesized from the first scalar fields) and source (source and event name).
+const EmptyMessagePolicy = "EMPTY_MESSAGE_POLICY"
+
+// RoutingOverrideHeader is the invocation header carrying a JSON routing override for a single call,
+// e.g. {"routes":[{"alias":"sec","secretOcid":"ocid1.vaultsecret..."}],"profile":"canary"}.
+// It is honored only when AllowRoutingOverride is enabled.
+const RoutingOverrideHeader = "X-NR-Routing"
+
+// HealthCheckHeader is the invocation header that, when set, makes the function answer with its health report,
+// including the ConfigSchema, instead of processing the payload.
+const HealthCheckHeader = "X-NR-Health-Check"
+
+// AllowRoutingOverride is the name of the environment variable that, when "true", lets test tooling override
+// the routing table and transform profile per invocation through the RoutingOverrideHeader.
+const AllowRoutingOverride = "ALLOW_ROUTING_OVERRIDE"
+
+// SecurityEvents is the name of the environment variable that, when "true", also sends the records parsed by the
+// security parsers (Cloud Guard, Bastion) to the Event API as custom events.
+const SecurityEvents = "SECURITY_EVENTS"
+
+// AlarmEvents is the name of the environment variable that, when "true", also sends the OCI Monitoring alarm
+// notifications received from a Notifications topic to the Event API as custom events, for New Relic workflows.
+const AlarmEvents = "ALARM_EVENTS"
+
+// NewRelicAccountID is the name of the environment variable for the New Relic account ID custom events are sent to.
+const NewRelicAccountID = "NEW_RELIC_ACCOUNT_ID"
+
+// LineageAttribute is the common attribute recording the forwarder version, transform profile, parser and
+// configuration version that shaped the records of a batch, e.g. "version=1.0.0,profile=stable,parser=envoy,config=1a2b3c4d".
+const LineageAttribute = "forwarder.lineage"
+
+// DLQBucket is the name of the environment variable for the Object Storage bucket that batches which could not be
+// delivered are written to as dead-letter envelopes. Dead-lettering is disabled when it is unset.
+const DLQBucket = "DLQ_BUCKET"
+
+// DLQNamespace is the name of the environment variable for the Object Storage namespace of the DLQ bucket.
+const DLQNamespace = "DLQ_NAMESPACE"
+
+// DLQPrefix is the name of the environment variable for the object name prefix of dead-letter envelopes, "dlq" by default.
+// Objects are partitioned below it by UTC date and hour (<prefix>/YYYY/MM/DD/HH/) for lifecycle rules.
+const DLQPrefix = "DLQ_PREFIX"
+
+// DLQKMSKeyOCID is the name of the environment variable for the OCID of the customer-managed Vault key that
+// dead-letter objects are encrypted with at rest. The bucket's own encryption key is used when it is unset.
+const DLQKMSKeyOCID = "DLQ_KMS_KEY_OCID"
+
+// VerifyLogGroups is the name of the environment variable holding the comma-separated OCIDs of critical log groups
+// whose delivery is confirmed with a NRQL query after posting, reported as OciLogVerification events.
+const VerifyLogGroups = "VERIFY_LOG_GROUPS"
+
+// VerifySamplePercent is the name of the environment variable for the percentage of critical batches verified.
+const VerifySamplePercent = "VERIFY_SAMPLE_PERCENT"
+
+// DefaultVerifySamplePercent is the default percentage of critical batches verified.
+const DefaultVerifySamplePercent = 10
+
+// UserAPIKeySecretOCID is the name of the environment variable for the Vault secret holding the New Relic User API key
+// used for NerdGraph requests.
+const UserAPIKeySecretOCID = "USER_API_KEY_SECRET_OCID"
+
+// VerificationAttribute is the common attribute carrying the ID of a batch selected for delivery verification.
+const VerificationAttribute = "forwarder.verificationId"
+
+// SelfRegistration is the name of the environment variable that, when "true", records the integration (function,
+// application, region and forwarder version) in NerdStorage of the NEW_RELIC_ACCOUNT_ID account on startup, so that
+// New Relic can display its status. It requires USER_API_KEY_SECRET_OCID.
+const SelfRegistration = "SELF_REGISTRATION"
+
+// Environment variables set by the Fn platform and the OCI resource principal, describing the running function.
+const (
+	FnFunctionID   = "FN_FN_ID"                      // FnFunctionID is the OCID of the function.
+	FnFunctionName = "FN_FN_NAME"                    // FnFunctionName is the display name of the function.
+	FnAppID        = "FN_APP_ID"                     // FnAppID is the OCID of the function's application.
+	FnAppName      = "FN_APP_NAME"                   // FnAppName is the display name of the function's application.
+	OCIRegion      = "OCI_RESOURCE_PRINCIPAL_REGION" // OCIRegion is the OCI region the function runs in.
+)
+
+// PayloadFormat is the name of the environment variable selecting the version of the Log API payload format
+// batches are posted in: "v1" (detailed JSON, the default) or "simple" (a flat array of logs), for proxies and
+// gateways that only validate the simple format.
+const PayloadFormat = "PAYLOAD_FORMAT"
+
+// MetricsOutput is the name of the environment variable holding the comma-separated outputs the internal metrics
+// of each invocation are reported to: "response" writes them as JSON to the function response and "events" sends
+// them as an OciLogForwarderMetrics custom event. Metrics are not reported when it is unset.
+const MetricsOutput = "METRICS_OUTPUT"
+
+// MetricsNoiseScale is the name of the environment variable for the scale of the Laplace noise added to the counts
+// of the OciLogForwarderMetrics events, such as records.sent and the per-log-group top.loggroups.bytes, for summaries
+// of sensitive sources; about 86% of the noisy counts are within twice the scale of the exact ones. Metrics written
+// to the function response stay exact. Counts are exact when it is 0, the default.
+const MetricsNoiseScale = "METRICS_NOISE_SCALE"
+
+// DefaultMetricsNoiseScale is the default scale of the noise added to the counts of the metrics events.
+const DefaultMetricsNoiseScale = 0.0
+
+// RetryStreamOCID is the name of the environment variable for the OCI Streaming stream that batches failing delivery
+// are republished to. A Connector Hub connector from the stream to this function retries them in later invocations.
+const RetryStreamOCID = "RETRY_STREAM_OCID"
+
+// RetryStreamEndpoint is the name of the environment variable for the messages endpoint of the retry stream.
+const RetryStreamEndpoint = "RETRY_STREAM_ENDPOINT"
+
+// RetryMaxAttempts is the name of the environment variable for the number of delivery attempts of a batch before it
+// is written to the DLQ bucket instead of the retry stream.
+const RetryMaxAttempts = "RETRY_MAX_ATTEMPTS"
+
+// DefaultRetryMaxAttempts is the default number of delivery attempts through the retry stream.
+const DefaultRetryMaxAttempts = 5
+
+// ReplayWindow is the name of the environment variable for the age, in hours, beyond which the records of batches
+// replayed from the retry stream or a DLQ archive are skipped instead of re-ingested. Set it to 0 to replay records of
+// any age.
+const ReplayWindow = "REPLAY_WINDOW_HOURS"
+
+// DefaultReplayWindow is the default replay window in hours, replaying records of any age.
+const DefaultReplayWindow = 0
+
+// Replay attributes stamped on every replayed batch.
+const (
+	ReplayedAttribute   = "forwarder.replayed"   // ReplayedAttribute flags batches replayed from the retry stream or a DLQ archive.
+	ReplayedAtAttribute = "forwarder.replayedAt" // ReplayedAtAttribute is the time the batch was replayed, in RFC 3339.
+)
+
+// RetryAttemptAttribute is the common attribute carrying the number of failed delivery attempts of a batch
+// replayed from the retry stream.
+const RetryAttemptAttribute = "forwarder.retryAttempt"
+
+// SequenceAttribute is the record attribute holding the record's position in the invocation payload, so that the
+// original order can be reconstructed in New Relic even when batches interleave.
+const SequenceAttribute = "forwarder.seq"
+
+// BatchTimeRangeStart and BatchTimeRangeEnd are the common attributes holding the earliest and latest record
+// times of a batch, in epoch milliseconds. The batch timestamp is set to the earliest record time as well.
+const (
+	BatchTimeRangeStart = "batch.timeRange.start"
+	BatchTimeRangeEnd   = "batch.timeRange.end"
+)
+
+// DoubleEncodedFields is the name of the environment variable holding the comma-separated dot paths of the record
+// fields checked for JSON encoded more than once, e.g. "message,data.message". Such values, and the JSON strings
+// nested in them, are decoded up to DOUBLE_ENCODING_MAX_DEPTH times into structured values. A repaired message
+// stays a string holding the JSON encoded once, which the Log API parses into attributes.
+const DoubleEncodedFields = "DOUBLE_ENCODED_FIELDS"
+
+// DoubleEncodingMaxDepth is the name of the environment variable for the number of times a value of a
+// DOUBLE_ENCODED_FIELDS field is decoded at most, counting the decodes of the JSON strings nested in it.
+const DoubleEncodingMaxDepth = "DOUBLE_ENCODING_MAX_DEPTH"
+
+// DefaultDoubleEncodingMaxDepth is the default of DOUBLE_ENCODING_MAX_DEPTH.
+const DefaultDoubleEncodingMaxDepth = 4
+
+// DoubleEncodingAttribute is the record attribute holding the number of decodes that repaired its fields.
+const DoubleEncodingAttribute = "forwarder.doubleEncoding.decodes"
+
+// FutureTimestampThreshold is the name of the environment variable for how many seconds ahead of the current time a
+// record may be dated before it is re-stamped with the current time. Set it to 0 to keep future-dated records as-is.
+const FutureTimestampThreshold = "FUTURE_TIMESTAMP_THRESHOLD_SECONDS"
+
+// DefaultFutureTimestampThreshold is the default future timestamp threshold in seconds.
+const DefaultFutureTimestampThreshold = 3600
+
+// FutureTimestampAttribute flags records whose future-dated time was replaced with the time they were forwarded.
+const FutureTimestampAttribute = "forwarder.futureTimestamp"
+
+// OriginalTimestampAttribute holds the original time of a re-stamped record.
+const OriginalTimestampAttribute = "forwarder.originalTimestamp"
+
+// DedupMessages is the name of the environment variable that, when "true", collapses runs of consecutive records
+// with the same type and message within an invocation into their first record, stamped with DedupCountAttribute.
+const DedupMessages = "DEDUP_MESSAGES"
+
+// DedupCountAttribute is the record attribute holding the number of identical records a collapsed record stands for.
+const DedupCountAttribute = "forwarder.dedupCount"
+
+// DebugOutput is the name of the environment variable enabling the developer mode in which the final Log API payload
+// of every batch is written, scrubbed and indented, to the function output for `fn invoke` testing: "tee" also posts
+// the payload, "only" does not.
+const DebugOutput = "DEBUG_OUTPUT"
+
+// ConfigProfileTag is the name of the environment variable naming the function tag that selects the configuration
+// profile, as "<namespace>.<key>" for a defined tag or "<key>" for a freeform tag. With a tag value of "prod", every
+// PROD_-prefixed variable (e.g. PROD_SECRET_OCID) overrides its unprefixed counterpart.
+const ConfigProfileTag = "CONFIG_PROFILE_TAG"
+
+// LogAPITimeoutMin is the name of the environment variable for the lower bound, in seconds, of the adaptive Log API
+// request timeout.
+const LogAPITimeoutMin = "LOG_API_TIMEOUT_MIN_SECONDS"
+
+// LogAPITimeoutMax is the name of the environment variable for the upper bound, in seconds, of the adaptive Log API
+// request timeout, which is also the timeout used until enough latencies have been observed.
+const LogAPITimeoutMax = "LOG_API_TIMEOUT_MAX_SECONDS"
+
+// Default bounds of the adaptive Log API request timeout.
+const (
+	DefaultLogAPITimeoutMin = 5 * time.Second
+	DefaultLogAPITimeoutMax = 30 * time.Second
+)
+
+// KeepOracleEnvelope is the name of the environment variable selecting how the OCI "oracle" envelope block of each
+// record (tenantid, compartmentid, loggroupid, logid, ingestedtime) is forwarded: "record" (default) keeps it on every
+// record, "hoist" moves the values shared by all records of a batch to common attributes, and "drop" removes it.
+const KeepOracleEnvelope = "KEEP_ORACLE_ENVELOPE"
+
+// Modes of KEEP_ORACLE_ENVELOPE.
+const (
+	OracleEnvelopeRecord = "record"
+	OracleEnvelopeHoist  = "hoist"
+	OracleEnvelopeDrop   = "drop"
+)
+
+// OracleEnvelopeKey is the record field holding the OCI envelope metadata.
+const OracleEnvelopeKey = "oracle"
+
+// SecretVersion is the name of the environment variable pinning the version number of the license key secret
+// referenced by SECRET_OCID. The current version is used when it is unset.
+const SecretVersion = "SECRET_VERSION"
+
+// QuotaExceededEventType is the high-priority custom event type sent when the Log API refuses batches because the
+// account is over its ingest limit or not entitled to logs.
+const QuotaExceededEventType = "OCILogForwarderQuotaExceeded"
+
+// SecretVersionEventType is the custom event type recording a change of the fetched version of a Vault secret.
+const SecretVersionEventType = "OciSecretVersionChange"
+
+// MigrationSecretOCID is the name of the environment variable for the OCI Vault secret holding the license key of
+// the account a migration ships to. While it is set, batches are also posted to that account, so that no data is lost
+// while dashboards and alerts are cut over from the account referenced by SECRET_OCID.
+const MigrationSecretOCID = "MIGRATION_SECRET_OCID"
+
+// MigrationPercent is the name of the environment variable for the percentage of batches also posted to the migration
+// account, or "all" (default).
+const MigrationPercent = "MIGRATION_PERCENT"
+
+// MigrationUntil is the name of the environment variable for the RFC 3339 time at which the overlap window ends and
+// batches stop being posted to the migration account.
+const MigrationUntil = "MIGRATION_UNTIL"
+
+// OCICallTimeout is the name of the environment variable for the timeout, in seconds, of each attempt of an OCI API
+// call (Vault, Object Storage, Streaming, Functions), so a hung call cannot consume the whole invocation.
+const OCICallTimeout = "OCI_CALL_TIMEOUT_SECONDS"
+
+// DefaultOCICallTimeout is the default OCI API call attempt timeout.
+const DefaultOCICallTimeout = 10 * time.Second
+
+// OCIRetryMaxAttempts is the name of the environment variable for the number of attempts of a failed OCI API call.
+const OCIRetryMaxAttempts = "OCI_RETRY_MAX_ATTEMPTS"
+
+// DefaultOCIRetryMaxAttempts is the default number of attempts of a failed OCI API call.
+const DefaultOCIRetryMaxAttempts = 3
+
+// DegradedDropPercent is the name of the environment variable for the percentage of records failing delivery or going
+// missing over the rolling window at or above which an OCILogForwarderDegraded event is sent to the account configured
+// through NEW_RELIC_ACCOUNT_ID, or "off" to disable the alert.
+const DegradedDropPercent = "DEGRADED_DROP_PERCENT"
+
+// DefaultDegradedDropPercent is the default drop percentage alerted on.
+const DefaultDegradedDropPercent = 5.0
+
+// DegradedWindow is the name of the environment variable for the length, in seconds, of the rolling window the drop
+// rate is computed over. While the rate stays above the threshold the alert is repeated once per window.
+const DegradedWindow = "DEGRADED_WINDOW_SECONDS"
+
+// DefaultDegradedWindow is the default length of the drop rate window.
+const DefaultDegradedWindow = 15 * time.Minute
+
+// OCI location attributes stamped on every batch when the region of the function is known.
+const (
+	OCIRegionAttribute = "oci.region" // OCIRegionAttribute is the OCI region the function runs in.
+	OCIRealmAttribute  = "oci.realm"  // OCIRealmAttribute is the OCI realm of that region, e.g. oc1.
+)
+
+// TenancyNameLookup is the name of the environment variable that, when "true", resolves the display name of the
+// tenancy the function runs in with the Identity API and stamps it on every batch as oci.tenancy.name, giving
+// multi-tenancy deployments a human-readable tenant dimension. The function needs to be allowed to read tenancies.
+const TenancyNameLookup = "TENANCY_NAME_LOOKUP"
+
+// OCITenancyNameAttribute is the common attribute holding the display name of the tenancy of the function.
+const OCITenancyNameAttribute = "oci.tenancy.name"
+
+// ParsersEnabled is the name of the environment variable holding the comma-separated names of the parsers tried on
+// records, e.g. "flowLogs,vault". "*" enables every parser and "-<name>" disables one, e.g. "*,-vault". All parsers
+// are enabled when it is unset. Like every transform setting, a CANARY_PARSERS_ENABLED value lets a new parser be
+// rolled out to a share of the records first.
+const ParsersEnabled = "PARSERS_ENABLED"
+
+// ParserOverrides is the name of the environment variable holding comma-separated <log group OCID>=<parser> pairs
+// forcing the named parser on every record of the log group, without its match check, even when it is disabled
+// by PARSERS_ENABLED. The parser "none" leaves the records of the log group unparsed.
+const ParserOverrides = "PARSER_OVERRIDES"
+
+// SeverityConversion is the name of the environment variable that, when "true", normalizes the severity of each record,
+// read from its level or severity field, into a level attribute and an OpenTelemetry severity.number.
+const SeverityConversion = "SEVERITY_CONVERSION"
+
+// Severity attributes set by SEVERITY_CONVERSION.
+const (
+	LevelAttribute          = "level"           // LevelAttribute is the log level shown by the New Relic Logs UI.
+	SeverityNumberAttribute = "severity.number" // SeverityNumberAttribute is the OpenTelemetry severity number, 1 to 24.
+)
+
+// LogsBaseURL is the name of the environment variable, read by the New Relic client, for a custom Log API endpoint
+// such as an internal log gateway.
+const LogsBaseURL = "NEW_RELIC_LOGS_BASE_URL"
+
+// HostOverrides is the name of the environment variable mapping New Relic hostnames to static IP addresses, as a
+// comma-separated list of <hostname>=<ip>, for functions without public egress that reach New Relic over private
+// connectivity. TLS still verifies the certificate against the hostname.
+const HostOverrides = "NEW_RELIC_HOST_OVERRIDES"
+
+// DNSResolver is the name of the environment variable for the DNS server, as <ip> or <ip>:<port>, that New Relic
+// hostnames without a NEW_RELIC_HOST_OVERRIDES entry are resolved with instead of the system resolver.
+const DNSResolver = "NEW_RELIC_DNS_RESOLVER"
+
+// SigningKeySecretOCID is the name of the environment variable for the Vault secret holding the HMAC key the body of
+// every request to the NEW_RELIC_LOGS_BASE_URL custom endpoint is signed with, in the SignatureHeader header.
+const SigningKeySecretOCID = "SIGNING_KEY_SECRET_OCID"
+
+// SignatureHeader is the request header holding the HMAC-SHA256 signature of the request body as sha256=<hex>.
+const SignatureHeader = "X-NR-Forwarder-Signature"
+
+// Invocation headers identifying the Service Connector that invoked the function, set by the invoker when one
+// function deployment serves several connectors.
+const (
+	ConnectorIDHeader   = "X-OCI-Connector-Id"   // ConnectorIDHeader carries the OCID of the connector.
+	ConnectorNameHeader = "X-OCI-Connector-Name" // ConnectorNameHeader carries the display name of the connector.
+)
+
+// DeliveryAttemptHeader is the invocation header carrying the number of the Connector Hub attempt to deliver the
+// payload, starting at 1, when the invoker reports it.
+const DeliveryAttemptHeader = "X-OCI-Delivery-Attempt"
+
+// DeliveryAttemptField is the CloudEvents extension attribute of a record carrying the number of the delivery
+// attempt, read when the DeliveryAttemptHeader is absent.
+const DeliveryAttemptField = "deliveryattempt"
+
+// OCIDeliveryAttemptAttribute is the common attribute stamped on every batch of an invocation whose delivery attempt
+// is known, so that retry storms are visible in New Relic.
+const OCIDeliveryAttemptAttribute = "oci.deliveryAttempt"
+
+// Connector attributes stamped on every batch of an invocation carrying the connector headers.
+const (
+	OCIConnectorIDAttribute   = "oci.connectorId"   // OCIConnectorIDAttribute is the OCID of the invoking connector.
+	OCIConnectorNameAttribute = "oci.connectorName" // OCIConnectorNameAttribute is the display name of the invoking connector.
+)
+
+// FaasAttributes is the name of the environment variable that, when "true", stamps the faas.* attributes identifying
+// the function, its application and the invocation on every batch, so instances of the same image can be told apart.
+const FaasAttributes = "FAAS_ATTRIBUTES"
+
+// Function attributes stamped on every batch when FAAS_ATTRIBUTES is "true", named after the OpenTelemetry FaaS
+// semantic conventions. OCI Functions applications have no OpenTelemetry equivalent and use faas.app.*.
+const (
+	FaasIDAttribute           = "faas.id"            // FaasIDAttribute is the OCID of the function.
+	FaasNameAttribute         = "faas.name"          // FaasNameAttribute is the name of the function.
+	FaasInvocationIDAttribute = "faas.invocation_id" // FaasInvocationIDAttribute is the call ID of the invocation.
+	FaasAppIDAttribute        = "faas.app.id"        // FaasAppIDAttribute is the OCID of the application of the function.
+	FaasAppNameAttribute      = "faas.app.name"      // FaasAppNameAttribute is the name of the application of the function.
+)
+
+// VaultRequestsPerSecond is the name of the environment variable for the rate of OCI Vault secret requests of the warm
+// container, shared by every client so concurrent warm-ups do not burst into throttling.
+const VaultRequestsPerSecond = "VAULT_REQUESTS_PER_SECOND"
+
+// DefaultVaultRequestsPerSecond is the default rate of OCI Vault secret requests, also the size of the allowed burst.
+const DefaultVaultRequestsPerSecond = 10.0
+
+// GzipLevel is the name of the environment variable for the gzip compression level of Log API requests, from 1 (fastest)
+// to 9 (smallest). Higher levels trade CPU time for smaller payloads, which pays off for large, repetitive audit batches.
+const GzipLevel = "GZIP_LEVEL"
+
+// DefaultGzipLevel is the default gzip compression level, the level of the New Relic client.
+const DefaultGzipLevel = 6
+
+// ConfigPreset is the name of the environment variable naming the built-in set of settings applied on startup. Each
+// environment variable of the function, including those of the CONFIG_PROFILE_TAG profile, overrides the preset value.
+const ConfigPreset = "CONFIG_PRESET"
+
+// ValidateInput is the name of the environment variable selecting how payloads are checked against the JSON Schemas
+// of the supported input formats, which the health check publishes. "warn" logs the failure against each schema of
+// a payload matching none and forwards it anyway, and "strict" also rejects it.
+const ValidateInput = "VALIDATE_INPUT"
+
+// StrictSchema is the name of the environment variable that, when "true", rejects OCI Logging payloads with
+// unexpected top-level structures, i.e. records that are not objects or have fields the oci-logging input schema does
+// not declare, and writes them to the DLQ bucket instead of parsing them best-effort, so that producer-side contract
+// changes are detected immediately. The invocation is acknowledged once the payload is written, and fails otherwise.
+const StrictSchema = "STRICT_SCHEMA"
+
+// DefaultValidateInput is the default value of VALIDATE_INPUT.
+const DefaultValidateInput = ValidateInputOff
+
+// Modes of VALIDATE_INPUT.
+const (
+	ValidateInputOff    = "off"    // ValidateInputOff does not validate payloads.
+	ValidateInputWarn   = "warn"   // ValidateInputWarn logs the schema failures of invalid payloads.
+	ValidateInputStrict = "strict" // ValidateInputStrict logs and rejects invalid payloads.
+)
+
+// Modes of CONFIG_PRESET.
+const (
+	PresetAuditStrict = "audit-strict" // PresetAuditStrict curates _Audit records and hashes encoded values.
+	PresetVerbose     = "verbose"      // PresetVerbose forwards everything with every derived attribute.
+	PresetMinimal     = "minimal"      // PresetMinimal reduces ingest by hoisting, collapsing and truncating.
+)
+
+// HeartbeatFilter is the name of the environment variable selecting the treatment of OCI heartbeat and synthetic
+// records, such as Service Connector test messages and health probes, recognized by a built-in pattern list.
+const HeartbeatFilter = "HEARTBEAT_FILTER"
+
+// DefaultHeartbeatFilter is the default value of HEARTBEAT_FILTER.
+const DefaultHeartbeatFilter = HeartbeatFilterDrop
+
+// Modes of HEARTBEAT_FILTER.
+const (
+	HeartbeatFilterDrop   = "drop"   // HeartbeatFilterDrop drops heartbeat records.
+	HeartbeatFilterSample = "sample" // HeartbeatFilterSample forwards a HEARTBEAT_SAMPLE_PERCENT share of heartbeat records.
+	HeartbeatFilterKeep   = "keep"   // HeartbeatFilterKeep forwards heartbeat records.
+)
+
+// HeartbeatSamplePercent is the name of the environment variable for the percentage of heartbeat records forwarded
+// when HEARTBEAT_FILTER is "sample".
+const HeartbeatSamplePercent = "HEARTBEAT_SAMPLE_PERCENT"
+
+// DefaultHeartbeatSamplePercent is the default percentage of heartbeat records forwarded when sampling.
+const DefaultHeartbeatSamplePercent = 1
+
+// HeartbeatAttribute is the record attribute naming the heartbeat pattern of a forwarded heartbeat record.
+const HeartbeatAttribute = "forwarder.heartbeat"
+
+// DuplicateRecords is the name of the environment variable selecting the treatment of exact-duplicate records
+// within one payload, as some OCI agents emit records twice under backpressure: "keep" (default) forwards them,
+// "detect" forwards them and counts them, and "collapse" forwards the first of each set of identical records
+// stamped with DuplicateCountAttribute.
+const DuplicateRecords = "DUPLICATE_RECORDS"
+
+// DefaultDuplicateRecords is the default value of DUPLICATE_RECORDS.
+const DefaultDuplicateRecords = DuplicateRecordsKeep
+
+// Modes of DUPLICATE_RECORDS.
+const (
+	DuplicateRecordsKeep     = "keep"     // DuplicateRecordsKeep forwards duplicate records without hashing the payload.
+	DuplicateRecordsDetect   = "detect"   // DuplicateRecordsDetect forwards duplicate records and counts them.
+	DuplicateRecordsCollapse = "collapse" // DuplicateRecordsCollapse forwards one record per set of identical records.
+)
+
+// DuplicateCountAttribute is the record attribute holding the number of identical records of the payload a
+// collapsed record stands for.
+const DuplicateCountAttribute = "forwarder.duplicateCount"
+
+// AttributeBudgetPercent is the name of the environment variable for the share of MaxAttributes, in percent, from
+// which a record counts as approaching the New Relic attribute cap in the top.loggroups.attributes.nearcap metric.
+const AttributeBudgetPercent = "ATTRIBUTE_BUDGET_PERCENT"
+
+// DefaultAttributeBudgetPercent is the default value of ATTRIBUTE_BUDGET_PERCENT.
+const DefaultAttributeBudgetPercent = 80
+
+// LeaseBucket is the name of the environment variable for the Object Storage bucket holding the lease that elects
+// one of several active/active deployments reading the same stream, e.g. in two regions, as the one forwarding
+// records. The others acknowledge their invocations without forwarding until the leaseholder stops renewing the
+// lease. Every deployment forwards when it is unset.
+const LeaseBucket = "LEASE_BUCKET"
+
+// LeaseNamespace is the name of the environment variable for the Object Storage namespace of the lease bucket.
+const LeaseNamespace = "LEASE_NAMESPACE"
+
+// LeaseObject is the name of the environment variable for the name of the lease object, shared by the deployments
+// electing a leaseholder.
+const LeaseObject = "LEASE_OBJECT"
+
+// DefaultLeaseObject is the default name of the lease object.
+const DefaultLeaseObject = "lease/forwarder.json"
+
+// LeaseHolder is the name of the environment variable identifying the deployment in the lease, the region of the
+// function by default. The containers of a deployment share its lease.
+const LeaseHolder = "LEASE_HOLDER"
+
+// LeaseTTL is the name of the environment variable for the number of seconds a lease lasts without being renewed,
+// after which another deployment takes it over.
+const LeaseTTL = "LEASE_TTL_SECONDS"
+
+// DefaultLeaseTTL is the default lease duration in seconds.
+const DefaultLeaseTTL = 60
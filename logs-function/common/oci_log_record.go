@@ -1,5 +1,110 @@
 package common
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // OCILoggingEvent represents a collection of OCI log entries as JSON strings.
 // Each string in the slice contains a JSON-encoded log entry from OCI Logging service.
-type OCILoggingEvent []map[string]interface{}
\ No newline at end of file
+type OCILoggingEvent []map[string]interface{}
+
+// LookupValue walks the nested record along path and returns the value found at its end.
+func LookupValue(record map[string]interface{}, path ...string) (interface{}, bool) {
+	var current interface{} = record
+	for _, key := range path {
+		node, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = node[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// LookupString walks the nested record along path and returns the non-empty string found at its end.
+func LookupString(record map[string]interface{}, path ...string) (string, bool) {
+	value, ok := LookupValue(record, path...)
+	if !ok {
+		return "", false
+	}
+	str, ok := value.(string)
+	return str, ok && str != ""
+}
+
+// CompartmentID returns the compartment OCID of the record, read from the OCI envelope or, for audit events, from the event data.
+func CompartmentID(record map[string]interface{}) string {
+	if id, ok := LookupString(record, "oracle", "compartmentid"); ok {
+		return id
+	}
+	id, _ := LookupString(record, "data", "compartmentId")
+	return id
+}
+
+// LogGroupID returns the log group OCID of the record from the OCI envelope.
+func LogGroupID(record map[string]interface{}) string {
+	id, _ := LookupString(record, "oracle", "loggroupid")
+	return id
+}
+
+// Subject returns the CloudEvent subject of the record, the path of the log object of custom logs.
+func Subject(record map[string]interface{}) string {
+	subject, _ := LookupString(record, "subject")
+	return subject
+}
+
+// RecordTime returns the time of the record, read from the OCI envelope "time" field or a top-level "timestamp",
+// given either as an RFC 3339 string or as epoch seconds, milliseconds, microseconds or nanoseconds.
+func RecordTime(record map[string]interface{}) (time.Time, bool) {
+	_, t, ok := RecordTimeField(record)
+	return t, ok
+}
+
+// RecordTimeField returns the name of the field RecordTime reads the record time from, and that time.
+func RecordTimeField(record map[string]interface{}) (string, time.Time, bool) {
+	for _, key := range []string{"time", "timestamp"} {
+		if t, ok := parseTime(record[key]); ok {
+			return key, t, true
+		}
+	}
+	return "", time.Time{}, false
+}
+
+// parseTime converts a timestamp value of a decoded record into a time.
+func parseTime(value interface{}) (time.Time, bool) {
+	var epoch float64
+	switch v := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		epoch = f
+	case float64:
+		epoch = v
+	case int64:
+		epoch = float64(v)
+	case int:
+		epoch = float64(v)
+	default:
+		return time.Time{}, false
+	}
+
+	switch {
+	case epoch <= 0:
+		return time.Time{}, false
+	case epoch < 1e11:
+		return time.Unix(0, int64(epoch*float64(time.Second))), true
+	case epoch < 1e14:
+		return time.UnixMilli(int64(epoch)), true
+	case epoch < 1e17:
+		return time.UnixMicro(int64(epoch)), true
+	default:
+		return time.Unix(0, int64(epoch)), true
+	}
+}
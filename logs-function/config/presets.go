@@ -0,0 +1,85 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// presetFiles holds the built-in presets, one <name>.env file of NAME=value lines each.
+//
+//go:embed presets/*.env
+var presetFiles embed.FS
+
+// Presets returns the names of the built-in presets.
+func Presets() []string {
+	entries, _ := presetFiles.ReadDir("presets")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".env"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadPreset returns the settings of the named built-in preset. Blank lines and lines starting with # are ignored.
+func LoadPreset(name string) (map[string]string, error) {
+	data, err := presetFiles.ReadFile(path.Join("presets", name+".env"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown preset %q, expected one of %s", name, strings.Join(Presets(), ", "))
+	}
+	return parseSettings(fmt.Sprintf("preset %q", name), data)
+}
+
+// LoadEnvFile returns the settings of a file of NAME=value lines in the format of the built-in presets.
+func LoadEnvFile(name string) (map[string]string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return parseSettings(name, data)
+}
+
+// parseSettings parses NAME=value lines, ignoring blank lines and lines starting with #. The source names the
+// settings in errors.
+func parseSettings(source string, data []byte) (map[string]string, error) {
+	settings := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("%s line %d is not a NAME=value setting", source, line)
+		}
+		settings[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return settings, scanner.Err()
+}
+
+// ApplyPreset sets every setting of the named preset that is not already set in the environment, so the
+// configuration of the function overrides the preset, and returns the number of settings applied.
+func ApplyPreset(name string) (int, error) {
+	settings, err := LoadPreset(name)
+	if err != nil {
+		return 0, err
+	}
+	applied := 0
+	for key, value := range settings {
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
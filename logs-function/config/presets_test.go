@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPresets tests that every preset mode is shipped and only sets variables the function reads.
+func TestPresets(t *testing.T) {
+	var schema struct {
+		Settings []struct {
+			Name string `json:"name"`
+		} `json:"settings"`
+	}
+	require.NoError(t, json.Unmarshal(common.ConfigSchema, &schema))
+	known := map[string]bool{}
+	for _, setting := range schema.Settings {
+		known[setting.Name] = true
+	}
+
+	assert.Equal(t, []string{common.PresetAuditStrict, common.PresetMinimal, common.PresetVerbose}, Presets())
+	for _, name := range Presets() {
+		settings, err := LoadPreset(name)
+		require.NoError(t, err, name)
+		assert.NotEmpty(t, settings, name)
+		for key := range settings {
+			assert.True(t, known[key], "preset %s sets unknown variable %s", name, key)
+		}
+	}
+}
+
+// TestApplyPreset tests that the environment overrides the preset.
+func TestApplyPreset(t *testing.T) {
+	settings, err := LoadPreset(common.PresetMinimal)
+	require.NoError(t, err)
+	for key := range settings {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Setenv(common.KeepOracleEnvelope, "drop")
+
+	applied, err := ApplyPreset(common.PresetMinimal)
+	require.NoError(t, err)
+
+	assert.Equal(t, len(settings)-1, applied)
+	assert.Equal(t, "drop", os.Getenv(common.KeepOracleEnvelope))
+	assert.Equal(t, "true", os.Getenv(common.DedupMessages))
+	assert.Equal(t, "*=16384:headtail", os.Getenv(common.MessageLengthLimits))
+}
+
+// TestApplyUnknownPreset tests that an unknown preset is rejected with the list of presets.
+func TestApplyUnknownPreset(t *testing.T) {
+	_, err := ApplyPreset("chatty")
+	assert.ErrorContains(t, err, `unknown preset "chatty", expected one of audit-strict, minimal, verbose`)
+}
+
+// TestLoadEnvFile tests that settings files are read like presets and malformed lines are reported.
+func TestLoadEnvFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "proposed.env")
+	require.NoError(t, os.WriteFile(name, []byte("# proposed\nSEVERITY_CONVERSION = true\n\nHEARTBEAT_FILTER=keep\n"), 0o644))
+	settings, err := LoadEnvFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"SEVERITY_CONVERSION": "true", "HEARTBEAT_FILTER": "keep"}, settings)
+
+	require.NoError(t, os.WriteFile(name, []byte("SEVERITY_CONVERSION\n"), 0o644))
+	_, err = LoadEnvFile(name)
+	assert.ErrorContains(t, err, "line 1 is not a NAME=value setting")
+}
@@ -0,0 +1,55 @@
+// Package config publishes configuration to concurrent readers as immutable snapshots.
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Snapshot holds the current value of a configuration. Readers load it without locks and keep using the
+// value they loaded, so a reader working through an invocation sees one consistent view even while a
+// reload publishes a new value. Published values must not be modified.
+type Snapshot[T any] struct {
+	value  atomic.Value // value holds a holder of the current T.
+	load   func() T
+	loadMu sync.Mutex // loadMu serializes loads so concurrent reloads publish in the order they read.
+}
+
+// holder gives every stored value the same concrete type, as required by atomic.Value.
+type holder[T any] struct{ value T }
+
+// NewSnapshot returns a Snapshot populated by load, which is called on first use and on every Reload.
+func NewSnapshot[T any](load func() T) *Snapshot[T] {
+	return &Snapshot[T]{load: load}
+}
+
+// Load returns the current value, loading it when none was published yet.
+func (s *Snapshot[T]) Load() T {
+	if current, ok := s.value.Load().(holder[T]); ok {
+		return current.value
+	}
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	if current, ok := s.value.Load().(holder[T]); ok {
+		return current.value
+	}
+	value := s.load()
+	s.value.Store(holder[T]{value: value})
+	return value
+}
+
+// Reload loads a new value, publishes it and returns it.
+func (s *Snapshot[T]) Reload() T {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	value := s.load()
+	s.value.Store(holder[T]{value: value})
+	return value
+}
+
+// Store publishes the value.
+func (s *Snapshot[T]) Store(value T) {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	s.value.Store(holder[T]{value: value})
+}
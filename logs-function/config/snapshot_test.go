@@ -0,0 +1,86 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// settings is a configuration whose fields are always written together, so a torn read is detectable.
+type settings struct {
+	generation int64
+	copy       int64
+	labels     map[string]int64
+}
+
+// TestSnapshotLoadsLazily tests that the value is loaded on first use and kept until reloaded.
+func TestSnapshotLoadsLazily(t *testing.T) {
+	loads := 0
+	snapshot := NewSnapshot(func() int {
+		loads++
+		return loads
+	})
+
+	assert.Equal(t, 0, loads)
+	assert.Equal(t, 1, snapshot.Load())
+	assert.Equal(t, 1, snapshot.Load())
+	assert.Equal(t, 2, snapshot.Reload())
+	assert.Equal(t, 2, snapshot.Load())
+
+	snapshot.Store(10)
+	assert.Equal(t, 10, snapshot.Load())
+	assert.Equal(t, 2, loads)
+}
+
+// TestSnapshotConcurrentReloads tests, under the race detector, that readers always see a consistent value
+// while reloads publish new ones, and never observe generations going backwards.
+func TestSnapshotConcurrentReloads(t *testing.T) {
+	var generation atomic.Int64
+	snapshot := NewSnapshot(func() settings {
+		g := generation.Add(1)
+		return settings{generation: g, copy: g, labels: map[string]int64{"generation": g}}
+	})
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					snapshot.Reload()
+				}
+			}
+		}()
+	}
+
+	var readers sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			last := int64(0)
+			for j := 0; j < 10000; j++ {
+				current := snapshot.Load()
+				if current.generation != current.copy || current.labels["generation"] != current.generation {
+					t.Errorf("torn read: %+v", current)
+					return
+				}
+				if current.generation < last {
+					t.Errorf("generation went back from %d to %d", last, current.generation)
+					return
+				}
+				last = current.generation
+			}
+		}()
+	}
+	readers.Wait()
+	close(stop)
+	wg.Wait()
+}
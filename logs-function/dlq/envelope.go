@@ -0,0 +1,72 @@
+// Package dlq defines the dead-letter envelope used to persist log batches that could not be
+// delivered to New Relic, so they can later be replayed.
+package dlq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// EnvelopeVersion is the current version of the envelope schema. Any change to the serialized
+// fields must bump this version so replay tooling can keep reading older envelopes.
+const EnvelopeVersion = 1
+
+// Envelope wraps a batch that failed delivery together with the metadata needed to replay it. Batches are
+// replayed by resending Transformed as is; the records they were built from are not kept, so Original is only set
+// for payloads rejected before they were transformed, which hold no batch to resend.
+type Envelope struct {
+	Version          int                      `json:"version"`
+	TransformVersion string                   `json:"transformVersion"`   // TransformVersion is the forwarder version that produced Transformed.
+	Original         json.RawMessage          `json:"original,omitempty"` // Original holds a payload rejected before it was transformed.
+	Transformed      common.DetailedLogsBatch `json:"transformed"`        // Transformed is the exact batch that failed to send.
+	ErrorChain       []string                 `json:"errorChain"`         // ErrorChain lists the delivery error and its wrapped causes, outermost first.
+	Attempts         int                      `json:"attempts"`
+	FirstAttemptAt   time.Time                `json:"firstAttemptAt"`
+	LastAttemptAt    time.Time                `json:"lastAttemptAt"`
+}
+
+// NewEnvelope creates an envelope for a batch whose delivery failed with err.
+func NewEnvelope(batch common.DetailedLogsBatch, original json.RawMessage, err error, attempts int, firstAttemptAt time.Time) Envelope {
+	return Envelope{
+		Version:          EnvelopeVersion,
+		TransformVersion: common.InstrumentationVersion,
+		Original:         original,
+		Transformed:      batch,
+		ErrorChain:       errorChain(err),
+		Attempts:         attempts,
+		FirstAttemptAt:   firstAttemptAt.UTC(),
+		LastAttemptAt:    clock.Now().UTC(),
+	}
+}
+
+// Decode parses a serialized envelope, rejecting versions newer than this forwarder understands.
+func Decode(data []byte) (Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return Envelope{}, fmt.Errorf("invalid dlq envelope: %w", err)
+	}
+	if envelope.Version < 1 || envelope.Version > EnvelopeVersion {
+		return Envelope{}, fmt.Errorf("unsupported dlq envelope version %d", envelope.Version)
+	}
+	return envelope, nil
+}
+
+// Rejected reports whether the envelope holds a payload rejected before it was transformed. Such a payload has no
+// batch to resend, so it is written to the DLQ bucket rather than the retry stream.
+func (e Envelope) Rejected() bool {
+	return len(e.Transformed) == 0 && len(e.Original) > 0
+}
+
+// errorChain flattens err and its wrapped causes into their messages.
+func errorChain(err error) []string {
+	var chain []string
+	for ; err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, err.Error())
+	}
+	return chain
+}
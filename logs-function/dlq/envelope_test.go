@@ -0,0 +1,69 @@
+package dlq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// envelopeV1 is a version 1 envelope as written by earlier forwarder releases. It must keep decoding
+// unchanged; if this test breaks, bump EnvelopeVersion and keep a decoder for version 1.
+const envelopeV1 = `{
+	"version": 1,
+	"transformVersion": "1.0.0",
+	"original": [{"message":"hello"}],
+	"transformed": [{"common":{"attributes":{"instrumentation.provider":"oci"},"timestamp":""},"logs":[{"message":"hello"}]}],
+	"errorChain": ["post failed: 503", "503"],
+	"attempts": 3,
+	"firstAttemptAt": "2024-01-01T00:00:00Z",
+	"lastAttemptAt": "2024-01-01T00:00:05Z"
+}`
+
+// TestEnvelopeSchema tests that the serialized field names of the envelope stay stable.
+func TestEnvelopeSchema(t *testing.T) {
+	envelope, err := Decode([]byte(envelopeV1))
+	assert.NoError(t, err)
+
+	encoded, err := json.Marshal(envelope)
+	assert.NoError(t, err)
+	assert.JSONEq(t, envelopeV1, string(encoded))
+}
+
+// TestDecode tests decoding of valid and unsupported envelopes.
+func TestDecode(t *testing.T) {
+	envelope, err := Decode([]byte(envelopeV1))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, envelope.Attempts)
+	assert.Equal(t, []string{"post failed: 503", "503"}, envelope.ErrorChain)
+	assert.Equal(t, "hello", envelope.Transformed[0].Entries[0]["message"])
+
+	_, err = Decode([]byte(`{"version": 2}`))
+	assert.ErrorContains(t, err, "unsupported dlq envelope version 2")
+
+	_, err = Decode([]byte(`{"version": 0}`))
+	assert.ErrorContains(t, err, "unsupported dlq envelope version 0")
+
+	_, err = Decode([]byte(`not json`))
+	assert.ErrorContains(t, err, "invalid dlq envelope")
+}
+
+// TestNewEnvelope tests envelope construction from a failed delivery.
+func TestNewEnvelope(t *testing.T) {
+	cause := errors.New("503")
+	batch := common.DetailedLogsBatch{{Entries: common.LogData{{"message": "hello"}}}}
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	envelope := NewEnvelope(batch, nil, fmt.Errorf("post failed: %w", cause), 2, first)
+
+	assert.Equal(t, EnvelopeVersion, envelope.Version)
+	assert.Equal(t, common.InstrumentationVersion, envelope.TransformVersion)
+	assert.Equal(t, []string{"post failed: 503", "503"}, envelope.ErrorChain)
+	assert.Equal(t, 2, envelope.Attempts)
+	assert.Equal(t, first, envelope.FirstAttemptAt)
+	assert.False(t, envelope.LastAttemptAt.Before(first))
+}
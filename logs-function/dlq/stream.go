@@ -0,0 +1,152 @@
+package dlq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+	"github.com/oracle/oci-go-sdk/v65/streaming"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/ociclient"
+)
+
+// MaxStreamMessageSize is the largest message accepted by OCI Streaming. Larger envelopes go to the DLQ bucket.
+const MaxStreamMessageSize = 1 << 20
+
+// EnvelopeWriter persists envelopes and returns where they were written.
+type EnvelopeWriter interface {
+	Write(ctx context.Context, envelope Envelope) (string, error)
+}
+
+// StreamAPI is the subset of the OCI Streaming client used to publish envelopes.
+type StreamAPI interface {
+	PutMessages(ctx context.Context, request streaming.PutMessagesRequest) (streaming.PutMessagesResponse, error)
+}
+
+// StreamPublisher publishes envelopes to the retry stream, from which a Connector Hub connector delivers them
+// back to the function so that retries do not consume the time budget of the failed invocation.
+type StreamPublisher struct {
+	Client   StreamAPI
+	StreamID string
+}
+
+// Write publishes the envelope and returns its stream location as "<stream>/<partition>/<offset>".
+func (p *StreamPublisher) Write(ctx context.Context, envelope Envelope) (string, error) {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode dlq envelope: %w", err)
+	}
+	if len(data) > MaxStreamMessageSize {
+		return "", fmt.Errorf("envelope of %d bytes exceeds the stream message limit", len(data))
+	}
+
+	response, err := p.Client.PutMessages(ctx, streaming.PutMessagesRequest{
+		StreamId: ociCommon.String(p.StreamID),
+		PutMessagesDetails: streaming.PutMessagesDetails{
+			Messages: []streaming.PutMessagesDetailsEntry{{Value: data}},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to publish to retry stream: %w", err)
+	}
+	if len(response.Entries) == 0 {
+		return "", errors.New("retry stream returned no result")
+	}
+	entry := response.Entries[0]
+	if entry.Error != nil {
+		return "", fmt.Errorf("retry stream rejected the message: %s: %s", *entry.Error, stringValue(entry.ErrorMessage))
+	}
+	var offset int64
+	if entry.Offset != nil {
+		offset = *entry.Offset
+	}
+	return fmt.Sprintf("%s/%s/%d", p.StreamID, stringValue(entry.Partition), offset), nil
+}
+
+// stringValue dereferences an optional string of an OCI response.
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// RetryWriter republishes envelopes to the retry stream until they reach MaxAttempts, and writes them
+// to the fallback writer afterwards or when publishing fails. Rejected payloads go to the fallback writer directly.
+type RetryWriter struct {
+	Stream      EnvelopeWriter
+	Fallback    EnvelopeWriter
+	MaxAttempts int
+}
+
+// Write publishes or persists the envelope.
+func (w *RetryWriter) Write(ctx context.Context, envelope Envelope) (string, error) {
+	var streamErr error
+	if envelope.Rejected() {
+		streamErr = errors.New("rejected payloads are not retried")
+	} else if envelope.Attempts < w.MaxAttempts {
+		location, err := w.Stream.Write(ctx, envelope)
+		if err == nil {
+			return "stream:" + location, nil
+		}
+		streamErr = err
+	}
+	if w.Fallback == nil {
+		if streamErr == nil {
+			streamErr = fmt.Errorf("batch exhausted %d delivery attempts", envelope.Attempts)
+		}
+		return "", fmt.Errorf("no dlq bucket configured: %w", streamErr)
+	}
+	return w.Fallback.Write(ctx, envelope)
+}
+
+// NewFromEnv returns the writer of undelivered batches configured in the function environment: the retry
+// stream backed by the DLQ bucket, either one alone, or nil without error when neither is configured.
+func NewFromEnv() (EnvelopeWriter, error) {
+	bucket, err := NewWriterFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	streamID := os.Getenv(common.RetryStreamOCID)
+	if streamID == "" {
+		if bucket == nil {
+			return nil, nil
+		}
+		return bucket, nil
+	}
+	endpoint := os.Getenv(common.RetryStreamEndpoint)
+	if endpoint == "" {
+		return nil, fmt.Errorf("%s is set but %s is not", common.RetryStreamOCID, common.RetryStreamEndpoint)
+	}
+
+	provider, err := auth.ResourcePrincipalConfigurationProvider()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource principal configuration provider: %w", err)
+	}
+	client, err := streaming.NewStreamClientWithConfigurationProvider(provider, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCI streaming client: %w", err)
+	}
+	ociclient.Configure(&client.BaseClient, "Streaming")
+
+	writer := &RetryWriter{Stream: &StreamPublisher{Client: client, StreamID: streamID}, MaxAttempts: retryMaxAttempts()}
+	if bucket != nil {
+		writer.Fallback = bucket
+	}
+	return writer, nil
+}
+
+// retryMaxAttempts returns the number of delivery attempts before a batch leaves the retry stream.
+func retryMaxAttempts() int {
+	if value, err := strconv.Atoi(os.Getenv(common.RetryMaxAttempts)); err == nil && value > 0 {
+		return value
+	}
+	return common.DefaultRetryMaxAttempts
+}
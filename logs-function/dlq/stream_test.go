@@ -0,0 +1,116 @@
+package dlq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/streaming"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStream records published messages, or fails every publish with err.
+type fakeStream struct {
+	messages [][]byte
+	err      error
+}
+
+func (s *fakeStream) PutMessages(_ context.Context, request streaming.PutMessagesRequest) (streaming.PutMessagesResponse, error) {
+	if s.err != nil {
+		return streaming.PutMessagesResponse{}, s.err
+	}
+	s.messages = append(s.messages, request.Messages[0].Value)
+	partition, offset := "0", int64(len(s.messages))
+	return streaming.PutMessagesResponse{PutMessagesResult: streaming.PutMessagesResult{
+		Entries: []streaming.PutMessagesResultEntry{{Partition: &partition, Offset: &offset}},
+	}}, nil
+}
+
+// recordingWriter records the envelopes it writes.
+type recordingWriter struct {
+	envelopes []Envelope
+}
+
+func (w *recordingWriter) Write(_ context.Context, envelope Envelope) (string, error) {
+	w.envelopes = append(w.envelopes, envelope)
+	return "bucket", nil
+}
+
+// TestStreamPublisher tests that envelopes are published as decodable stream messages.
+func TestStreamPublisher(t *testing.T) {
+	stream := &fakeStream{}
+	publisher := &StreamPublisher{Client: stream, StreamID: "ocid1.stream.a"}
+
+	location, err := publisher.Write(context.Background(), testEnvelope(10))
+	assert.NoError(t, err)
+	assert.Equal(t, "ocid1.stream.a/0/1", location)
+	envelope, err := Decode(stream.messages[0])
+	assert.NoError(t, err)
+	assert.Equal(t, 1, envelope.Attempts)
+
+	_, err = publisher.Write(context.Background(), testEnvelope(MaxStreamMessageSize))
+	assert.ErrorContains(t, err, "exceeds the stream message limit")
+}
+
+// TestRetryWriter tests that envelopes go to the retry stream until they exhaust their attempts.
+func TestRetryWriter(t *testing.T) {
+	envelope := func(attempts int, rejected bool) Envelope {
+		if rejected {
+			return NewEnvelope(nil, json.RawMessage(`[{"x":1}]`), errors.New("schema violation"), attempts, time.Now())
+		}
+		e := testEnvelope(10)
+		e.Attempts = attempts
+		return e
+	}
+
+	tests := []struct {
+		name        string
+		attempts    int
+		rejected    bool
+		streamErr   error
+		fallback    bool
+		expected    string
+		expectedErr string
+	}{
+		{"retried through the stream", 1, false, nil, true, "stream:s/0/1", ""},
+		{"exhausted attempts go to the bucket", 3, false, nil, true, "bucket", ""},
+		{"stream failure goes to the bucket", 1, false, errors.New("unavailable"), true, "bucket", ""},
+		{"stream failure without bucket", 1, false, errors.New("unavailable"), false, "", "unavailable"},
+		{"exhausted attempts without bucket", 3, false, nil, false, "", "exhausted 3 delivery attempts"},
+		{"rejected payload goes to the bucket", 1, true, nil, true, "bucket", ""},
+		{"rejected payload without bucket", 1, true, nil, false, "", "rejected payloads are not retried"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &RetryWriter{Stream: &StreamPublisher{Client: &fakeStream{err: tt.streamErr}, StreamID: "s"}, MaxAttempts: 3}
+			if tt.fallback {
+				writer.Fallback = &recordingWriter{}
+			}
+
+			location, err := writer.Write(context.Background(), envelope(tt.attempts, tt.rejected))
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, location)
+		})
+	}
+}
+
+// TestNewFromEnv tests that nothing is dead-lettered unless a bucket or stream is configured.
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("DLQ_BUCKET", "")
+	t.Setenv("RETRY_STREAM_OCID", "")
+	writer, err := NewFromEnv()
+	assert.NoError(t, err)
+	assert.Nil(t, writer)
+
+	t.Setenv("RETRY_STREAM_OCID", "ocid1.stream.a")
+	t.Setenv("RETRY_STREAM_ENDPOINT", "")
+	_, err = NewFromEnv()
+	assert.ErrorContains(t, err, "RETRY_STREAM_ENDPOINT")
+}
@@ -0,0 +1,276 @@
+package dlq
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/ociclient"
+)
+
+// Multipart upload settings used by NewWriter.
+const (
+	DefaultPartSize          = 8 << 20 // DefaultPartSize is the part size, and the largest envelope written with a single put.
+	DefaultUploadConcurrency = 4       // DefaultUploadConcurrency is the number of parts uploaded at the same time.
+	DefaultMaxAttempts       = 3       // DefaultMaxAttempts is the number of attempts for each put or part upload.
+	DefaultPrefix            = "dlq"   // DefaultPrefix is the object name prefix used when DLQ_PREFIX is unset.
+)
+
+// retryBackoff is the delay before the second attempt of an upload, doubled on every further attempt.
+var retryBackoff = 200 * time.Millisecond
+
+// ObjectStorageAPI is the subset of the OCI Object Storage client used to write envelopes.
+type ObjectStorageAPI interface {
+	PutObject(ctx context.Context, request objectstorage.PutObjectRequest) (objectstorage.PutObjectResponse, error)
+	CreateMultipartUpload(ctx context.Context, request objectstorage.CreateMultipartUploadRequest) (objectstorage.CreateMultipartUploadResponse, error)
+	UploadPart(ctx context.Context, request objectstorage.UploadPartRequest) (objectstorage.UploadPartResponse, error)
+	CommitMultipartUpload(ctx context.Context, request objectstorage.CommitMultipartUploadRequest) (objectstorage.CommitMultipartUploadResponse, error)
+	AbortMultipartUpload(ctx context.Context, request objectstorage.AbortMultipartUploadRequest) (objectstorage.AbortMultipartUploadResponse, error)
+}
+
+// Writer persists envelopes to an Object Storage bucket. Envelopes larger than PartSize are written
+// with a multipart upload whose parts are uploaded concurrently and retried independently. When KMSKeyID
+// is set, objects are encrypted server-side with that customer-managed key.
+type Writer struct {
+	Client      ObjectStorageAPI
+	Namespace   string
+	Bucket      string
+	Prefix      string
+	KMSKeyID    string
+	PartSize    int
+	Concurrency int
+	MaxAttempts int
+}
+
+// NewWriter creates a Writer with the default multipart settings.
+func NewWriter(client ObjectStorageAPI, namespace string, bucket string, prefix string) *Writer {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Writer{
+		Client:      client,
+		Namespace:   namespace,
+		Bucket:      bucket,
+		Prefix:      prefix,
+		PartSize:    DefaultPartSize,
+		Concurrency: DefaultUploadConcurrency,
+		MaxAttempts: DefaultMaxAttempts,
+	}
+}
+
+// NewWriterFromEnv creates a Writer for the bucket configured in the function environment, authenticated
+// with the function's resource principal. It returns nil without error when no DLQ bucket is configured.
+func NewWriterFromEnv() (*Writer, error) {
+	bucket := os.Getenv(common.DLQBucket)
+	if bucket == "" {
+		return nil, nil
+	}
+	namespace := os.Getenv(common.DLQNamespace)
+	if namespace == "" {
+		return nil, fmt.Errorf("%s is set but %s is not", common.DLQBucket, common.DLQNamespace)
+	}
+
+	provider, err := auth.ResourcePrincipalConfigurationProvider()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource principal configuration provider: %w", err)
+	}
+	client, err := objectstorage.NewObjectStorageClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCI object storage client: %w", err)
+	}
+	ociclient.Configure(&client.BaseClient, "ObjectStorage")
+	writer := NewWriter(client, namespace, bucket, os.Getenv(common.DLQPrefix))
+	writer.KMSKeyID = os.Getenv(common.DLQKMSKeyOCID)
+	return writer, nil
+}
+
+// ObjectName returns the name of an envelope written at t, partitioned by UTC date and hour so that
+// lifecycle rules and replay tooling can select whole days by prefix,
+// e.g. "dlq/2024/01/31/15/20240131T150405.000000000Z-1a2b3c4d5e6f7a8b.json".
+func ObjectName(prefix string, t time.Time, id string) string {
+	t = t.UTC()
+	return path.Join(prefix, t.Format("2006/01/02/15"), t.Format("20060102T150405.000000000Z")+"-"+id+".json")
+}
+
+// Write serializes the envelope and stores it, returning the object name.
+func (w *Writer) Write(ctx context.Context, envelope Envelope) (string, error) {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode dlq envelope: %w", err)
+	}
+
+	name := ObjectName(w.Prefix, clock.Now(), randomID())
+	if len(data) <= w.PartSize {
+		err = w.retry(ctx, func() error {
+			_, err := w.Client.PutObject(ctx, objectstorage.PutObjectRequest{
+				NamespaceName:  ociCommon.String(w.Namespace),
+				BucketName:     ociCommon.String(w.Bucket),
+				ObjectName:     ociCommon.String(name),
+				ContentLength:  ociCommon.Int64(int64(len(data))),
+				ContentType:    ociCommon.String("application/json"),
+				OpcSseKmsKeyId: w.kmsKeyID(),
+				PutObjectBody:  io.NopCloser(bytes.NewReader(data)),
+			})
+			return err
+		})
+	} else {
+		err = w.writeMultipart(ctx, name, data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write dlq object %s: %w", name, err)
+	}
+	return name, nil
+}
+
+// writeMultipart uploads data in PartSize parts, committing the upload once every part succeeded
+// and aborting it otherwise so no incomplete upload is left behind.
+func (w *Writer) writeMultipart(ctx context.Context, name string, data []byte) error {
+	created, err := w.Client.CreateMultipartUpload(ctx, objectstorage.CreateMultipartUploadRequest{
+		NamespaceName:  ociCommon.String(w.Namespace),
+		BucketName:     ociCommon.String(w.Bucket),
+		OpcSseKmsKeyId: w.kmsKeyID(),
+		CreateMultipartUploadDetails: objectstorage.CreateMultipartUploadDetails{
+			Object:      ociCommon.String(name),
+			ContentType: ociCommon.String("application/json"),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+	uploadID := created.UploadId
+
+	parts, err := w.uploadParts(ctx, name, uploadID, data)
+	if err == nil {
+		_, err = w.Client.CommitMultipartUpload(ctx, objectstorage.CommitMultipartUploadRequest{
+			NamespaceName:                ociCommon.String(w.Namespace),
+			BucketName:                   ociCommon.String(w.Bucket),
+			ObjectName:                   ociCommon.String(name),
+			UploadId:                     uploadID,
+			CommitMultipartUploadDetails: objectstorage.CommitMultipartUploadDetails{PartsToCommit: parts},
+		})
+		if err == nil {
+			return nil
+		}
+		err = fmt.Errorf("failed to commit multipart upload: %w", err)
+	}
+
+	if _, abortErr := w.Client.AbortMultipartUpload(context.Background(), objectstorage.AbortMultipartUploadRequest{
+		NamespaceName: ociCommon.String(w.Namespace),
+		BucketName:    ociCommon.String(w.Bucket),
+		ObjectName:    ociCommon.String(name),
+		UploadId:      uploadID,
+	}); abortErr != nil {
+		err = fmt.Errorf("%w (abort failed: %v)", err, abortErr)
+	}
+	return err
+}
+
+// uploadParts uploads the parts of data with up to Concurrency uploads in flight and returns them in order.
+func (w *Writer) uploadParts(ctx context.Context, name string, uploadID *string, data []byte) ([]objectstorage.CommitMultipartUploadPartDetails, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		parts    []objectstorage.CommitMultipartUploadPartDetails
+		firstErr error
+	)
+	slots := make(chan struct{}, max(w.Concurrency, 1))
+	for offset, partNum := 0, 1; offset < len(data); offset, partNum = offset+w.PartSize, partNum+1 {
+		part := data[offset:min(offset+w.PartSize, len(data))]
+		num := partNum
+
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			var etag *string
+			err := w.retry(ctx, func() error {
+				response, err := w.Client.UploadPart(ctx, objectstorage.UploadPartRequest{
+					NamespaceName:  ociCommon.String(w.Namespace),
+					BucketName:     ociCommon.String(w.Bucket),
+					ObjectName:     ociCommon.String(name),
+					UploadId:       uploadID,
+					UploadPartNum:  ociCommon.Int(num),
+					ContentLength:  ociCommon.Int64(int64(len(part))),
+					UploadPartBody: io.NopCloser(bytes.NewReader(part)),
+				})
+				etag = response.ETag
+				return err
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to upload part %d: %w", num, err)
+					cancel()
+				}
+				return
+			}
+			parts = append(parts, objectstorage.CommitMultipartUploadPartDetails{PartNum: ociCommon.Int(num), Etag: etag})
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	sort.Slice(parts, func(i, j int) bool { return *parts[i].PartNum < *parts[j].PartNum })
+	return parts, nil
+}
+
+// kmsKeyID returns the customer-managed key objects are encrypted with, or nil for the bucket's key.
+func (w *Writer) kmsKeyID() *string {
+	if w.KMSKeyID == "" {
+		return nil
+	}
+	return ociCommon.String(w.KMSKeyID)
+}
+
+// retry calls fn up to MaxAttempts times with exponential backoff, stopping early when ctx is done.
+func (w *Writer) retry(ctx context.Context, fn func() error) error {
+	delay := retryBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || attempt >= w.MaxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// randomID returns a short random hex identifier that keeps object names unique across containers.
+func randomID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%016x", clock.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,174 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// fakeObjectStorage stores objects in memory and fails the first failures calls of each part number.
+type fakeObjectStorage struct {
+	mu        sync.Mutex
+	objects   map[string]string
+	parts     map[int]string
+	failures  map[int]int
+	puts      int
+	kmsKeyIDs []*string
+	committed bool
+	aborted   bool
+}
+
+func newFakeObjectStorage() *fakeObjectStorage {
+	return &fakeObjectStorage{objects: map[string]string{}, parts: map[int]string{}, failures: map[int]int{}}
+}
+
+func (f *fakeObjectStorage) PutObject(_ context.Context, request objectstorage.PutObjectRequest) (objectstorage.PutObjectResponse, error) {
+	body, _ := io.ReadAll(request.PutObjectBody)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.puts++
+	f.kmsKeyIDs = append(f.kmsKeyIDs, request.OpcSseKmsKeyId)
+	f.objects[*request.ObjectName] = string(body)
+	return objectstorage.PutObjectResponse{}, nil
+}
+
+func (f *fakeObjectStorage) CreateMultipartUpload(_ context.Context, request objectstorage.CreateMultipartUploadRequest) (objectstorage.CreateMultipartUploadResponse, error) {
+	f.mu.Lock()
+	f.kmsKeyIDs = append(f.kmsKeyIDs, request.OpcSseKmsKeyId)
+	f.mu.Unlock()
+	uploadID := "upload-1"
+	return objectstorage.CreateMultipartUploadResponse{MultipartUpload: objectstorage.MultipartUpload{UploadId: &uploadID}}, nil
+}
+
+func (f *fakeObjectStorage) UploadPart(_ context.Context, request objectstorage.UploadPartRequest) (objectstorage.UploadPartResponse, error) {
+	body, _ := io.ReadAll(request.UploadPartBody)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	num := *request.UploadPartNum
+	if f.failures[num] > 0 {
+		f.failures[num]--
+		return objectstorage.UploadPartResponse{}, errors.New("503")
+	}
+	f.parts[num] = string(body)
+	etag := "etag"
+	return objectstorage.UploadPartResponse{ETag: &etag}, nil
+}
+
+func (f *fakeObjectStorage) CommitMultipartUpload(_ context.Context, request objectstorage.CommitMultipartUploadRequest) (objectstorage.CommitMultipartUploadResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var object strings.Builder
+	for _, part := range request.CommitMultipartUploadDetails.PartsToCommit {
+		object.WriteString(f.parts[*part.PartNum])
+	}
+	f.objects[*request.ObjectName] = object.String()
+	f.committed = true
+	return objectstorage.CommitMultipartUploadResponse{}, nil
+}
+
+func (f *fakeObjectStorage) AbortMultipartUpload(_ context.Context, _ objectstorage.AbortMultipartUploadRequest) (objectstorage.AbortMultipartUploadResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.aborted = true
+	return objectstorage.AbortMultipartUploadResponse{}, nil
+}
+
+// testEnvelope returns an envelope whose serialized size exceeds size bytes.
+func testEnvelope(size int) Envelope {
+	batch := common.DetailedLogsBatch{{Entries: common.LogData{{"message": strings.Repeat("x", size)}}}}
+	return NewEnvelope(batch, nil, errors.New("503"), 1, time.Now())
+}
+
+// TestObjectName tests the date-partitioned object naming.
+func TestObjectName(t *testing.T) {
+	at := time.Date(2024, 1, 31, 15, 4, 5, 6, time.FixedZone("CET", 3600))
+	assert.Equal(t, "dlq/2024/01/31/14/20240131T140405.000000006Z-abc.json", ObjectName("dlq", at, "abc"))
+}
+
+// TestWriteSinglePut tests that small envelopes are written with a single put.
+func TestWriteSinglePut(t *testing.T) {
+	client := newFakeObjectStorage()
+	writer := NewWriter(client, "ns", "bucket", "")
+
+	name, err := writer.Write(context.Background(), testEnvelope(10))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(name, DefaultPrefix+"/"))
+	assert.Equal(t, 1, client.puts)
+
+	envelope, err := Decode([]byte(client.objects[name]))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"503"}, envelope.ErrorChain)
+}
+
+// TestWriteMultipart tests that large envelopes are uploaded in parts, retrying failed parts.
+func TestWriteMultipart(t *testing.T) {
+	retryBackoff = time.Millisecond
+	client := newFakeObjectStorage()
+	client.failures[2] = 2
+	writer := NewWriter(client, "ns", "bucket", "dlq")
+	writer.PartSize = 64
+
+	name, err := writer.Write(context.Background(), testEnvelope(1000))
+	assert.NoError(t, err)
+	assert.Zero(t, client.puts)
+	assert.True(t, client.committed)
+	assert.Greater(t, len(client.parts), 10)
+
+	envelope, err := Decode([]byte(client.objects[name]))
+	assert.NoError(t, err)
+	assert.Len(t, envelope.Transformed[0].Entries[0]["message"], 1000)
+}
+
+// TestWriteMultipartAborts tests that an upload whose part keeps failing is aborted.
+func TestWriteMultipartAborts(t *testing.T) {
+	retryBackoff = time.Millisecond
+	client := newFakeObjectStorage()
+	client.failures[3] = DefaultMaxAttempts
+	writer := NewWriter(client, "ns", "bucket", "dlq")
+	writer.PartSize = 64
+
+	_, err := writer.Write(context.Background(), testEnvelope(1000))
+	assert.ErrorContains(t, err, "failed to upload part 3")
+	assert.True(t, client.aborted)
+	assert.False(t, client.committed)
+}
+
+// TestWriteKMSKey tests that objects are encrypted with the configured customer-managed key on both upload paths.
+func TestWriteKMSKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		kmsKeyID string
+		size     int
+		expected *string
+	}{
+		{"single put", "ocid1.key.a", 10, stringPointer("ocid1.key.a")},
+		{"multipart", "ocid1.key.a", 1000, stringPointer("ocid1.key.a")},
+		{"bucket key", "", 10, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeObjectStorage()
+			writer := NewWriter(client, "ns", "bucket", "dlq")
+			writer.PartSize = 64
+			writer.KMSKeyID = tt.kmsKeyID
+
+			_, err := writer.Write(context.Background(), testEnvelope(tt.size))
+			assert.NoError(t, err)
+			assert.Equal(t, []*string{tt.expected}, client.kmsKeyIDs)
+		})
+	}
+}
+
+func stringPointer(s string) *string {
+	return &s
+}
@@ -0,0 +1,222 @@
+// Package lease elects one of several active/active deployments of the function, e.g. in two regions reading the
+// same stream, as the one forwarding records, so that records are not forwarded twice. The leaseholder is recorded
+// in an Object Storage object claimed and renewed with conditional puts; another deployment takes the lease over
+// once it expires without being renewed.
+package lease
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/newrelic/oci-log-integration/logs-function/ociclient"
+)
+
+// renewFraction is the share of the TTL after which a lease is checked again, so that the leaseholder renews it
+// well before it expires and a standby notices an expired lease soon after.
+const renewFraction = 3
+
+// ObjectStorageAPI is the subset of the OCI Object Storage client used to claim and renew the lease.
+type ObjectStorageAPI interface {
+	GetObject(ctx context.Context, request objectstorage.GetObjectRequest) (objectstorage.GetObjectResponse, error)
+	PutObject(ctx context.Context, request objectstorage.PutObjectRequest) (objectstorage.PutObjectResponse, error)
+}
+
+// Record is the content of the lease object.
+type Record struct {
+	Holder    string    `json:"holder"`    // Holder identifies the deployment holding the lease.
+	ExpiresAt time.Time `json:"expiresAt"` // ExpiresAt is the time after which another deployment may take the lease over.
+}
+
+// Lease is the forwarding lease of a deployment. The outcome of a check is kept for a third of the TTL, for the
+// lifetime of the warm container, so that invocations do not each call Object Storage.
+type Lease struct {
+	Client    ObjectStorageAPI
+	Namespace string
+	Bucket    string
+	Object    string
+	Holder    string
+	TTL       time.Duration
+
+	mu        sync.Mutex
+	held      bool
+	owner     string
+	checkedAt time.Time
+}
+
+// NewFromEnv creates the lease configured in the function environment, authenticated with the function's resource
+// principal. It returns nil without error when no lease bucket is configured.
+func NewFromEnv() (*Lease, error) {
+	bucket := os.Getenv(common.LeaseBucket)
+	if bucket == "" {
+		return nil, nil
+	}
+	namespace := os.Getenv(common.LeaseNamespace)
+	if namespace == "" {
+		return nil, fmt.Errorf("%s is set but %s is not", common.LeaseBucket, common.LeaseNamespace)
+	}
+	holder := os.Getenv(common.LeaseHolder)
+	if holder == "" {
+		holder = os.Getenv(auth.ResourcePrincipalRegionEnvVar)
+	}
+	if holder == "" {
+		return nil, fmt.Errorf("%s is set but %s is not and the function region is unknown", common.LeaseBucket, common.LeaseHolder)
+	}
+	ttl := time.Duration(common.DefaultLeaseTTL) * time.Second
+	if value := strings.TrimSpace(os.Getenv(common.LeaseTTL)); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("invalid %s value %q", common.LeaseTTL, value)
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+	object := os.Getenv(common.LeaseObject)
+	if object == "" {
+		object = common.DefaultLeaseObject
+	}
+
+	provider, err := auth.ResourcePrincipalConfigurationProvider()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource principal configuration provider: %w", err)
+	}
+	client, err := objectstorage.NewObjectStorageClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCI object storage client: %w", err)
+	}
+	ociclient.Configure(&client.BaseClient, "ObjectStorage")
+	return &Lease{Client: client, Namespace: namespace, Bucket: bucket, Object: object, Holder: holder, TTL: ttl}, nil
+}
+
+// Held reports whether the deployment holds the lease, claiming it when it is free or expired and renewing it when
+// it is due. An error means the lease could not be checked; the previous outcome is then checked again on the next
+// call.
+func (l *Lease) Held(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := clock.Now()
+	if !l.checkedAt.IsZero() && now.Sub(l.checkedAt) < l.TTL/renewFraction {
+		return l.held, nil
+	}
+
+	owner, err := l.acquire(ctx, now)
+	if err != nil {
+		return false, err
+	}
+	held := owner == l.Holder
+	switch {
+	case held && !l.held:
+		metrics.Default.Counter("lease.acquired").Inc()
+		logger.FromContext(ctx).Infof("Acquired lease %s as %s: forwarding records", l.Object, l.Holder)
+	case !held && (l.held || owner != l.owner):
+		logger.FromContext(ctx).Infof("Lease %s is held by %s: acknowledging records without forwarding them", l.Object, owner)
+	}
+	l.held, l.owner, l.checkedAt = held, owner, now
+	return held, nil
+}
+
+// acquire claims or renews the lease unless another deployment holds an unexpired lease, and returns the holder.
+// A concurrent claim, e.g. by another container of the same deployment, is resolved by reading the lease again.
+func (l *Lease) acquire(ctx context.Context, now time.Time) (string, error) {
+	current, etag, err := l.read(ctx)
+	if err != nil {
+		return "", err
+	}
+	if current != nil && current.Holder != l.Holder && now.Before(current.ExpiresAt) {
+		return current.Holder, nil
+	}
+
+	err = l.write(ctx, Record{Holder: l.Holder, ExpiresAt: now.Add(l.TTL)}, etag)
+	if !isConflict(err) {
+		return l.Holder, err
+	}
+	current, _, err = l.read(ctx)
+	if err != nil {
+		return "", err
+	}
+	if current == nil || !now.Before(current.ExpiresAt) {
+		return "", fmt.Errorf("lease %s changed concurrently", l.Object)
+	}
+	return current.Holder, nil
+}
+
+// read returns the lease and its entity tag, or nil when there is no lease yet.
+func (l *Lease) read(ctx context.Context) (*Record, *string, error) {
+	response, err := l.Client.GetObject(ctx, objectstorage.GetObjectRequest{
+		NamespaceName: ociCommon.String(l.Namespace),
+		BucketName:    ociCommon.String(l.Bucket),
+		ObjectName:    ociCommon.String(l.Object),
+	})
+	if statusCode(err) == http.StatusNotFound {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read lease %s: %w", l.Object, err)
+	}
+	defer response.Content.Close()
+
+	var record Record
+	if err := json.NewDecoder(response.Content).Decode(&record); err != nil {
+		// An unreadable lease is replaced, guarded by its entity tag
+		logger.FromContext(ctx).Warnf("Replacing unreadable lease %s: %v", l.Object, err)
+		return &Record{}, response.ETag, nil
+	}
+	return &record, response.ETag, nil
+}
+
+// write stores the lease if it was not changed since it was read with the given entity tag, or does not exist yet
+// when etag is nil.
+func (l *Lease) write(ctx context.Context, record Record, etag *string) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode lease: %w", err)
+	}
+	request := objectstorage.PutObjectRequest{
+		NamespaceName: ociCommon.String(l.Namespace),
+		BucketName:    ociCommon.String(l.Bucket),
+		ObjectName:    ociCommon.String(l.Object),
+		ContentLength: ociCommon.Int64(int64(len(data))),
+		ContentType:   ociCommon.String("application/json"),
+		PutObjectBody: io.NopCloser(bytes.NewReader(data)),
+	}
+	if etag != nil {
+		request.IfMatch = etag
+	} else {
+		request.IfNoneMatch = ociCommon.String("*")
+	}
+	if _, err := l.Client.PutObject(ctx, request); err != nil {
+		return fmt.Errorf("failed to write lease %s: %w", l.Object, err)
+	}
+	return nil
+}
+
+// isConflict reports whether err is the failure of a conditional put because the lease changed since it was read.
+func isConflict(err error) bool {
+	code := statusCode(err)
+	return code == http.StatusPreconditionFailed || code == http.StatusConflict
+}
+
+// statusCode returns the HTTP status code of an OCI service error, or 0.
+func statusCode(err error) int {
+	var serviceErr ociCommon.ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.GetHTTPStatusCode()
+	}
+	return 0
+}
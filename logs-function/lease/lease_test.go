@@ -0,0 +1,187 @@
+package lease
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// manualClock is a clock advanced by the test.
+type manualClock struct{ now time.Time }
+
+func (c *manualClock) Now() time.Time { return c.now }
+
+// statusError is an OCI service error with the given HTTP status code.
+type statusError int
+
+func (e statusError) Error() string {
+	return "Service error. http status code: " + strconv.Itoa(int(e))
+}
+func (e statusError) GetHTTPStatusCode() int  { return int(e) }
+func (e statusError) GetMessage() string      { return http.StatusText(int(e)) }
+func (e statusError) GetCode() string         { return "" }
+func (e statusError) GetOpcRequestID() string { return "opc-request-id" }
+
+// objectStore is an in-memory bucket holding one object that honors the conditions of puts.
+type objectStore struct {
+	mu        sync.Mutex
+	data      []byte
+	version   int
+	gets      int
+	getErr    error
+	beforePut func() // beforePut, when set, runs before each put, e.g. to change the object concurrently.
+}
+
+func (s *objectStore) GetObject(_ context.Context, _ objectstorage.GetObjectRequest) (objectstorage.GetObjectResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gets++
+	if s.getErr != nil {
+		return objectstorage.GetObjectResponse{}, s.getErr
+	}
+	if s.data == nil {
+		return objectstorage.GetObjectResponse{}, statusError(http.StatusNotFound)
+	}
+	return objectstorage.GetObjectResponse{
+		Content: io.NopCloser(bytes.NewReader(s.data)),
+		ETag:    ociCommon.String(strconv.Itoa(s.version)),
+	}, nil
+}
+
+func (s *objectStore) PutObject(_ context.Context, request objectstorage.PutObjectRequest) (objectstorage.PutObjectResponse, error) {
+	if s.beforePut != nil {
+		s.beforePut()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case request.IfNoneMatch != nil && s.data != nil:
+		return objectstorage.PutObjectResponse{}, statusError(http.StatusPreconditionFailed)
+	case request.IfMatch != nil && *request.IfMatch != strconv.Itoa(s.version):
+		return objectstorage.PutObjectResponse{}, statusError(http.StatusPreconditionFailed)
+	}
+	s.data, _ = io.ReadAll(request.PutObjectBody)
+	s.version++
+	return objectstorage.PutObjectResponse{}, nil
+}
+
+// newLease returns the lease of a holder in the store.
+func newLease(store *objectStore, holder string) *Lease {
+	return &Lease{Client: store, Namespace: "ns", Bucket: "leases", Object: common.DefaultLeaseObject, Holder: holder, TTL: time.Minute}
+}
+
+// TestLeaseFailover tests that one deployment holds the lease while renewing it and another takes it over once it expires.
+func TestLeaseFailover(t *testing.T) {
+	now := &manualClock{now: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)}
+	defer clock.Set(now)()
+	store := &objectStore{}
+	primary, secondary := newLease(store, "us-ashburn-1"), newLease(store, "us-phoenix-1")
+
+	assertHeld := func(lease *Lease, expected bool) {
+		t.Helper()
+		held, err := lease.Held(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, held)
+	}
+	assertHeld(primary, true)
+	assertHeld(secondary, false)
+
+	gets := store.gets
+	now.now = now.now.Add(10 * time.Second)
+	assertHeld(primary, true)
+	assertHeld(secondary, false)
+	assert.Equal(t, gets, store.gets, "checks are cached for a third of the TTL")
+
+	now.now = now.now.Add(50 * time.Second)
+	assertHeld(primary, true)
+	now.now = now.now.Add(50 * time.Second)
+	assertHeld(secondary, false)
+
+	now.now = now.now.Add(time.Minute)
+	assertHeld(secondary, true)
+	assertHeld(primary, false)
+}
+
+// TestLeaseErrors tests that a failed check is reported and retried on the next call.
+func TestLeaseErrors(t *testing.T) {
+	defer clock.Set(&manualClock{now: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)})()
+	store := &objectStore{getErr: statusError(http.StatusServiceUnavailable)}
+	lease := newLease(store, "us-ashburn-1")
+
+	_, err := lease.Held(context.Background())
+	assert.ErrorContains(t, err, "failed to read lease")
+
+	store.getErr = nil
+	held, err := lease.Held(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, held)
+}
+
+// TestLeaseConcurrentClaim tests that a claim losing to a concurrent one reads the winner.
+func TestLeaseConcurrentClaim(t *testing.T) {
+	defer clock.Set(&manualClock{now: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)})()
+	store := &objectStore{}
+	rival := newLease(store, "us-phoenix-1")
+	lease := newLease(store, "us-ashburn-1")
+	store.beforePut = func() {
+		store.beforePut = nil
+		_, err := rival.Held(context.Background())
+		assert.NoError(t, err)
+	}
+
+	held, err := lease.Held(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, held)
+	assert.Equal(t, "us-phoenix-1", lease.owner)
+}
+
+// TestLeaseUnreadable tests that an unreadable lease is replaced.
+func TestLeaseUnreadable(t *testing.T) {
+	defer clock.Set(&manualClock{now: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)})()
+	store := &objectStore{data: []byte("not json"), version: 3}
+
+	held, err := newLease(store, "us-ashburn-1").Held(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, 4, store.version)
+}
+
+// TestNewFromEnv tests the validation of the lease configuration.
+func TestNewFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{"disabled", map[string]string{}, ""},
+		{"no namespace", map[string]string{common.LeaseBucket: "leases"}, common.LeaseNamespace},
+		{"no holder", map[string]string{common.LeaseBucket: "leases", common.LeaseNamespace: "ns"}, common.LeaseHolder},
+		{"invalid ttl", map[string]string{common.LeaseBucket: "leases", common.LeaseNamespace: "ns", common.LeaseHolder: "a", common.LeaseTTL: "soon"}, common.LeaseTTL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{common.LeaseBucket, common.LeaseNamespace, common.LeaseHolder, common.LeaseTTL, "OCI_RESOURCE_PRINCIPAL_REGION"} {
+				t.Setenv(name, tt.env[name])
+			}
+			lease, err := NewFromEnv()
+			assert.Nil(t, lease)
+			if tt.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Field names attached to log lines by the context-carried logger.
+const (
+	FieldInvocationID = "invocationId"
+	FieldStage        = "stage"
+	FieldParser       = "parser"
+	FieldBatchIndex   = "batchIndex"
+)
+
+// Stages of an invocation used as the value of FieldStage.
+const (
+	StageUnmarshal = "unmarshal"
+	StageTransform = "transform"
+	StageDispatch  = "dispatch"
+	StagePost      = "post"
+	StageReport    = "report"
+)
+
+// fieldsKey is the context key the log fields are stored under.
+type fieldsKey struct{}
+
+// loggerKey is the context key the logger is stored under.
+type loggerKey struct{}
+
+// std is the logger of contexts carrying none, shared by every package, so that code running outside an
+// invocation, e.g. at startup, logs with the same level and format.
+var std = NewLogrusLogger(WithDebugLevel())
+
+// WithLogger returns a copy of ctx whose log lines are written with l instead of the shared logger.
+func WithLogger(ctx context.Context, l *log.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// WithFields returns a copy of ctx carrying the given log fields on top of the ones already carried.
+// Fields set later replace earlier ones with the same name.
+func WithFields(ctx context.Context, fields log.Fields) context.Context {
+	parent := Fields(ctx)
+	merged := make(log.Fields, len(parent)+len(fields))
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// WithInvocationID returns a copy of ctx whose log lines are tagged with the ID of the invocation.
+func WithInvocationID(ctx context.Context, id string) context.Context {
+	return WithFields(ctx, log.Fields{FieldInvocationID: id})
+}
+
+// WithStage returns a copy of ctx whose log lines are tagged with the given stage.
+func WithStage(ctx context.Context, stage string) context.Context {
+	return WithFields(ctx, log.Fields{FieldStage: stage})
+}
+
+// WithParser returns a copy of ctx whose log lines are tagged with the parser that recognized a record.
+func WithParser(ctx context.Context, name string) context.Context {
+	return WithFields(ctx, log.Fields{FieldParser: name})
+}
+
+// WithBatch returns a copy of ctx whose log lines are tagged with the index of a batch of the invocation
+// and the post stage.
+func WithBatch(ctx context.Context, index int) context.Context {
+	return WithFields(ctx, log.Fields{FieldStage: StagePost, FieldBatchIndex: index})
+}
+
+// Fields returns the log fields carried by ctx. The returned map must not be modified.
+func Fields(ctx context.Context) log.Fields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).(log.Fields)
+	return fields
+}
+
+// FromContext returns an entry of the logger carried by ctx, or of the shared logger, that includes the log
+// fields carried by ctx, so that output of concurrent invocations and workers can be told apart.
+func FromContext(ctx context.Context) *log.Entry {
+	l := std
+	if ctx != nil {
+		if carried, ok := ctx.Value(loggerKey{}).(*log.Logger); ok {
+			l = carried
+		}
+	}
+	return l.WithFields(Fields(ctx))
+}
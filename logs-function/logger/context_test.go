@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithFields tests that fields are layered on the ones already carried without changing the parent context.
+func TestWithFields(t *testing.T) {
+	invocation := WithInvocationID(context.Background(), "call-1")
+	batch := WithBatch(WithStage(invocation, StageDispatch), 3)
+	parsed := WithParser(WithStage(invocation, StageTransform), "flowLogs")
+
+	assert.Equal(t, log.Fields{FieldInvocationID: "call-1"}, Fields(invocation))
+	assert.Equal(t, log.Fields{FieldInvocationID: "call-1", FieldStage: StagePost, FieldBatchIndex: 3}, Fields(batch))
+	assert.Equal(t, log.Fields{FieldInvocationID: "call-1", FieldStage: StageTransform, FieldParser: "flowLogs"}, Fields(parsed))
+	assert.Nil(t, Fields(context.Background()))
+}
+
+// TestFromContext tests that log lines include the fields carried by the context.
+func TestFromContext(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogrusLogger(WithLogLevel("info"))
+	l.SetOutput(&out)
+	l.SetFormatter(&log.TextFormatter{DisableTimestamp: true, DisableColors: true})
+
+	base := WithLogger(context.Background(), l)
+	ctx := WithBatch(WithInvocationID(base, "call-1"), 2)
+	FromContext(ctx).WithField(FieldParser, "cloudguard").Warn("rejected")
+	FromContext(base).Info("plain")
+	assert.Same(t, std, FromContext(context.Background()).Logger)
+
+	assert.Equal(t, "level=warning msg=rejected batchIndex=2 invocationId=call-1 parser=cloudguard stage=post\n"+
+		"level=info msg=plain\n", out.String())
+}
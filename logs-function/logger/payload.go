@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AllowPayloadLogging is the environment variable name that must be set to "true" for debug logs to include payload contents.
+const AllowPayloadLogging = "ALLOW_PAYLOAD_LOGGING"
+
+// redacted replaces the values of sensitive fields in payload dumps.
+const redacted = "[REDACTED]"
+
+// maxDumpedStringLength bounds the length of each string value in payload dumps.
+const maxDumpedStringLength = 256
+
+// sensitiveKeyParts lists, in lower case, the key fragments whose values are never dumped.
+var sensitiveKeyParts = []string{"authorization", "password", "passwd", "secret", "token", "cookie", "licensekey", "apikey", "api-key", "credential", "signature"}
+
+// payloadLoggingNotice makes the notice about withheld payloads appear once per container.
+var payloadLoggingNotice sync.Once
+
+// DebugPayload logs the payload at debug level. Payload contents are only written when ALLOW_PAYLOAD_LOGGING
+// is "true", and even then the values of sensitive fields are redacted and long strings shortened, so
+// debug mode cannot silently copy log data or credentials into the function's own logs.
+func DebugPayload(l *log.Entry, msg string, payload interface{}) {
+	if !l.Logger.IsLevelEnabled(log.DebugLevel) {
+		return
+	}
+	if os.Getenv(AllowPayloadLogging) != "true" {
+		payloadLoggingNotice.Do(func() {
+			l.Debugf("Payload contents are withheld from debug logs; set %s=true to include them", AllowPayloadLogging)
+		})
+		return
+	}
+
+	dump, err := json.Marshal(ScrubPayload(payload))
+	if err != nil {
+		l.Debugf("%s: <unserializable payload: %v>", msg, err)
+		return
+	}
+	l.Debugf("%s: %s", msg, dump)
+}
+
+// ScrubPayload returns a copy of the payload, as decoded from JSON, with the values of sensitive fields
+// redacted and long strings shortened.
+func ScrubPayload(payload interface{}) interface{} {
+	switch v := payload.(type) {
+	case map[string]interface{}:
+		scrubbed := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if isSensitiveKey(key) {
+				scrubbed[key] = redacted
+				continue
+			}
+			scrubbed[key] = ScrubPayload(value)
+		}
+		return scrubbed
+	case []interface{}:
+		scrubbed := make([]interface{}, len(v))
+		for i, value := range v {
+			scrubbed[i] = ScrubPayload(value)
+		}
+		return scrubbed
+	case string:
+		if len(v) > maxDumpedStringLength {
+			return v[:maxDumpedStringLength] + "..."
+		}
+		return v
+	default:
+		return toGeneric(payload)
+	}
+}
+
+// toGeneric converts typed values such as structs into their generic JSON form so they can be scrubbed.
+func toGeneric(payload interface{}) interface{} {
+	switch payload.(type) {
+	case nil, bool, float64, int, int64, json.Number:
+		return payload
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return payload
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return payload
+	}
+	switch generic.(type) {
+	case map[string]interface{}, []interface{}, string:
+		return ScrubPayload(generic)
+	}
+	return generic
+}
+
+// isSensitiveKey reports whether a field name denotes a credential.
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
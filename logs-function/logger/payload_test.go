@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestScrubPayload tests that sensitive values are redacted and long strings shortened at any depth.
+func TestScrubPayload(t *testing.T) {
+	payload := []map[string]interface{}{{
+		"message": strings.Repeat("a", maxDumpedStringLength+10),
+		"data": map[string]interface{}{
+			"request": map[string]interface{}{
+				"headers": map[string]interface{}{"Authorization": []interface{}{"Bearer abc"}, "Accept": "json"},
+			},
+			"dbPassword": "hunter2",
+			"items":      []interface{}{map[string]interface{}{"apiKey": "k"}, 1.5},
+		},
+	}}
+
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"message": strings.Repeat("a", maxDumpedStringLength) + "...",
+		"data": map[string]interface{}{
+			"request": map[string]interface{}{
+				"headers": map[string]interface{}{"Authorization": redacted, "Accept": "json"},
+			},
+			"dbPassword": redacted,
+			"items":      []interface{}{map[string]interface{}{"apiKey": redacted}, 1.5},
+		},
+	}}, ScrubPayload(payload))
+}
+
+// TestDebugPayload tests that payload contents are only logged when explicitly allowed.
+func TestDebugPayload(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogrusLogger(WithLogLevel("debug"))
+	l.SetOutput(&out)
+	payload := map[string]interface{}{"message": "card 4111", "token": "t0k3n"}
+
+	t.Setenv(AllowPayloadLogging, "")
+	DebugPayload(log.NewEntry(l), "Received payload", payload)
+	assert.NotContains(t, out.String(), "card 4111")
+
+	t.Setenv(AllowPayloadLogging, "true")
+	DebugPayload(log.NewEntry(l), "Received payload", payload)
+	assert.Contains(t, out.String(), "card 4111")
+	assert.NotContains(t, out.String(), "t0k3n")
+
+	out.Reset()
+	l.SetLevel(log.InfoLevel)
+	DebugPayload(log.NewEntry(l), "Received payload", payload)
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,52 @@
+package loggroup
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// attributeBudget returns the number of attributes from which a record counts as approaching the New Relic cap,
+// read from ATTRIBUTE_BUDGET_PERCENT.
+func attributeBudget(ctx context.Context) int {
+	percent := common.DefaultAttributeBudgetPercent
+	if value := strings.TrimSpace(os.Getenv(common.AttributeBudgetPercent)); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > 100 {
+			logger.FromContext(ctx).Warnf("Ignoring invalid %s value %q", common.AttributeBudgetPercent, value)
+		} else {
+			percent = parsed
+		}
+	}
+	return common.MaxAttributes * percent / 100
+}
+
+// countAttributes returns the number of attributes New Relic stores for a value: one per leaf of nested objects.
+func countAttributes(value interface{}) int {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return 1
+	}
+	count := 0
+	for _, child := range object {
+		count += countAttributes(child)
+	}
+	return count
+}
+
+// observeAttributes records the number of attributes of a record, including the common attributes of its batch,
+// in the mean attributes per log group, and counts the records of the log group at or above the budget, so that
+// allowlists can be tuned before New Relic truncates records.
+func observeAttributes(record map[string]interface{}, logGroupID string, commonCount int, budget int) {
+	count := commonCount + countAttributes(record)
+	metrics.Default.Averages("top.loggroups.attributes").Observe(logGroupID, float64(count))
+	if count >= budget {
+		metrics.Default.Counter("records.attributes.nearcap").Inc()
+		metrics.Default.Talkers("top.loggroups.attributes.nearcap").Add(logGroupID, 1)
+	}
+}
@@ -0,0 +1,67 @@
+package loggroup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCountAttributes tests that nested objects count one attribute per leaf.
+func TestCountAttributes(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected int
+	}{
+		{"hello", 1},
+		{[]interface{}{1, 2, 3}, 1},
+		{map[string]interface{}{}, 0},
+		{map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": "x", "d": map[string]interface{}{"e": true}}}, 3},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, countAttributes(tt.value), "%v", tt.value)
+	}
+}
+
+// TestAttributeBudget tests the budget read from ATTRIBUTE_BUDGET_PERCENT.
+func TestAttributeBudget(t *testing.T) {
+	for value, expected := range map[string]int{"": 204, "50": 127, "100": 255, "0": 204, "150": 204, "many": 204} {
+		t.Setenv(common.AttributeBudgetPercent, value)
+		assert.Equal(t, expected, attributeBudget(context.Background()), value)
+	}
+}
+
+// TestProcessInvocationAttributeBudget tests that the attributes of each log group are averaged and the records
+// approaching the cap are counted.
+func TestProcessInvocationAttributeBudget(t *testing.T) {
+	t.Setenv(common.AttributeBudgetPercent, "6")
+	metrics.Default.Reset()
+	wideData := map[string]interface{}{}
+	for i := 0; i < 20; i++ {
+		wideData[fmt.Sprintf("field%d", i)] = i
+	}
+	wide := map[string]interface{}{"loggroupid": "ocid1.loggroup.wide"}
+	narrow := map[string]interface{}{"loggroupid": "ocid1.loggroup.narrow"}
+	logs := common.OCILoggingEvent{
+		{"data": wideData, "oracle": wide},
+		{"message": "short", "oracle": wide},
+		{"message": "short", "oracle": narrow},
+	}
+
+	channel := make(chan common.DetailedLogsBatch, 10)
+	ProcessInvocation(Invocation{Records: logs}, channel)
+	close(channel)
+
+	batch := <-channel
+	commonCount := len(batch[0].CommonData.Attributes)
+	snapshot := metrics.Default.Snapshot()
+	assert.Equal(t, []metrics.Average{
+		{Source: "ocid1.loggroup.wide", Mean: float64(commonCount) + 13.5, Max: float64(commonCount + 23), Count: 2},
+		{Source: "ocid1.loggroup.narrow", Mean: float64(commonCount + 4), Max: float64(commonCount + 4), Count: 1},
+	}, snapshot["top.loggroups.attributes"])
+	assert.Equal(t, int64(1), snapshot["records.attributes.nearcap"])
+	assert.Equal(t, []metrics.Talker{{Source: "ocid1.loggroup.wide", Value: 1}}, snapshot["top.loggroups.attributes.nearcap"])
+}
@@ -0,0 +1,55 @@
+package loggroup
+
+import (
+	"context"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// collapseRuns replaces every run of consecutive records with the same source and message by its first record,
+// stamped with the number of records in the run. Records without a message are never collapsed.
+func collapseRuns(ctx context.Context, records common.OCILoggingEvent) common.OCILoggingEvent {
+	collapsed := make(common.OCILoggingEvent, 0, len(records))
+	var runKey dedupKey
+	runLength := 0
+	for _, record := range records {
+		key, ok := recordDedupKey(record)
+		if ok && runLength > 0 && key == runKey {
+			runLength++
+			collapsed[len(collapsed)-1][common.DedupCountAttribute] = runLength
+			continue
+		}
+
+		collapsed = append(collapsed, record)
+		runKey, runLength = key, 0
+		if ok {
+			runLength = 1
+		}
+	}
+	metrics.Default.Counter("records.collapsed").Add(int64(len(records) - len(collapsed)))
+	if dropped := len(records) - len(collapsed); dropped > 0 {
+		logger.FromContext(ctx).Debugf("Collapsed %d repeated log records", dropped)
+	}
+	return collapsed
+}
+
+// dedupKey identifies records considered identical.
+type dedupKey struct {
+	source  string
+	message string
+}
+
+// recordDedupKey returns the key of a record with a message, read from the record or its data.
+func recordDedupKey(record map[string]interface{}) (dedupKey, bool) {
+	message, ok := common.LookupString(record, "message")
+	if !ok {
+		message, ok = common.LookupString(record, "data", "message")
+	}
+	if !ok {
+		return dedupKey{}, false
+	}
+	source, _ := common.LookupString(record, "type")
+	return dedupKey{source: source, message: message}, true
+}
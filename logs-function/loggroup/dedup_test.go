@@ -0,0 +1,56 @@
+package loggroup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCollapseRuns tests that only consecutive records with the same type and message are collapsed.
+func TestCollapseRuns(t *testing.T) {
+	probe := func() map[string]interface{} {
+		return map[string]interface{}{"type": "lb", "data": map[string]interface{}{"message": "health check failed"}}
+	}
+	records := common.OCILoggingEvent{
+		probe(), probe(), probe(),
+		{"type": "lb", "message": "request served"},
+		probe(),
+		{"type": "other", "data": map[string]interface{}{"message": "health check failed"}},
+		{"type": "lb"},
+		{"type": "lb"},
+	}
+
+	collapsed := collapseRuns(context.Background(), records)
+
+	assert.Len(t, collapsed, 6)
+	assert.Equal(t, 3, collapsed[0][common.DedupCountAttribute])
+	assert.NotContains(t, collapsed[1], common.DedupCountAttribute)
+	assert.NotContains(t, collapsed[2], common.DedupCountAttribute)
+	assert.NotContains(t, collapsed[3], common.DedupCountAttribute)
+	assert.NotContains(t, collapsed[4], common.DedupCountAttribute)
+	assert.NotContains(t, collapsed[5], common.DedupCountAttribute)
+}
+
+// TestProcessInvocationDedup tests that repeated records are collapsed only when enabled.
+func TestProcessInvocationDedup(t *testing.T) {
+	records := func() common.OCILoggingEvent {
+		return common.OCILoggingEvent{{"message": "probe failed"}, {"message": "probe failed"}}
+	}
+
+	for _, enabled := range []string{"", "true"} {
+		t.Setenv(common.DedupMessages, enabled)
+		channel := make(chan common.DetailedLogsBatch, 10)
+		ProcessInvocation(Invocation{Records: records()}, channel)
+		close(channel)
+
+		batch := <-channel
+		if enabled == "true" {
+			assert.Len(t, batch[0].Entries, 1)
+			assert.Equal(t, 2, batch[0].Entries[0][common.DedupCountAttribute])
+		} else {
+			assert.Len(t, batch[0].Entries, 2)
+		}
+	}
+}
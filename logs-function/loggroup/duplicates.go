@@ -0,0 +1,61 @@
+package loggroup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// duplicateRecordsMode returns the DUPLICATE_RECORDS mode, falling back to the default for unknown values.
+func duplicateRecordsMode(ctx context.Context) string {
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv(common.DuplicateRecords))); mode {
+	case common.DuplicateRecordsDetect, common.DuplicateRecordsCollapse:
+		return mode
+	case "", common.DuplicateRecordsKeep:
+		return common.DuplicateRecordsKeep
+	default:
+		logger.FromContext(ctx).Warnf("Ignoring unknown %s value %q", common.DuplicateRecords, mode)
+		return common.DefaultDuplicateRecords
+	}
+}
+
+// duplicateCounts returns, for each record of the payload, the number of identical records when it is the first of
+// them and 0 when it repeats an earlier record. Records are compared by the hash of their raw bytes when raw holds
+// a value per record, and of their JSON encoding otherwise, before any transformation. Records that cannot be
+// encoded are considered unique.
+func duplicateCounts(ctx context.Context, records common.OCILoggingEvent, raw []json.RawMessage) []int {
+	counts := make([]int, len(records))
+	first := make(map[[sha256.Size]byte]int, len(records))
+	duplicates := 0
+	for i, record := range records {
+		var data []byte
+		if len(raw) == len(records) {
+			data = raw[i]
+		} else {
+			var err error
+			if data, err = json.Marshal(record); err != nil {
+				counts[i] = 1
+				continue
+			}
+		}
+		key := sha256.Sum256(data)
+		if j, ok := first[key]; ok {
+			counts[j]++
+			duplicates++
+			continue
+		}
+		first[key] = i
+		counts[i] = 1
+	}
+	metrics.Default.Counter("records.duplicate").Add(int64(duplicates))
+	if duplicates > 0 {
+		logger.FromContext(ctx).Debugf("Found %d duplicate log records in the payload", duplicates)
+	}
+	return counts
+}
@@ -0,0 +1,58 @@
+package loggroup
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDuplicateCounts tests that identical records are counted on their first occurrence, wherever they appear.
+func TestDuplicateCounts(t *testing.T) {
+	records := common.OCILoggingEvent{
+		{"id": "a", "data": map[string]interface{}{"message": "retry"}},
+		{"id": "b", "data": map[string]interface{}{"message": "retry"}},
+		{"data": map[string]interface{}{"message": "retry"}, "id": "a"},
+		{"id": "a", "data": map[string]interface{}{"message": "retry"}},
+		{"id": "b", "data": map[string]interface{}{"message": "retry"}},
+	}
+	assert.Equal(t, []int{3, 2, 0, 0, 0}, duplicateCounts(context.Background(), records, nil))
+
+	raw := []json.RawMessage{[]byte(`{"id":"a"}`), []byte(`{ "id": "a" }`), []byte(`{"id":"a"}`)}
+	assert.Equal(t, []int{2, 1, 0}, duplicateCounts(context.Background(), common.OCILoggingEvent{{"id": "a"}, {"id": "a"}, {"id": "a"}}, raw))
+}
+
+// TestProcessInvocationDuplicates tests that duplicate records are collapsed only in collapse mode.
+func TestProcessInvocationDuplicates(t *testing.T) {
+	tests := []struct {
+		mode     string
+		messages []string
+		counts   []interface{}
+	}{
+		{"", []string{"retry", "retry", "done", "retry"}, []interface{}{nil, nil, nil, nil}},
+		{"detect", []string{"retry", "retry", "done", "retry"}, []interface{}{nil, nil, nil, nil}},
+		{"collapse", []string{"retry", "done"}, []interface{}{3, nil}},
+		{"unknown", []string{"retry", "retry", "done", "retry"}, []interface{}{nil, nil, nil, nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			t.Setenv(common.DuplicateRecords, tt.mode)
+			records := common.OCILoggingEvent{{"message": "retry"}, {"message": "retry"}, {"message": "done"}, {"message": "retry"}}
+			channel := make(chan common.DetailedLogsBatch, 10)
+			ProcessInvocation(Invocation{Records: records}, channel)
+			close(channel)
+
+			batch := <-channel
+			var messages []string
+			var counts []interface{}
+			for _, entry := range batch[0].Entries {
+				messages = append(messages, entry["message"].(string))
+				counts = append(counts, entry[common.DuplicateCountAttribute])
+			}
+			assert.Equal(t, tt.messages, messages)
+			assert.Equal(t, tt.counts, counts)
+		})
+	}
+}
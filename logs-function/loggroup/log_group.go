@@ -3,41 +3,225 @@
 package loggroup
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
 	"github.com/newrelic/oci-log-integration/logs-function/common"
 	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/newrelic/oci-log-integration/logs-function/parser"
+	"github.com/newrelic/oci-log-integration/logs-function/routing"
+	"github.com/newrelic/oci-log-integration/logs-function/transform"
 	"github.com/newrelic/oci-log-integration/logs-function/util"
 )
 
-var log = logger.NewLogrusLogger(logger.WithDebugLevel())
+// Invocation holds the records received by one function invocation and the settings they are forwarded with.
+type Invocation struct {
+	Records    common.OCILoggingEvent // Records are the decoded log records.
+	RawRecords []json.RawMessage      // RawRecords, when set, holds the original bytes of each record, forwarded verbatim as its message.
+	Routes     []routing.Route        // Routes is the multi-account routing table.
+	Profile    string                 // Profile, when set, forces every record through the named transform profile.
+	Events     util.EventSender       // Events, when set, receives the custom events of records parsed by the security and alarm parsers.
+	Connector  Connector              // Connector identifies the Service Connector that invoked the function, when known.
+	Function   util.FunctionInfo      // Function, when set, is stamped on the batches as faas.* attributes.
+	Tenancy    string                 // Tenancy, when set, is the tenancy name stamped on the batches as oci.tenancy.name.
+	Context    context.Context        // Context, when set, carries the log fields of the invocation; see logger.FromContext.
+}
+
+// Connector identifies the Service Connector an invocation came from and its delivery of the payload, read from the
+// invocation headers and records.
+type Connector struct {
+	ID              string // ID is the OCID of the connector.
+	Name            string // Name is the display name of the connector.
+	DeliveryAttempt int    // DeliveryAttempt is the number of the attempt to deliver the payload, or 0 when unknown.
+}
 
 // ProcessLogs processes OCI logging events and splits them into batches for New Relic ingestion.
-// It adds instrumentation metadata to each batch and sends the batches through the provided channel.
+// Each record is filtered and transformed according to the function configuration, then instrumentation
+// metadata is added to each batch and the batches are sent through the provided channel.
 // The function respects payload size limits to ensure compatibility with New Relic's API constraints.
 func ProcessLogs(OCILoggingEvent common.OCILoggingEvent, channel chan common.DetailedLogsBatch) {
-	attributes := common.LogAttributes{
-		"instrumentation.provider": common.InstrumentationProvider,
-		"instrumentation.name":     common.InstrumentationName,
-		"instrumentation.version":  common.InstrumentationVersion,
+	ProcessInvocation(Invocation{Records: OCILoggingEvent}, channel)
+}
+
+// ProcessInvocation processes the records of an invocation like ProcessLogs, batching the records of each
+// account route separately and stamping the route alias on the batch when routes are configured.
+// Records with raw bytes are filtered and routed on their decoded fields but forwarded untransformed.
+// Records are transformed with the current transform profiles; see transform.ReloadProfiles. The repeated string
+// values of the forwarded records are interned for the rest of the invocation.
+func ProcessInvocation(invocation Invocation, channel chan common.DetailedLogsBatch) {
+	ctx := invocation.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = logger.WithStage(ctx, logger.StageTransform)
+	clog := logger.FromContext(ctx)
+	profiles := transform.CurrentProfiles()
+	if invocation.Profile != "" {
+		forced, err := profiles.Force(invocation.Profile)
+		if err != nil {
+			clog.Warnf("Ignoring transform profile override: %v", err)
+		}
+		profiles = forced
+	}
+	configVersion := profiles.Version()
+	passthrough := len(invocation.RawRecords) == len(invocation.Records)
+	var groups []batchGroup
+	recordsByGroup := make(map[batchGroup]common.OCILoggingEvent)
+	var events []map[string]interface{}
+	parsed := make(map[string]int)
+	dropped := 0
+	metrics.Default.Counter("records.received").Add(int64(len(invocation.Records)))
+	var duplicates []int
+	duplicateMode := duplicateRecordsMode(ctx)
+	if duplicateMode != common.DuplicateRecordsKeep {
+		duplicates = duplicateCounts(ctx, invocation.Records, invocation.RawRecords)
+	}
+	collapse := duplicateMode == common.DuplicateRecordsCollapse
+	collapsed := 0
+	interner := transform.NewInterner()
+	for i, record := range invocation.Records {
+		if collapse && duplicates[i] == 0 {
+			collapsed++
+			continue
+		}
+		result := profiles.Process(ctx, record)
+		if !result.Keep {
+			dropped++
+			continue
+		}
+		interner.InternRecord(record)
+		if result.Parser != "" {
+			metrics.Default.Counter("parser." + result.Parser + ".records").Inc()
+			parsed[result.Parser]++
+		}
+		if invocation.Events != nil {
+			if event, ok := parser.Event(record); ok {
+				events = append(events, event)
+			}
+		}
+		group := batchGroup{
+			alias:   routing.Match(invocation.Routes, record),
+			lineage: lineage(result, configVersion),
+		}
+		if _, ok := recordsByGroup[group]; !ok {
+			groups = append(groups, group)
+		}
+		if passthrough {
+			record = map[string]interface{}{"message": string(invocation.RawRecords[i])}
+		}
+		if collapse && duplicates[i] > 1 {
+			record[common.DuplicateCountAttribute] = duplicates[i]
+		}
+		record[common.SequenceAttribute] = int64(i)
+		recordsByGroup[group] = append(recordsByGroup[group], record)
+	}
+	metrics.Default.Counter("records.dropped").Add(int64(dropped))
+	metrics.Default.Counter("records.duplicate.collapsed").Add(int64(collapsed))
+	metrics.Default.Counter("records.transformed").Add(int64(len(invocation.Records) - dropped - collapsed))
+	if dropped > 0 {
+		clog.Debugf("Dropped %d log records by configuration", dropped)
+	}
+	for name, count := range parsed {
+		clog.WithField(logger.FieldParser, name).Debugf("Parsed %d log records", count)
+	}
+	if len(events) > 0 {
+		if err := invocation.Events.CreateEvents(events); err != nil {
+			clog.Errorf("error posting %d parser events: %v", len(events), err)
+		}
 	}
 
-	splitLogsIntoBatches(OCILoggingEvent, common.MaxPayloadSize, attributes, channel)
+	dedup := os.Getenv(common.DedupMessages) == "true"
+	envelopeMode := oracleEnvelopeMode(ctx)
+	ociRegion, ociRealm := util.OCIRegion()
+	for _, group := range groups {
+		attributes := common.LogAttributes{
+			"instrumentation.provider": common.InstrumentationProvider,
+			"instrumentation.name":     common.InstrumentationName,
+			"instrumentation.version":  common.InstrumentationVersion,
+			common.LineageAttribute:    group.lineage,
+		}
+		if len(invocation.Routes) > 0 {
+			attributes[common.AccountAliasAttribute] = group.alias
+		}
+		if ociRegion != "" {
+			attributes[common.OCIRegionAttribute] = ociRegion
+		}
+		if ociRealm != "" {
+			attributes[common.OCIRealmAttribute] = ociRealm
+		}
+		if invocation.Tenancy != "" {
+			attributes[common.OCITenancyNameAttribute] = invocation.Tenancy
+		}
+		if invocation.Connector.ID != "" {
+			attributes[common.OCIConnectorIDAttribute] = invocation.Connector.ID
+		}
+		if invocation.Connector.Name != "" {
+			attributes[common.OCIConnectorNameAttribute] = invocation.Connector.Name
+		}
+		if invocation.Connector.DeliveryAttempt > 0 {
+			attributes[common.OCIDeliveryAttemptAttribute] = invocation.Connector.DeliveryAttempt
+		}
+		for name, value := range invocation.Function.Attributes() {
+			attributes[name] = value
+		}
+		if skew, significant := clock.SignificantSkew(); significant {
+			attributes[common.ClockSkewAttribute] = int64(skew.Seconds())
+		}
+
+		records := recordsByGroup[group]
+		applyOracleEnvelope(records, envelopeMode, attributes)
+		if dedup {
+			records = collapseRuns(ctx, records)
+		}
+		splitLogsIntoBatches(ctx, records, common.MaxPayloadSize, attributes, channel)
+	}
+}
+
+// batchGroup identifies records batched together: those sent to the same account and shaped by the same code path.
+type batchGroup struct {
+	alias   string
+	lineage string
 }
 
+// lineage returns the lineage attribute value of a processed record.
+func lineage(result transform.Result, configVersion string) string {
+	parserName := result.Parser
+	if parserName == "" {
+		parserName = "none"
+	}
+	return fmt.Sprintf("version=%s,profile=%s,parser=%s,config=%s",
+		common.InstrumentationVersion, result.Profile, parserName, configVersion)
+}
+
+// recordSizeBuckets are the upper bounds, in bytes, of the record.bytes histogram buckets.
+var recordSizeBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576}
+
+// unknownLogGroup names the source of records without a log group OCID in the top talkers report.
+const unknownLogGroup = "unknown"
+
 // splitLogsIntoBatches splits the incoming logs into batches for processing.
 // It loosely respects (if a single log entry exceeds the maximum payload size we still try to send it) 
 // the maximum payload size and sends each batch through the provided channel.
-func splitLogsIntoBatches(logs common.OCILoggingEvent, maxPayloadSize int, commonAttributes common.LogAttributes, channel chan common.DetailedLogsBatch) {
+func splitLogsIntoBatches(ctx context.Context, logs common.OCILoggingEvent, maxPayloadSize int, commonAttributes common.LogAttributes, channel chan common.DetailedLogsBatch) {
 	var currentBatch common.LogData
 	currentBatchSize := 0
+	budget := attributeBudget(ctx)
+	commonCount := countAttributes(map[string]interface{}(commonAttributes))
 
 	for _, logData := range logs {
 		logBytes, err := json.Marshal(logData)
 		if err != nil {
-			log.Warnf("Warning: Could not marshal detailed log for size estimation: %v", err)
+			logger.FromContext(ctx).Warnf("Warning: Could not marshal detailed log for size estimation: %v", err)
 			continue
 		}
 		logSize := len(logBytes)
+		logGroupID := recordLogGroup(logData, commonAttributes)
+		observeRecordSize(logGroupID, logSize)
+		observeAttributes(logData, logGroupID, commonCount, budget)
 
 		// this case handles the case where a single log entry is larger than the maxpayload size.
 		// In this case OCI has a 1MB limit per log line, we try to push this to New Relic anyway
@@ -45,7 +229,7 @@ func splitLogsIntoBatches(logs common.OCILoggingEvent, maxPayloadSize int, commo
 			currentBatch = common.LogData{logData}
 			currentBatchSize = logSize
 		} else if currentBatchSize+logSize > maxPayloadSize && len(currentBatch) > 0 {
-			util.ProduceMessageToChannel(channel, currentBatch, commonAttributes)
+			produceBatch(channel, currentBatch, currentBatchSize, commonAttributes)
 			currentBatch = common.LogData{logData}
 			currentBatchSize = logSize
 		} else {
@@ -55,6 +239,36 @@ func splitLogsIntoBatches(logs common.OCILoggingEvent, maxPayloadSize int, commo
 	}
 
 	if len(currentBatch) > 0 {
-		util.ProduceMessageToChannel(channel, currentBatch, commonAttributes)
+		produceBatch(channel, currentBatch, currentBatchSize, commonAttributes)
 	}
 }
+
+// recordLogGroup returns the log group OCID of a record, read from the batch attributes when the OCI envelope was
+// moved there, or unknownLogGroup.
+func recordLogGroup(record map[string]interface{}, commonAttributes common.LogAttributes) string {
+	logGroupID := common.LogGroupID(record)
+	if logGroupID == "" {
+		logGroupID, _ = commonAttributes[common.OracleEnvelopeKey+".loggroupid"].(string)
+	}
+	if logGroupID == "" {
+		logGroupID = unknownLogGroup
+	}
+	return logGroupID
+}
+
+// observeRecordSize records the size of a record in the record.bytes histogram and adds it to the bytes of its
+// log group, reported as the top talkers of the invocation.
+func observeRecordSize(logGroupID string, size int) {
+	metrics.Default.Histogram("record.bytes", recordSizeBuckets...).Observe(float64(size))
+	metrics.Default.Talkers("top.loggroups.bytes").Add(logGroupID, int64(size))
+}
+
+// produceBatch records the batch metrics and sends the batch through the channel.
+func produceBatch(channel chan common.DetailedLogsBatch, batch common.LogData, size int, commonAttributes common.LogAttributes) {
+	metrics.Default.Counter("batches.produced").Inc()
+	metrics.Default.Counter("records.batched").Add(int64(len(batch)))
+	metrics.Default.Counter("bytes.batched").Add(int64(size))
+	metrics.Default.Histogram("batch.bytes").Observe(float64(size))
+	metrics.Default.Histogram("batch.records").Observe(float64(len(batch)))
+	util.ProduceMessageToChannel(channel, batch, commonAttributes)
+}
@@ -1,10 +1,16 @@
 package loggroup
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/newrelic/oci-log-integration/logs-function/routing"
+	"github.com/newrelic/oci-log-integration/logs-function/transform"
+	"github.com/newrelic/oci-log-integration/logs-function/util"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -188,7 +194,7 @@ func TestSplitLogsIntoBatches(t *testing.T) {
 				"test.attribute": "test.value",
 			}
 
-			splitLogsIntoBatches(tt.logs, tt.maxPayloadSize, commonAttributes, channel)
+			splitLogsIntoBatches(context.Background(), tt.logs, tt.maxPayloadSize, commonAttributes, channel)
 
 			close(channel)
 			var batches []common.DetailedLogsBatch
@@ -229,7 +235,7 @@ func TestSplitLogsIntoBatchesPayloadSizeAccuracy(t *testing.T) {
 		"test": "value",
 	}
 
-	splitLogsIntoBatches(logs, 50, commonAttributes, channel)
+	splitLogsIntoBatches(context.Background(), logs, 50, commonAttributes, channel)
 
 	close(channel)
 	var batches []common.DetailedLogsBatch
@@ -300,6 +306,7 @@ func TestProcessLogsAttributes(t *testing.T) {
 		"instrumentation.provider": common.InstrumentationProvider,
 		"instrumentation.name":     common.InstrumentationName,
 		"instrumentation.version":  common.InstrumentationVersion,
+		common.LineageAttribute:    lineage(transform.Result{Profile: transform.ProfileStable}, transform.LoadProfiles().Version()),
 	}
 
 	for key, expectedValue := range expectedAttributes {
@@ -310,3 +317,199 @@ func TestProcessLogsAttributes(t *testing.T) {
 
 	assert.Len(t, detailedLog.CommonData.Attributes, len(expectedAttributes), "Should only have expected attributes")
 }
+
+// TestProcessInvocationWithRoutes tests that records are batched per account route with the alias stamped on each batch
+func TestProcessInvocationWithRoutes(t *testing.T) {
+	routes := []routing.Route{
+		{Alias: "sec", SecretOCID: "ocid1.vaultsecret.sec", Compartments: []string{"ocid1.compartment.sec"}},
+	}
+	logs := common.OCILoggingEvent{
+		map[string]interface{}{"message": "1", "oracle": map[string]interface{}{"compartmentid": "ocid1.compartment.sec"}},
+		map[string]interface{}{"message": "2", "oracle": map[string]interface{}{"compartmentid": "ocid1.compartment.app"}},
+		map[string]interface{}{"message": "3", "oracle": map[string]interface{}{"compartmentid": "ocid1.compartment.sec"}},
+	}
+
+	channel := make(chan common.DetailedLogsBatch, 10)
+	ProcessInvocation(Invocation{Records: logs, Routes: routes}, channel)
+	close(channel)
+
+	entriesByAlias := map[interface{}]int{}
+	for batch := range channel {
+		entriesByAlias[batch[0].CommonData.Attributes[common.AccountAliasAttribute]] += len(batch[0].Entries)
+	}
+
+	assert.Equal(t, map[interface{}]int{"sec": 2, routing.DefaultAlias: 1}, entriesByAlias)
+}
+
+// setTransformEnv sets a transform setting for the test and publishes the transform profiles reading it,
+// restoring both when the test ends.
+func setTransformEnv(t *testing.T, key, value string) {
+	t.Cleanup(func() { transform.ReloadProfiles() })
+	t.Setenv(key, value)
+	transform.ReloadProfiles()
+}
+
+// TestProcessInvocationRawPassthrough tests that raw records are forwarded verbatim as the message
+// while filtering still applies to their decoded fields
+func TestProcessInvocationRawPassthrough(t *testing.T) {
+	setTransformEnv(t, common.CompartmentDenylist, "ocid1.compartment.denied")
+	raw := []json.RawMessage{
+		json.RawMessage(`{"z":1,"a":{"y":"1.50","x":true},"oracle":{"compartmentid":"ocid1.compartment.ok"}}`),
+		json.RawMessage(`{"oracle":{"compartmentid":"ocid1.compartment.denied"}}`),
+	}
+	logs := common.OCILoggingEvent{
+		map[string]interface{}{"z": 1, "oracle": map[string]interface{}{"compartmentid": "ocid1.compartment.ok"}},
+		map[string]interface{}{"oracle": map[string]interface{}{"compartmentid": "ocid1.compartment.denied"}},
+	}
+
+	channel := make(chan common.DetailedLogsBatch, 10)
+	ProcessInvocation(Invocation{Records: logs, RawRecords: raw}, channel)
+	close(channel)
+
+	batch := <-channel
+	assert.Equal(t, common.LogData{{"message": string(raw[0]), common.SequenceAttribute: int64(0)}}, batch[0].Entries)
+}
+
+// mockEventSender records the events it receives.
+type mockEventSender struct {
+	events []map[string]interface{}
+}
+
+func (m *mockEventSender) CreateEvents(events []map[string]interface{}) error {
+	m.events = append(m.events, events...)
+	return nil
+}
+
+// TestProcessInvocationSecurityEvents tests that records parsed by the security parsers are also sent as custom events
+func TestProcessInvocationSecurityEvents(t *testing.T) {
+	logs := common.OCILoggingEvent{
+		map[string]interface{}{"type": "com.oraclecloud.cloudguard.problemdetected", "data": map[string]interface{}{"resourceId": "p1"}},
+		map[string]interface{}{"type": "com.oraclecloud.logging.custom.app", "message": "not a security record"},
+	}
+	sender := &mockEventSender{}
+
+	channel := make(chan common.DetailedLogsBatch, 10)
+	ProcessInvocation(Invocation{Records: logs, Events: sender}, channel)
+	close(channel)
+
+	assert.Len(t, sender.events, 1)
+	assert.Equal(t, "OciCloudGuardProblem", sender.events[0]["eventType"])
+	assert.Equal(t, "p1", sender.events[0]["cloudguard.problemId"])
+	entries := 0
+	for batch := range channel {
+		entries += len(batch[0].Entries)
+	}
+	assert.Equal(t, 2, entries)
+}
+
+// TestProcessInvocationLineage tests that records shaped by different parsers are batched separately with their lineage
+func TestProcessInvocationLineage(t *testing.T) {
+	logs := common.OCILoggingEvent{
+		map[string]interface{}{"message": "plain"},
+		map[string]interface{}{"type": "com.oraclecloud.bastion.createsession", "data": map[string]interface{}{"resourceId": "s1"}},
+		map[string]interface{}{"message": "plain again"},
+	}
+
+	channel := make(chan common.DetailedLogsBatch, 10)
+	ProcessInvocation(Invocation{Records: logs}, channel)
+	close(channel)
+
+	entriesByLineage := map[interface{}]int{}
+	for batch := range channel {
+		entriesByLineage[batch[0].CommonData.Attributes[common.LineageAttribute]] += len(batch[0].Entries)
+	}
+
+	version := transform.LoadProfiles().Version()
+	assert.Equal(t, map[interface{}]int{
+		"version=" + common.InstrumentationVersion + ",profile=stable,parser=none,config=" + version:    2,
+		"version=" + common.InstrumentationVersion + ",profile=stable,parser=bastion,config=" + version: 1,
+	}, entriesByLineage)
+}
+
+// TestProcessInvocationSequence tests that records keep their payload position across dropped records and batches.
+func TestProcessInvocationSequence(t *testing.T) {
+	setTransformEnv(t, common.CompartmentDenylist, "ocid1.compartment.denied")
+	logs := common.OCILoggingEvent{
+		{"message": "first"},
+		{"message": "dropped", "oracle": map[string]interface{}{"compartmentid": "ocid1.compartment.denied"}},
+		{"message": "third"},
+	}
+
+	channel := make(chan common.DetailedLogsBatch, 10)
+	ProcessInvocation(Invocation{Records: logs}, channel)
+	close(channel)
+
+	sequence := map[interface{}]interface{}{}
+	for batch := range channel {
+		for _, entry := range batch[0].Entries {
+			sequence[entry["message"]] = entry[common.SequenceAttribute]
+		}
+	}
+	assert.Equal(t, map[interface{}]interface{}{"first": int64(0), "third": int64(2)}, sequence)
+}
+
+// TestProcessInvocationOCIRegion tests that the region and realm of the function are stamped on the batches.
+func TestProcessInvocationOCIRegion(t *testing.T) {
+	t.Setenv("OCI_RESOURCE_PRINCIPAL_REGION", "eu-frankfurt-1")
+
+	channel := make(chan common.DetailedLogsBatch, 10)
+	ProcessInvocation(Invocation{Records: common.OCILoggingEvent{{"message": "hello"}}}, channel)
+	close(channel)
+
+	batch := <-channel
+	assert.Equal(t, "eu-frankfurt-1", batch[0].CommonData.Attributes[common.OCIRegionAttribute])
+	assert.Equal(t, "oc1", batch[0].CommonData.Attributes[common.OCIRealmAttribute])
+}
+
+// TestProcessInvocationTopTalkers tests that record sizes are observed and summed per log group.
+func TestProcessInvocationTopTalkers(t *testing.T) {
+	metrics.Default.Reset()
+	noisy := map[string]interface{}{"loggroupid": "ocid1.loggroup.noisy"}
+	quiet := map[string]interface{}{"loggroupid": "ocid1.loggroup.quiet"}
+	logs := common.OCILoggingEvent{
+		{"message": "a long message from the noisy log group", "oracle": noisy},
+		{"message": "another long message from the noisy log group", "oracle": noisy},
+		{"message": "hi", "oracle": quiet},
+		{"message": "no log group"},
+	}
+
+	channel := make(chan common.DetailedLogsBatch, 10)
+	ProcessInvocation(Invocation{Records: logs}, channel)
+	close(channel)
+
+	snapshot := metrics.Default.Snapshot()
+	assert.Equal(t, int64(4), snapshot["record.bytes"].(metrics.HistogramSnapshot).Count)
+	top := snapshot["top.loggroups.bytes"].([]metrics.Talker)
+	assert.Len(t, top, 3)
+	assert.Equal(t, "ocid1.loggroup.noisy", top[0].Source)
+	assert.Equal(t, snapshot["bytes.batched"], top[0].Value+top[1].Value+top[2].Value)
+}
+
+// TestProcessInvocationConnector tests that the invoking connector, delivery attempt, function and tenancy are stamped on the batches when known.
+func TestProcessInvocationConnector(t *testing.T) {
+	channel := make(chan common.DetailedLogsBatch, 10)
+	ProcessInvocation(Invocation{
+		Records:   common.OCILoggingEvent{{"message": "hello"}},
+		Connector: Connector{ID: "ocid1.serviceconnector.oc1..a", Name: "audit-to-nr", DeliveryAttempt: 2},
+		Function:  util.FunctionInfo{Name: "nr-logs", CallID: "01CALL"},
+		Tenancy:   "acme-prod",
+	}, channel)
+	ProcessInvocation(Invocation{Records: common.OCILoggingEvent{{"message": "hello"}}}, channel)
+	close(channel)
+
+	attributes := (<-channel)[0].CommonData.Attributes
+	assert.Equal(t, "ocid1.serviceconnector.oc1..a", attributes[common.OCIConnectorIDAttribute])
+	assert.Equal(t, "audit-to-nr", attributes[common.OCIConnectorNameAttribute])
+	assert.Equal(t, 2, attributes[common.OCIDeliveryAttemptAttribute])
+	assert.Equal(t, "nr-logs", attributes[common.FaasNameAttribute])
+	assert.Equal(t, "01CALL", attributes[common.FaasInvocationIDAttribute])
+	assert.NotContains(t, attributes, common.FaasIDAttribute)
+	assert.Equal(t, "acme-prod", attributes[common.OCITenancyNameAttribute])
+
+	attributes = (<-channel)[0].CommonData.Attributes
+	assert.NotContains(t, attributes, common.OCITenancyNameAttribute)
+	assert.NotContains(t, attributes, common.OCIConnectorIDAttribute)
+	assert.NotContains(t, attributes, common.OCIConnectorNameAttribute)
+	assert.NotContains(t, attributes, common.OCIDeliveryAttemptAttribute)
+	assert.NotContains(t, attributes, common.FaasNameAttribute)
+}
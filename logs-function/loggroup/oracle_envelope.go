@@ -0,0 +1,74 @@
+package loggroup
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+)
+
+// oracleEnvelopeMode returns the KEEP_ORACLE_ENVELOPE mode, defaulting to forwarding the envelope on every record.
+func oracleEnvelopeMode(ctx context.Context) string {
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv(common.KeepOracleEnvelope))); mode {
+	case common.OracleEnvelopeHoist, common.OracleEnvelopeDrop:
+		return mode
+	case "", common.OracleEnvelopeRecord:
+		return common.OracleEnvelopeRecord
+	default:
+		logger.FromContext(ctx).Warnf("Ignoring unknown %s value %q", common.KeepOracleEnvelope, mode)
+		return common.OracleEnvelopeRecord
+	}
+}
+
+// applyOracleEnvelope removes the oracle envelope block from the records according to mode. When hoisting,
+// each envelope field with the same value on every record is removed from the records and added to the
+// attributes as "oracle.<field>"; fields whose values differ stay on the records.
+func applyOracleEnvelope(records common.OCILoggingEvent, mode string, attributes common.LogAttributes) {
+	switch mode {
+	case common.OracleEnvelopeDrop:
+		for _, record := range records {
+			delete(record, common.OracleEnvelopeKey)
+		}
+	case common.OracleEnvelopeHoist:
+		for key, value := range sharedEnvelopeFields(records) {
+			attributes[common.OracleEnvelopeKey+"."+key] = value
+			for _, record := range records {
+				envelope := record[common.OracleEnvelopeKey].(map[string]interface{})
+				delete(envelope, key)
+				if len(envelope) == 0 {
+					delete(record, common.OracleEnvelopeKey)
+				}
+			}
+		}
+	}
+}
+
+// sharedEnvelopeFields returns the scalar envelope fields present with the same value on every record.
+func sharedEnvelopeFields(records common.OCILoggingEvent) map[string]interface{} {
+	var shared map[string]interface{}
+	for _, record := range records {
+		envelope, ok := record[common.OracleEnvelopeKey].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if shared == nil {
+			shared = make(map[string]interface{}, len(envelope))
+			for key, value := range envelope {
+				switch value.(type) {
+				case map[string]interface{}, []interface{}:
+				default:
+					shared[key] = value
+				}
+			}
+			continue
+		}
+		for key, value := range shared {
+			if other, ok := envelope[key]; !ok || other != value {
+				delete(shared, key)
+			}
+		}
+	}
+	return shared
+}
@@ -0,0 +1,75 @@
+package loggroup
+
+import (
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcessInvocationOracleEnvelope tests that the oracle envelope is kept, hoisted or dropped per KEEP_ORACLE_ENVELOPE.
+func TestProcessInvocationOracleEnvelope(t *testing.T) {
+	records := func() common.OCILoggingEvent {
+		return common.OCILoggingEvent{
+			{"message": "a", "oracle": map[string]interface{}{"tenantid": "ocid1.tenancy.t", "loggroupid": "ocid1.loggroup.a", "ingestedtime": "2024-01-01T00:00:01Z"}},
+			{"message": "b", "oracle": map[string]interface{}{"tenantid": "ocid1.tenancy.t", "loggroupid": "ocid1.loggroup.a", "ingestedtime": "2024-01-01T00:00:02Z"}},
+		}
+	}
+
+	tests := []struct {
+		name               string
+		mode               string
+		expectedEnvelope   interface{}
+		expectedAttributes common.LogAttributes
+	}{
+		{
+			name:             "kept on records by default",
+			mode:             "",
+			expectedEnvelope: map[string]interface{}{"tenantid": "ocid1.tenancy.t", "loggroupid": "ocid1.loggroup.a", "ingestedtime": "2024-01-01T00:00:01Z"},
+		},
+		{
+			name:               "shared fields hoisted",
+			mode:               "hoist",
+			expectedEnvelope:   map[string]interface{}{"ingestedtime": "2024-01-01T00:00:01Z"},
+			expectedAttributes: common.LogAttributes{"oracle.tenantid": "ocid1.tenancy.t", "oracle.loggroupid": "ocid1.loggroup.a"},
+		},
+		{
+			name:             "dropped",
+			mode:             "drop",
+			expectedEnvelope: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(common.KeepOracleEnvelope, tt.mode)
+			channel := make(chan common.DetailedLogsBatch, 10)
+			ProcessInvocation(Invocation{Records: records()}, channel)
+			close(channel)
+
+			batch := <-channel
+			assert.Equal(t, tt.expectedEnvelope, batch[0].Entries[0]["oracle"])
+			for key, value := range tt.expectedAttributes {
+				assert.Equal(t, value, batch[0].CommonData.Attributes[key])
+			}
+			if tt.expectedAttributes == nil {
+				assert.NotContains(t, batch[0].CommonData.Attributes, "oracle.tenantid")
+			}
+		})
+	}
+}
+
+// TestApplyOracleEnvelopeHoistRemovesEmptyEnvelope tests that a fully hoisted envelope is removed from the records.
+func TestApplyOracleEnvelopeHoistRemovesEmptyEnvelope(t *testing.T) {
+	records := common.OCILoggingEvent{
+		{"oracle": map[string]interface{}{"logid": "ocid1.log.a"}},
+		{"oracle": map[string]interface{}{"logid": "ocid1.log.a"}},
+	}
+	attributes := common.LogAttributes{}
+
+	applyOracleEnvelope(records, common.OracleEnvelopeHoist, attributes)
+
+	assert.Equal(t, common.LogAttributes{"oracle.logid": "ocid1.log.a"}, attributes)
+	assert.NotContains(t, records[0], "oracle")
+	assert.NotContains(t, records[1], "oracle")
+}
@@ -0,0 +1,408 @@
+// Package metrics provides the forwarder's internal metrics: counters, gauges and histograms that every
+// pipeline stage updates concurrently without locking, and that are reported once per invocation.
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets are the upper bounds of histogram buckets used when none are given, suited to sizes
+// in bytes and durations in milliseconds alike.
+var DefaultBuckets = []float64{1, 10, 100, 1000, 10000, 100000, 1000000}
+
+// TopTalkers is the number of sources reported by the snapshot of a Talkers.
+const TopTalkers = 10
+
+// Default is the registry shared by all pipeline stages.
+var Default = NewRegistry()
+
+// Registry holds named metrics. Metrics are created on first use and live for the lifetime of the registry.
+type Registry struct {
+	mu         sync.RWMutex
+	counters   map[string]*Counter
+	gauges     map[string]*Gauge
+	histograms map[string]*Histogram
+	talkers    map[string]*Talkers
+	averages   map[string]*Averages
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		counters:   map[string]*Counter{},
+		gauges:     map[string]*Gauge{},
+		histograms: map[string]*Histogram{},
+		talkers:    map[string]*Talkers{},
+		averages:   map[string]*Averages{},
+	}
+}
+
+// Counter returns the counter with the given name, creating it if needed.
+func (r *Registry) Counter(name string) *Counter {
+	return lookup(r, r.counters, name, func() *Counter { return &Counter{} })
+}
+
+// Gauge returns the gauge with the given name, creating it if needed.
+func (r *Registry) Gauge(name string) *Gauge {
+	return lookup(r, r.gauges, name, func() *Gauge { return &Gauge{} })
+}
+
+// Histogram returns the histogram with the given name, creating it with the given bucket upper bounds,
+// or DefaultBuckets when none are given. The buckets of an existing histogram are not changed.
+func (r *Registry) Histogram(name string, buckets ...float64) *Histogram {
+	return lookup(r, r.histograms, name, func() *Histogram { return newHistogram(buckets) })
+}
+
+// Talkers returns the per-source totals with the given name, creating them if needed.
+func (r *Registry) Talkers(name string) *Talkers {
+	return lookup(r, r.talkers, name, func() *Talkers { return &Talkers{totals: map[string]int64{}} })
+}
+
+// Averages returns the per-source averages with the given name, creating them if needed.
+func (r *Registry) Averages(name string) *Averages {
+	return lookup(r, r.averages, name, func() *Averages { return &Averages{stats: map[string]*averageStats{}} })
+}
+
+// lookup returns the metric of the given name from metrics, creating it with create if needed.
+func lookup[M any](r *Registry, metrics map[string]*M, name string, create func() *M) *M {
+	r.mu.RLock()
+	metric, ok := metrics[name]
+	r.mu.RUnlock()
+	if ok {
+		return metric
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if metric, ok := metrics[name]; ok {
+		return metric
+	}
+	metric = create()
+	metrics[name] = metric
+	return metric
+}
+
+// Reset zeroes every metric, keeping them registered.
+func (r *Registry) Reset() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, counter := range r.counters {
+		counter.value.Store(0)
+	}
+	for _, gauge := range r.gauges {
+		gauge.Set(0)
+	}
+	for _, histogram := range r.histograms {
+		histogram.reset()
+	}
+	for _, talkers := range r.talkers {
+		talkers.reset()
+	}
+	for _, averages := range r.averages {
+		averages.reset()
+	}
+}
+
+// Snapshot returns the current value of every metric that was updated since the last reset: counters as
+// int64, gauges as float64, histograms as HistogramSnapshot, talkers as the TopTalkers largest []Talker and
+// averages as the TopTalkers largest []Average.
+func (r *Registry) Snapshot() map[string]interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot := make(map[string]interface{}, len(r.counters)+len(r.gauges)+len(r.histograms)+len(r.talkers)+len(r.averages))
+	for name, counter := range r.counters {
+		if value := counter.Value(); value != 0 {
+			snapshot[name] = value
+		}
+	}
+	for name, gauge := range r.gauges {
+		if value := gauge.Value(); value != 0 {
+			snapshot[name] = value
+		}
+	}
+	for name, histogram := range r.histograms {
+		if h := histogram.Snapshot(); h.Count > 0 {
+			snapshot[name] = h
+		}
+	}
+	for name, talkers := range r.talkers {
+		if top := talkers.Top(TopTalkers); len(top) > 0 {
+			snapshot[name] = top
+		}
+	}
+	for name, averages := range r.averages {
+		if top := averages.Top(TopTalkers); len(top) > 0 {
+			snapshot[name] = top
+		}
+	}
+	return snapshot
+}
+
+// Flatten returns the snapshot with histograms expanded into scalar <name>.count, <name>.sum, <name>.min
+// and <name>.max values, talkers into <name>.<rank>.source and <name>.<rank>.value values and averages into
+// <name>.<rank>.source, <name>.<rank>.mean, <name>.<rank>.max and <name>.<rank>.count values, as required by
+// event attributes.
+func Flatten(snapshot map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{}, len(snapshot))
+	for name, value := range snapshot {
+		switch value := value.(type) {
+		case HistogramSnapshot:
+			flat[name+".count"] = value.Count
+			flat[name+".sum"] = value.Sum
+			flat[name+".min"] = value.Min
+			flat[name+".max"] = value.Max
+		case []Talker:
+			for i, talker := range value {
+				rank := name + "." + strconv.Itoa(i+1)
+				flat[rank+".source"] = talker.Source
+				flat[rank+".value"] = talker.Value
+			}
+		case []Average:
+			for i, average := range value {
+				rank := name + "." + strconv.Itoa(i+1)
+				flat[rank+".source"] = average.Source
+				flat[rank+".mean"] = average.Mean
+				flat[rank+".max"] = average.Max
+				flat[rank+".count"] = average.Count
+			}
+		default:
+			flat[name] = value
+		}
+	}
+	return flat
+}
+
+// Counter is a monotonically increasing count.
+type Counter struct {
+	value atomic.Int64
+}
+
+// Inc adds one to the counter.
+func (c *Counter) Inc() { c.value.Add(1) }
+
+// Add adds n to the counter.
+func (c *Counter) Add(n int64) { c.value.Add(n) }
+
+// Value returns the current count.
+func (c *Counter) Value() int64 { return c.value.Load() }
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set replaces the value of the gauge.
+func (g *Gauge) Set(value float64) { g.bits.Store(math.Float64bits(value)) }
+
+// Add adds delta to the value of the gauge.
+func (g *Gauge) Add(delta float64) { addFloat(&g.bits, delta) }
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() float64 { return math.Float64frombits(g.bits.Load()) }
+
+// Histogram counts observed values into buckets and tracks their count, sum, minimum and maximum.
+type Histogram struct {
+	bounds []float64
+	counts []atomic.Int64 // counts has one entry per bound plus one for values above the last bound.
+	count  atomic.Int64
+	sum    atomic.Uint64
+	min    atomic.Uint64
+	max    atomic.Uint64
+}
+
+// HistogramSnapshot is the state of a histogram at one point in time. Buckets maps each upper bound,
+// formatted as "le_<bound>", and "le_inf" to the number of values in that bucket.
+type HistogramSnapshot struct {
+	Count   int64            `json:"count"`
+	Sum     float64          `json:"sum"`
+	Min     float64          `json:"min"`
+	Max     float64          `json:"max"`
+	Buckets map[string]int64 `json:"buckets"`
+}
+
+// newHistogram creates a histogram with the given sorted bucket upper bounds.
+func newHistogram(bounds []float64) *Histogram {
+	if len(bounds) == 0 {
+		bounds = DefaultBuckets
+	}
+	bounds = append([]float64(nil), bounds...)
+	sort.Float64s(bounds)
+	h := &Histogram{bounds: bounds, counts: make([]atomic.Int64, len(bounds)+1)}
+	h.reset()
+	return h
+}
+
+// Observe records a value.
+func (h *Histogram) Observe(value float64) {
+	h.counts[sort.SearchFloat64s(h.bounds, value)].Add(1)
+	h.count.Add(1)
+	addFloat(&h.sum, value)
+	for current := h.min.Load(); value < math.Float64frombits(current); current = h.min.Load() {
+		if h.min.CompareAndSwap(current, math.Float64bits(value)) {
+			break
+		}
+	}
+	for current := h.max.Load(); value > math.Float64frombits(current); current = h.max.Load() {
+		if h.max.CompareAndSwap(current, math.Float64bits(value)) {
+			break
+		}
+	}
+}
+
+// Snapshot returns the current state of the histogram.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	snapshot := HistogramSnapshot{
+		Count:   h.count.Load(),
+		Sum:     math.Float64frombits(h.sum.Load()),
+		Buckets: make(map[string]int64, len(h.counts)),
+	}
+	if snapshot.Count > 0 {
+		snapshot.Min = math.Float64frombits(h.min.Load())
+		snapshot.Max = math.Float64frombits(h.max.Load())
+	}
+	for i := range h.counts {
+		name := "le_inf"
+		if i < len(h.bounds) {
+			name = "le_" + strconv.FormatFloat(h.bounds[i], 'f', -1, 64)
+		}
+		snapshot.Buckets[name] = h.counts[i].Load()
+	}
+	return snapshot
+}
+
+// reset zeroes the histogram.
+func (h *Histogram) reset() {
+	for i := range h.counts {
+		h.counts[i].Store(0)
+	}
+	h.count.Store(0)
+	h.sum.Store(0)
+	h.min.Store(math.Float64bits(math.Inf(1)))
+	h.max.Store(math.Float64bits(math.Inf(-1)))
+}
+
+// Talkers sums a quantity per source, such as the bytes of each log group, to report the largest sources.
+type Talkers struct {
+	mu     sync.Mutex
+	totals map[string]int64
+}
+
+// Talker is the total of one source.
+type Talker struct {
+	Source string `json:"source"`
+	Value  int64  `json:"value"`
+}
+
+// Add adds n to the total of the source.
+func (t *Talkers) Add(source string, n int64) {
+	t.mu.Lock()
+	t.totals[source] += n
+	t.mu.Unlock()
+}
+
+// Top returns the n sources with the largest totals, largest first, ties ordered by source.
+func (t *Talkers) Top(n int) []Talker {
+	t.mu.Lock()
+	talkers := make([]Talker, 0, len(t.totals))
+	for source, value := range t.totals {
+		talkers = append(talkers, Talker{Source: source, Value: value})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(talkers, func(i, j int) bool {
+		if talkers[i].Value != talkers[j].Value {
+			return talkers[i].Value > talkers[j].Value
+		}
+		return talkers[i].Source < talkers[j].Source
+	})
+	if len(talkers) > n {
+		talkers = talkers[:n]
+	}
+	return talkers
+}
+
+// reset removes every source.
+func (t *Talkers) reset() {
+	t.mu.Lock()
+	t.totals = map[string]int64{}
+	t.mu.Unlock()
+}
+
+// Averages tracks the mean and maximum of a quantity observed per source, such as the attributes of the records
+// of each log group, to report the sources with the largest means.
+type Averages struct {
+	mu    sync.Mutex
+	stats map[string]*averageStats
+}
+
+// averageStats are the values observed for one source.
+type averageStats struct {
+	count int64
+	sum   float64
+	max   float64
+}
+
+// Average is the mean, maximum and number of the values observed for one source.
+type Average struct {
+	Source string  `json:"source"`
+	Mean   float64 `json:"mean"`
+	Max    float64 `json:"max"`
+	Count  int64   `json:"count"`
+}
+
+// Observe records a value of the source.
+func (a *Averages) Observe(source string, value float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	stats, ok := a.stats[source]
+	if !ok {
+		stats = &averageStats{max: value}
+		a.stats[source] = stats
+	}
+	stats.count++
+	stats.sum += value
+	stats.max = math.Max(stats.max, value)
+}
+
+// Top returns the n sources with the largest means, largest first, ties ordered by source.
+func (a *Averages) Top(n int) []Average {
+	a.mu.Lock()
+	averages := make([]Average, 0, len(a.stats))
+	for source, stats := range a.stats {
+		averages = append(averages, Average{Source: source, Mean: stats.sum / float64(stats.count), Max: stats.max, Count: stats.count})
+	}
+	a.mu.Unlock()
+
+	sort.Slice(averages, func(i, j int) bool {
+		if averages[i].Mean != averages[j].Mean {
+			return averages[i].Mean > averages[j].Mean
+		}
+		return averages[i].Source < averages[j].Source
+	})
+	if len(averages) > n {
+		averages = averages[:n]
+	}
+	return averages
+}
+
+// reset removes every source.
+func (a *Averages) reset() {
+	a.mu.Lock()
+	a.stats = map[string]*averageStats{}
+	a.mu.Unlock()
+}
+
+// addFloat atomically adds delta to the float64 stored as bits.
+func addFloat(bits *atomic.Uint64, delta float64) {
+	for {
+		current := bits.Load()
+		if bits.CompareAndSwap(current, math.Float64bits(math.Float64frombits(current)+delta)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRegistryConcurrentUpdates tests that metrics updated from many goroutines lose no update.
+func TestRegistryConcurrentUpdates(t *testing.T) {
+	registry := NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				registry.Counter("records").Inc()
+				registry.Gauge("inflight").Add(1)
+				registry.Histogram("bytes").Observe(float64(j))
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(5000), registry.Counter("records").Value())
+	assert.Equal(t, 5000.0, registry.Gauge("inflight").Value())
+	histogram := registry.Histogram("bytes").Snapshot()
+	assert.Equal(t, int64(5000), histogram.Count)
+	assert.Equal(t, 50*4950.0, histogram.Sum)
+	assert.Equal(t, 0.0, histogram.Min)
+	assert.Equal(t, 99.0, histogram.Max)
+}
+
+// TestHistogramBuckets tests that values are counted in the first bucket whose bound is not below them.
+func TestHistogramBuckets(t *testing.T) {
+	histogram := NewRegistry().Histogram("latency", 10, 1)
+	for _, value := range []float64{0.5, 1, 5, 10, 11} {
+		histogram.Observe(value)
+	}
+
+	assert.Equal(t, map[string]int64{"le_1": 2, "le_10": 2, "le_inf": 1}, histogram.Snapshot().Buckets)
+}
+
+// TestSnapshotAndReset tests that snapshots only hold updated metrics and that reset zeroes them.
+func TestSnapshotAndReset(t *testing.T) {
+	registry := NewRegistry()
+	registry.Counter("records").Add(3)
+	registry.Counter("unused")
+	registry.Gauge("workers").Set(2)
+	registry.Histogram("bytes").Observe(42)
+
+	snapshot := registry.Snapshot()
+	assert.Equal(t, int64(3), snapshot["records"])
+	assert.Equal(t, 2.0, snapshot["workers"])
+	assert.NotContains(t, snapshot, "unused")
+	assert.Equal(t, map[string]interface{}{
+		"records": int64(3), "workers": 2.0,
+		"bytes.count": int64(1), "bytes.sum": 42.0, "bytes.min": 42.0, "bytes.max": 42.0,
+	}, Flatten(snapshot))
+
+	registry.Reset()
+	assert.Empty(t, registry.Snapshot())
+	registry.Histogram("bytes").Observe(7)
+	assert.Equal(t, 7.0, registry.Histogram("bytes").Snapshot().Min)
+}
+
+// TestTalkers tests that the largest sources are reported in order and flattened by rank.
+func TestTalkers(t *testing.T) {
+	registry := NewRegistry()
+	talkers := registry.Talkers("top")
+	for i := 0; i < TopTalkers+5; i++ {
+		talkers.Add(fmt.Sprintf("source-%02d", i), int64(i))
+	}
+	talkers.Add("source-00", 100)
+
+	top := registry.Snapshot()["top"].([]Talker)
+	assert.Len(t, top, TopTalkers)
+	assert.Equal(t, Talker{Source: "source-00", Value: 100}, top[0])
+	assert.Equal(t, Talker{Source: "source-14", Value: 14}, top[1])
+
+	flat := Flatten(map[string]interface{}{"top": top[:2]})
+	assert.Equal(t, map[string]interface{}{
+		"top.1.source": "source-00", "top.1.value": int64(100),
+		"top.2.source": "source-14", "top.2.value": int64(14),
+	}, flat)
+
+	registry.Reset()
+	assert.Empty(t, registry.Snapshot())
+}
+
+// TestAverages tests that the sources with the largest means are reported in order and flattened by rank.
+func TestAverages(t *testing.T) {
+	registry := NewRegistry()
+	averages := registry.Averages("attributes")
+	for i := 0; i < TopTalkers+5; i++ {
+		averages.Observe(fmt.Sprintf("source-%02d", i), float64(i))
+	}
+	averages.Observe("source-00", 200)
+	averages.Observe("source-00", 10)
+
+	top := registry.Snapshot()["attributes"].([]Average)
+	assert.Len(t, top, TopTalkers)
+	assert.Equal(t, Average{Source: "source-00", Mean: 70, Max: 200, Count: 3}, top[0])
+	assert.Equal(t, Average{Source: "source-14", Mean: 14, Max: 14, Count: 1}, top[1])
+
+	flat := Flatten(map[string]interface{}{"attributes": top[:1]})
+	assert.Equal(t, map[string]interface{}{
+		"attributes.1.source": "source-00", "attributes.1.mean": 70.0,
+		"attributes.1.max": 200.0, "attributes.1.count": int64(3),
+	}, flat)
+
+	registry.Reset()
+	assert.Empty(t, registry.Snapshot())
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// uniform returns a pseudo-random number in [0, 1), replaced in tests for deterministic noise.
+var uniform = rand.Float64
+
+// AddNoise returns a copy of the snapshot with Laplace noise of the given scale added to its counts: counter
+// values, talker values and the counts of histograms, histogram buckets and averages. Noisy counts are rounded
+// and never negative, and talkers are ranked by their noisy values. Gauges and the sums, means, minimums and
+// maximums of histograms and averages are left exact. A scale of 0 or less returns the snapshot unchanged.
+func AddNoise(snapshot map[string]interface{}, scale float64) map[string]interface{} {
+	if scale <= 0 {
+		return snapshot
+	}
+	noisy := make(map[string]interface{}, len(snapshot))
+	for name, value := range snapshot {
+		switch value := value.(type) {
+		case int64:
+			noisy[name] = noisyCount(value, scale)
+		case HistogramSnapshot:
+			value.Count = noisyCount(value.Count, scale)
+			buckets := make(map[string]int64, len(value.Buckets))
+			for bucket, count := range value.Buckets {
+				buckets[bucket] = noisyCount(count, scale)
+			}
+			value.Buckets = buckets
+			noisy[name] = value
+		case []Talker:
+			talkers := make([]Talker, len(value))
+			for i, talker := range value {
+				talkers[i] = Talker{Source: talker.Source, Value: noisyCount(talker.Value, scale)}
+			}
+			sort.SliceStable(talkers, func(i, j int) bool { return talkers[i].Value > talkers[j].Value })
+			noisy[name] = talkers
+		case []Average:
+			averages := make([]Average, len(value))
+			for i, average := range value {
+				average.Count = noisyCount(average.Count, scale)
+				averages[i] = average
+			}
+			noisy[name] = averages
+		default:
+			noisy[name] = value
+		}
+	}
+	return noisy
+}
+
+// noisyCount returns the count with Laplace noise of the given scale, rounded and at least 0.
+func noisyCount(count int64, scale float64) int64 {
+	u := uniform() - 0.5
+	if math.Abs(u) >= 0.5 {
+		u = 0
+	}
+	noise := math.Copysign(-scale*math.Log(1-2*math.Abs(u)), u)
+	return max(int64(math.Round(float64(count)+noise)), 0)
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// useUniform makes the noise draw the given values in turn, repeating the last one.
+func useUniform(t *testing.T, values ...float64) {
+	previous := uniform
+	uniform = func() float64 {
+		value := values[0]
+		if len(values) > 1 {
+			values = values[1:]
+		}
+		return value
+	}
+	t.Cleanup(func() { uniform = previous })
+}
+
+// TestNoisyCount tests the Laplace noise added to a count, its rounding and its floor at 0.
+func TestNoisyCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		uniform  float64
+		count    int64
+		expected int64
+	}{
+		{"median draw", 0.5, 10, 10},
+		{"positive noise", 0.75, 10, 12},
+		{"negative noise", 0.25, 10, 8},
+		{"clamped at zero", 0.01, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useUniform(t, tt.uniform)
+			assert.Equal(t, tt.expected, noisyCount(tt.count, 3))
+		})
+	}
+}
+
+// TestAddNoise tests that only the counts of a snapshot are perturbed.
+func TestAddNoise(t *testing.T) {
+	registry := NewRegistry()
+	registry.Counter("records.sent").Add(10)
+	registry.Gauge("delivery.attempt").Set(2)
+	registry.Histogram("bytes", 100).Observe(50)
+	registry.Averages("top.loggroups.attributes").Observe("a", 7)
+	snapshot := registry.Snapshot()
+
+	assert.Equal(t, snapshot, AddNoise(snapshot, 0))
+
+	useUniform(t, 0.75)
+	assert.Equal(t, map[string]interface{}{
+		"records.sent":             int64(12),
+		"delivery.attempt":         2.0,
+		"bytes":                    HistogramSnapshot{Count: 3, Sum: 50, Min: 50, Max: 50, Buckets: map[string]int64{"le_100": 3, "le_inf": 2}},
+		"top.loggroups.attributes": []Average{{Source: "a", Mean: 7, Max: 7, Count: 3}},
+	}, AddNoise(snapshot, 3))
+	assert.Equal(t, int64(10), snapshot["records.sent"], "the snapshot is not modified")
+}
+
+// TestAddNoiseTalkers tests that talkers are ranked by their noisy values.
+func TestAddNoiseTalkers(t *testing.T) {
+	snapshot := map[string]interface{}{"top.loggroups.bytes": []Talker{{Source: "a", Value: 20}, {Source: "b", Value: 19}}}
+	useUniform(t, 0.25, 0.75)
+	assert.Equal(t, []Talker{{Source: "b", Value: 21}, {Source: "a", Value: 18}}, AddNoise(snapshot, 3)["top.loggroups.bytes"])
+}
@@ -0,0 +1,73 @@
+// Package ociclient applies the function's timeout, retry and circuit breaker settings to OCI SDK clients.
+package ociclient
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// maxRetryBackoff caps the pause between two attempts of an OCI API call.
+const maxRetryBackoff = 5 * time.Second
+
+// Circuit breakers shared by the clients of each service, so that clients created per call still trip them.
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*ociCommon.OciCircuitBreaker{}
+)
+
+// Configure sets the attempt timeout, the retry policy and the circuit breaker of the named service on the client.
+// Failed calls are retried with exponential backoff, accounting for the eventual consistency of recently changed
+// resources, up to OCI_RETRY_MAX_ATTEMPTS attempts of at most OCI_CALL_TIMEOUT_SECONDS each.
+func Configure(client *ociCommon.BaseClient, service string) {
+	if httpClient, ok := client.HTTPClient.(*http.Client); ok {
+		httpClient.Timeout = CallTimeout()
+	}
+	policy := RetryPolicy()
+	client.Configuration.RetryPolicy = &policy
+	client.Configuration.CircuitBreaker = circuitBreaker(service)
+}
+
+// RetryPolicy returns the retry policy of OCI API calls.
+func RetryPolicy() ociCommon.RetryPolicy {
+	return ociCommon.NewRetryPolicyWithOptions(
+		ociCommon.WithMaximumNumberAttempts(uint(maxAttempts())),
+		ociCommon.WithShouldRetryOperation(ociCommon.DefaultShouldRetryOperation),
+		ociCommon.WithExponentialBackoff(maxRetryBackoff, 2),
+		ociCommon.WithEventualConsistency(),
+	)
+}
+
+// CallTimeout returns the timeout of each attempt of an OCI API call.
+func CallTimeout() time.Duration {
+	if seconds, err := strconv.ParseFloat(os.Getenv(common.OCICallTimeout), 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return common.DefaultOCICallTimeout
+}
+
+// maxAttempts returns the number of attempts of a failed OCI API call.
+func maxAttempts() int {
+	if attempts, err := strconv.Atoi(os.Getenv(common.OCIRetryMaxAttempts)); err == nil && attempts > 0 {
+		return attempts
+	}
+	return common.DefaultOCIRetryMaxAttempts
+}
+
+// circuitBreaker returns the circuit breaker shared by the clients of the service.
+func circuitBreaker(service string) *ociCommon.OciCircuitBreaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	if breaker, ok := breakers[service]; ok {
+		return breaker
+	}
+	breaker := ociCommon.NewCircuitBreaker(ociCommon.DefaultCircuitBreakerSettingWithServiceName(service))
+	breakers[service] = breaker
+	return breaker
+}
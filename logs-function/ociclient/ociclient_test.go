@@ -0,0 +1,56 @@
+package ociclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// TestConfigure tests that the timeout, retry policy and shared circuit breaker are applied to clients.
+func TestConfigure(t *testing.T) {
+	tests := []struct {
+		name             string
+		timeout          string
+		attempts         string
+		expectedTimeout  time.Duration
+		expectedAttempts uint
+	}{
+		{"defaults", "", "", 10 * time.Second, 3},
+		{"configured", "2.5", "5", 2500 * time.Millisecond, 5},
+		{"invalid values", "-1", "zero", 10 * time.Second, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(common.OCICallTimeout, tt.timeout)
+			t.Setenv(common.OCIRetryMaxAttempts, tt.attempts)
+			httpClient := &http.Client{Timeout: time.Minute}
+			client := ociCommon.BaseClient{HTTPClient: httpClient}
+
+			Configure(&client, "Secrets")
+
+			assert.Equal(t, tt.expectedTimeout, httpClient.Timeout)
+			assert.Equal(t, tt.expectedAttempts, client.Configuration.RetryPolicy.MaximumNumberAttempts)
+			assert.NotNil(t, client.Configuration.CircuitBreaker)
+		})
+	}
+}
+
+// TestCircuitBreakerShared tests that clients of the same service share their circuit breaker.
+func TestCircuitBreakerShared(t *testing.T) {
+	first := ociCommon.BaseClient{HTTPClient: &http.Client{}}
+	second := ociCommon.BaseClient{HTTPClient: &http.Client{}}
+	other := ociCommon.BaseClient{HTTPClient: &http.Client{}}
+
+	Configure(&first, "ObjectStorage")
+	Configure(&second, "ObjectStorage")
+	Configure(&other, "Streaming")
+
+	assert.Same(t, first.Configuration.CircuitBreaker, second.Configuration.CircuitBreaker)
+	assert.NotSame(t, first.Configuration.CircuitBreaker, other.Configuration.CircuitBreaker)
+}
@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// AlarmEventType is the custom event type of OCI Monitoring alarm notifications.
+const AlarmEventType = "OciMonitoringAlarm"
+
+// alarmSeverity is the New Relic level and OpenTelemetry severity number of an alarm severity.
+type alarmSeverity struct {
+	level  string
+	number int64
+}
+
+// alarmSeverities maps the severities of OCI Monitoring alarms to New Relic levels.
+var alarmSeverities = map[string]alarmSeverity{
+	"CRITICAL": {level: "critical", number: 21},
+	"ERROR":    {level: "error", number: 17},
+	"WARNING":  {level: "warn", number: 13},
+	"INFO":     {level: "info", number: 9},
+}
+
+// alarmClearedTypes are the notification types of an alarm that stopped firing, logged as info whatever the
+// severity of the alarm.
+var alarmClearedTypes = map[string]bool{
+	"FIRING_TO_OK": true,
+	"RESET":        true,
+}
+
+func init() {
+	register(alarm{})
+	eventTypes["oci_alarm"] = AlarmEventType
+}
+
+// alarm parses OCI Monitoring alarm notifications, as published to a Notifications (ONS) topic:
+//
+//	{"dedupeKey":"...","title":"HighCpu","body":"...","type":"OK_TO_FIRING","severity":"CRITICAL",
+//	 "timestampEpochMillis":1700000000000,"alarmMetaData":[{"id":"ocid1.alarm...","status":"FIRING",
+//	 "namespace":"oci_computeagent","query":"CpuUtilization[1m].mean() > 90",
+//	 "dimensions":[{"resourceId":"ocid1.instance...","resourceDisplayName":"web-1"}],"alarmUrl":"..."}]}
+type alarm struct{}
+
+// Name returns the parser name.
+func (alarm) Name() string {
+	return "alarm"
+}
+
+// Match reports whether the record is an alarm notification.
+func (alarm) Match(record map[string]interface{}) bool {
+	_, ok := record["alarmMetaData"].([]interface{})
+	_, hasDedupeKey := record["dedupeKey"]
+	return ok && hasDedupeKey
+}
+
+// Parse adds the alarm.* attributes and the level and severity number of the alarm, and uses the body or title
+// of the notification as the message and its time as the timestamp when the record has none.
+func (alarm) Parse(record map[string]interface{}) {
+	fields := map[string]string{
+		"title":            "alarm.name",
+		"type":             "alarm.transition",
+		"severity":         "alarm.severity",
+		"dedupeKey":        "alarm.dedupeKey",
+		"notificationType": "alarm.notificationType",
+	}
+	for field, attribute := range fields {
+		if value, ok := common.LookupString(record, field); ok {
+			record[attribute] = value
+		}
+	}
+
+	if metadata := alarmMetadata(record); metadata != nil {
+		metadataFields := map[string]string{
+			"id":        "alarm.id",
+			"status":    "alarm.status",
+			"namespace": "alarm.namespace",
+			"query":     "alarm.query",
+			"alarmUrl":  "alarm.url",
+		}
+		for field, attribute := range metadataFields {
+			if value, ok := common.LookupString(metadata, field); ok {
+				record[attribute] = value
+			}
+		}
+		if value, ok := common.LookupString(metadata, "severity"); ok && record["alarm.severity"] == nil {
+			record["alarm.severity"] = value
+		}
+		if dimensions, ok := metadata["dimensions"].([]interface{}); ok && len(dimensions) > 0 {
+			record["alarm.streams"] = int64(len(dimensions))
+			first, _ := dimensions[0].(map[string]interface{})
+			for key, value := range first {
+				if s, ok := value.(string); ok {
+					record["alarm.dimension."+key] = s
+				}
+			}
+		}
+	}
+
+	severity, known := alarmSeverities[strings.ToUpper(stringValue(record["alarm.severity"]))]
+	if alarmClearedTypes[stringValue(record["alarm.transition"])] {
+		severity, known = alarmSeverities["INFO"], true
+	}
+	if known {
+		if _, ok := record[common.LevelAttribute]; !ok {
+			record[common.LevelAttribute] = severity.level
+		}
+		record[common.SeverityNumberAttribute] = severity.number
+	}
+
+	if _, ok := record["message"]; !ok {
+		if body, ok := common.LookupString(record, "body"); ok && body != "" {
+			record["message"] = body
+		} else if title, ok := record["alarm.name"].(string); ok {
+			record["message"] = title
+		}
+	}
+	if _, ok := record["timestamp"]; !ok {
+		if millis, ok := record["timestampEpochMillis"].(json.Number); ok {
+			record["timestamp"] = millis
+		}
+	}
+	setLogType(record, "oci_alarm")
+}
+
+// alarmMetadata returns the metadata of the first alarm of the notification.
+func alarmMetadata(record map[string]interface{}) map[string]interface{} {
+	alarms, _ := record["alarmMetaData"].([]interface{})
+	if len(alarms) == 0 {
+		return nil
+	}
+	metadata, _ := alarms[0].(map[string]interface{})
+	return metadata
+}
+
+// stringValue returns the value when it is a string, or an empty string.
+func stringValue(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// alarmRecord returns an alarm notification of the given transition and severity.
+func alarmRecord(transition string, severity string) map[string]interface{} {
+	return map[string]interface{}{
+		"dedupeKey":            "5e4d",
+		"title":                "HighCpu",
+		"body":                 "CPU above 90% on web-1",
+		"type":                 transition,
+		"severity":             severity,
+		"timestampEpochMillis": json.Number("1700000000000"),
+		"alarmMetaData": []interface{}{map[string]interface{}{
+			"id":        "ocid1.alarm.oc1..a",
+			"status":    "FIRING",
+			"namespace": "oci_computeagent",
+			"query":     "CpuUtilization[1m].mean() > 90",
+			"alarmUrl":  "https://cloud.oracle.com/monitoring/alarms/ocid1.alarm.oc1..a",
+			"dimensions": []interface{}{
+				map[string]interface{}{"resourceId": "ocid1.instance.oc1..i", "resourceDisplayName": "web-1"},
+				map[string]interface{}{"resourceId": "ocid1.instance.oc1..j", "resourceDisplayName": "web-2"},
+			},
+		}},
+	}
+}
+
+// TestAlarm tests mapping of an alarm notification into alarm attributes and a level.
+func TestAlarm(t *testing.T) {
+	record := alarmRecord("OK_TO_FIRING", "CRITICAL")
+
+	assert.Equal(t, "alarm", Apply(record))
+	assert.Equal(t, "HighCpu", record["alarm.name"])
+	assert.Equal(t, "OK_TO_FIRING", record["alarm.transition"])
+	assert.Equal(t, "ocid1.alarm.oc1..a", record["alarm.id"])
+	assert.Equal(t, "FIRING", record["alarm.status"])
+	assert.Equal(t, "oci_computeagent", record["alarm.namespace"])
+	assert.Equal(t, "CpuUtilization[1m].mean() > 90", record["alarm.query"])
+	assert.Equal(t, int64(2), record["alarm.streams"])
+	assert.Equal(t, "web-1", record["alarm.dimension.resourceDisplayName"])
+	assert.Equal(t, "critical", record[common.LevelAttribute])
+	assert.Equal(t, int64(21), record[common.SeverityNumberAttribute])
+	assert.Equal(t, "CPU above 90% on web-1", record["message"])
+	assert.Equal(t, json.Number("1700000000000"), record["timestamp"])
+	assert.Equal(t, "oci_alarm", record["logtype"])
+
+	event, ok := Event(record)
+	assert.True(t, ok)
+	assert.Equal(t, AlarmEventType, event["eventType"])
+	assert.Equal(t, "ocid1.alarm.oc1..a", event["alarm.id"])
+}
+
+// TestAlarmSeverity tests the level of alarm notifications by severity and transition.
+func TestAlarmSeverity(t *testing.T) {
+	tests := []struct {
+		transition string
+		severity   string
+		level      interface{}
+	}{
+		{"OK_TO_FIRING", "ERROR", "error"},
+		{"REPEAT", "WARNING", "warn"},
+		{"OK_TO_FIRING", "info", "info"},
+		{"FIRING_TO_OK", "CRITICAL", "info"},
+		{"RESET", "ERROR", "info"},
+		{"OK_TO_FIRING", "UNKNOWN", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.transition+" "+tt.severity, func(t *testing.T) {
+			record := alarmRecord(tt.transition, tt.severity)
+			Apply(record)
+			assert.Equal(t, tt.level, record[common.LevelAttribute])
+		})
+	}
+}
+
+// TestAlarmNoMatch tests that records without alarm metadata are not parsed as alarms.
+func TestAlarmNoMatch(t *testing.T) {
+	assert.False(t, alarm{}.Match(map[string]interface{}{"dedupeKey": "k"}))
+	assert.False(t, alarm{}.Match(map[string]interface{}{"alarmMetaData": []interface{}{}}))
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// goldenGateErrorCode matches GoldenGate message codes such as OGG-01224.
+var goldenGateErrorCode = regexp.MustCompile(`\bOGG-\d{5}\b`)
+
+// goldenGateLevel matches the severity word of a GoldenGate report line.
+var goldenGateLevel = regexp.MustCompile(`\b(ERROR|WARNING|INFO)\b`)
+
+func init() {
+	register(goldenGate{})
+	register(dataIntegration{})
+}
+
+// goldenGate parses OCI GoldenGate deployment logs, extracting the deployment, the Extract or
+// Replicat process and the OGG message code.
+type goldenGate struct{}
+
+// Name returns the parser name.
+func (goldenGate) Name() string {
+	return "goldenGate"
+}
+
+// Match reports whether the record comes from a GoldenGate deployment.
+func (goldenGate) Match(record map[string]interface{}) bool {
+	recordType, _ := common.LookupString(record, "type")
+	return strings.HasPrefix(recordType, "com.oraclecloud.goldengate.")
+}
+
+// Parse adds the goldengate.* attributes.
+func (goldenGate) Parse(record map[string]interface{}) {
+	if id, ok := firstString(record, []string{"data", "deploymentId"}, []string{"oracle", "resourceid"}); ok {
+		record["goldengate.deploymentId"] = id
+	}
+	if process, ok := firstString(record, []string{"data", "processName"}, []string{"data", "process"}); ok {
+		record["goldengate.process"] = process
+	}
+
+	if message, ok := firstString(record, []string{"data", "message"}, []string{"message"}); ok {
+		if code := goldenGateErrorCode.FindString(message); code != "" {
+			record["goldengate.errorCode"] = code
+		}
+		if level := goldenGateLevel.FindString(message); level != "" {
+			record["goldengate.level"] = level
+		}
+	}
+	setLogType(record, "oci_goldengate")
+}
+
+// dataIntegration parses OCI Data Integration task run logs, extracting the task, the run and its
+// status, and the error code of failed runs.
+type dataIntegration struct{}
+
+// Name returns the parser name.
+func (dataIntegration) Name() string {
+	return "dataIntegration"
+}
+
+// Match reports whether the record comes from Data Integration.
+func (dataIntegration) Match(record map[string]interface{}) bool {
+	recordType, _ := common.LookupString(record, "type")
+	return strings.HasPrefix(recordType, "com.oraclecloud.dataintegration.")
+}
+
+// Parse adds the dataintegration.* attributes.
+func (dataIntegration) Parse(record map[string]interface{}) {
+	fields := []struct {
+		attribute string
+		paths     [][]string
+	}{
+		{"dataintegration.taskId", [][]string{{"data", "taskKey"}, {"data", "taskId"}}},
+		{"dataintegration.taskName", [][]string{{"data", "taskName"}}},
+		{"dataintegration.taskRunId", [][]string{{"data", "taskRunKey"}, {"data", "taskRunId"}}},
+		{"dataintegration.workspaceId", [][]string{{"data", "workspaceId"}, {"oracle", "resourceid"}}},
+		{"dataintegration.errorCode", [][]string{{"data", "errorCode"}, {"data", "error", "code"}}},
+		{"dataintegration.errorMessage", [][]string{{"data", "errorMessage"}, {"data", "error", "message"}}},
+	}
+	for _, field := range fields {
+		if value, ok := firstString(record, field.paths...); ok {
+			record[field.attribute] = value
+		}
+	}
+
+	if status, ok := firstString(record, []string{"data", "status"}, []string{"data", "taskRunStatus"}); ok {
+		record["dataintegration.status"] = strings.ToUpper(status)
+	}
+	setLogType(record, "oci_data_integration")
+}
+
+// firstString returns the first non-empty string found along the paths.
+func firstString(record map[string]interface{}, paths ...[]string) (string, bool) {
+	for _, path := range paths {
+		if value, ok := common.LookupString(record, path...); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGoldenGate tests extraction of the deployment, process and OGG code from deployment logs.
+func TestGoldenGate(t *testing.T) {
+	record := map[string]interface{}{
+		"type":   "com.oraclecloud.goldengate.deployment.log",
+		"oracle": map[string]interface{}{"resourceid": "ocid1.goldengatedeployment.a"},
+		"data": map[string]interface{}{
+			"processName": "REP1",
+			"message":     "2024-01-01 10:00:00  ERROR   OGG-01296  Error mapping from SRC.T1 to TGT.T1.",
+		},
+	}
+
+	assert.Equal(t, "goldenGate", Apply(record))
+	assert.Equal(t, "ocid1.goldengatedeployment.a", record["goldengate.deploymentId"])
+	assert.Equal(t, "REP1", record["goldengate.process"])
+	assert.Equal(t, "OGG-01296", record["goldengate.errorCode"])
+	assert.Equal(t, "ERROR", record["goldengate.level"])
+	assert.Equal(t, "oci_goldengate", record["logtype"])
+}
+
+// TestDataIntegration tests extraction of task run details from Data Integration logs.
+func TestDataIntegration(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name: "failed run",
+			data: map[string]interface{}{
+				"taskKey":    "task-1",
+				"taskName":   "load_orders",
+				"taskRunKey": "run-9",
+				"status":     "failed",
+				"error":      map[string]interface{}{"code": "DIS_DATAFLOW_0006", "message": "Target table not found"},
+			},
+			expected: map[string]interface{}{
+				"dataintegration.taskId":       "task-1",
+				"dataintegration.taskName":     "load_orders",
+				"dataintegration.taskRunId":    "run-9",
+				"dataintegration.status":       "FAILED",
+				"dataintegration.errorCode":    "DIS_DATAFLOW_0006",
+				"dataintegration.errorMessage": "Target table not found",
+			},
+		},
+		{
+			name: "successful run with alternate field names",
+			data: map[string]interface{}{"taskId": "task-2", "taskRunId": "run-1", "taskRunStatus": "SUCCESS"},
+			expected: map[string]interface{}{
+				"dataintegration.taskId":    "task-2",
+				"dataintegration.taskRunId": "run-1",
+				"dataintegration.status":    "SUCCESS",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := map[string]interface{}{"type": "com.oraclecloud.dataintegration.taskrun", "data": tt.data}
+			assert.Equal(t, "dataIntegration", Apply(record))
+			for key, value := range tt.expected {
+				assert.Equal(t, value, record[key], key)
+			}
+			assert.Equal(t, "oci_data_integration", record["logtype"])
+			assert.NotContains(t, record, "dataintegration.workspaceId")
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// istioAccessLog matches the default Istio access log format, which extends the Envoy default format with
+// response code details, termination details, transport failure reason and upstream cluster.
+var istioAccessLog = regexp.MustCompile(`^\[(?P<start_time>[^\]]+)\] "(?P<method>\S+) (?P<path>\S+) (?P<protocol>[^"]+)" ` +
+	`(?P<response_code>\d{1,3}) (?P<response_flags>\S+) (?P<response_code_details>\S+) (?P<connection_termination_details>\S+) ` +
+	`"(?P<upstream_transport_failure_reason>[^"]*)" (?P<bytes_received>\d+) (?P<bytes_sent>\d+) (?P<duration>\S+) ` +
+	`(?P<upstream_service_time>\S+) "(?P<x_forwarded_for>[^"]*)" "(?P<user_agent>[^"]*)" "(?P<request_id>[^"]*)" ` +
+	`"(?P<authority>[^"]*)" "(?P<upstream_host>[^"]*)" (?P<upstream_cluster>\S+)`)
+
+// envoyAccessLog matches the default Envoy access log format.
+var envoyAccessLog = regexp.MustCompile(`^\[(?P<start_time>[^\]]+)\] "(?P<method>\S+) (?P<path>\S+) (?P<protocol>[^"]+)" ` +
+	`(?P<response_code>\d{1,3}) (?P<response_flags>\S+) (?P<bytes_received>\d+) (?P<bytes_sent>\d+) (?P<duration>\S+) ` +
+	`(?P<upstream_service_time>\S+) "(?P<x_forwarded_for>[^"]*)" "(?P<user_agent>[^"]*)" "(?P<request_id>[^"]*)" ` +
+	`"(?P<authority>[^"]*)" "(?P<upstream_host>[^"]*)"`)
+
+// envoyAttributes maps Envoy access log fields, named as in the Istio JSON encoding, to attributes.
+var envoyAttributes = map[string]string{
+	"method":                "http.method",
+	"path":                  "http.url",
+	"protocol":              "http.protocol",
+	"response_code":         "http.statusCode",
+	"response_flags":        "envoy.responseFlags",
+	"response_code_details": "envoy.responseCodeDetails",
+	"bytes_received":        "envoy.bytesReceived",
+	"bytes_sent":            "envoy.bytesSent",
+	"duration":              "duration.ms",
+	"upstream_service_time": "envoy.upstreamServiceTime",
+	"x_forwarded_for":       "envoy.forwardedFor",
+	"user_agent":            "user_agent.original",
+	"request_id":            "request.id",
+	"authority":             "http.host",
+	"upstream_host":         "peer.address",
+	"upstream_cluster":      "envoy.upstreamCluster",
+}
+
+// envoyNumericFields lists the fields converted to numbers.
+var envoyNumericFields = map[string]bool{
+	"response_code":         true,
+	"bytes_received":        true,
+	"bytes_sent":            true,
+	"duration":              true,
+	"upstream_service_time": true,
+}
+
+func init() {
+	register(envoy{})
+}
+
+// envoy parses Envoy access logs written by OCI Service Mesh or Istio sidecars on OKE, in the default
+// text formats or the Istio JSON encoding, into HTTP and peer attributes.
+type envoy struct{}
+
+// Name returns the parser name.
+func (envoy) Name() string {
+	return "envoy"
+}
+
+// Match reports whether the record holds an Envoy access log.
+func (envoy) Match(record map[string]interface{}) bool {
+	_, ok := envoyFields(record)
+	return ok
+}
+
+// Parse adds the http.*, peer.* and envoy.* attributes.
+func (envoy) Parse(record map[string]interface{}) {
+	fields, _ := envoyFields(record)
+	for field, value := range fields {
+		attribute, ok := envoyAttributes[field]
+		if !ok || value == "" || value == "-" {
+			continue
+		}
+		if envoyNumericFields[field] {
+			if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+				record[attribute] = number
+			}
+			continue
+		}
+		record[attribute] = value
+	}
+
+	if service := istioPeerService(fields["upstream_cluster"]); service != "" {
+		record["peer.service"] = service
+	}
+	setLogType(record, "envoy_access")
+}
+
+// envoyFields extracts the access log fields from the record message, or from a JSON encoded access log
+// held in the record data.
+func envoyFields(record map[string]interface{}) (map[string]string, bool) {
+	if data, ok := record["data"].(map[string]interface{}); ok {
+		if fields, ok := jsonAccessLog(data); ok {
+			return fields, true
+		}
+	}
+
+	message, ok := firstString(record, []string{"data", "message"}, []string{"message"})
+	if !ok || !strings.HasPrefix(message, "[") {
+		return nil, false
+	}
+	for _, format := range []*regexp.Regexp{istioAccessLog, envoyAccessLog} {
+		if match := format.FindStringSubmatch(message); match != nil {
+			fields := make(map[string]string, len(match))
+			for i, name := range format.SubexpNames() {
+				if name != "" {
+					fields[name] = match[i]
+				}
+			}
+			return fields, true
+		}
+	}
+	return nil, false
+}
+
+// jsonAccessLog reads an access log in the Istio JSON encoding, recognized by its upstream cluster and response code.
+func jsonAccessLog(data map[string]interface{}) (map[string]string, bool) {
+	if _, ok := data["upstream_cluster"]; !ok {
+		return nil, false
+	}
+	if _, ok := data["response_code"]; !ok {
+		return nil, false
+	}
+
+	fields := make(map[string]string, len(envoyAttributes))
+	for field := range envoyAttributes {
+		switch v := data[field].(type) {
+		case string:
+			fields[field] = v
+		case json.Number:
+			fields[field] = v.String()
+		case float64:
+			fields[field] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return fields, true
+}
+
+// istioPeerService returns the destination service of an Istio cluster name such as
+// "outbound|9080||reviews.default.svc.cluster.local".
+func istioPeerService(cluster string) string {
+	parts := strings.Split(cluster, "|")
+	if len(parts) != 4 || parts[0] != "outbound" {
+		return ""
+	}
+	return parts[3]
+}
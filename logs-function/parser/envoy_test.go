@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEnvoy tests parsing of each supported access log format.
+func TestEnvoy(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:   "istio default format",
+			record: map[string]interface{}{"data": map[string]interface{}{"message": `[2024-01-01T10:00:00.123Z] "GET /reviews/0 HTTP/1.1" 503 UF upstream_reset_before_response_started{connection_failure} - "-" 0 91 12 - "-" "curl/8.0" "3f1c-4a" "reviews:9080" "10.0.1.7:9080" outbound|9080||reviews.default.svc.cluster.local 10.0.1.5:40210 10.96.0.10:9080 10.0.1.5:40208 - default`}},
+			expected: map[string]interface{}{
+				"http.method":               "GET",
+				"http.url":                  "/reviews/0",
+				"http.protocol":             "HTTP/1.1",
+				"http.statusCode":           int64(503),
+				"envoy.responseFlags":       "UF",
+				"envoy.responseCodeDetails": "upstream_reset_before_response_started{connection_failure}",
+				"envoy.bytesReceived":       int64(0),
+				"envoy.bytesSent":           int64(91),
+				"duration.ms":               int64(12),
+				"user_agent.original":       "curl/8.0",
+				"request.id":                "3f1c-4a",
+				"http.host":                 "reviews:9080",
+				"peer.address":              "10.0.1.7:9080",
+				"envoy.upstreamCluster":     "outbound|9080||reviews.default.svc.cluster.local",
+				"peer.service":              "reviews.default.svc.cluster.local",
+				"logtype":                   "envoy_access",
+			},
+		},
+		{
+			name:   "envoy default format",
+			record: map[string]interface{}{"message": `[2016-04-15T20:17:00.310Z] "POST /api/v1/locations HTTP/2" 204 - 154 0 226 100 "10.0.35.28" "nsq2http" "cc21d9b0" "locations" "tcp://10.0.2.1:80"`},
+			expected: map[string]interface{}{
+				"http.method":               "POST",
+				"http.statusCode":           int64(204),
+				"duration.ms":               int64(226),
+				"envoy.upstreamServiceTime": int64(100),
+				"envoy.forwardedFor":        "10.0.35.28",
+				"peer.address":              "tcp://10.0.2.1:80",
+			},
+		},
+		{
+			name: "istio json encoding",
+			record: map[string]interface{}{"data": map[string]interface{}{
+				"method":           "GET",
+				"path":             "/health",
+				"response_code":    json.Number("200"),
+				"response_flags":   "-",
+				"duration":         json.Number("3"),
+				"upstream_cluster": "inbound|8080||",
+			}},
+			expected: map[string]interface{}{
+				"http.method":           "GET",
+				"http.url":              "/health",
+				"http.statusCode":       int64(200),
+				"duration.ms":           int64(3),
+				"envoy.upstreamCluster": "inbound|8080||",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, "envoy", Apply(tt.record))
+			for key, value := range tt.expected {
+				assert.Equal(t, value, tt.record[key], key)
+			}
+		})
+	}
+}
+
+// TestEnvoyNoMatch tests that other bracketed log lines are not parsed as access logs.
+func TestEnvoyNoMatch(t *testing.T) {
+	assert.False(t, envoy{}.Match(map[string]interface{}{"message": "[INFO] server started on :8080"}))
+	assert.False(t, envoy{}.Match(map[string]interface{}{"data": map[string]interface{}{"response_code": 200}}))
+}
@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// flowLogTypePrefix is the prefix of the record types of VCN flow logs.
+const flowLogTypePrefix = "com.oraclecloud.vcn.flowlogs."
+
+func init() {
+	register(flowLogs{})
+}
+
+// flowLogs parses VCN flow logs into source, destination and network attributes:
+//
+//	{"type":"com.oraclecloud.vcn.flowlogs.DataEvent","data":{"action":"ACCEPT","sourceAddress":"10.0.0.5",
+//	 "sourcePort":443,"destinationAddress":"10.0.1.7","destinationPort":51234,"protocol":6,...}}
+type flowLogs struct{}
+
+// Name returns the parser name.
+func (flowLogs) Name() string {
+	return "flowLogs"
+}
+
+// Match reports whether the record is a VCN flow log.
+func (flowLogs) Match(record map[string]interface{}) bool {
+	recordType, _ := common.LookupString(record, "type")
+	return strings.HasPrefix(recordType, flowLogTypePrefix)
+}
+
+// Parse adds the source.*, destination.*, network.* and flowlog.* attributes. A record that does not fit
+// the flow log schema is only given its logtype.
+func (flowLogs) Parse(record map[string]interface{}) {
+	if flow, ok := DecodeFlowLog(record); ok {
+		for attribute, value := range map[string]string{
+			"flowlog.id":          flow.FlowID,
+			"flowlog.action":      flow.Action,
+			"flowlog.status":      flow.Status,
+			"source.address":      flow.SourceAddress,
+			"destination.address": flow.DestinationAddress,
+			"network.transport":   strings.ToLower(flow.ProtocolName),
+		} {
+			if value != "" && value != "-" {
+				record[attribute] = value
+			}
+		}
+		for attribute, value := range map[string]json.Number{
+			"source.port":             flow.SourcePort,
+			"destination.port":        flow.DestinationPort,
+			"network.protocol.number": flow.Protocol,
+			"flowlog.bytes":           flow.Bytes,
+			"flowlog.packets":         flow.Packets,
+			"flowlog.startTime":       flow.StartTime,
+			"flowlog.endTime":         flow.EndTime,
+		} {
+			if value != "" {
+				record[attribute] = value
+			}
+		}
+	}
+	setLogType(record, "oci_vcn_flow")
+}
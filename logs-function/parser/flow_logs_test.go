@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFlowLogsParse tests that VCN flow logs are mapped into network attributes.
+func TestFlowLogsParse(t *testing.T) {
+	record := map[string]interface{}{
+		"type": "com.oraclecloud.vcn.flowlogs.DataEvent",
+		"data": map[string]interface{}{
+			"action":             "REJECT",
+			"status":             "OK",
+			"flowid":             "f1",
+			"sourceAddress":      "203.0.113.7",
+			"sourcePort":         json.Number("51234"),
+			"destinationAddress": "10.0.1.5",
+			"destinationPort":    json.Number("22"),
+			"protocol":           json.Number("6"),
+			"protocolName":       "TCP",
+			"bytesOut":           json.Number("60"),
+			"packets":            json.Number("1"),
+		},
+	}
+
+	assert.Equal(t, "flowLogs", Apply(record))
+	assert.Equal(t, "REJECT", record["flowlog.action"])
+	assert.Equal(t, "OK", record["flowlog.status"])
+	assert.Equal(t, "f1", record["flowlog.id"])
+	assert.Equal(t, "203.0.113.7", record["source.address"])
+	assert.Equal(t, json.Number("51234"), record["source.port"])
+	assert.Equal(t, "10.0.1.5", record["destination.address"])
+	assert.Equal(t, json.Number("22"), record["destination.port"])
+	assert.Equal(t, "tcp", record["network.transport"])
+	assert.Equal(t, json.Number("6"), record["network.protocol.number"])
+	assert.Equal(t, json.Number("60"), record["flowlog.bytes"])
+	assert.NotContains(t, record, "flowlog.startTime")
+	assert.Equal(t, "oci_vcn_flow", record["logtype"])
+}
+
+// TestFlowLogsParseMismatch tests that a flow log not fitting the schema is only given its logtype.
+func TestFlowLogsParseMismatch(t *testing.T) {
+	record := map[string]interface{}{
+		"type": "com.oraclecloud.vcn.flowlogs.DataEvent",
+		"data": map[string]interface{}{"sourceAddress": "203.0.113.7", "sourcePort": "ssh"},
+	}
+
+	assert.Equal(t, "flowLogs", Apply(record))
+	assert.NotContains(t, record, "source.address")
+	assert.Equal(t, "oci_vcn_flow", record["logtype"])
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// loadBalancerAccessType is the record type of OCI Load Balancer access logs.
+const loadBalancerAccessType = "com.oraclecloud.loadbalancer.access"
+
+// loadBalancerAttributes maps the data fields of a load balancer access log to attributes.
+var loadBalancerAttributes = map[string]string{
+	"lbStatusCode":          "http.statusCode",
+	"backendStatusCode":     "loadbalancer.backendStatusCode",
+	"clientAddr":            "client.address",
+	"backendAddr":           "peer.address",
+	"userAgent":             "user_agent.original",
+	"receivedBytes":         "loadbalancer.bytesReceived",
+	"sentBytes":             "loadbalancer.bytesSent",
+	"requestProcessingTime": "loadbalancer.requestProcessingTime",
+	"backendProcessingTime": "loadbalancer.backendProcessingTime",
+}
+
+// loadBalancerStatusFields lists the fields holding HTTP status codes, converted to numbers.
+var loadBalancerStatusFields = map[string]bool{
+	"lbStatusCode":      true,
+	"backendStatusCode": true,
+}
+
+func init() {
+	register(loadBalancer{})
+}
+
+// loadBalancer parses OCI Load Balancer access logs into HTTP, client and peer attributes.
+type loadBalancer struct{}
+
+// Name returns the parser name.
+func (loadBalancer) Name() string {
+	return "loadBalancer"
+}
+
+// Match reports whether the record is a load balancer access log.
+func (loadBalancer) Match(record map[string]interface{}) bool {
+	recordType, _ := common.LookupString(record, "type")
+	return recordType == loadBalancerAccessType
+}
+
+// Parse adds the http.*, client.*, peer.* and loadbalancer.* attributes, read from the decoded access log
+// or, when the record does not fit the access log schema, field by field from the generic record.
+func (loadBalancer) Parse(record map[string]interface{}) {
+	if access, ok := DecodeLBAccess(record); ok {
+		parseLBAccess(record, access)
+	} else {
+		parseLoadBalancerFields(record)
+	}
+	setLogType(record, "oci_lb_access")
+}
+
+// parseLBAccess adds the attributes of a decoded access log.
+func parseLBAccess(record map[string]interface{}, access LBAccessRecord) {
+	for attribute, value := range map[string]int64{
+		"http.statusCode":                access.StatusCode,
+		"loadbalancer.backendStatusCode": access.BackendStatusCode,
+	} {
+		if value != 0 {
+			record[attribute] = value
+		}
+	}
+	for attribute, value := range map[string]string{
+		"client.address":      access.ClientAddr,
+		"peer.address":        access.BackendAddr,
+		"user_agent.original": access.UserAgent,
+	} {
+		if value != "" && value != "-" {
+			record[attribute] = value
+		}
+	}
+	for attribute, value := range map[string]json.Number{
+		"loadbalancer.bytesReceived":         access.ReceivedBytes,
+		"loadbalancer.bytesSent":             access.SentBytes,
+		"loadbalancer.requestProcessingTime": access.RequestProcessingTime,
+		"loadbalancer.backendProcessingTime": access.BackendProcessingTime,
+	} {
+		if value != "" {
+			record[attribute] = value
+		}
+	}
+	parseRequestLine(record, access.Request)
+}
+
+// parseLoadBalancerFields adds the attributes of an access log read field by field, skipping the fields
+// of unexpected types.
+func parseLoadBalancerFields(record map[string]interface{}) {
+	data, _ := record["data"].(map[string]interface{})
+	for field, attribute := range loadBalancerAttributes {
+		switch value := data[field].(type) {
+		case string:
+			if value == "" || value == "-" {
+				continue
+			}
+			if number, err := strconv.ParseInt(value, 10, 64); err == nil && loadBalancerStatusFields[field] {
+				record[attribute] = number
+				continue
+			}
+			record[attribute] = value
+		case json.Number, float64:
+			record[attribute] = value
+		}
+	}
+	request, _ := data["request"].(string)
+	parseRequestLine(record, request)
+}
+
+// parseRequestLine adds the http.method, http.url and http.protocol attributes of a request line of the
+// form "GET https://example.com:443/path HTTP/1.1".
+func parseRequestLine(record map[string]interface{}, request string) {
+	if parts := strings.Fields(request); len(parts) == 3 {
+		record["http.method"] = parts[0]
+		record["http.url"] = parts[1]
+		record["http.protocol"] = parts[2]
+	}
+}
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadBalancerParse tests that load balancer access logs are mapped into HTTP attributes.
+func TestLoadBalancerParse(t *testing.T) {
+	record := map[string]interface{}{
+		"type": "com.oraclecloud.loadbalancer.access",
+		"data": map[string]interface{}{
+			"lbStatusCode":      "502",
+			"backendStatusCode": "-",
+			"request":           "POST https://shop.example.com:443/cart HTTP/1.1",
+			"clientAddr":        "203.0.113.7:51234",
+			"backendAddr":       "10.0.1.5:8080",
+			"userAgent":         "curl/8.0",
+			"sentBytes":         json.Number("512"),
+		},
+	}
+
+	assert.Equal(t, "loadBalancer", Apply(record))
+	assert.Equal(t, int64(502), record["http.statusCode"])
+	assert.NotContains(t, record, "loadbalancer.backendStatusCode")
+	assert.Equal(t, "POST", record["http.method"])
+	assert.Equal(t, "https://shop.example.com:443/cart", record["http.url"])
+	assert.Equal(t, "HTTP/1.1", record["http.protocol"])
+	assert.Equal(t, "203.0.113.7:51234", record["client.address"])
+	assert.Equal(t, "10.0.1.5:8080", record["peer.address"])
+	assert.Equal(t, json.Number("512"), record["loadbalancer.bytesSent"])
+	assert.Equal(t, "oci_lb_access", record["logtype"])
+}
+
+// TestLoadBalancerMatch tests that only load balancer access logs are matched.
+func TestLoadBalancerMatch(t *testing.T) {
+	assert.True(t, loadBalancer{}.Match(map[string]interface{}{"type": "com.oraclecloud.loadbalancer.access"}))
+	assert.False(t, loadBalancer{}.Match(map[string]interface{}{"type": "com.oraclecloud.loadbalancer.error"}))
+}
+
+// TestLoadBalancerParseFallback tests that an access log not fitting the schema is parsed field by field.
+func TestLoadBalancerParseFallback(t *testing.T) {
+	record := map[string]interface{}{
+		"type": "com.oraclecloud.loadbalancer.access",
+		"data": map[string]interface{}{
+			"lbStatusCode": "200",
+			"request":      "GET https://shop.example.com:443/ HTTP/1.1",
+			"userAgent":    map[string]interface{}{"name": "curl"},
+		},
+	}
+
+	assert.Equal(t, "loadBalancer", Apply(record))
+	assert.Equal(t, int64(200), record["http.statusCode"])
+	assert.Equal(t, "GET", record["http.method"])
+	assert.NotContains(t, record, "user_agent.original")
+	assert.Equal(t, "oci_lb_access", record["logtype"])
+}
@@ -0,0 +1,113 @@
+package parser
+
+// loggingAnalyticsPrefix is the prefix of the attributes mapped from Logging Analytics fields.
+const loggingAnalyticsPrefix = "loganalytics."
+
+// loggingAnalyticsLabelPrefix is the prefix of the attributes mapped from Logging Analytics labels.
+const loggingAnalyticsLabelPrefix = "loganalytics.label."
+
+// loggingAnalyticsMessageField is the field holding the original log line.
+const loggingAnalyticsMessageField = "Original Log Content"
+
+func init() {
+	register(loggingAnalytics{})
+}
+
+// loggingAnalytics parses records exported from OCI Logging Analytics, which carry their structure as
+// a fields array of {"name","value"} pairs and a labels array of label names or {"name","value"} pairs:
+//
+//	{"fields":[{"name":"Host Name (Server)","value":"web-1"}],"labels":["Error",{"name":"Tier","value":"gold"}]}
+//
+// Each field becomes a loganalytics.<name> attribute, each label a loganalytics.label.<name> attribute,
+// and the original log content becomes the message.
+type loggingAnalytics struct{}
+
+// Name returns the parser name.
+func (loggingAnalytics) Name() string {
+	return "loggingAnalytics"
+}
+
+// Match reports whether the record, or its data, carries a Logging Analytics fields array.
+func (loggingAnalytics) Match(record map[string]interface{}) bool {
+	_, ok := loggingAnalyticsBody(record)
+	return ok
+}
+
+// Parse maps the fields and labels into attributes and removes the arrays from the record.
+func (loggingAnalytics) Parse(record map[string]interface{}) {
+	body, _ := loggingAnalyticsBody(record)
+
+	for _, item := range body["fields"].([]interface{}) {
+		name, value, ok := namedValue(item)
+		if !ok {
+			continue
+		}
+		if name == loggingAnalyticsMessageField {
+			if _, exists := record["message"]; !exists {
+				record["message"] = value
+				continue
+			}
+		}
+		if key := attributeName(name); key != "" {
+			record[loggingAnalyticsPrefix+key] = value
+		}
+	}
+
+	if labels, ok := body["labels"].([]interface{}); ok {
+		for _, item := range labels {
+			if name, ok := item.(string); ok {
+				if key := attributeName(name); key != "" {
+					record[loggingAnalyticsLabelPrefix+key] = true
+				}
+				continue
+			}
+			if name, value, ok := namedValue(item); ok {
+				if key := attributeName(name); key != "" {
+					record[loggingAnalyticsLabelPrefix+key] = value
+				}
+			}
+		}
+	}
+
+	delete(body, "fields")
+	delete(body, "labels")
+	setLogType(record, "oci_logging_analytics")
+}
+
+// loggingAnalyticsBody returns the map holding the fields array: the record itself or its data.
+func loggingAnalyticsBody(record map[string]interface{}) (map[string]interface{}, bool) {
+	candidates := []map[string]interface{}{record}
+	if data, ok := record["data"].(map[string]interface{}); ok {
+		candidates = append(candidates, data)
+	}
+	for _, candidate := range candidates {
+		fields, ok := candidate["fields"].([]interface{})
+		if !ok || len(fields) == 0 {
+			continue
+		}
+		if _, _, ok := namedValue(fields[0]); ok {
+			return candidate, true
+		}
+	}
+	return nil, false
+}
+
+// namedValue reads a {"name": ..., "value": ...} pair. A value that is not a scalar is kept as is.
+func namedValue(item interface{}) (string, interface{}, bool) {
+	pair, ok := item.(map[string]interface{})
+	if !ok {
+		return "", nil, false
+	}
+	name, ok := pair["name"].(string)
+	if !ok || name == "" {
+		return "", nil, false
+	}
+	value, ok := pair["value"]
+	if !ok {
+		return "", nil, false
+	}
+	if values, ok := value.([]interface{}); ok && len(values) == 1 {
+		value = values[0]
+	}
+	return name, value, true
+}
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoggingAnalytics tests mapping of exported fields and labels into attributes.
+func TestLoggingAnalytics(t *testing.T) {
+	record := map[string]interface{}{
+		"data": map[string]interface{}{
+			"fields": []interface{}{
+				map[string]interface{}{"name": "Log Source", "value": "Linux Syslog Logs"},
+				map[string]interface{}{"name": "Host Name (Server)", "value": "web-1"},
+				map[string]interface{}{"name": "Original Log Content", "value": "kernel: oom-killer invoked"},
+				map[string]interface{}{"name": "Entity Type", "value": []interface{}{"Host (Linux)"}},
+				map[string]interface{}{"name": "ignored"},
+			},
+			"labels": []interface{}{
+				"Critical Error",
+				map[string]interface{}{"name": "Tier", "value": "gold"},
+			},
+			"other": "kept",
+		},
+	}
+
+	assert.Equal(t, "loggingAnalytics", Apply(record))
+	assert.Equal(t, map[string]interface{}{
+		"data":                             map[string]interface{}{"other": "kept"},
+		"message":                          "kernel: oom-killer invoked",
+		"loganalytics.logSource":           "Linux Syslog Logs",
+		"loganalytics.hostNameServer":      "web-1",
+		"loganalytics.entityType":          "Host (Linux)",
+		"loganalytics.label.criticalError": true,
+		"loganalytics.label.tier":          "gold",
+		"logtype":                          "oci_logging_analytics",
+	}, record)
+}
+
+// TestLoggingAnalyticsNoMatch tests that records without a fields array are left to other parsers.
+func TestLoggingAnalyticsNoMatch(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"message": "plain"},
+		{"fields": []interface{}{"a", "b"}},
+		{"data": map[string]interface{}{"fields": []interface{}{}}},
+	}
+	for _, record := range tests {
+		assert.False(t, loggingAnalytics{}.Match(record))
+	}
+}
+
+// TestAttributeName tests conversion of display names into attribute names.
+func TestAttributeName(t *testing.T) {
+	assert.Equal(t, "hostNameServer", attributeName("Host Name (Server)"))
+	assert.Equal(t, "logSource", attributeName("Log Source"))
+	assert.Equal(t, "http2Status", attributeName("HTTP2 status"))
+	assert.Equal(t, "", attributeName("()"))
+}
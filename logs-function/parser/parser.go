@@ -0,0 +1,126 @@
+// Package parser recognizes records of specific OCI sources and maps their source-specific
+// structure into flat New Relic attributes.
+package parser
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// Parser maps the records of one OCI source into attributes.
+type Parser interface {
+	// Name identifies the parser in logs and configuration.
+	Name() string
+	// Match reports whether the record comes from the parser's source.
+	Match(record map[string]interface{}) bool
+	// Parse adds the source attributes to the record in place.
+	Parse(record map[string]interface{})
+}
+
+// Registered parsers in the order they are tried: custom parsers before the built-in ones.
+var (
+	customParsers []Parser
+	parsers       []Parser
+)
+
+// register adds a built-in parser to the registry. Built-in parsers are registered from init functions.
+func register(p Parser) {
+	parsers = append(parsers, p)
+}
+
+// Register adds a custom parser, tried before the built-in parsers in registration order. It is meant
+// to be called before the function starts serving invocations and is not safe for concurrent use.
+func Register(p Parser) {
+	customParsers = append(customParsers, p)
+}
+
+// ParserNone is the forced parser leaving the records of a log group unparsed.
+const ParserNone = "none"
+
+// Selection selects the parsers applied to records.
+type Selection struct {
+	Enabled map[string]bool   // Enabled holds the names of the parsers tried on records; nil enables every parser.
+	Forced  map[string]string // Forced maps log group OCIDs to the parser applied to all of their records, or ParserNone.
+}
+
+// Apply parses the record with the first matching parser and returns the parser name,
+// or an empty string when no parser recognizes the record.
+func Apply(record map[string]interface{}) string {
+	return Selection{}.Apply(record)
+}
+
+// Apply parses the record with the parser forced for its log group, or else with the first matching enabled
+// parser, and returns the parser name, or an empty string when the record is left unparsed.
+func (s Selection) Apply(record map[string]interface{}) string {
+	if name, ok := s.Forced[common.LogGroupID(record)]; ok {
+		if p, found := Lookup(name); found {
+			p.Parse(record)
+			return p.Name()
+		}
+		return ""
+	}
+	for _, registered := range [][]Parser{customParsers, parsers} {
+		for _, p := range registered {
+			if s.Enabled != nil && !s.Enabled[p.Name()] {
+				continue
+			}
+			if p.Match(record) {
+				p.Parse(record)
+				return p.Name()
+			}
+		}
+	}
+	return ""
+}
+
+// Lookup returns the registered parser with the given name.
+func Lookup(name string) (Parser, bool) {
+	for _, registered := range [][]Parser{customParsers, parsers} {
+		for _, p := range registered {
+			if p.Name() == name {
+				return p, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Names returns the names of the registered parsers in the order they are tried.
+func Names() []string {
+	var names []string
+	for _, registered := range [][]Parser{customParsers, parsers} {
+		for _, p := range registered {
+			names = append(names, p.Name())
+		}
+	}
+	return names
+}
+
+// setLogType sets logtype on the record unless the record already has one.
+func setLogType(record map[string]interface{}, logType string) {
+	if _, ok := record["logtype"]; !ok {
+		record["logtype"] = logType
+	}
+}
+
+// attributeName converts a display name such as "Host Name (Server)" into a camel-cased
+// attribute name such as "hostNameServer".
+func attributeName(displayName string) string {
+	words := strings.FieldsFunc(displayName, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for i, word := range words {
+		runes := []rune(word)
+		if i == 0 {
+			b.WriteString(strings.ToLower(word))
+			continue
+		}
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(strings.ToLower(string(runes[1:])))
+	}
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// typeParser is a custom parser matching records of one type.
+type typeParser struct{ recordType string }
+
+func (p typeParser) Name() string { return "custom" }
+
+func (p typeParser) Match(record map[string]interface{}) bool { return record["type"] == p.recordType }
+
+func (p typeParser) Parse(record map[string]interface{}) { record["custom"] = true }
+
+// TestRegister tests that custom parsers are tried before the built-in ones and fall through to them.
+func TestRegister(t *testing.T) {
+	defer func(registered []Parser) { customParsers = registered }(customParsers)
+	Register(typeParser{recordType: "com.oraclecloud.goldengate.deployment"})
+
+	record := map[string]interface{}{"type": "com.oraclecloud.goldengate.deployment"}
+	assert.Equal(t, "custom", Apply(record))
+	assert.Equal(t, true, record["custom"])
+
+	record = map[string]interface{}{"type": "com.oraclecloud.goldengate.other"}
+	assert.Equal(t, "goldenGate", Apply(record))
+}
+
+// TestSelection tests that disabled parsers are skipped and forced parsers apply to every record of their log group.
+func TestSelection(t *testing.T) {
+	alarmNotification := func(logGroup string) map[string]interface{} {
+		return map[string]interface{}{
+			"dedupeKey":     "k",
+			"alarmMetaData": []interface{}{map[string]interface{}{"id": "ocid1.alarm.a"}},
+			"oracle":        map[string]interface{}{"loggroupid": logGroup},
+		}
+	}
+	tests := []struct {
+		name      string
+		selection Selection
+		record    map[string]interface{}
+		expected  string
+	}{
+		{"all enabled", Selection{}, alarmNotification("lg1"), "alarm"},
+		{"enabled", Selection{Enabled: map[string]bool{"alarm": true}}, alarmNotification("lg1"), "alarm"},
+		{"disabled", Selection{Enabled: map[string]bool{"vault": true}}, alarmNotification("lg1"), ""},
+		{"forced", Selection{Forced: map[string]string{"lg1": "vault"}}, map[string]interface{}{
+			"data":   map[string]interface{}{"eventName": "Decrypt"},
+			"oracle": map[string]interface{}{"loggroupid": "lg1"},
+		}, "vault"},
+		{"forced while disabled", Selection{Enabled: map[string]bool{}, Forced: map[string]string{"lg1": "alarm"}}, alarmNotification("lg1"), "alarm"},
+		{"forced none", Selection{Forced: map[string]string{"lg1": ParserNone}}, alarmNotification("lg1"), ""},
+		{"other log group", Selection{Forced: map[string]string{"lg1": ParserNone}}, alarmNotification("lg2"), "alarm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.selection.Apply(tt.record))
+		})
+	}
+}
+
+// TestLookup tests finding registered parsers by name.
+func TestLookup(t *testing.T) {
+	p, ok := Lookup("alarm")
+	assert.True(t, ok)
+	assert.Equal(t, "alarm", p.Name())
+
+	_, ok = Lookup(ParserNone)
+	assert.False(t, ok)
+	assert.Contains(t, Names(), "vault")
+}
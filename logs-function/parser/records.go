@@ -0,0 +1,236 @@
+package parser
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Typed views of the records of well-known OCI sources. Decoding walks the generic record once and checks
+// the type of every known field, so the fields are read without nested lookups and type assertions. A
+// record whose structure does not fit its schema is not decoded, and the caller falls back to reading the
+// generic map. The record itself is never replaced: fields outside the schema are forwarded as they are.
+
+// AuditRecord is an OCI _Audit event.
+type AuditRecord struct {
+	EventName     string        // EventName is the name of the audited API operation.
+	CompartmentID string        // CompartmentID is the compartment of the audited resource.
+	TenantID      string        // TenantID is the tenancy of the audited resource, from the OCI envelope.
+	Identity      AuditIdentity // Identity describes who made the call.
+	Request       AuditRequest  // Request describes the audited request.
+	Response      AuditResponse // Response describes the answer to the audited request.
+}
+
+// AuditIdentity is the identity of the actors of an audit event.
+type AuditIdentity struct {
+	PrincipalID   string // PrincipalID is the OCID of the principal whose authority the call used.
+	PrincipalName string // PrincipalName is the name of the principal.
+	AuthType      string // AuthType is how the principal authenticated.
+	IPAddress     string // IPAddress is the address the call came from.
+	UserAgent     string // UserAgent is the user agent of the caller.
+	TenantID      string // TenantID is the tenancy of the principal.
+	CallerID      string // CallerID is the OCID of the caller, which differs from the principal for on-behalf-of calls.
+	CallerName    string // CallerName is the name of the caller.
+}
+
+// AuditRequest is the request of an audit event.
+type AuditRequest struct {
+	Action  string                 // Action is the HTTP method of the request.
+	Path    string                 // Path is the path of the request.
+	Headers map[string]interface{} // Headers are the request headers, shared with the record.
+}
+
+// AuditResponse is the response of an audit event.
+type AuditResponse struct {
+	Status  int64                  // Status is the HTTP status of the response, 0 when unknown.
+	Headers map[string]interface{} // Headers are the response headers, shared with the record.
+}
+
+// FlowLogRecord is an OCI VCN flow log record.
+type FlowLogRecord struct {
+	FlowID             string      // FlowID identifies the flow.
+	Action             string      // Action is ACCEPT or REJECT.
+	Status             string      // Status is OK, NODATA or SKIPDATA.
+	SourceAddress      string      // SourceAddress is the source IP address.
+	SourcePort         json.Number // SourcePort is the source port, empty when unknown.
+	DestinationAddress string      // DestinationAddress is the destination IP address.
+	DestinationPort    json.Number // DestinationPort is the destination port, empty when unknown.
+	Protocol           json.Number // Protocol is the IANA protocol number, empty when unknown.
+	ProtocolName       string      // ProtocolName is the protocol name, e.g. TCP.
+	Bytes              json.Number // Bytes is the number of bytes of the flow, empty when unknown.
+	Packets            json.Number // Packets is the number of packets of the flow, empty when unknown.
+	StartTime          json.Number // StartTime is the start of the capture window, in epoch seconds.
+	EndTime            json.Number // EndTime is the end of the capture window, in epoch seconds.
+}
+
+// LBAccessRecord is an OCI Load Balancer access log record.
+type LBAccessRecord struct {
+	StatusCode            int64       // StatusCode is the status returned by the load balancer, 0 when unknown.
+	BackendStatusCode     int64       // BackendStatusCode is the status returned by the backend, 0 when unknown.
+	Request               string      // Request is the request line, e.g. "GET https://example.com:443/path HTTP/1.1".
+	ClientAddr            string      // ClientAddr is the address and port of the client.
+	BackendAddr           string      // BackendAddr is the address and port of the backend.
+	UserAgent             string      // UserAgent is the user agent of the client.
+	ReceivedBytes         json.Number // ReceivedBytes is the size of the request, empty when unknown.
+	SentBytes             json.Number // SentBytes is the size of the response, empty when unknown.
+	RequestProcessingTime json.Number // RequestProcessingTime is the load balancer processing time, empty when unknown.
+	BackendProcessingTime json.Number // BackendProcessingTime is the backend processing time, empty when unknown.
+}
+
+// DecodeAudit decodes an audit event, reporting false when the record does not fit the audit schema.
+func DecodeAudit(record map[string]interface{}) (AuditRecord, bool) {
+	r := newFieldReader(record)
+	data := r.object("data")
+	identity := data.object("identity")
+	request := data.object("request")
+	response := data.object("response")
+	audit := AuditRecord{
+		EventName:     data.string("eventName"),
+		CompartmentID: data.string("compartmentId"),
+		TenantID:      r.object("oracle").string("tenantid"),
+		Identity: AuditIdentity{
+			PrincipalID:   identity.string("principalId"),
+			PrincipalName: identity.string("principalName"),
+			AuthType:      identity.string("authType"),
+			IPAddress:     identity.string("ipAddress"),
+			UserAgent:     identity.string("userAgent"),
+			TenantID:      identity.string("tenantId"),
+			CallerID:      identity.string("callerId"),
+			CallerName:    identity.string("callerName"),
+		},
+		Request: AuditRequest{
+			Action:  request.string("action"),
+			Path:    request.string("path"),
+			Headers: request.object("headers").fields,
+		},
+		Response: AuditResponse{
+			Status:  response.integer("status"),
+			Headers: response.object("headers").fields,
+		},
+	}
+	return audit, r.valid()
+}
+
+// DecodeFlowLog decodes a VCN flow log record, reporting false when the record does not fit the flow log schema.
+func DecodeFlowLog(record map[string]interface{}) (FlowLogRecord, bool) {
+	r := newFieldReader(record)
+	data := r.object("data")
+	flow := FlowLogRecord{
+		FlowID:             data.string("flowid"),
+		Action:             data.string("action"),
+		Status:             data.string("status"),
+		SourceAddress:      data.string("sourceAddress"),
+		SourcePort:         data.number("sourcePort"),
+		DestinationAddress: data.string("destinationAddress"),
+		DestinationPort:    data.number("destinationPort"),
+		Protocol:           data.number("protocol"),
+		ProtocolName:       data.string("protocolName"),
+		Bytes:              data.number("bytesOut"),
+		Packets:            data.number("packets"),
+		StartTime:          data.number("startTime"),
+		EndTime:            data.number("endTime"),
+	}
+	return flow, r.valid()
+}
+
+// DecodeLBAccess decodes a load balancer access log record, reporting false when the record does not fit
+// the access log schema.
+func DecodeLBAccess(record map[string]interface{}) (LBAccessRecord, bool) {
+	r := newFieldReader(record)
+	data := r.object("data")
+	access := LBAccessRecord{
+		StatusCode:            data.integer("lbStatusCode"),
+		BackendStatusCode:     data.integer("backendStatusCode"),
+		Request:               data.string("request"),
+		ClientAddr:            data.string("clientAddr"),
+		BackendAddr:           data.string("backendAddr"),
+		UserAgent:             data.string("userAgent"),
+		ReceivedBytes:         data.number("receivedBytes"),
+		SentBytes:             data.number("sentBytes"),
+		RequestProcessingTime: data.number("requestProcessingTime"),
+		BackendProcessingTime: data.number("backendProcessingTime"),
+	}
+	return access, r.valid()
+}
+
+// fieldReader reads the typed fields of a JSON object, recording whether a field had an unexpected type.
+// Absent and null fields read as zero values. The readers of nested objects share the mismatch flag.
+type fieldReader struct {
+	fields   map[string]interface{}
+	mismatch *bool
+}
+
+// newFieldReader returns a reader of the fields of the record.
+func newFieldReader(record map[string]interface{}) fieldReader {
+	return fieldReader{fields: record, mismatch: new(bool)}
+}
+
+// valid reports whether every field read so far had its expected type.
+func (r fieldReader) valid() bool {
+	return !*r.mismatch
+}
+
+// object returns a reader of the nested object field.
+func (r fieldReader) object(key string) fieldReader {
+	switch value := r.fields[key].(type) {
+	case nil:
+	case map[string]interface{}:
+		return fieldReader{fields: value, mismatch: r.mismatch}
+	default:
+		*r.mismatch = true
+	}
+	return fieldReader{mismatch: r.mismatch}
+}
+
+// string returns the string field.
+func (r fieldReader) string(key string) string {
+	switch value := r.fields[key].(type) {
+	case nil:
+	case string:
+		return value
+	default:
+		*r.mismatch = true
+	}
+	return ""
+}
+
+// number returns the numeric field, held as a JSON number or a numeric string. Empty strings and "-",
+// used by OCI for missing values, read as absent.
+func (r fieldReader) number(key string) json.Number {
+	switch value := r.fields[key].(type) {
+	case nil:
+	case json.Number:
+		return value
+	case float64:
+		return json.Number(strconv.FormatFloat(value, 'f', -1, 64))
+	case int64:
+		return json.Number(strconv.FormatInt(value, 10))
+	case int:
+		return json.Number(strconv.Itoa(value))
+	case string:
+		value = strings.TrimSpace(value)
+		if value == "" || value == "-" {
+			return ""
+		}
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+		*r.mismatch = true
+	default:
+		*r.mismatch = true
+	}
+	return ""
+}
+
+// integer returns the integer field, held as a JSON number or a numeric string, or 0 when absent.
+func (r fieldReader) integer(key string) int64 {
+	number := r.number(key)
+	if number == "" {
+		return 0
+	}
+	n, err := number.Int64()
+	if err != nil {
+		*r.mismatch = true
+	}
+	return n
+}
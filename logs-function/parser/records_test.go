@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDecodeAudit tests that audit events are decoded, and that records not fitting the schema are not.
+func TestDecodeAudit(t *testing.T) {
+	headers := map[string]interface{}{"opc-request-id": []interface{}{"abc"}}
+	record := map[string]interface{}{
+		"oracle": map[string]interface{}{"tenantid": "ocid1.tenancy.oc1..resource"},
+		"data": map[string]interface{}{
+			"eventName": "GetInstance",
+			"identity": map[string]interface{}{
+				"principalId": "ocid1.user.oc1..alice",
+				"tenantId":    "ocid1.tenancy.oc1..home",
+			},
+			"request":  map[string]interface{}{"action": "GET", "headers": headers},
+			"response": map[string]interface{}{"status": "404"},
+		},
+	}
+
+	audit, ok := DecodeAudit(record)
+	assert.True(t, ok)
+	assert.Equal(t, "GetInstance", audit.EventName)
+	assert.Equal(t, "ocid1.tenancy.oc1..resource", audit.TenantID)
+	assert.Equal(t, "ocid1.user.oc1..alice", audit.Identity.PrincipalID)
+	assert.Equal(t, "ocid1.tenancy.oc1..home", audit.Identity.TenantID)
+	assert.Equal(t, "GET", audit.Request.Action)
+	assert.Equal(t, int64(404), audit.Response.Status)
+	assert.Nil(t, audit.Response.Headers)
+
+	// The headers are shared with the record so they can be edited in place
+	delete(audit.Request.Headers, "opc-request-id")
+	assert.Empty(t, headers)
+
+	record["data"].(map[string]interface{})["identity"] = "alice"
+	_, ok = DecodeAudit(record)
+	assert.False(t, ok)
+}
+
+// TestDecodeLBAccess tests the field types accepted when decoding load balancer access logs.
+func TestDecodeLBAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     map[string]interface{}
+		expected LBAccessRecord
+		ok       bool
+	}{
+		{
+			name:     "numbers held as strings and JSON numbers",
+			data:     map[string]interface{}{"lbStatusCode": "200", "sentBytes": json.Number("512"), "receivedBytes": 128.0},
+			expected: LBAccessRecord{StatusCode: 200, SentBytes: "512", ReceivedBytes: "128"},
+			ok:       true,
+		},
+		{
+			name:     "missing values",
+			data:     map[string]interface{}{"backendStatusCode": "-", "sentBytes": "", "clientAddr": nil},
+			expected: LBAccessRecord{},
+			ok:       true,
+		},
+		{
+			name: "non-numeric status",
+			data: map[string]interface{}{"lbStatusCode": "OK"},
+			ok:   false,
+		},
+		{
+			name: "fractional status",
+			data: map[string]interface{}{"lbStatusCode": 200.5},
+			ok:   false,
+		},
+		{
+			name: "request line of another type",
+			data: map[string]interface{}{"request": []interface{}{"GET"}},
+			ok:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			access, ok := DecodeLBAccess(map[string]interface{}{"data": tt.data})
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.expected, access)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// Custom event types of the security records forwarded to the Event API.
+const (
+	CloudGuardEventType = "OciCloudGuardProblem" // CloudGuardEventType is the custom event type of Cloud Guard problems.
+	BastionEventType    = "OciBastionSession"    // BastionEventType is the custom event type of Bastion sessions.
+)
+
+// eventTypes maps the logtype set by the security and alarm parsers to the custom event type of the record.
+var eventTypes = map[string]string{
+	"oci_cloud_guard": CloudGuardEventType,
+	"oci_bastion":     BastionEventType,
+}
+
+func init() {
+	register(cloudGuard{})
+	register(bastion{})
+}
+
+// EventType returns the custom event type of a record parsed by a security or alarm parser.
+func EventType(record map[string]interface{}) (string, bool) {
+	logType, _ := record["logtype"].(string)
+	eventType, ok := eventTypes[logType]
+	return eventType, ok
+}
+
+// cloudGuard parses Cloud Guard problem and detector events, as delivered by the Events service:
+//
+//	{"type":"com.oraclecloud.cloudguard.problemdetected","data":{"resourceId":"ocid1.cloudguardproblem...",
+//	 "additionalDetails":{"problemName":"...","riskLevel":"HIGH","status":"OPEN","resourceId":"...",...}}}
+type cloudGuard struct{}
+
+// Name returns the parser name.
+func (cloudGuard) Name() string {
+	return "cloudGuard"
+}
+
+// Match reports whether the record is a Cloud Guard event.
+func (cloudGuard) Match(record map[string]interface{}) bool {
+	recordType, _ := common.LookupString(record, "type")
+	return strings.HasPrefix(recordType, "com.oraclecloud.cloudguard.")
+}
+
+// Parse adds the cloudguard.* and security.* attributes.
+func (cloudGuard) Parse(record map[string]interface{}) {
+	recordType, _ := common.LookupString(record, "type")
+	record["cloudguard.eventName"] = strings.TrimPrefix(recordType, "com.oraclecloud.cloudguard.")
+
+	if id, ok := common.LookupString(record, "data", "resourceId"); ok {
+		record["cloudguard.problemId"] = id
+	}
+	details := map[string]string{
+		"problemName":           "cloudguard.problemName",
+		"problemType":           "cloudguard.problemType",
+		"problemDescription":    "cloudguard.problemDescription",
+		"problemRecommendation": "cloudguard.recommendation",
+		"riskLevel":             "cloudguard.riskLevel",
+		"status":                "cloudguard.status",
+		"reason":                "cloudguard.reason",
+		"detectorId":            "cloudguard.detectorId",
+		"detectorRuleId":        "cloudguard.detectorRuleId",
+		"targetId":              "cloudguard.targetId",
+		"resourceId":            "cloudguard.resourceId",
+		"resourceName":          "cloudguard.resourceName",
+		"resourceType":          "cloudguard.resourceType",
+		"region":                "cloudguard.region",
+		"firstDetected":         "cloudguard.firstDetected",
+		"lastDetected":          "cloudguard.lastDetected",
+	}
+	for field, attribute := range details {
+		if value, ok := common.LookupString(record, "data", "additionalDetails", field); ok {
+			record[attribute] = value
+		}
+	}
+	if labels, ok := common.LookupValue(record, "data", "additionalDetails", "labels"); ok {
+		if joined := joinStrings(labels); joined != "" {
+			record["cloudguard.labels"] = joined
+		}
+	}
+
+	record["security.source"] = "cloudguard"
+	if risk, ok := record["cloudguard.riskLevel"].(string); ok {
+		record["security.severity"] = strings.ToLower(risk)
+	}
+	setLogType(record, "oci_cloud_guard")
+}
+
+// bastion parses Bastion session events, recording who opened which kind of session to which target.
+type bastion struct{}
+
+// Name returns the parser name.
+func (bastion) Name() string {
+	return "bastion"
+}
+
+// Match reports whether the record is a Bastion event.
+func (bastion) Match(record map[string]interface{}) bool {
+	recordType, _ := common.LookupString(record, "type")
+	return strings.HasPrefix(recordType, "com.oraclecloud.bastion.")
+}
+
+// Parse adds the bastion.* and security.* attributes.
+func (bastion) Parse(record map[string]interface{}) {
+	recordType, _ := common.LookupString(record, "type")
+	record["bastion.action"] = strings.TrimPrefix(recordType, "com.oraclecloud.bastion.")
+
+	fields := []struct {
+		attribute string
+		paths     [][]string
+	}{
+		{"bastion.sessionId", [][]string{{"data", "resourceId"}, {"data", "sessionId"}}},
+		{"bastion.sessionName", [][]string{{"data", "resourceName"}, {"data", "sessionName"}}},
+		{"bastion.id", [][]string{{"data", "additionalDetails", "bastionId"}, {"data", "bastionId"}}},
+		{"bastion.name", [][]string{{"data", "additionalDetails", "bastionName"}, {"data", "bastionName"}}},
+		{"bastion.sessionType", [][]string{{"data", "additionalDetails", "sessionType"}, {"data", "sessionType"}}},
+		{"bastion.targetResourceId", [][]string{{"data", "additionalDetails", "targetResourceId"}, {"data", "targetResourceId"}}},
+		{"bastion.targetAddress", [][]string{{"data", "additionalDetails", "targetResourcePrivateIp"}, {"data", "targetResourcePrivateIp"}}},
+		{"bastion.targetUser", [][]string{{"data", "additionalDetails", "targetResourceOperatingSystemUserName"}, {"data", "targetUser"}}},
+		{"enduser.id", [][]string{{"data", "identity", "principalId"}}},
+		{"enduser.name", [][]string{{"data", "identity", "principalName"}}},
+		{"client.address", [][]string{{"data", "identity", "ipAddress"}, {"data", "clientIp"}}},
+	}
+	for _, field := range fields {
+		if value, ok := firstString(record, field.paths...); ok {
+			record[field.attribute] = value
+		}
+	}
+	if port, ok := firstValue(record, []string{"data", "additionalDetails", "targetResourcePort"}, []string{"data", "targetResourcePort"}); ok {
+		record["bastion.targetPort"] = port
+	}
+
+	record["security.source"] = "bastion"
+	setLogType(record, "oci_bastion")
+}
+
+// firstValue returns the first value found along the paths.
+func firstValue(record map[string]interface{}, paths ...[]string) (interface{}, bool) {
+	for _, path := range paths {
+		if value, ok := common.LookupValue(record, path...); ok && value != nil {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// joinStrings joins the string elements of a JSON array with commas.
+func joinStrings(value interface{}) string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return ""
+	}
+	var values []string
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" {
+			values = append(values, s)
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+// maxEventAttributeLength is the longest string attribute value accepted by the Event API.
+const maxEventAttributeLength = 4096
+
+// Event returns the custom event of a record parsed by a security or alarm parser, holding the record's
+// top-level scalar attributes.
+func Event(record map[string]interface{}) (map[string]interface{}, bool) {
+	eventType, ok := EventType(record)
+	if !ok {
+		return nil, false
+	}
+
+	event := make(map[string]interface{}, len(record)+1)
+	for key, value := range record {
+		switch v := value.(type) {
+		case string:
+			if len(v) > maxEventAttributeLength {
+				v = v[:maxEventAttributeLength]
+			}
+			event[key] = v
+		case bool, float64, int, int64, json.Number:
+			event[key] = v
+		}
+	}
+	event["eventType"] = eventType
+	return event, true
+}
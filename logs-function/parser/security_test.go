@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCloudGuard tests mapping of a Cloud Guard problem event into security attributes.
+func TestCloudGuard(t *testing.T) {
+	record := map[string]interface{}{
+		"type": "com.oraclecloud.cloudguard.problemdetected",
+		"data": map[string]interface{}{
+			"resourceId": "ocid1.cloudguardproblem.a",
+			"additionalDetails": map[string]interface{}{
+				"problemName":  "BUCKET_IS_PUBLIC",
+				"riskLevel":    "CRITICAL",
+				"status":       "OPEN",
+				"resourceName": "backups",
+				"resourceType": "Bucket",
+				"labels":       []interface{}{"CIS_OCI_V1.1_OBJECTSTORAGE", "ObjectStorage"},
+			},
+		},
+	}
+
+	assert.Equal(t, "cloudGuard", Apply(record))
+	assert.Equal(t, "problemdetected", record["cloudguard.eventName"])
+	assert.Equal(t, "ocid1.cloudguardproblem.a", record["cloudguard.problemId"])
+	assert.Equal(t, "BUCKET_IS_PUBLIC", record["cloudguard.problemName"])
+	assert.Equal(t, "OPEN", record["cloudguard.status"])
+	assert.Equal(t, "backups", record["cloudguard.resourceName"])
+	assert.Equal(t, "CIS_OCI_V1.1_OBJECTSTORAGE,ObjectStorage", record["cloudguard.labels"])
+	assert.Equal(t, "critical", record["security.severity"])
+	assert.Equal(t, "cloudguard", record["security.source"])
+	assert.Equal(t, "oci_cloud_guard", record["logtype"])
+}
+
+// TestBastion tests mapping of a Bastion session event into security attributes.
+func TestBastion(t *testing.T) {
+	record := map[string]interface{}{
+		"type": "com.oraclecloud.bastion.createsession.end",
+		"data": map[string]interface{}{
+			"resourceId":   "ocid1.bastionsession.a",
+			"resourceName": "debug-session",
+			"identity":     map[string]interface{}{"principalName": "jdoe", "ipAddress": "192.0.2.10"},
+			"additionalDetails": map[string]interface{}{
+				"bastionName":                           "prod-bastion",
+				"sessionType":                           "MANAGED_SSH",
+				"targetResourcePrivateIp":               "10.0.1.5",
+				"targetResourcePort":                    json.Number("22"),
+				"targetResourceOperatingSystemUserName": "opc",
+			},
+		},
+	}
+
+	assert.Equal(t, "bastion", Apply(record))
+	assert.Equal(t, "createsession.end", record["bastion.action"])
+	assert.Equal(t, "ocid1.bastionsession.a", record["bastion.sessionId"])
+	assert.Equal(t, "debug-session", record["bastion.sessionName"])
+	assert.Equal(t, "prod-bastion", record["bastion.name"])
+	assert.Equal(t, "MANAGED_SSH", record["bastion.sessionType"])
+	assert.Equal(t, "10.0.1.5", record["bastion.targetAddress"])
+	assert.Equal(t, json.Number("22"), record["bastion.targetPort"])
+	assert.Equal(t, "opc", record["bastion.targetUser"])
+	assert.Equal(t, "jdoe", record["enduser.name"])
+	assert.Equal(t, "192.0.2.10", record["client.address"])
+	assert.Equal(t, "oci_bastion", record["logtype"])
+}
+
+// TestEvent tests that only security records become custom events, keeping their scalar attributes.
+func TestEvent(t *testing.T) {
+	_, ok := Event(map[string]interface{}{"logtype": "oci_audit"})
+	assert.False(t, ok)
+
+	event, ok := Event(map[string]interface{}{
+		"logtype":              "oci_cloud_guard",
+		"cloudguard.riskLevel": "HIGH",
+		"message":              strings.Repeat("a", maxEventAttributeLength+10),
+		"data":                 map[string]interface{}{"nested": true},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, CloudGuardEventType, event["eventType"])
+	assert.Equal(t, "HIGH", event["cloudguard.riskLevel"])
+	assert.Len(t, event["message"], maxEventAttributeLength)
+	assert.NotContains(t, event, "data")
+}
@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// vaultResourceTypes maps the OCID prefix of Vault resources to the key.resourceType attribute.
+var vaultResourceTypes = map[string]string{
+	"ocid1.key.":         "key",
+	"ocid1.keyversion.":  "keyVersion",
+	"ocid1.vaultsecret.": "secret",
+	"ocid1.vault.":       "vault",
+}
+
+// vaultEventTypePrefixes lists the audit event type prefixes of the Vault, KMS and secret retrieval services.
+var vaultEventTypePrefixes = []string{
+	"com.oraclecloud.kms",
+	"com.oraclecloud.keymanagement",
+	"com.oraclecloud.vaultmng",
+	"com.oraclecloud.secretretrieval",
+	"com.oraclecloud.secretmgmt",
+}
+
+// cryptoOperations lists the KMS operations that use key material.
+var cryptoOperations = map[string]bool{
+	"Encrypt":                   true,
+	"Decrypt":                   true,
+	"ReEncrypt":                 true,
+	"GenerateDataEncryptionKey": true,
+	"Sign":                      true,
+	"Verify":                    true,
+	"ExportKey":                 true,
+}
+
+// Values of the key.usage attribute.
+const (
+	keyUsageCrypto       = "crypto"       // keyUsageCrypto is an operation using key material.
+	keyUsageSecretAccess = "secretAccess" // keyUsageSecretAccess is a read of secret contents.
+	keyUsageManagement   = "management"   // keyUsageManagement is any other Vault operation.
+)
+
+func init() {
+	register(vault{})
+}
+
+// vault parses the audit events of OCI Vault and KMS, describing which principal used or read which
+// key or secret, so anomalous secret access can be alerted on.
+type vault struct{}
+
+// Name returns the parser name.
+func (vault) Name() string {
+	return "vault"
+}
+
+// Match reports whether the record is an audit event of a Vault resource.
+func (vault) Match(record map[string]interface{}) bool {
+	if _, ok := common.LookupString(record, "data", "eventName"); !ok {
+		return false
+	}
+	if vaultResourceType(record) != "" {
+		return true
+	}
+	recordType, _ := common.LookupString(record, "type")
+	recordType = strings.ToLower(recordType)
+	for _, prefix := range vaultEventTypePrefixes {
+		if strings.HasPrefix(recordType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse adds the key.* attributes.
+func (vault) Parse(record map[string]interface{}) {
+	operation, _ := common.LookupString(record, "data", "eventName")
+	record["key.operation"] = operation
+	record["key.usage"] = keyUsage(operation)
+
+	if resourceType := vaultResourceType(record); resourceType != "" {
+		record["key.resourceType"] = resourceType
+	}
+	fields := []struct {
+		attribute string
+		paths     [][]string
+	}{
+		{"key.id", [][]string{{"data", "resourceId"}}},
+		{"key.name", [][]string{{"data", "resourceName"}}},
+		{"key.vaultId", [][]string{{"data", "additionalDetails", "vaultId"}}},
+		{"key.versionId", [][]string{{"data", "additionalDetails", "keyVersionId"}, {"data", "additionalDetails", "secretVersionNumber"}}},
+		{"key.algorithm", [][]string{{"data", "additionalDetails", "encryptionAlgorithm"}, {"data", "additionalDetails", "signingAlgorithm"}}},
+		{"enduser.id", [][]string{{"data", "identity", "principalId"}}},
+		{"enduser.name", [][]string{{"data", "identity", "principalName"}}},
+		{"client.address", [][]string{{"data", "identity", "ipAddress"}}},
+	}
+	for _, field := range fields {
+		if value, ok := firstString(record, field.paths...); ok {
+			record[field.attribute] = value
+		}
+	}
+
+	if status, ok := responseStatus(record); ok {
+		record["key.responseStatus"] = status
+		record["key.success"] = status >= 200 && status < 300
+	}
+	setLogType(record, "oci_vault_audit")
+}
+
+// vaultResourceType returns the Vault resource type of the audited resource, or an empty string.
+func vaultResourceType(record map[string]interface{}) string {
+	resourceID, _ := common.LookupString(record, "data", "resourceId")
+	for prefix, resourceType := range vaultResourceTypes {
+		if strings.HasPrefix(resourceID, prefix) {
+			return resourceType
+		}
+	}
+	return ""
+}
+
+// keyUsage classifies a Vault operation.
+func keyUsage(operation string) string {
+	switch {
+	case cryptoOperations[operation]:
+		return keyUsageCrypto
+	case strings.HasPrefix(operation, "GetSecretBundle"):
+		return keyUsageSecretAccess
+	default:
+		return keyUsageManagement
+	}
+}
+
+// responseStatus returns the HTTP status of the audited request, which audit events carry as a string.
+func responseStatus(record map[string]interface{}) (int, bool) {
+	value, ok := common.LookupValue(record, "data", "response", "status")
+	if !ok {
+		return 0, false
+	}
+	var status int
+	var err error
+	switch v := value.(type) {
+	case string:
+		status, err = strconv.Atoi(v)
+	case json.Number:
+		status, err = strconv.Atoi(v.String())
+	case float64:
+		status = int(v)
+	default:
+		return 0, false
+	}
+	return status, err == nil
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestVault tests mapping of Vault and KMS audit events into key attributes.
+func TestVault(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name: "secret read",
+			record: map[string]interface{}{
+				"type": "com.oraclecloud.secretretrieval.GetSecretBundle",
+				"data": map[string]interface{}{
+					"eventName":    "GetSecretBundle",
+					"resourceId":   "ocid1.vaultsecret.oc1.a",
+					"resourceName": "db-password",
+					"identity":     map[string]interface{}{"principalName": "fn-app", "ipAddress": "10.0.0.4"},
+					"response":     map[string]interface{}{"status": "200"},
+				},
+			},
+			expected: map[string]interface{}{
+				"key.operation":      "GetSecretBundle",
+				"key.usage":          "secretAccess",
+				"key.resourceType":   "secret",
+				"key.id":             "ocid1.vaultsecret.oc1.a",
+				"key.name":           "db-password",
+				"key.responseStatus": 200,
+				"key.success":        true,
+				"enduser.name":       "fn-app",
+				"client.address":     "10.0.0.4",
+				"logtype":            "oci_vault_audit",
+			},
+		},
+		{
+			name: "denied decrypt",
+			record: map[string]interface{}{
+				"type": "com.oraclecloud.kms.keymanagement.Decrypt",
+				"data": map[string]interface{}{
+					"eventName":         "Decrypt",
+					"resourceId":        "ocid1.key.oc1.b",
+					"additionalDetails": map[string]interface{}{"keyVersionId": "ocid1.keyversion.oc1.c", "encryptionAlgorithm": "AES_256_GCM"},
+					"response":          map[string]interface{}{"status": "404"},
+				},
+			},
+			expected: map[string]interface{}{
+				"key.operation":      "Decrypt",
+				"key.usage":          "crypto",
+				"key.resourceType":   "key",
+				"key.versionId":      "ocid1.keyversion.oc1.c",
+				"key.algorithm":      "AES_256_GCM",
+				"key.responseStatus": 404,
+				"key.success":        false,
+			},
+		},
+		{
+			name: "vault management matched by event type",
+			record: map[string]interface{}{
+				"type": "com.oraclecloud.kms.keymanagement.ListKeys",
+				"data": map[string]interface{}{"eventName": "ListKeys"},
+			},
+			expected: map[string]interface{}{
+				"key.operation": "ListKeys",
+				"key.usage":     "management",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, "vault", Apply(tt.record))
+			for key, value := range tt.expected {
+				assert.Equal(t, value, tt.record[key], key)
+			}
+		})
+	}
+}
+
+// TestVaultNoMatch tests that audit events of other services are not parsed as Vault events.
+func TestVaultNoMatch(t *testing.T) {
+	record := map[string]interface{}{
+		"type": "com.oraclecloud.ComputeApi.GetInstance",
+		"data": map[string]interface{}{"eventName": "GetInstance", "resourceId": "ocid1.instance.oc1.a"},
+	}
+	assert.False(t, vault{}.Match(record))
+}
@@ -0,0 +1,121 @@
+// Package payload builds the request bodies posted to the New Relic Log API. Each builder implements
+// one version of the API contract, so that a change in the contract is isolated from the pipeline,
+// which always produces common.DetailedLogsBatch.
+package payload
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// DefaultFormat is the payload format used when PAYLOAD_FORMAT is unset.
+const DefaultFormat = FormatDetailedV1
+
+// Payload formats.
+const (
+	FormatDetailedV1 = "v1"     // FormatDetailedV1 is the detailed JSON format: batches of common attributes and log records.
+	FormatSimple     = "simple" // FormatSimple is the simple JSON format: a flat array of logs carrying their own attributes.
+)
+
+// Builder converts a batch into the request body of one payload format.
+type Builder interface {
+	// Format returns the name of the payload format the builder produces.
+	Format() string
+	// Build returns the request body for the batch, to be serialized as JSON.
+	Build(batch common.DetailedLogsBatch) (interface{}, error)
+}
+
+// builders holds the registered builders by format.
+var builders = map[string]Builder{}
+
+func init() {
+	register(detailedV1{})
+	register(simple{})
+}
+
+// register makes a builder selectable by its format.
+func register(builder Builder) {
+	builders[builder.Format()] = builder
+}
+
+// Lookup returns the builder of the given format.
+func Lookup(format string) (Builder, error) {
+	if builder, ok := builders[format]; ok {
+		return builder, nil
+	}
+	formats := make([]string, 0, len(builders))
+	for name := range builders {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return nil, fmt.Errorf("unknown %s %q, expected one of %s", common.PayloadFormat, format, strings.Join(formats, ", "))
+}
+
+// FromEnv returns the builder selected by the function environment.
+func FromEnv() (Builder, error) {
+	format := os.Getenv(common.PayloadFormat)
+	if format == "" {
+		format = DefaultFormat
+	}
+	return Lookup(format)
+}
+
+// detailedV1 builds the detailed JSON format, which the pipeline batches already follow.
+//
+// Reference: https://docs.newrelic.com/docs/logs/log-api/introduction-log-api/#detailed-json
+type detailedV1 struct{}
+
+func (detailedV1) Format() string { return FormatDetailedV1 }
+
+func (detailedV1) Build(batch common.DetailedLogsBatch) (interface{}, error) {
+	return batch, nil
+}
+
+// simple builds the simple JSON format: every log of the batch becomes a {timestamp, message, attributes} object,
+// its attributes being the common attributes of its batch overridden by its own fields.
+//
+// Reference: https://docs.newrelic.com/docs/logs/log-api/introduction-log-api/#simple-json
+type simple struct{}
+
+// simpleLog is a log of the simple JSON format.
+type simpleLog struct {
+	Timestamp  interface{}            `json:"timestamp,omitempty"`
+	Message    interface{}            `json:"message,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+func (simple) Format() string { return FormatSimple }
+
+func (simple) Build(batch common.DetailedLogsBatch) (interface{}, error) {
+	var logs []simpleLog
+	for _, detailed := range batch {
+		for _, entry := range detailed.Entries {
+			flat := simpleLog{Timestamp: entry["timestamp"], Message: entry["message"]}
+			if flat.Timestamp == nil && detailed.CommonData.Timestamp != "" {
+				flat.Timestamp = detailed.CommonData.Timestamp
+			}
+
+			attributes := make(map[string]interface{}, len(detailed.CommonData.Attributes)+len(entry))
+			for key, value := range detailed.CommonData.Attributes {
+				attributes[key] = value
+			}
+			for key, value := range entry {
+				if key != "timestamp" && key != "message" {
+					attributes[key] = value
+				}
+			}
+			if len(attributes) > 0 {
+				flat.Attributes = attributes
+			}
+			logs = append(logs, flat)
+		}
+	}
+	if logs == nil {
+		logs = []simpleLog{}
+	}
+	return logs, nil
+}
@@ -0,0 +1,84 @@
+package payload
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// TestFromEnv tests the selection of the payload builder.
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		expected      string
+		expectedError string
+	}{
+		{"default", "", FormatDetailedV1, ""},
+		{"detailed v1", "v1", FormatDetailedV1, ""},
+		{"simple", "simple", FormatSimple, ""},
+		{"unknown", "v9", "", `unknown PAYLOAD_FORMAT "v9", expected one of simple, v1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(common.PayloadFormat, tt.format)
+			builder, err := FromEnv()
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, builder.Format())
+		})
+	}
+}
+
+// TestDetailedV1 tests that the v1 body keeps the detailed JSON contract of the Log API.
+func TestDetailedV1(t *testing.T) {
+	batch := common.DetailedLogsBatch{{
+		CommonData: common.Common{Attributes: common.LogAttributes{"instrumentation.provider": "oci"}},
+		Entries:    common.LogData{{"message": "hello"}},
+	}}
+
+	body, err := detailedV1{}.Build(batch)
+	assert.NoError(t, err)
+	encoded, err := json.Marshal(body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"common":{"attributes":{"instrumentation.provider":"oci"},"timestamp":""},"logs":[{"message":"hello"}]}]`, string(encoded))
+}
+
+// TestSimple tests that the simple body flattens batches into logs carrying the common attributes.
+func TestSimple(t *testing.T) {
+	batch := common.DetailedLogsBatch{
+		{
+			CommonData: common.Common{Attributes: common.LogAttributes{"instrumentation.provider": "oci", "logtype": "default"}},
+			Entries: common.LogData{
+				{"message": "hello", "timestamp": 1700000000000.0, "logtype": "audit"},
+				{"message": "world"},
+			},
+		},
+		{
+			CommonData: common.Common{Timestamp: "1700000000001"},
+			Entries:    common.LogData{{"message": "bare"}},
+		},
+	}
+
+	body, err := simple{}.Build(batch)
+	assert.NoError(t, err)
+	encoded, err := json.Marshal(body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"timestamp":1700000000000,"message":"hello","attributes":{"instrumentation.provider":"oci","logtype":"audit"}},
+		{"message":"world","attributes":{"instrumentation.provider":"oci","logtype":"default"}},
+		{"timestamp":"1700000000001","message":"bare"}
+	]`, string(encoded))
+
+	body, err = simple{}.Build(nil)
+	assert.NoError(t, err)
+	encoded, _ = json.Marshal(body)
+	assert.Equal(t, "[]", string(encoded))
+}
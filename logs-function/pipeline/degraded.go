@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/newrelic/oci-log-integration/logs-function/util"
+)
+
+// DegradedEventType is the high-priority custom event type sent when the drop rate crosses DEGRADED_DROP_PERCENT.
+const DegradedEventType = "OCILogForwarderDegraded"
+
+// minDegradedRecords is the number of records the window must hold before its drop rate is alerted on, so that a
+// few failures in a quiet container do not page anyone.
+const minDegradedRecords = 100
+
+// dropStats are the record counts of the drop rate window.
+type dropStats struct {
+	total   int64
+	dropped int64
+}
+
+// percent returns the percentage of the records that were dropped.
+func (s dropStats) percent() float64 {
+	if s.total == 0 {
+		return 0
+	}
+	return float64(s.dropped) * 100 / float64(s.total)
+}
+
+// dropBucket holds the record counts of one minute.
+type dropBucket struct {
+	minute time.Time
+	dropStats
+}
+
+// dropRateWindow tracks the share of records dropped over a rolling window of the warm container.
+type dropRateWindow struct {
+	mu        sync.Mutex
+	buckets   []dropBucket
+	degraded  bool
+	alertedAt time.Time
+}
+
+// dropRate is the drop rate window of the warm container.
+var dropRate dropRateWindow
+
+// observe adds the records of an invocation and returns the counts of the window ending at now, and whether an
+// alert is due: the window holds enough records, its drop rate is at or above the threshold, and the rate either
+// just crossed the threshold or was last alerted on a window ago.
+func (w *dropRateWindow) observe(now time.Time, invocation dropStats, threshold float64, window time.Duration) (dropStats, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	minute := now.Truncate(time.Minute)
+	if n := len(w.buckets); n > 0 && w.buckets[n-1].minute.Equal(minute) {
+		w.buckets[n-1].total += invocation.total
+		w.buckets[n-1].dropped += invocation.dropped
+	} else {
+		w.buckets = append(w.buckets, dropBucket{minute: minute, dropStats: invocation})
+	}
+
+	var stats dropStats
+	kept := w.buckets[:0]
+	for _, bucket := range w.buckets {
+		if now.Sub(bucket.minute) < window {
+			kept = append(kept, bucket)
+			stats.total += bucket.total
+			stats.dropped += bucket.dropped
+		}
+	}
+	w.buckets = kept
+
+	if stats.total < minDegradedRecords || stats.percent() < threshold {
+		w.degraded = false
+		return stats, false
+	}
+	if w.degraded && now.Sub(w.alertedAt) < window {
+		return stats, false
+	}
+	w.degraded = true
+	w.alertedAt = now
+	return stats, true
+}
+
+// checkDropRate adds the records of the invocation to the drop rate window, counting as dropped those whose post
+// failed and the lost ones found missing by the parity check. The rate of the window is reported as the
+// droprate.percent gauge, and a DegradedEventType event is sent when an alert is due.
+func checkDropRate(ctx context.Context, lost int64) {
+	threshold, enabled := degradedDropPercent()
+	if !enabled {
+		return
+	}
+	dropped := metrics.Default.Counter("records.failed").Value() + lost
+	invocation := dropStats{total: metrics.Default.Counter("records.sent").Value() + dropped, dropped: dropped}
+
+	window := degradedWindow()
+	stats, alert := dropRate.observe(clock.Now(), invocation, threshold, window)
+	metrics.Default.Gauge("droprate.percent").Set(stats.percent())
+	if alert {
+		sendDegradedEvent(ctx, stats, threshold, window)
+	}
+}
+
+// sendDegradedEvent logs the degradation and sends it as a DegradedEventType event when NEW_RELIC_ACCOUNT_ID is set.
+func sendDegradedEvent(ctx context.Context, stats dropStats, threshold float64, window time.Duration) {
+	logger.FromContext(ctx).Errorf("Forwarder degraded: %.1f%% of %d records dropped over the last %s, above the %.1f%% threshold",
+		stats.percent(), stats.total, window, threshold)
+	if os.Getenv(common.NewRelicAccountID) == "" {
+		return
+	}
+
+	sender, err := util.NewEventSender()
+	if err == nil {
+		err = sender.CreateEvents([]map[string]interface{}{{
+			"eventType":               DegradedEventType,
+			"priority":                "high",
+			"dropPercent":             stats.percent(),
+			"thresholdPercent":        threshold,
+			"droppedRecords":          stats.dropped,
+			"totalRecords":            stats.total,
+			"windowSeconds":           window.Seconds(),
+			"instrumentation.version": common.InstrumentationVersion,
+		}})
+	}
+	if err != nil {
+		logger.FromContext(ctx).Warnf("error posting degraded event: %v", err)
+	}
+}
+
+// degradedDropPercent returns the drop percentage alerted on, and false when the alert is disabled.
+func degradedDropPercent() (float64, bool) {
+	value := strings.TrimSpace(os.Getenv(common.DegradedDropPercent))
+	if strings.EqualFold(value, "off") {
+		return 0, false
+	}
+	if percent, err := strconv.ParseFloat(value, 64); err == nil && percent > 0 && percent <= 100 {
+		return percent, true
+	}
+	return common.DefaultDegradedDropPercent, true
+}
+
+// degradedWindow returns the length of the drop rate window.
+func degradedWindow() time.Duration {
+	if seconds, err := strconv.ParseFloat(os.Getenv(common.DegradedWindow), 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return common.DefaultDegradedWindow
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDropRateWindowObserve tests when the drop rate window alerts over a sequence of invocations.
+func TestDropRateWindowObserve(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	window := 10 * time.Minute
+	steps := []struct {
+		name    string
+		at      time.Duration
+		records dropStats
+		percent float64
+		alert   bool
+	}{
+		{name: "too few records", at: 0, records: dropStats{total: 50, dropped: 50}, percent: 100},
+		{name: "crossing the threshold", at: time.Minute, records: dropStats{total: 150}, percent: 25, alert: true},
+		{name: "still degraded", at: 2 * time.Minute, records: dropStats{total: 100, dropped: 10}, percent: 20},
+		{name: "still degraded a window later", at: 11 * time.Minute, records: dropStats{total: 100, dropped: 10}, percent: 10, alert: true},
+		{name: "recovered", at: 12 * time.Minute, records: dropStats{total: 400}, percent: 2},
+		{name: "crossing again", at: 13 * time.Minute, records: dropStats{total: 100, dropped: 100}, percent: 18.333, alert: true},
+	}
+
+	var w dropRateWindow
+	for _, step := range steps {
+		stats, alert := w.observe(start.Add(step.at), step.records, 5, window)
+		assert.InDelta(t, step.percent, stats.percent(), 0.001, step.name)
+		assert.Equal(t, step.alert, alert, step.name)
+	}
+	assert.LessOrEqual(t, len(w.buckets), 10)
+}
+
+// TestDegradedDropPercent tests the parsing of DEGRADED_DROP_PERCENT.
+func TestDegradedDropPercent(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected float64
+		enabled  bool
+	}{
+		{value: "", expected: common.DefaultDegradedDropPercent, enabled: true},
+		{value: "2.5", expected: 2.5, enabled: true},
+		{value: "150", expected: common.DefaultDegradedDropPercent, enabled: true},
+		{value: "Off", enabled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(common.DegradedDropPercent, tt.value)
+			percent, enabled := degradedDropPercent()
+			assert.Equal(t, tt.enabled, enabled)
+			if tt.enabled {
+				assert.Equal(t, tt.expected, percent)
+			}
+		})
+	}
+}
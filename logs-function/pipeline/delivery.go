@@ -0,0 +1,107 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/newrelic/oci-log-integration/logs-function/util"
+)
+
+// deliveries remembers the payloads the warm container forwarded without failures, so that a payload Connector
+// Hub delivers again, e.g. after the previous invocation timed out once its batches were posted, is not forwarded
+// twice. Like replays, it lives for the lifetime of the warm container.
+var deliveries = replayRegistry{seen: map[string]time.Time{}}
+
+// delivery is the Connector Hub delivery of the payload of an invocation.
+type delivery struct {
+	attempt int    // attempt is the number of the delivery attempt, or 0 when unknown.
+	key     string // key identifies the payload when the attempt is known.
+}
+
+// newDelivery reads the delivery attempt of the payload from the DeliveryAttemptHeader or, when it is absent, from
+// the DeliveryAttemptField of its records, and records the attempt in the metrics. It must be called before the
+// records are transformed.
+func newDelivery(ctx context.Context, records common.OCILoggingEvent) delivery {
+	attempt := parseAttempt(util.InvocationHeader(ctx, common.DeliveryAttemptHeader))
+	if attempt == 0 {
+		for _, record := range records {
+			attempt = max(attempt, parseAttempt(record[common.DeliveryAttemptField]))
+		}
+	}
+	if attempt == 0 {
+		return delivery{}
+	}
+
+	metrics.Default.Gauge("delivery.attempt").Set(float64(attempt))
+	if attempt > 1 {
+		metrics.Default.Counter("deliveries.redelivered").Inc()
+		metrics.Default.Counter("records.redelivered").Add(int64(len(records)))
+		logger.FromContext(ctx).Warnf("Connector Hub redelivered the payload of %d log records in attempt %d", len(records), attempt)
+	}
+	return delivery{attempt: attempt, key: payloadKey(records)}
+}
+
+// duplicate reports whether the payload is a redelivery of a payload the container already forwarded, in which
+// case the invocation is acknowledged without forwarding its records.
+func (d delivery) duplicate(ctx context.Context, now time.Time) bool {
+	if d.attempt <= 1 || d.key == "" || !deliveries.contains(d.key, now) {
+		return false
+	}
+	metrics.Default.Counter("deliveries.duplicate").Inc()
+	logger.FromContext(ctx).Warnf("Skipping payload already forwarded before delivery attempt %d", d.attempt)
+	return true
+}
+
+// forwarded records the payload as forwarded when every record of the invocation was delivered.
+func (d delivery) forwarded(lost int64, now time.Time) {
+	if d.key == "" || lost > 0 || metrics.Default.Counter("records.failed").Value() > 0 {
+		return
+	}
+	deliveries.mark(d.key, now)
+}
+
+// parseAttempt returns the positive delivery attempt held by a header or record value, or 0.
+func parseAttempt(value interface{}) int {
+	var attempt int
+	switch value := value.(type) {
+	case string:
+		attempt, _ = strconv.Atoi(strings.TrimSpace(value))
+	case float64:
+		attempt = int(value)
+	case json.Number:
+		n, _ := value.Int64()
+		attempt = int(n)
+	}
+	return max(attempt, 0)
+}
+
+// payloadKey identifies the records of a payload, regardless of the delivery attempt.
+func payloadKey(records common.OCILoggingEvent) string {
+	stripped := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		if _, ok := record[common.DeliveryAttemptField]; !ok {
+			stripped[i] = record
+			continue
+		}
+		stripped[i] = make(map[string]interface{}, len(record))
+		for field, value := range record {
+			if field != common.DeliveryAttemptField {
+				stripped[i][field] = value
+			}
+		}
+	}
+	data, err := json.Marshal(stripped)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/fnproject/fdk-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/newrelic/oci-log-integration/logs-function/routing"
+)
+
+// attemptContext returns an Fn invocation context carrying the delivery attempt header.
+func attemptContext(attempt string) context.Context {
+	return fdk.WithContext(context.Background(), headerContext{header: http.Header{"X-Oci-Delivery-Attempt": {attempt}}})
+}
+
+// TestNewDelivery tests that the delivery attempt is read from the header or, without it, from the records.
+func TestNewDelivery(t *testing.T) {
+	records := func() common.OCILoggingEvent {
+		return common.OCILoggingEvent{{"id": "a", common.DeliveryAttemptField: 2.0}, {"id": "b", common.DeliveryAttemptField: "3"}}
+	}
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		records  common.OCILoggingEvent
+		expected int
+	}{
+		{"header", attemptContext("4"), records(), 4},
+		{"records", context.Background(), records(), 3},
+		{"invalid header", attemptContext("first"), records(), 3},
+		{"unknown", context.Background(), common.OCILoggingEvent{{"id": "a"}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDelivery(tt.ctx, tt.records)
+			assert.Equal(t, tt.expected, d.attempt)
+			assert.Equal(t, tt.expected > 0, d.key != "")
+		})
+	}
+
+	first := records()
+	first[0][common.DeliveryAttemptField] = 1.0
+	assert.Equal(t, newDelivery(context.Background(), first).key, newDelivery(context.Background(), records()).key)
+}
+
+// TestHandleFunctionWithClientRedelivery tests that the delivery attempt is stamped on the batches and that a
+// redelivered payload is forwarded again only when its previous delivery failed.
+func TestHandleFunctionWithClientRedelivery(t *testing.T) {
+	deliveries.reset()
+	defer deliveries.reset()
+	deliver := func(attempt string, postErr error) *MockNewRelicClient {
+		mockClient := new(MockNewRelicClient)
+		mockClient.On("CreateLogEntry", mock.Anything).Return(postErr)
+		payload := `[{"message":"hello","deliveryattempt":"` + attempt + `"}]`
+		handleFunctionWithClient(context.Background(), bytes.NewBufferString(payload), &bytes.Buffer{}, mockClient, routing.Override{})
+		return mockClient
+	}
+
+	deliver("1", assert.AnError).AssertNumberOfCalls(t, "CreateLogEntry", 1)
+
+	redelivered := deliver("2", nil)
+	redelivered.AssertNumberOfCalls(t, "CreateLogEntry", 1)
+	batch := redelivered.Calls[0].Arguments[0].(common.DetailedLogsBatch)
+	assert.Equal(t, 2, batch[0].CommonData.Attributes[common.OCIDeliveryAttemptAttribute])
+	assert.Equal(t, int64(1), metrics.Default.Counter("deliveries.redelivered").Value())
+	assert.Equal(t, 2.0, metrics.Default.Gauge("delivery.attempt").Value())
+
+	deliver("3", nil).AssertNotCalled(t, "CreateLogEntry", mock.Anything)
+	assert.Equal(t, int64(1), metrics.Default.Counter("deliveries.duplicate").Value())
+}
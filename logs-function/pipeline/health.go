@@ -0,0 +1,42 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/unmarshal"
+)
+
+// HealthReport is the response to a health check invocation.
+type HealthReport struct {
+	Status       string          `json:"status"`
+	Version      string          `json:"version"`
+	ConfigSchema json.RawMessage `json:"configSchema"`
+	// InputSchemas are the JSON Schemas of the supported input payloads, which VALIDATE_INPUT checks against.
+	InputSchemas map[string]json.RawMessage `json:"inputSchemas"`
+	// Rates are the rolling rates of the warm container over the last 5, 15 and 60 minutes.
+	Rates map[string]Rates `json:"rates"`
+	// Startup is the report of the startup checks of the container.
+	Startup *StartupReport `json:"startup,omitempty"`
+}
+
+// writeHealthReport writes the health report of the function, which lets external tooling validate a
+// function configuration against the schema of the deployed version, and gives a quick view of the throughput
+// of the warm container.
+func writeHealthReport(ctx context.Context, out io.Writer) {
+	report := HealthReport{
+		Status:       "ok",
+		Version:      common.InstrumentationVersion,
+		ConfigSchema: common.ConfigSchema,
+		InputSchemas: unmarshal.InputSchemas(),
+		Rates:        rates.report(clock.Now()),
+		Startup:      startupReport,
+	}
+	if err := json.NewEncoder(out).Encode(report); err != nil {
+		logger.FromContext(ctx).Errorf("error writing health report: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/fnproject/fdk-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// headerContext is an Fn invocation context carrying only the given headers.
+type headerContext struct {
+	fdk.Context
+	header http.Header
+}
+
+func (c headerContext) Header() http.Header {
+	return c.header
+}
+
+// TestHandleFunctionHealthCheck tests that a health check invocation is answered with the config schema without posting.
+func TestHandleFunctionHealthCheck(t *testing.T) {
+	ctx := fdk.WithContext(context.Background(), headerContext{header: http.Header{"X-Nr-Health-Check": {"true"}}})
+
+	var out bytes.Buffer
+	handleFunction(ctx, bytes.NewBufferString(""), &out)
+
+	var report struct {
+		Status       string `json:"status"`
+		ConfigSchema struct {
+			Settings []struct {
+				Name string `json:"name"`
+			} `json:"settings"`
+		} `json:"configSchema"`
+		Rates map[string]Rates `json:"rates"`
+	}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, "ok", report.Status)
+	assert.Contains(t, report.Rates, "15m")
+	assert.Contains(t, report.ConfigSchema.Settings, struct {
+		Name string `json:"name"`
+	}{Name: "SECRET_OCID"})
+}
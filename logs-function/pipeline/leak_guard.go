@@ -0,0 +1,38 @@
+package pipeline
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// invocationGoroutines counts the goroutines spawned on behalf of an invocation that are still running.
+// Every invocation waits for its goroutines before returning, so a non-zero count when the next
+// invocation starts means goroutines outlived their invocation in the warm container.
+var invocationGoroutines atomic.Int64
+
+// goInvocation runs fn in a goroutine tracked as belonging to the current invocation. The returned
+// channel is closed once the goroutine is no longer counted.
+func goInvocation(fn func()) <-chan struct{} {
+	invocationGoroutines.Add(1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer invocationGoroutines.Add(-1)
+		fn()
+	}()
+	return done
+}
+
+// checkGoroutineLeaks reports the goroutines of previous invocations still running as the
+// invocation.goroutines.leaked gauge, and logs a warning when there are any. It returns their number.
+func checkGoroutineLeaks(ctx context.Context) int64 {
+	leaked := invocationGoroutines.Load()
+	metrics.Default.Gauge("invocation.goroutines.leaked").Set(float64(leaked))
+	if leaked > 0 {
+		logger.FromContext(ctx).Warnf("%d goroutines of previous invocations are still running", leaked)
+	}
+	return leaked
+}
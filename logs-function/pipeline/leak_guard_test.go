@@ -0,0 +1,42 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/newrelic/oci-log-integration/logs-function/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestCheckGoroutineLeaks tests that goroutines outliving their invocation are reported until they exit.
+func TestCheckGoroutineLeaks(t *testing.T) {
+	metrics.Default.Reset()
+	assert.Equal(t, int64(0), checkGoroutineLeaks(context.Background()))
+
+	release := make(chan struct{})
+	done := goInvocation(func() { <-release })
+
+	assert.Equal(t, int64(1), checkGoroutineLeaks(context.Background()))
+	assert.Equal(t, 1.0, metrics.Default.Gauge("invocation.goroutines.leaked").Value())
+
+	close(release)
+	<-done
+	assert.Equal(t, int64(0), checkGoroutineLeaks(context.Background()))
+	assert.Equal(t, 0.0, metrics.Default.Gauge("invocation.goroutines.leaked").Value())
+}
+
+// TestHandleFunctionWithClientLeavesNoGoroutines tests that an invocation waits for the goroutines it spawns.
+func TestHandleFunctionWithClientLeavesNoGoroutines(t *testing.T) {
+	mockClient := new(MockNewRelicClient)
+	mockClient.On("CreateLogEntry", mock.Anything).Return(nil)
+
+	for i := 0; i < 3; i++ {
+		input := bytes.NewReader([]byte(`[{"timestamp":"2023-01-01T12:00:00Z","level":"INFO","message":"Message"}]`))
+		handleFunctionWithClient(context.Background(), input, &bytes.Buffer{}, mockClient, routing.Override{})
+		assert.Equal(t, int64(0), invocationGoroutines.Load())
+	}
+	mockClient.AssertNumberOfCalls(t, "CreateLogEntry", 3)
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/newrelic/oci-log-integration/logs-function/lease"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/newrelic/oci-log-integration/logs-function/routing"
+)
+
+// leaseObject serves a fixed lease object, or fails every read with err.
+type leaseObject struct {
+	content string
+	err     error
+}
+
+func (o leaseObject) GetObject(context.Context, objectstorage.GetObjectRequest) (objectstorage.GetObjectResponse, error) {
+	if o.err != nil {
+		return objectstorage.GetObjectResponse{}, o.err
+	}
+	return objectstorage.GetObjectResponse{Content: io.NopCloser(strings.NewReader(o.content)), ETag: ociCommon.String("1")}, nil
+}
+
+func (o leaseObject) PutObject(context.Context, objectstorage.PutObjectRequest) (objectstorage.PutObjectResponse, error) {
+	return objectstorage.PutObjectResponse{}, errors.New("unexpected put")
+}
+
+// TestHandleFunctionWithClientLease tests that records are forwarded only when the lease is held or cannot be checked.
+func TestHandleFunctionWithClientLease(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	tests := []struct {
+		name      string
+		object    leaseObject
+		forwarded bool
+		counter   string
+	}{
+		{"held by another deployment", leaseObject{content: `{"holder":"us-phoenix-1","expiresAt":"` + expiresAt + `"}`}, false, "lease.standby"},
+		{"unavailable", leaseObject{err: errors.New("connection refused")}, true, "lease.errors"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwardingLease = &lease.Lease{Client: tt.object, Object: "lease/forwarder.json", Holder: "us-ashburn-1", TTL: time.Minute}
+			defer func() { forwardingLease = nil }()
+
+			mockClient := new(MockNewRelicClient)
+			mockClient.On("CreateLogEntry", mock.Anything).Return(nil)
+			input := bytes.NewReader([]byte(`[{"message":"hello"}]`))
+			handleFunctionWithClient(context.Background(), input, &bytes.Buffer{}, mockClient, routing.Override{})
+
+			if tt.forwarded {
+				mockClient.AssertNumberOfCalls(t, "CreateLogEntry", 1)
+			} else {
+				mockClient.AssertNotCalled(t, "CreateLogEntry", mock.Anything)
+			}
+			assert.Equal(t, int64(1), metrics.Default.Counter(tt.counter).Value())
+		})
+	}
+}
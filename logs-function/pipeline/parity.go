@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// ParityStage is the number of records, and of bytes when known, that reached a stage of an invocation.
+type ParityStage struct {
+	Stage   string `json:"stage"`
+	Records int64  `json:"records"`
+	Bytes   int64  `json:"bytes,omitempty"`
+	// Missing is the number of records of the previous stage that are unaccounted for at this stage. It is
+	// negative when the stage holds more records than the previous one.
+	Missing int64 `json:"missing,omitempty"`
+}
+
+// parityReport returns the record counts of the invocation at the parsed, transformed, batched and sent
+// stages, read from the invocation metrics. Records dropped by configuration, collapsed as duplicates of
+// other records of the payload or of consecutive messages, or written to the dead-letter queue after a
+// failed post are accounted for; any other difference between consecutive stages is missing.
+func parityReport() []ParityStage {
+	count := func(name string) int64 { return metrics.Default.Counter(name).Value() }
+	received := count("records.received")
+	transformed := count("records.transformed")
+	batched := count("records.batched")
+	sent := count("records.sent")
+	return []ParityStage{
+		{Stage: "parsed", Records: received, Bytes: count("bytes.received")},
+		{Stage: "transformed", Records: transformed, Missing: received - count("records.dropped") - count("records.duplicate.collapsed") - transformed},
+		{Stage: "batched", Records: batched, Bytes: count("bytes.batched"), Missing: transformed - count("records.collapsed") - batched},
+		{Stage: "sent", Records: sent, Missing: batched - count("records.failed") - sent},
+	}
+}
+
+// checkParity records the records missing at each stage as the parity.<stage>.missing counters, warning
+// about every stage with a mismatch, and logs the parity report at debug level. It returns the number of
+// records lost over all stages.
+func checkParity(ctx context.Context) int64 {
+	plog := logger.FromContext(ctx)
+	report := parityReport()
+	lost := int64(0)
+	for _, stage := range report {
+		if stage.Missing == 0 {
+			continue
+		}
+		if stage.Missing > 0 {
+			lost += stage.Missing
+		}
+		metrics.Default.Counter("parity." + stage.Stage + ".missing").Add(stage.Missing)
+		plog.Warnf("Record parity mismatch at the %s stage: %d of the records of the previous stage are unaccounted for", stage.Stage, stage.Missing)
+	}
+	plog.Debugf("Record parity report: %+v", report)
+	return lost
+}
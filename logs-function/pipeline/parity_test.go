@@ -0,0 +1,118 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/newrelic/oci-log-integration/logs-function/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestParityReport tests the records missing at each stage for the counters of an invocation.
+func TestParityReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		counters map[string]int64
+		missing  map[string]int64
+	}{
+		{
+			name: "every record accounted for",
+			counters: map[string]int64{
+				"records.received": 12, "records.dropped": 2, "records.duplicate.collapsed": 2, "records.transformed": 8,
+				"records.collapsed": 3, "records.batched": 5, "records.sent": 4, "records.failed": 1,
+			},
+			missing: map[string]int64{},
+		},
+		{
+			name: "records lost while batching",
+			counters: map[string]int64{
+				"records.received": 10, "records.transformed": 10, "records.batched": 9, "records.sent": 9,
+			},
+			missing: map[string]int64{"batched": 1},
+		},
+		{
+			name: "batches never posted",
+			counters: map[string]int64{
+				"records.received": 10, "records.transformed": 10, "records.batched": 10, "records.sent": 4,
+			},
+			missing: map[string]int64{"sent": 6},
+		},
+		{
+			name: "more records than received",
+			counters: map[string]int64{
+				"records.received": 2, "records.transformed": 3, "records.batched": 3, "records.sent": 3,
+			},
+			missing: map[string]int64{"transformed": -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics.Default.Reset()
+			for name, value := range tt.counters {
+				metrics.Default.Counter(name).Add(value)
+			}
+
+			missing := make(map[string]int64)
+			for _, stage := range parityReport() {
+				if stage.Missing != 0 {
+					missing[stage.Stage] = stage.Missing
+				}
+			}
+			assert.Equal(t, tt.missing, missing)
+
+			checkParity(context.Background())
+			for stage, value := range tt.missing {
+				assert.Equal(t, value, metrics.Default.Counter("parity."+stage+".missing").Value())
+			}
+		})
+	}
+}
+
+// TestHandleFunctionWithClientParity tests that the records of an invocation are all accounted for, whether
+// their batch is posted or fails.
+func TestHandleFunctionWithClientParity(t *testing.T) {
+	for _, postErr := range []error{nil, assert.AnError} {
+		mockClient := new(MockNewRelicClient)
+		mockClient.On("CreateLogEntry", mock.Anything).Return(postErr)
+
+		input := bytes.NewReader([]byte(`[
+			{"timestamp":"2023-01-01T12:00:00Z","level":"INFO","message":"Message 1"},
+			{"timestamp":"2023-01-01T12:00:01Z","level":"INFO","message":"Message 2"}
+		]`))
+		handleFunctionWithClient(context.Background(), input, &bytes.Buffer{}, mockClient, routing.Override{})
+
+		for _, stage := range parityReport() {
+			assert.Zero(t, stage.Missing, stage.Stage)
+		}
+		assert.Equal(t, int64(2), metrics.Default.Counter("records.batched").Value())
+		assert.Positive(t, metrics.Default.Counter("bytes.batched").Value())
+	}
+}
+
+// TestHandleFunctionWithClientParityCollapsed tests that the duplicate records collapsed by DUPLICATE_RECORDS are
+// accounted for rather than missing.
+func TestHandleFunctionWithClientParityCollapsed(t *testing.T) {
+	t.Setenv(common.DuplicateRecords, common.DuplicateRecordsCollapse)
+	mockClient := new(MockNewRelicClient)
+	mockClient.On("CreateLogEntry", mock.Anything).Return(nil)
+
+	input := bytes.NewReader([]byte(`[
+		{"timestamp":"2023-01-01T12:00:00Z","level":"INFO","message":"Message 1"},
+		{"timestamp":"2023-01-01T12:00:00Z","level":"INFO","message":"Message 1"},
+		{"timestamp":"2023-01-01T12:00:01Z","level":"INFO","message":"Message 2"},
+		{"timestamp":"2023-01-01T12:00:00Z","level":"INFO","message":"Message 1"}
+	]`))
+	handleFunctionWithClient(context.Background(), input, &bytes.Buffer{}, mockClient, routing.Override{})
+
+	for _, stage := range parityReport() {
+		assert.Zero(t, stage.Missing, stage.Stage)
+	}
+	assert.Equal(t, int64(2), metrics.Default.Counter("records.duplicate.collapsed").Value())
+	assert.Equal(t, int64(2), metrics.Default.Counter("records.transformed").Value())
+	assert.Zero(t, metrics.Default.Counter("parity.transformed.missing").Value())
+}
@@ -0,0 +1,501 @@
+// Package pipeline implements the OCI Function that processes OCI Logging events and forwards them
+// to New Relic's logging platform: event unmarshaling, batching and concurrent delivery. A main
+// package starts it with Start, after registering any custom parsers and sinks.
+package pipeline
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fnproject/fdk-go"
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/config"
+	"github.com/newrelic/oci-log-integration/logs-function/dlq"
+	"github.com/newrelic/oci-log-integration/logs-function/lease"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/loggroup"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/newrelic/oci-log-integration/logs-function/parser"
+	"github.com/newrelic/oci-log-integration/logs-function/routing"
+	"github.com/newrelic/oci-log-integration/logs-function/transform"
+	"github.com/newrelic/oci-log-integration/logs-function/unmarshal"
+	"github.com/newrelic/oci-log-integration/logs-function/util"
+)
+
+
+// workerPool is shared by all invocations served by a warm container.
+var workerPool = util.NewWorkerPool(util.MaxWorkers(), common.MessageChannelSize)
+
+// accountRoutes holds the multi-account routing table loaded at startup.
+var accountRoutes []routing.Route
+
+// forwardingLease is the lease electing the forwarding deployment among active/active deployments, or nil when
+// every deployment forwards.
+var forwardingLease *lease.Lease
+
+// Start loads the startup configuration and serves function invocations. It does not return. A failed startup
+// writes the startup report and exits with the exit code of the failure class; see StartupReport.
+func Start() {
+	slog := logger.FromContext(context.Background())
+	slog.Debug("Setting up function handler")
+	checkStartup(startupChecks())
+	go registerIntegration()
+	if os.Getenv(common.PrefetchSecrets) == "true" {
+		go func() {
+			if err := util.PrefetchNRClient(); err != nil {
+				slog.Warnf("error prefetching license key: %v", err)
+			}
+		}()
+	}
+	if os.Getenv(common.ConnectionWarmUp) == "true" && os.Getenv(common.StartupConnectivityCheck) != "true" {
+		go func() {
+			if err := util.WarmUpConnection(context.Background()); err != nil {
+				slog.Warnf("error warming up Log API connection: %v", err)
+			}
+		}()
+	}
+	handler := func(ctx context.Context, in io.Reader, out io.Writer) {
+		handleFunction(ctx, in, out)
+	}
+	fdk.Handle(fdk.HandlerFunc(handler))
+}
+
+// startupChecks returns the startup steps in the order they run.
+func startupChecks() []startupCheck {
+	checks := []startupCheck{
+		{name: "configProfile", class: FailureDependency, run: func() error {
+			_, err := util.ApplyConfigProfile(context.Background())
+			return err
+		}},
+		{name: "configPreset", class: FailureConfig, run: applyConfigPreset},
+		{name: "hostResolution", class: FailureConfig, run: util.ConfigureHostResolution},
+		{name: "accountRoutes", class: FailureConfig, run: loadAccountRoutes},
+		{name: "routeCredentials", class: FailureCredentials, classify: classifyRouteFailure, run: validateAccountRoutes},
+		{name: "dlqWriter", class: FailureDependency, run: loadDeadLetterWriter},
+		{name: "lease", class: FailureDependency, run: loadLease},
+		{name: "verifier", class: FailureDependency, run: loadVerifier},
+		{name: "migrationSink", class: FailureDependency, run: loadMigrationSink},
+	}
+	if os.Getenv(common.StartupConnectivityCheck) == "true" {
+		checks = append(checks, startupCheck{name: "logApi", class: FailureConnectivity, run: func() error {
+			return util.WarmUpConnection(context.Background())
+		}})
+	}
+	return checks
+}
+
+// applyConfigPreset fills the settings left unset with those of the built-in preset named by CONFIG_PRESET.
+func applyConfigPreset() error {
+	name := os.Getenv(common.ConfigPreset)
+	if name == "" {
+		return nil
+	}
+	applied, err := config.ApplyPreset(name)
+	if err != nil {
+		return fmt.Errorf("error applying configuration preset: %w", err)
+	}
+	logger.FromContext(context.Background()).Infof("Applied configuration preset %q with %d settings", name, applied)
+	return nil
+}
+
+// loadAccountRoutes loads the multi-account routing table.
+func loadAccountRoutes() error {
+	routes, err := routing.LoadRoutes()
+	if err != nil {
+		return fmt.Errorf("error loading account routes: %w", err)
+	}
+	accountRoutes = routes
+	return nil
+}
+
+// validateAccountRoutes validates the license key of every routed account, failing fast with a report of the
+// broken routes.
+func validateAccountRoutes() error {
+	if len(accountRoutes) == 0 {
+		return nil
+	}
+	logger.FromContext(context.Background()).Debugf("Validating %d account routes", len(accountRoutes))
+	if err := util.ValidateRoutes(accountRoutes); err != nil {
+		return fmt.Errorf("error validating account routes: %w", err)
+	}
+	return nil
+}
+
+// classifyRouteFailure returns the failure class of a route validation error: a Vault outage is a dependency
+// failure, a license key rejected by the Log API or of the wrong type a credentials failure, and any other error,
+// e.g. New Relic being unreachable, a connectivity failure.
+func classifyRouteFailure(err error) string {
+	var apiErr *util.LogAPIError
+	var keyTypeErr *util.KeyTypeError
+	switch {
+	case util.IsVaultError(err):
+		return FailureDependency
+	case errors.As(err, &apiErr) && apiErr.Class == util.ErrorClassAuth, errors.As(err, &keyTypeErr):
+		return FailureCredentials
+	default:
+		return FailureConnectivity
+	}
+}
+
+// loadDeadLetterWriter enables dead-lettering of undelivered batches when a retry stream or DLQ bucket is configured.
+func loadDeadLetterWriter() error {
+	writer, err := dlq.NewFromEnv()
+	if err != nil {
+		return fmt.Errorf("error initializing dlq writer: %w", err)
+	}
+	if writer != nil {
+		workerPool.SetDeadLetterWriter(writer)
+	}
+	return nil
+}
+
+// loadLease enables the election of the forwarding deployment when a lease bucket is configured.
+func loadLease() error {
+	l, err := lease.NewFromEnv()
+	if err != nil {
+		return fmt.Errorf("error initializing lease: %w", err)
+	}
+	forwardingLease = l
+	return nil
+}
+
+// loadMigrationSink enables dual-shipping to the migration account when it is configured.
+func loadMigrationSink() error {
+	sink, err := util.NewMigrationSinkFromEnv()
+	if err != nil {
+		return fmt.Errorf("error initializing migration sink: %w", err)
+	}
+	if sink != nil {
+		util.RegisterSink(sink)
+	}
+	return nil
+}
+
+// loadVerifier enables read-your-writes verification of critical log groups when it is configured.
+func loadVerifier() error {
+	verifier, err := util.NewVerifierFromEnv()
+	if err != nil {
+		return fmt.Errorf("error initializing verifier: %w", err)
+	}
+	if verifier != nil {
+		workerPool.SetVerifier(verifier)
+	}
+	return nil
+}
+
+// registerIntegration records the function in New Relic when self-registration is enabled.
+func registerIntegration() {
+	aliases := make([]string, 0, len(accountRoutes))
+	for _, route := range accountRoutes {
+		aliases = append(aliases, route.Alias)
+	}
+	if err := util.RegisterIntegration(context.Background(), aliases); err != nil {
+		logger.FromContext(context.Background()).Warnf("error registering integration: %v", err)
+	}
+}
+
+// handleFunction processes OCI logging events and forwards them to New Relic.
+// It creates the NewRelic client on each invocation (like your working simple function).
+func handleFunction(ctx context.Context, in io.Reader, out io.Writer) {
+	if util.InvocationHeader(ctx, common.HealthCheckHeader) != "" {
+		writeHealthReport(ctx, out)
+		return
+	}
+	override := invocationRouting(ctx)
+
+	// Create NewRelic client during function invocation, not startup
+	nrClient, err := util.NewRoutedNRClient(override.Routes)
+	if err != nil {
+		logger.FromContext(ctx).Panicf("error initializing newrelic client: %v", err)
+	}
+	
+	handleFunctionWithClient(ctx, in, out, nrClient, override)
+}
+
+// invocationRouting returns the routing table and transform profile of the invocation: the startup
+// configuration, or the routing override header when overrides are allowed.
+func invocationRouting(ctx context.Context) routing.Override {
+	value := util.InvocationHeader(ctx, common.RoutingOverrideHeader)
+	if value == "" {
+		return routing.Override{Routes: accountRoutes}
+	}
+	if os.Getenv(common.AllowRoutingOverride) != "true" {
+		logger.FromContext(ctx).Warnf("Ignoring %s header: routing overrides are disabled", common.RoutingOverrideHeader)
+		return routing.Override{Routes: accountRoutes}
+	}
+
+	override, err := routing.ParseOverride(value)
+	if err != nil {
+		logger.FromContext(ctx).Panicf("error applying routing override: %v", err)
+	}
+	logger.FromContext(ctx).Infof("Applying %s header with %d routes", common.RoutingOverrideHeader, len(override.Routes))
+	return override
+}
+
+// handleFunctionWithClient processes OCI logging events and forwards them to New Relic.
+// It unmarshals incoming events, dispatches the resulting log batches to the shared worker pool,
+// and waits for all of this invocation's batches to be processed before returning.
+func handleFunctionWithClient(ctx context.Context, in io.Reader, out io.Writer, nrClient util.NewRelicClientAPI, override routing.Override) {
+	ctx = invocationContext(ctx)
+	metrics.Default.Reset()
+	checkGoroutineLeaks(ctx)
+	// Configuration changes take effect at invocation boundaries
+	transform.ReloadProfiles()
+	if standby(ctx) {
+		reportMetrics(ctx, out)
+		return
+	}
+	event := unmarshal.Event{ContentEncoding: util.InvocationHeader(ctx, "Content-Encoding")}
+	if err := event.Unmarshal(logger.WithStage(ctx, logger.StageUnmarshal), in); err != nil {
+		var violation *unmarshal.SchemaViolation
+		if errors.As(err, &violation) && rejectPayload(ctx, event, err) {
+			reportMetrics(ctx, out)
+			return
+		}
+		logger.FromContext(logger.WithStage(ctx, logger.StageUnmarshal)).Panicf("Error unmarshalling event: %v", err)
+	}
+	logger.DebugPayload(logger.FromContext(ctx), "Received payload", event.OCILoggingEvent)
+	metrics.Default.Counter("bytes.received").Add(int64(event.PayloadSize))
+
+	nrClient, err := util.NewDebugOutputClient(nrClient, out, os.Getenv(common.DebugOutput))
+	if err != nil {
+		logger.FromContext(ctx).Panicf("error enabling debug output: %v", err)
+	}
+
+	channel := make(chan common.DetailedLogsBatch, common.MessageChannelSize)
+	workers := util.WorkerCount(event.PayloadSize, len(event.OCILoggingEvent), util.MaxWorkers())
+
+	var payloadDelivery delivery
+	if event.EventType == unmarshal.OCI_LOGGING {
+		payloadDelivery = newDelivery(ctx, event.OCILoggingEvent)
+	}
+
+	// Hand batches to the warm worker pool as they are produced
+	dispatched := goInvocation(func() {
+		workerPool.Dispatch(ctx, channel, nrClient, workers)
+	})
+
+	func() {
+		// Close channel after processing to signal completion, also when processing panics, so the
+		// dispatcher never outlives the invocation
+		defer close(channel)
+		switch event.EventType {
+		case unmarshal.OCI_LOGGING:
+			if payloadDelivery.duplicate(ctx, clock.Now()) {
+				return
+			}
+			checkSchemaDrift(ctx, event.OCILoggingEvent)
+			loggroup.ProcessInvocation(loggroup.Invocation{
+				Records:    event.OCILoggingEvent,
+				RawRecords: event.RawRecords,
+				Routes:     override.Routes,
+				Profile:    override.Profile,
+				Events:     parserEventSender(ctx),
+				Connector: loggroup.Connector{
+					ID:              util.InvocationHeader(ctx, common.ConnectorIDHeader),
+					Name:            util.InvocationHeader(ctx, common.ConnectorNameHeader),
+					DeliveryAttempt: payloadDelivery.attempt,
+				},
+				Function: invocationFunction(ctx),
+				Tenancy:  invocationTenancy(ctx),
+				Context:  ctx,
+			}, channel)
+		case unmarshal.RETRY_STREAM:
+			replayEnvelopes(ctx, event.RetryEnvelopes, channel)
+		default:
+			logger.FromContext(ctx).Warnf("Unknown event type: %s", event.EventType)
+		}
+	}()
+	// Wait for this invocation's batches to finish processing
+	<-dispatched
+
+	// Batches replayed from the retry stream were counted when first received
+	lost := int64(0)
+	if event.EventType == unmarshal.OCI_LOGGING {
+		lost = checkParity(ctx)
+		payloadDelivery.forwarded(lost, clock.Now())
+	}
+	checkDropRate(ctx, lost)
+	recordRates(lost)
+	reportMetrics(ctx, out)
+}
+
+// standby reports whether another deployment holds the forwarding lease, in which case the invocation is
+// acknowledged without forwarding its records. Records are forwarded when the lease cannot be checked, as a
+// duplicate is preferable to a loss.
+func standby(ctx context.Context) bool {
+	if forwardingLease == nil {
+		return false
+	}
+	held, err := forwardingLease.Held(ctx)
+	if err != nil {
+		metrics.Default.Counter("lease.errors").Inc()
+		logger.FromContext(ctx).Warnf("Forwarding records as the lease could not be checked: %v", err)
+		return false
+	}
+	if !held {
+		metrics.Default.Counter("lease.standby").Inc()
+		return true
+	}
+	return false
+}
+
+// rejectPayload dead-letters the records of a payload rejected by STRICT_SCHEMA, so that the payload can be
+// inspected and replayed once the contract change is handled. It reports whether the payload was written, in which
+// case the invocation is acknowledged, as a redelivery would only be rejected and written again.
+func rejectPayload(ctx context.Context, event unmarshal.Event, reason error) bool {
+	clog := logger.FromContext(logger.WithStage(ctx, logger.StageUnmarshal))
+	original, err := json.Marshal(event.OCILoggingEvent)
+	if len(event.RawRecords) == len(event.OCILoggingEvent) {
+		original, err = json.Marshal(event.RawRecords)
+	}
+	if err != nil {
+		clog.Errorf("error encoding rejected payload: %v", err)
+		return false
+	}
+	name, err := workerPool.DeadLetterPayload(ctx, original, reason)
+	switch {
+	case err != nil:
+		clog.Errorf("error writing rejected payload to dlq: %v", err)
+		return false
+	case name != "":
+		clog.Warnf("Wrote rejected payload of %d records to %s", len(event.OCILoggingEvent), name)
+		return true
+	}
+	return false
+}
+
+// invocationContext returns a copy of ctx whose log lines are tagged with the ID of the invocation: the Fn call ID,
+// or a random ID outside an Fn invocation, so the interleaved output of concurrent invocations can be told apart.
+func invocationContext(ctx context.Context) context.Context {
+	id := util.InvocationFunction(ctx).CallID
+	if id == "" {
+		id = randomInvocationID()
+	}
+	return logger.WithInvocationID(ctx, id)
+}
+
+// randomInvocationID returns a random identifier for an invocation without an Fn call ID.
+func randomInvocationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// invocationFunction returns the function stamped on the batches of the invocation, set when FAAS_ATTRIBUTES is "true".
+func invocationFunction(ctx context.Context) util.FunctionInfo {
+	if os.Getenv(common.FaasAttributes) != "true" {
+		return util.FunctionInfo{}
+	}
+	return util.InvocationFunction(ctx)
+}
+
+// invocationTenancy returns the tenancy name stamped on the batches of the invocation, resolved when TENANCY_NAME_LOOKUP
+// is "true".
+func invocationTenancy(ctx context.Context) string {
+	if os.Getenv(common.TenancyNameLookup) != "true" {
+		return ""
+	}
+	return util.TenancyName(ctx)
+}
+
+// MetricsEventType is the custom event type the metrics of each invocation are sent as.
+const MetricsEventType = "OciLogForwarderMetrics"
+
+// reportMetrics writes the metrics of the invocation to the outputs selected by METRICS_OUTPUT.
+func reportMetrics(ctx context.Context, out io.Writer) {
+	rlog := logger.FromContext(logger.WithStage(ctx, logger.StageReport))
+	outputs := strings.Split(os.Getenv(common.MetricsOutput), ",")
+	snapshot := metrics.Default.Snapshot()
+
+	for _, output := range outputs {
+		switch strings.TrimSpace(output) {
+		case "":
+		case "response":
+			if err := json.NewEncoder(out).Encode(map[string]interface{}{"metrics": snapshot}); err != nil {
+				rlog.Warnf("error writing metrics to the response: %v", err)
+			}
+		case "events":
+			sender, err := util.NewEventSender()
+			if err == nil {
+				event := metrics.Flatten(metrics.AddNoise(snapshot, metricsNoiseScale()))
+				event["eventType"] = MetricsEventType
+				event["instrumentation.version"] = common.InstrumentationVersion
+				err = sender.CreateEvents([]map[string]interface{}{event})
+			}
+			if err != nil {
+				rlog.Warnf("error posting metrics event: %v", err)
+			}
+		default:
+			rlog.Warnf("Ignoring unknown %s value %q", common.MetricsOutput, output)
+		}
+	}
+}
+
+// metricsNoiseScale returns the scale of the noise added to the counts of the metrics events, 0 for exact counts.
+func metricsNoiseScale() float64 {
+	value := strings.TrimSpace(os.Getenv(common.MetricsNoiseScale))
+	if value == "" {
+		return common.DefaultMetricsNoiseScale
+	}
+	scale, err := strconv.ParseFloat(value, 64)
+	if err != nil || scale < 0 {
+		logger.FromContext(context.Background()).Warnf("Ignoring invalid %s value %q", common.MetricsNoiseScale, value)
+		return common.DefaultMetricsNoiseScale
+	}
+	return scale
+}
+
+// parserEventSender returns the sender of the custom events of parsed records, limited to the event types enabled
+// by SECURITY_EVENTS and ALARM_EVENTS, or nil when none is enabled.
+func parserEventSender(ctx context.Context) util.EventSender {
+	enabled := map[string]bool{}
+	if os.Getenv(common.SecurityEvents) == "true" {
+		enabled[parser.CloudGuardEventType] = true
+		enabled[parser.BastionEventType] = true
+	}
+	if os.Getenv(common.AlarmEvents) == "true" {
+		enabled[parser.AlarmEventType] = true
+	}
+	if len(enabled) == 0 {
+		return nil
+	}
+	sender, err := util.NewEventSender()
+	if err != nil {
+		logger.FromContext(ctx).Errorf("error initializing parser event sender: %v", err)
+		return nil
+	}
+	return eventTypeFilter{sender: sender, enabled: enabled}
+}
+
+// eventTypeFilter sends only the events of the enabled event types.
+type eventTypeFilter struct {
+	sender  util.EventSender
+	enabled map[string]bool
+}
+
+// CreateEvents sends the events of the enabled types.
+func (f eventTypeFilter) CreateEvents(events []map[string]interface{}) error {
+	var kept []map[string]interface{}
+	for _, event := range events {
+		if eventType, _ := event["eventType"].(string); f.enabled[eventType] {
+			kept = append(kept, event)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return f.sender.CreateEvents(kept)
+}
@@ -0,0 +1,365 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fnproject/fdk-go"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/dlq"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/parser"
+	"github.com/newrelic/oci-log-integration/logs-function/routing"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockNewRelicClient is a mock implementation of the NewRelicClientAPI interface
+type MockNewRelicClient struct {
+	mock.Mock
+}
+
+func (m *MockNewRelicClient) CreateLogEntry(logEntry interface{}) error {
+	args := m.Called(logEntry)
+	return args.Error(0)
+}
+
+// TestHandleFunctionWithClient tests the main log processing function
+func TestHandleFunctionWithClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedCalls int
+		mockError     error
+		expectError   bool
+		description   string
+	}{
+		{
+			name: "successful OCI logging event processing",
+			input: `[
+				{"timestamp":"2023-01-01T12:00:00Z","level":"INFO","message":"Application started","service":"web-server"},
+				{"timestamp":"2023-01-01T12:01:00Z","level":"ERROR","message":"Database error","service":"web-server"}
+			]`,
+			expectedCalls: 1,
+			mockError:     nil,
+			expectError:   false,
+			description:   "Should successfully process valid OCI logging events",
+		},
+		{
+			name:          "single OCI logging event",
+			input:         `[{"timestamp":"2023-01-01T12:00:00Z","level":"WARN","message":"High memory usage","service":"api-gateway"}]`,
+			expectedCalls: 1,
+			mockError:     nil,
+			expectError:   false,
+			description:   "Should successfully process single OCI logging event",
+		},
+		{
+			name:          "empty log array",
+			input:         `[]`,
+			expectedCalls: 0,
+			mockError:     nil,
+			expectError:   false,
+			description:   "Should handle empty log arrays without errors",
+		},
+		{
+			name:          "invalid JSON input",
+			input:         `{invalid json`,
+			expectedCalls: 0,
+			mockError:     nil,
+			expectError:   true,
+			description:   "Should panic on invalid JSON input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockNewRelicClient)
+
+			if tt.expectedCalls > 0 {
+				mockClient.On("CreateLogEntry", mock.Anything).Return(tt.mockError).Times(tt.expectedCalls)
+			}
+
+			input := bytes.NewReader([]byte(tt.input))
+			output := &bytes.Buffer{}
+
+			ctx := context.Background()
+
+			if tt.expectError {
+				assert.Panics(t, func() {
+					handleFunctionWithClient(ctx, input, output, mockClient, routing.Override{})
+				}, tt.description)
+			} else {
+				assert.NotPanics(t, func() {
+					handleFunctionWithClient(ctx, input, output, mockClient, routing.Override{})
+
+					time.Sleep(100 * time.Millisecond)
+				}, tt.description)
+
+				mockClient.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+// TestHandleFunctionWithClientConcurrency tests concurrent processing
+func TestHandleFunctionWithClientConcurrency(t *testing.T) {
+	mockClient := new(MockNewRelicClient)
+
+	mockClient.On("CreateLogEntry", mock.Anything).Return(nil).Maybe()
+
+	input := bytes.NewReader([]byte(`[
+		{"timestamp":"2023-01-01T12:00:00Z","level":"INFO","message":"Message 1","service":"service-1"},
+		{"timestamp":"2023-01-01T12:00:01Z","level":"INFO","message":"Message 2","service":"service-2"},
+		{"timestamp":"2023-01-01T12:00:02Z","level":"INFO","message":"Message 3","service":"service-3"}
+	]`))
+	output := &bytes.Buffer{}
+
+	ctx := context.Background()
+
+	done := make(chan bool, 1)
+	go func() {
+		handleFunctionWithClient(ctx, input, output, mockClient, routing.Override{})
+		done <- true
+	}()
+
+	select {
+	case <-done:
+		assert.True(t, true, "Function completed without hanging")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Function execution timed out - possible goroutine leak or deadlock")
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	mockClient.AssertExpectations(t)
+}
+
+// TestHandleFunctionErrorCases tests various error scenarios
+func TestHandleFunctionErrorCases(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		description string
+	}{
+		{
+			name:        "completely invalid input",
+			input:       "not json at all",
+			description: "Should panic on non-JSON input",
+		},
+		{
+			name:        "empty input",
+			input:       "",
+			description: "Should panic on empty input",
+		},
+		{
+			name:        "null input",
+			input:       "null",
+			description: "Should handle null JSON input gracefully",
+		},
+		{
+			name:        "single object instead of array",
+			input:       `{"wrong": "structure"}`,
+			description: "Should panic when JSON is object instead of expected array",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockNewRelicClient)
+
+			input := bytes.NewReader([]byte(tt.input))
+			output := &bytes.Buffer{}
+
+			ctx := context.Background()
+
+			if tt.name == "null input" {
+				assert.NotPanics(t, func() {
+					handleFunctionWithClient(ctx, input, output, mockClient, routing.Override{})
+					time.Sleep(50 * time.Millisecond)
+				}, tt.description)
+				mockClient.AssertExpectations(t)
+			} else {
+				assert.Panics(t, func() {
+					handleFunctionWithClient(ctx, input, output, mockClient, routing.Override{})
+				}, tt.description)
+			}
+		})
+	}
+}
+
+// routingHeaderContext is an Fn invocation context carrying only the routing override header.
+type routingHeaderContext struct {
+	fdk.Context
+	value string
+}
+
+func (c routingHeaderContext) Header() http.Header {
+	return http.Header{"X-Nr-Routing": {c.value}}
+}
+
+// TestInvocationRouting tests that the routing override header is applied only when allowed.
+func TestInvocationRouting(t *testing.T) {
+	header := `{"routes":[{"alias":"test","secretOcid":"ocid1.vaultsecret.test"}],"profile":"canary"}`
+	ctx := fdk.WithContext(context.Background(), routingHeaderContext{value: header})
+
+	assert.Equal(t, routing.Override{Routes: accountRoutes}, invocationRouting(context.Background()))
+	assert.Equal(t, routing.Override{Routes: accountRoutes}, invocationRouting(ctx))
+
+	t.Setenv(common.AllowRoutingOverride, "true")
+	override := invocationRouting(ctx)
+	assert.Equal(t, "canary", override.Profile)
+	assert.Equal(t, []routing.Route{{Alias: "test", SecretOCID: "ocid1.vaultsecret.test"}}, override.Routes)
+
+	invalid := fdk.WithContext(context.Background(), routingHeaderContext{value: `{"profile":"beta"}`})
+	assert.Panics(t, func() { invocationRouting(invalid) })
+}
+
+// TestHandleFunctionMetricsResponse tests that the invocation metrics are written to the response when selected.
+func TestHandleFunctionMetricsResponse(t *testing.T) {
+	t.Setenv(common.MetricsOutput, "response")
+	mockClient := new(MockNewRelicClient)
+	mockClient.On("CreateLogEntry", mock.Anything).Return(nil)
+
+	var out bytes.Buffer
+	handleFunctionWithClient(context.Background(), bytes.NewBufferString(`[{"message":"a"},{"message":"b"}]`), &out, mockClient, routing.Override{})
+
+	var response struct {
+		Metrics map[string]interface{} `json:"metrics"`
+	}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &response))
+	assert.Equal(t, 2.0, response.Metrics["records.received"])
+	assert.Equal(t, 1.0, response.Metrics["sink.batches.posted"])
+}
+
+// TestReportMetricsLogStage tests that log lines of the metrics report are tagged with the report stage.
+func TestReportMetricsLogStage(t *testing.T) {
+	t.Setenv(common.MetricsOutput, "bogus")
+	var out bytes.Buffer
+	l := logger.NewLogrusLogger(logger.WithLogLevel("info"))
+	l.SetOutput(&out)
+	l.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true, DisableColors: true})
+
+	reportMetrics(logger.WithInvocationID(logger.WithLogger(context.Background(), l), "call-1"), &bytes.Buffer{})
+
+	assert.Equal(t, "level=warning msg=\"Ignoring unknown METRICS_OUTPUT value \\\"bogus\\\"\" invocationId=call-1 stage=report\n", out.String())
+}
+
+// TestMetricsNoiseScale tests the scale of the noise added to the metrics events.
+func TestMetricsNoiseScale(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected float64
+	}{
+		{"", 0},
+		{"2.5", 2.5},
+		{"-1", 0},
+		{"lots", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(common.MetricsNoiseScale, tt.value)
+			assert.Equal(t, tt.expected, metricsNoiseScale())
+		})
+	}
+}
+
+// TestHandleFunctionRetryStream tests that batches replayed from the retry stream are resent with their attempt count.
+func TestHandleFunctionRetryStream(t *testing.T) {
+	replays.reset()
+	envelope := base64.StdEncoding.EncodeToString([]byte(`{"version":1,"transformed":[{"common":{"attributes":{"instrumentation.provider":"oci"}},"logs":[{"message":"retry me"}]}],"attempts":2}`))
+	input := `[{"stream":"retry","partition":"0","value":"` + envelope + `","offset":1}]`
+
+	mockClient := new(MockNewRelicClient)
+	mockClient.On("CreateLogEntry", mock.Anything).Return(nil)
+	handleFunctionWithClient(context.Background(), bytes.NewBufferString(input), &bytes.Buffer{}, mockClient, routing.Override{})
+
+	mockClient.AssertNumberOfCalls(t, "CreateLogEntry", 1)
+	batch := mockClient.Calls[0].Arguments.Get(0).(common.DetailedLogsBatch)
+	assert.Equal(t, 2, batch[0].CommonData.Attributes[common.RetryAttemptAttribute])
+	assert.Equal(t, "retry me", batch[0].Entries[0]["message"])
+}
+
+// deadLetterWriter records the envelopes it is asked to write, or fails every write with err.
+type deadLetterWriter struct {
+	envelopes []dlq.Envelope
+	err       error
+}
+
+func (w *deadLetterWriter) Write(_ context.Context, envelope dlq.Envelope) (string, error) {
+	if w.err != nil {
+		return "", w.err
+	}
+	w.envelopes = append(w.envelopes, envelope)
+	return "dlq/rejected.json", nil
+}
+
+// TestHandleFunctionRejectedPayload tests that a payload rejected by STRICT_SCHEMA is acknowledged once it is
+// dead-lettered, and redelivered otherwise.
+func TestHandleFunctionRejectedPayload(t *testing.T) {
+	t.Setenv(common.StrictSchema, "true")
+	t.Cleanup(func() { workerPool.SetDeadLetterWriter(nil) })
+	input := `[{"message":"hi","tags":{}}]`
+
+	tests := []struct {
+		name     string
+		writer   *deadLetterWriter
+		expected bool
+	}{
+		{"written", &deadLetterWriter{}, true},
+		{"write failed", &deadLetterWriter{err: errors.New("unavailable")}, false},
+		{"no dlq", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workerPool.SetDeadLetterWriter(nil)
+			if tt.writer != nil {
+				workerPool.SetDeadLetterWriter(tt.writer)
+			}
+			mockClient := new(MockNewRelicClient)
+			handle := func() {
+				handleFunctionWithClient(context.Background(), bytes.NewBufferString(input), &bytes.Buffer{}, mockClient, routing.Override{})
+			}
+
+			if !tt.expected {
+				assert.Panics(t, handle)
+				return
+			}
+			assert.NotPanics(t, handle)
+			mockClient.AssertNotCalled(t, "CreateLogEntry", mock.Anything)
+			assert.Len(t, tt.writer.envelopes, 1)
+			assert.JSONEq(t, input, string(tt.writer.envelopes[0].Original))
+			assert.True(t, tt.writer.envelopes[0].Rejected())
+		})
+	}
+}
+
+// recordingEventSender records the events it is asked to send.
+type recordingEventSender struct {
+	events []map[string]interface{}
+}
+
+func (s *recordingEventSender) CreateEvents(events []map[string]interface{}) error {
+	s.events = append(s.events, events...)
+	return nil
+}
+
+// TestEventTypeFilter tests that only the events of enabled types are sent.
+func TestEventTypeFilter(t *testing.T) {
+	sender := &recordingEventSender{}
+	filter := eventTypeFilter{sender: sender, enabled: map[string]bool{parser.AlarmEventType: true}}
+
+	assert.NoError(t, filter.CreateEvents([]map[string]interface{}{
+		{"eventType": parser.AlarmEventType, "alarm.id": "a"},
+		{"eventType": parser.CloudGuardEventType},
+	}))
+	assert.NoError(t, filter.CreateEvents([]map[string]interface{}{{"eventType": parser.BastionEventType}}))
+
+	assert.Equal(t, []map[string]interface{}{{"eventType": parser.AlarmEventType, "alarm.id": "a"}}, sender.events)
+}
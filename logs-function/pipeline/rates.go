@@ -0,0 +1,115 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// rateWindows are the windows the rolling rates are reported over, keyed by their name in the health report.
+var rateWindows = []struct {
+	name   string
+	length time.Duration
+}{
+	{name: "5m", length: 5 * time.Minute},
+	{name: "15m", length: 15 * time.Minute},
+	{name: "60m", length: 60 * time.Minute},
+}
+
+// rateBuckets is the number of one-minute buckets of the ring, enough for the longest window.
+const rateBuckets = 60
+
+// rateCounts are the counts the rolling rates are computed from.
+type rateCounts struct {
+	records int64
+	bytes   int64
+	errors  int64
+}
+
+// rateBucket holds the counts of one minute.
+type rateBucket struct {
+	minute time.Time
+	rateCounts
+}
+
+// Rates are the throughput and error rate of the warm container over a window. Windows longer than the life of the
+// container are averaged over its life.
+type Rates struct {
+	RecordsPerSecond float64 `json:"recordsPerSecond"`
+	BytesPerSecond   float64 `json:"bytesPerSecond"`
+	ErrorPercent     float64 `json:"errorPercent"`
+}
+
+// rateRing holds the counts of the last hour of the warm container in a ring of one-minute buckets.
+type rateRing struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	buckets   [rateBuckets]rateBucket
+}
+
+// rates is the rate ring of the warm container.
+var rates rateRing
+
+// observe adds the counts of an invocation to the bucket of the minute of now, replacing the bucket of an
+// hour ago it reuses.
+func (r *rateRing) observe(now time.Time, counts rateCounts) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.startedAt.IsZero() {
+		r.startedAt = now
+	}
+	minute := now.Truncate(time.Minute)
+	bucket := &r.buckets[minute.Unix()/60%rateBuckets]
+	if !bucket.minute.Equal(minute) {
+		*bucket = rateBucket{minute: minute}
+	}
+	bucket.records += counts.records
+	bucket.bytes += counts.bytes
+	bucket.errors += counts.errors
+}
+
+// report returns the rates of each window ending at now. Rates are averaged over at least a minute, so the first
+// invocations of a container do not report bursts as sustained throughput.
+func (r *rateRing) report(now time.Time) map[string]Rates {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := make(map[string]Rates, len(rateWindows))
+	for _, window := range rateWindows {
+		var total rateCounts
+		for _, bucket := range r.buckets {
+			if age := now.Sub(bucket.minute); !bucket.minute.IsZero() && age >= 0 && age < window.length {
+				total.records += bucket.records
+				total.bytes += bucket.bytes
+				total.errors += bucket.errors
+			}
+		}
+
+		span := window.length
+		if !r.startedAt.IsZero() && now.Sub(r.startedAt) < span {
+			span = max(now.Sub(r.startedAt), time.Minute)
+		}
+		rate := Rates{
+			RecordsPerSecond: float64(total.records) / span.Seconds(),
+			BytesPerSecond:   float64(total.bytes) / span.Seconds(),
+		}
+		if total.records > 0 {
+			rate.ErrorPercent = float64(total.errors) * 100 / float64(total.records)
+		}
+		report[window.name] = rate
+	}
+	return report
+}
+
+// recordRates adds the records and bytes received by the invocation to the rate ring, with the records whose post
+// failed and the lost ones found missing by the parity check as errors.
+func recordRates(lost int64) {
+	rates.observe(clock.Now(), rateCounts{
+		records: metrics.Default.Counter("records.received").Value(),
+		bytes:   metrics.Default.Counter("bytes.received").Value(),
+		errors:  metrics.Default.Counter("records.failed").Value() + lost,
+	})
+}
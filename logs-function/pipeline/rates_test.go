@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRateRingReport tests the rolling rates over a container life longer than the ring.
+func TestRateRingReport(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var r rateRing
+
+	r.observe(start, rateCounts{records: 600, bytes: 6000, errors: 60})
+	report := r.report(start.Add(30 * time.Second))
+	assert.InDelta(t, 10, report["5m"].RecordsPerSecond, 0.001, "averaged over at least a minute")
+	assert.InDelta(t, 100, report["60m"].BytesPerSecond, 0.001)
+	assert.InDelta(t, 10, report["15m"].ErrorPercent, 0.001)
+
+	// An hour and a half later the first bucket is reused
+	for minute := 1; minute <= 90; minute++ {
+		r.observe(start.Add(time.Duration(minute)*time.Minute), rateCounts{records: 60, bytes: 120})
+	}
+	report = r.report(start.Add(90*time.Minute + 30*time.Second))
+	tests := []struct {
+		window  string
+		records float64
+	}{
+		{window: "5m", records: 1},
+		{window: "15m", records: 1},
+		{window: "60m", records: 1},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.records, report[tt.window].RecordsPerSecond, 0.001, tt.window)
+		assert.InDelta(t, 2*tt.records, report[tt.window].BytesPerSecond, 0.001, tt.window)
+		assert.Zero(t, report[tt.window].ErrorPercent, tt.window)
+	}
+}
+
+// TestRateRingReportEmpty tests that a container without invocations reports zero rates.
+func TestRateRingReportEmpty(t *testing.T) {
+	var r rateRing
+	report := r.report(time.Now())
+	assert.Len(t, report, 3)
+	assert.Equal(t, Rates{}, report["60m"])
+}
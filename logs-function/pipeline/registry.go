@@ -0,0 +1,22 @@
+package pipeline
+
+import (
+	"github.com/newrelic/oci-log-integration/logs-function/parser"
+	"github.com/newrelic/oci-log-integration/logs-function/util"
+)
+
+// Parser maps the records of one source into attributes. See parser.Parser.
+type Parser = parser.Parser
+
+// Sink receives every batch once delivery to New Relic was attempted. See util.Sink.
+type Sink = util.Sink
+
+// RegisterParser adds a custom parser, tried before the built-in parsers. Call it before Start.
+func RegisterParser(p Parser) {
+	parser.Register(p)
+}
+
+// RegisterSink adds a sink receiving every batch next to New Relic. Call it before Start.
+func RegisterSink(sink Sink) {
+	util.RegisterSink(sink)
+}
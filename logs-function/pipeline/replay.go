@@ -0,0 +1,177 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/dlq"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// Bounds of the replay registry.
+const (
+	replayKeyTTL  = 24 * time.Hour // replayKeyTTL is how long a replayed envelope is remembered.
+	maxReplayKeys = 10000          // maxReplayKeys bounds the number of remembered envelopes.
+)
+
+// replayRegistry remembers the envelopes replayed by the warm container, so that an envelope delivered twice, e.g.
+// when a recovery drill re-ingests an archive the retry stream already replayed, is only resent once. It lives for
+// the lifetime of the warm container, as the function has no state store shared across containers.
+type replayRegistry struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// replays is the replay registry of the warm container.
+var replays = replayRegistry{seen: map[string]time.Time{}}
+
+// mark records the envelope key as replayed at now and reports whether it was already replayed.
+func (r *replayRegistry) mark(key string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if replayedAt, ok := r.seen[key]; ok && now.Sub(replayedAt) < replayKeyTTL {
+		return true
+	}
+	if len(r.seen) >= maxReplayKeys {
+		for k, replayedAt := range r.seen {
+			if now.Sub(replayedAt) >= replayKeyTTL {
+				delete(r.seen, k)
+			}
+		}
+		if len(r.seen) >= maxReplayKeys {
+			r.seen = map[string]time.Time{}
+		}
+	}
+	r.seen[key] = now
+	return false
+}
+
+// contains reports whether the key was recorded less than replayKeyTTL before now.
+func (r *replayRegistry) contains(key string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recordedAt, ok := r.seen[key]
+	return ok && now.Sub(recordedAt) < replayKeyTTL
+}
+
+// reset forgets every replayed envelope.
+func (r *replayRegistry) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen = map[string]time.Time{}
+}
+
+// replayKey identifies an envelope by its batch, or the payload of a rejected one, and its number of delivery
+// attempts, so that a batch failing again after a replay is replayed once more while a duplicate delivery of the
+// same envelope is not.
+func replayKey(envelope dlq.Envelope) string {
+	data := []byte(envelope.Original)
+	if !envelope.Rejected() {
+		var err error
+		if data, err = json.Marshal(envelope.Transformed); err != nil {
+			return ""
+		}
+	}
+	hash := sha256.New()
+	hash.Write(data)
+	hash.Write([]byte(strconv.Itoa(envelope.Attempts)))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replayWindow returns the REPLAY_WINDOW_HOURS window, or 0 to replay records of any age.
+func replayWindow() time.Duration {
+	hours, err := strconv.Atoi(strings.TrimSpace(os.Getenv(common.ReplayWindow)))
+	if err != nil || hours < 0 {
+		hours = common.DefaultReplayWindow
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// replayEnvelopes resends the transformed batches of envelopes received from the retry stream, stamped
+// with their number of failed delivery attempts and the replay metadata. Envelopes already replayed by the
+// container and records older than the replay window are skipped. Rejected payloads, published to the stream by
+// earlier releases, hold no batch to resend and are moved to the DLQ bucket.
+func replayEnvelopes(ctx context.Context, envelopes []dlq.Envelope, channel chan common.DetailedLogsBatch) {
+	clog := logger.FromContext(ctx)
+	window := replayWindow()
+	now := clock.Now()
+	for _, envelope := range envelopes {
+		if key := replayKey(envelope); key != "" && replays.mark(key, now) {
+			metrics.Default.Counter("replay.batches.duplicate").Inc()
+			clog.Warnf("Skipping log batch already replayed after %d attempts", envelope.Attempts)
+			continue
+		}
+		if envelope.Rejected() {
+			moveRejectedPayload(ctx, envelope)
+			continue
+		}
+		batch := make(common.DetailedLogsBatch, 0, len(envelope.Transformed))
+		expired := 0
+		for _, logs := range envelope.Transformed {
+			attributes := make(common.LogAttributes, len(logs.CommonData.Attributes)+3)
+			for key, value := range logs.CommonData.Attributes {
+				attributes[key] = value
+			}
+			attributes[common.RetryAttemptAttribute] = envelope.Attempts
+			attributes[common.ReplayedAttribute] = true
+			attributes[common.ReplayedAtAttribute] = now.UTC().Format(time.RFC3339)
+			logs.CommonData.Attributes = attributes
+			if window > 0 {
+				kept := len(logs.Entries)
+				logs.Entries = withinReplayWindow(logs.Entries, now.Add(-window))
+				expired += kept - len(logs.Entries)
+			}
+			if len(logs.Entries) > 0 {
+				batch = append(batch, logs)
+			}
+		}
+		if expired > 0 {
+			metrics.Default.Counter("replay.records.expired").Add(int64(expired))
+			clog.Warnf("Skipping %d replayed log records older than %v", expired, window)
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		clog.Infof("Replaying log batch from the retry stream after %d attempts", envelope.Attempts)
+		channel <- batch
+	}
+}
+
+// moveRejectedPayload writes a rejected payload received from the retry stream to the DLQ bucket.
+func moveRejectedPayload(ctx context.Context, envelope dlq.Envelope) {
+	clog := logger.FromContext(ctx)
+	reason := errors.New("rejected payload")
+	if len(envelope.ErrorChain) > 0 {
+		reason = errors.New(envelope.ErrorChain[0])
+	}
+	name, err := workerPool.DeadLetterPayload(ctx, envelope.Original, reason)
+	switch {
+	case err != nil:
+		clog.Errorf("error moving rejected payload from the retry stream to dlq: %v", err)
+	case name != "":
+		clog.Warnf("Moved rejected payload from the retry stream to %s", name)
+	}
+}
+
+// withinReplayWindow returns the records dated after cutoff. Records without a time are kept.
+func withinReplayWindow(entries common.LogData, cutoff time.Time) common.LogData {
+	kept := make(common.LogData, 0, len(entries))
+	for _, entry := range entries {
+		if _, recordTime, ok := common.RecordTimeField(entry); ok && recordTime.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/dlq"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedClock is a clock stopped at a given time.
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+// replayed runs the envelopes through replayEnvelopes and returns the batches it resends.
+func replayed(envelopes ...dlq.Envelope) []common.DetailedLogsBatch {
+	channel := make(chan common.DetailedLogsBatch, len(envelopes))
+	replayEnvelopes(context.Background(), envelopes, channel)
+	close(channel)
+	var batches []common.DetailedLogsBatch
+	for batch := range channel {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// TestReplayEnvelopes tests that replayed batches are stamped with the replay metadata and that a duplicate
+// delivery of an envelope is skipped while a later attempt of the same batch is not.
+func TestReplayEnvelopes(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	defer clock.Set(fixedClock{now: now})()
+	replays.reset()
+	metrics.Default.Reset()
+
+	envelope := dlq.Envelope{Version: 1, Attempts: 1, Transformed: common.DetailedLogsBatch{{
+		CommonData: common.Common{Attributes: common.LogAttributes{"instrumentation.provider": "oci"}},
+		Entries:    common.LogData{{"message": "a"}},
+	}}}
+	retried := envelope
+	retried.Attempts = 2
+
+	batches := replayed(envelope, envelope, retried)
+	assert.Len(t, batches, 2)
+	assert.Equal(t, common.LogAttributes{
+		"instrumentation.provider":   "oci",
+		common.RetryAttemptAttribute: 1,
+		common.ReplayedAttribute:     true,
+		common.ReplayedAtAttribute:   "2026-03-02T10:00:00Z",
+	}, batches[0][0].CommonData.Attributes)
+	assert.Equal(t, 2, batches[1][0].CommonData.Attributes[common.RetryAttemptAttribute])
+	assert.Nil(t, envelope.Transformed[0].CommonData.Attributes[common.ReplayedAttribute])
+	assert.Equal(t, int64(1), metrics.Default.Counter("replay.batches.duplicate").Value())
+
+	assert.Empty(t, replayed(envelope))
+	defer clock.Set(fixedClock{now: now.Add(replayKeyTTL)})()
+	assert.Len(t, replayed(envelope), 1)
+}
+
+// TestReplayWindow tests that records older than the replay window are skipped, and batches left empty are not sent.
+func TestReplayWindow(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	defer clock.Set(fixedClock{now: now})()
+	replays.reset()
+	metrics.Default.Reset()
+	t.Setenv(common.ReplayWindow, "24")
+
+	recent := common.LogData{{"message": "recent", "time": "2026-03-02T09:00:00Z"}, {"message": "undated"}}
+	old := common.LogData{{"message": "old", "time": "2026-02-28T09:00:00Z"}}
+	batches := replayed(
+		dlq.Envelope{Version: 1, Attempts: 1, Transformed: common.DetailedLogsBatch{{Entries: append(append(common.LogData{}, old...), recent...)}}},
+		dlq.Envelope{Version: 1, Attempts: 1, Transformed: common.DetailedLogsBatch{{Entries: old}}},
+	)
+
+	assert.Len(t, batches, 1)
+	assert.Equal(t, recent, batches[0][0].Entries)
+	assert.Equal(t, int64(2), metrics.Default.Counter("replay.records.expired").Value())
+}
+
+// TestReplayRegistryBounds tests that the registry stays bounded.
+func TestReplayRegistryBounds(t *testing.T) {
+	registry := replayRegistry{seen: map[string]time.Time{}}
+	now := time.Now()
+	for i := 0; i <= maxReplayKeys; i++ {
+		assert.False(t, registry.mark(string(rune(i)), now))
+	}
+	assert.LessOrEqual(t, len(registry.seen), maxReplayKeys)
+}
+
+// TestReplayRejectedPayload tests that rejected payloads are keyed on their payload and moved to the DLQ bucket.
+func TestReplayRejectedPayload(t *testing.T) {
+	replays.reset()
+	writer := &deadLetterWriter{}
+	workerPool.SetDeadLetterWriter(writer)
+	t.Cleanup(func() { workerPool.SetDeadLetterWriter(nil) })
+
+	rejected := func(original string) dlq.Envelope {
+		return dlq.NewEnvelope(nil, json.RawMessage(original), errors.New("schema violation"), 1, time.Now())
+	}
+	first, second := rejected(`[{"x":1}]`), rejected(`[{"x":2}]`)
+	assert.NotEqual(t, replayKey(first), replayKey(second))
+	assert.Equal(t, replayKey(first), replayKey(rejected(`[{"x":1}]`)))
+
+	assert.Empty(t, replayed(first, second, first))
+	assert.Len(t, writer.envelopes, 2)
+	assert.JSONEq(t, `[{"x":2}]`, string(writer.envelopes[1].Original))
+	assert.Equal(t, []string{"schema violation"}, writer.envelopes[1].ErrorChain)
+}
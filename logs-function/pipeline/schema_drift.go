@@ -0,0 +1,187 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/newrelic/oci-log-integration/logs-function/util"
+)
+
+// SchemaDriftEventType is the custom event type sent when the records of a source gain a field or a field
+// takes a type it never had.
+const SchemaDriftEventType = "OCILogSchemaDrift"
+
+// Kinds of schema drift.
+const (
+	driftFieldAdded  = "fieldAdded"  // driftFieldAdded is a field never seen on the source.
+	driftTypeChanged = "typeChanged" // driftTypeChanged is a known field holding a value of a new type.
+)
+
+// Bounds of the schema registry, so records with unbounded keys cannot grow it without limit.
+const (
+	maxSchemaSources = 1000 // maxSchemaSources is the number of sources tracked.
+	maxSchemaFields  = 500  // maxSchemaFields is the number of fields tracked per source.
+	maxDriftEvents   = 20   // maxDriftEvents is the number of drift events sent per invocation.
+)
+
+// unknownSchemaSource is the source of records without a type.
+const unknownSchemaSource = "unknown"
+
+// JSON types of field values, as bits of a fieldTypes entry.
+const (
+	typeBoolean uint8 = 1 << iota
+	typeNumber
+	typeString
+	typeObject
+	typeArray
+)
+
+// typeNames names the JSON types in bit order.
+var typeNames = []string{"boolean", "number", "string", "object", "array"}
+
+// schemaDrift is a change of the schema of a source.
+type schemaDrift struct {
+	source        string
+	field         string
+	change        string
+	previousTypes string
+	newType       string
+}
+
+// fieldTypes maps the fields of a source to the types their values were seen with.
+type fieldTypes map[string]uint8
+
+// schemaRegistry holds the schema first seen for each source, widened as drift is reported. It lives for the
+// lifetime of the warm container, as the function has no state store shared across containers.
+type schemaRegistry struct {
+	mu      sync.Mutex
+	sources map[string]fieldTypes
+}
+
+// schemas is the schema registry of the warm container.
+var schemas = schemaRegistry{sources: map[string]fieldTypes{}}
+
+// observe adds the fields of the records, their top-level fields and those under data, to the registry and
+// returns the drift from the known schemas. The first record of a source defines its schema without drift.
+// Null values carry no type and are ignored.
+func (r *schemaRegistry) observe(records common.OCILoggingEvent) []schemaDrift {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var drifts []schemaDrift
+	for _, record := range records {
+		source, ok := common.LookupString(record, "type")
+		if !ok {
+			source = unknownSchemaSource
+		}
+		known, seen := r.sources[source]
+		if !seen {
+			if len(r.sources) >= maxSchemaSources {
+				continue
+			}
+			known = fieldTypes{}
+			r.sources[source] = known
+		}
+
+		visit := func(field string, value interface{}) {
+			bit := jsonType(value)
+			if bit == 0 {
+				return
+			}
+			previous, exists := known[field]
+			switch {
+			case previous&bit != 0:
+				return
+			case !exists && len(known) >= maxSchemaFields:
+				return
+			case seen && !exists:
+				drifts = append(drifts, schemaDrift{source: source, field: field, change: driftFieldAdded, newType: typeName(bit)})
+			case seen:
+				drifts = append(drifts, schemaDrift{source: source, field: field, change: driftTypeChanged,
+					previousTypes: typeName(previous), newType: typeName(bit)})
+			}
+			known[field] = previous | bit
+		}
+		for field, value := range record {
+			visit(field, value)
+		}
+		if data, ok := record["data"].(map[string]interface{}); ok {
+			for field, value := range data {
+				visit("data."+field, value)
+			}
+		}
+	}
+	return drifts
+}
+
+// jsonType returns the type bit of a decoded JSON value, or 0 for null.
+func jsonType(value interface{}) uint8 {
+	switch value.(type) {
+	case bool:
+		return typeBoolean
+	case float64, json.Number, int, int64:
+		return typeNumber
+	case string:
+		return typeString
+	case map[string]interface{}:
+		return typeObject
+	case []interface{}:
+		return typeArray
+	default:
+		return 0
+	}
+}
+
+// typeName returns the names of the types set in bits, joined with "|".
+func typeName(bits uint8) string {
+	var names []string
+	for i, name := range typeNames {
+		if bits&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// checkSchemaDrift reports the schema drift of the records of the invocation, counted as schema.drift, and sends
+// a drift event for each change when NEW_RELIC_ACCOUNT_ID is set.
+func checkSchemaDrift(ctx context.Context, records common.OCILoggingEvent) {
+	drifts := schemas.observe(records)
+	if len(drifts) == 0 {
+		return
+	}
+	metrics.Default.Counter("schema.drift").Add(int64(len(drifts)))
+	logger.FromContext(ctx).Warnf("Detected %d schema changes, first: %s %s of %s", len(drifts), drifts[0].change, drifts[0].field, drifts[0].source)
+	if os.Getenv(common.NewRelicAccountID) == "" {
+		return
+	}
+
+	if len(drifts) > maxDriftEvents {
+		drifts = drifts[:maxDriftEvents]
+	}
+	events := make([]map[string]interface{}, 0, len(drifts))
+	for _, drift := range drifts {
+		events = append(events, map[string]interface{}{
+			"eventType":               SchemaDriftEventType,
+			"source":                  drift.source,
+			"field":                   drift.field,
+			"change":                  drift.change,
+			"previousType":            drift.previousTypes,
+			"type":                    drift.newType,
+			"instrumentation.version": common.InstrumentationVersion,
+		})
+	}
+	sender, err := util.NewEventSender()
+	if err == nil {
+		err = sender.CreateEvents(events)
+	}
+	if err != nil {
+		logger.FromContext(ctx).Warnf("error posting schema drift events: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSchemaRegistryObserve tests that the first records of a source define its schema and later records report
+// new fields and new types once.
+func TestSchemaRegistryObserve(t *testing.T) {
+	registry := schemaRegistry{sources: map[string]fieldTypes{}}
+
+	first := registry.observe(common.OCILoggingEvent{
+		{"type": "app", "message": "a", "data": map[string]interface{}{"status": 200.0}},
+		{"message": "b"},
+	})
+	assert.Empty(t, first)
+
+	drifts := registry.observe(common.OCILoggingEvent{
+		{"type": "app", "message": "a", "user": nil, "data": map[string]interface{}{"status": "OK", "latency": 1.5}},
+		{"type": "app", "message": "a", "data": map[string]interface{}{"status": "OK", "latency": 2.0}},
+		{"type": "other", "level": "INFO"},
+	})
+	assert.ElementsMatch(t, []schemaDrift{
+		{source: "app", field: "data.status", change: driftTypeChanged, previousTypes: "number", newType: "string"},
+		{source: "app", field: "data.latency", change: driftFieldAdded, newType: "number"},
+	}, drifts)
+
+	assert.Empty(t, registry.observe(common.OCILoggingEvent{
+		{"type": "app", "data": map[string]interface{}{"status": 404.0}},
+	}))
+	assert.Equal(t, "number|string", typeName(registry.sources["app"]["data.status"]))
+}
+
+// TestCheckSchemaDrift tests that drift is counted.
+func TestCheckSchemaDrift(t *testing.T) {
+	t.Setenv(common.NewRelicAccountID, "")
+	schemas = schemaRegistry{sources: map[string]fieldTypes{}}
+	t.Cleanup(func() { schemas = schemaRegistry{sources: map[string]fieldTypes{}} })
+	metrics.Default.Reset()
+
+	checkSchemaDrift(context.Background(), common.OCILoggingEvent{{"type": "drift.test", "message": "a"}})
+	checkSchemaDrift(context.Background(), common.OCILoggingEvent{{"type": "drift.test", "message": "a", "code": 1.0}})
+
+	assert.Equal(t, int64(1), metrics.Default.Counter("schema.drift").Value())
+}
@@ -0,0 +1,137 @@
+//go:build soak
+
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/routing"
+)
+
+// Soak test settings, overridable through the environment of `make soak`.
+const (
+	// DefaultSoakInvocations is the number of invocations driven through the pipeline.
+	DefaultSoakInvocations = 5000
+	// DefaultSoakRecords is the number of log records in each invocation.
+	DefaultSoakRecords = 200
+	// soakWarmup is the fraction of invocations run before the baseline is taken, letting the worker pool,
+	// caches and latency windows reach their steady state.
+	soakWarmup = 0.1
+	// soakSamples is the number of heap and goroutine samples logged over the run.
+	soakSamples = 20
+	// maxHeapGrowth is the heap growth over the baseline tolerated at the end of the run.
+	maxHeapGrowth = 16 << 20
+	// maxGoroutineGrowth is the goroutine growth over the baseline tolerated at the end of the run.
+	maxGoroutineGrowth = 5
+)
+
+// countingClient is a NewRelicClientAPI that only counts the batches it receives. Unlike
+// MockNewRelicClient it keeps no record of the calls, so it does not grow over a long run.
+type countingClient struct {
+	calls atomic.Int64
+}
+
+func (c *countingClient) CreateLogEntry(logEntry interface{}) error {
+	c.calls.Add(1)
+	return nil
+}
+
+// soakSample is the heap and goroutine usage after a garbage collection.
+type soakSample struct {
+	heap       uint64
+	goroutines int
+}
+
+// takeSoakSample collects garbage and returns the live heap and goroutine count. Goroutines of the
+// previous invocation are given a moment to exit so that they are not mistaken for leaks.
+func takeSoakSample() soakSample {
+	time.Sleep(50 * time.Millisecond)
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return soakSample{heap: stats.HeapAlloc, goroutines: runtime.NumGoroutine()}
+}
+
+// TestSoak drives thousands of invocations through the pipeline in-process, as a warm container serving
+// traffic would, and fails when the heap or the goroutine count keeps growing past the warmup.
+func TestSoak(t *testing.T) {
+	invocations := soakSetting(t, "SOAK_INVOCATIONS", DefaultSoakInvocations)
+	records := soakSetting(t, "SOAK_RECORDS", DefaultSoakRecords)
+	payloads := [][]byte{soakPayload(t, records, 0), soakPayload(t, records, 1), soakPayload(t, records, 2)}
+	client := &countingClient{}
+
+	warmup := int(float64(invocations) * soakWarmup)
+	var baseline soakSample
+	start := time.Now()
+	for i := 0; i < invocations; i++ {
+		if i == warmup {
+			baseline = takeSoakSample()
+			t.Logf("baseline after %d invocations: heap=%d KiB goroutines=%d", i, baseline.heap>>10, baseline.goroutines)
+		}
+		handleFunctionWithClient(context.Background(), bytes.NewReader(payloads[i%len(payloads)]), &bytes.Buffer{}, client, routing.Override{})
+		if (i+1)%max(invocations/soakSamples, 1) == 0 {
+			sample := takeSoakSample()
+			t.Logf("invocation %d: heap=%d KiB goroutines=%d", i+1, sample.heap>>10, sample.goroutines)
+		}
+	}
+	final := takeSoakSample()
+	t.Logf("%d invocations and %d batches in %s", invocations, client.calls.Load(), time.Since(start).Round(time.Millisecond))
+
+	if client.calls.Load() == 0 {
+		t.Fatal("no batch reached the client")
+	}
+	if final.heap > baseline.heap+maxHeapGrowth {
+		t.Errorf("heap grew from %d KiB to %d KiB, more than %d KiB", baseline.heap>>10, final.heap>>10, maxHeapGrowth>>10)
+	}
+	if final.goroutines > baseline.goroutines+maxGoroutineGrowth {
+		t.Errorf("goroutines grew from %d to %d, more than %d", baseline.goroutines, final.goroutines, maxGoroutineGrowth)
+	}
+}
+
+// soakSetting returns the positive integer held by the environment variable, or the default when unset.
+func soakSetting(t *testing.T, name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		t.Fatalf("%s must be a positive integer, got %q", name, value)
+	}
+	return n
+}
+
+// soakPayload returns an OCI logging payload of records spread over a few log groups.
+func soakPayload(t *testing.T, records int, seed int) []byte {
+	events := make([]map[string]interface{}, records)
+	for i := range events {
+		events[i] = map[string]interface{}{
+			"id":     fmt.Sprintf("soak-%d-%d", seed, i),
+			"source": "soak",
+			"time":   "2023-01-01T12:00:00Z",
+			"type":   "com.oraclecloud.logging.custom.application",
+			"oracle": map[string]interface{}{
+				"loggroupid": fmt.Sprintf("ocid1.loggroup.oc1..soak%d", i%4),
+				"logid":      fmt.Sprintf("ocid1.log.oc1..soak%d", i%8),
+			},
+			"data": map[string]interface{}{
+				"level":   "INFO",
+				"message": fmt.Sprintf("soak message %d of payload %d", i, seed),
+			},
+		}
+	}
+	payload, err := json.Marshal(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+)
+
+// Startup failure classes, each with its own exit code.
+const (
+	FailureConfig       = "config"       // FailureConfig is an invalid configuration.
+	FailureCredentials  = "credentials"  // FailureCredentials is a license key rejected by New Relic or missing from Vault.
+	FailureDependency   = "dependency"   // FailureDependency is an OCI service the startup depends on failing.
+	FailureConnectivity = "connectivity" // FailureConnectivity is New Relic being unreachable.
+)
+
+// Exit codes of a failed startup per failure class, following sysexits.h, so that orchestrators can tell a
+// configuration to fix from an outage to wait out.
+var exitCodes = map[string]int{
+	FailureConfig:       78, // EX_CONFIG
+	FailureCredentials:  77, // EX_NOPERM
+	FailureDependency:   69, // EX_UNAVAILABLE
+	FailureConnectivity: 75, // EX_TEMPFAIL
+}
+
+// Statuses of startup checks and reports.
+const (
+	startupOK      = "ok"
+	startupFailed  = "failed"
+	startupSkipped = "skipped"
+)
+
+// StartupReport is the machine-readable result of the startup checks.
+type StartupReport struct {
+	Status   string               `json:"status"`
+	Version  string               `json:"version"`
+	Class    string               `json:"class,omitempty"`    // Class is the failure class of a failed startup.
+	ExitCode int                  `json:"exitCode,omitempty"` // ExitCode is the exit code of a failed startup.
+	Checks   []StartupCheckReport `json:"checks"`
+}
+
+// StartupCheckReport is the result of one startup check. Checks after a failed one are skipped.
+type StartupCheckReport struct {
+	Name       string `json:"name"`
+	Class      string `json:"class"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// startupCheck is one step of the startup, failing with the given class, or with the class returned by classify
+// when set.
+type startupCheck struct {
+	name     string
+	class    string
+	classify func(error) string
+	run      func() error
+}
+
+// startupReport is the report of the startup of the container, included in the health report.
+var startupReport *StartupReport
+
+// exit terminates the process; replaced in tests.
+var exit = os.Exit
+
+// runStartupChecks runs the checks in order until one fails and returns the report.
+func runStartupChecks(checks []startupCheck) StartupReport {
+	report := StartupReport{Status: startupOK, Version: common.InstrumentationVersion, Checks: []StartupCheckReport{}}
+	for _, check := range checks {
+		result := StartupCheckReport{Name: check.name, Class: check.class, Status: startupSkipped}
+		if report.Status == startupOK {
+			start := time.Now()
+			err := check.run()
+			result.DurationMs = time.Since(start).Milliseconds()
+			result.Status = startupOK
+			if err != nil {
+				if check.classify != nil {
+					result.Class = check.classify(err)
+				}
+				result.Status, result.Error = startupFailed, err.Error()
+				report.Status, report.Class, report.ExitCode = startupFailed, result.Class, exitCodes[result.Class]
+			}
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
+
+// checkStartup runs the startup checks and publishes the report to STARTUP_REPORT_FILE. A failed startup also
+// writes the report to stderr and exits with the exit code of its failure class.
+func checkStartup(checks []startupCheck) {
+	slog := logger.FromContext(context.Background())
+	report := runStartupChecks(checks)
+	startupReport = &report
+	data, err := json.Marshal(report)
+	if err != nil {
+		slog.Errorf("error encoding startup report: %v", err)
+	}
+	if path := os.Getenv(common.StartupReportFile); path != "" && err == nil {
+		if writeErr := os.WriteFile(path, append(data, '\n'), 0o644); writeErr != nil {
+			slog.Warnf("error writing startup report to %s: %v", path, writeErr)
+		}
+	}
+	if report.Status == startupOK {
+		slog.Infof("Passed %d startup checks", len(report.Checks))
+		return
+	}
+
+	if err == nil {
+		_, _ = os.Stderr.Write(append(data, '\n'))
+	}
+	for _, check := range report.Checks {
+		if check.Status == startupFailed {
+			slog.Errorf("startup check %s failed (%s): %s", check.Name, check.Class, check.Error)
+		}
+	}
+	exit(report.ExitCode)
+}
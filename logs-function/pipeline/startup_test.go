@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunStartupChecks tests that the checks after a failed one are skipped and the failure class sets the exit code.
+func TestRunStartupChecks(t *testing.T) {
+	ran := 0
+	ok := func() error { ran++; return nil }
+	report := runStartupChecks([]startupCheck{
+		{name: "configPreset", class: FailureConfig, run: ok},
+		{name: "routeCredentials", class: FailureCredentials, run: func() error { return errors.New("broken account routes: sec") }},
+		{name: "dlqWriter", class: FailureDependency, run: ok},
+	})
+
+	assert.Equal(t, 1, ran)
+	assert.Equal(t, startupFailed, report.Status)
+	assert.Equal(t, FailureCredentials, report.Class)
+	assert.Equal(t, 77, report.ExitCode)
+	assert.Equal(t, []string{startupOK, startupFailed, startupSkipped},
+		[]string{report.Checks[0].Status, report.Checks[1].Status, report.Checks[2].Status})
+	assert.Equal(t, "broken account routes: sec", report.Checks[1].Error)
+
+	report = runStartupChecks([]startupCheck{{name: "configPreset", class: FailureConfig, run: ok}})
+	assert.Equal(t, startupOK, report.Status)
+	assert.Zero(t, report.ExitCode)
+}
+
+// TestRunStartupChecksClassify tests that a check classifying its failure overrides its declared class.
+func TestRunStartupChecksClassify(t *testing.T) {
+	report := runStartupChecks([]startupCheck{{
+		name:     "routeCredentials",
+		class:    FailureCredentials,
+		classify: func(error) string { return FailureConnectivity },
+		run:      func() error { return errors.New("connection refused") },
+	}})
+
+	assert.Equal(t, FailureConnectivity, report.Class)
+	assert.Equal(t, 75, report.ExitCode)
+	assert.Equal(t, FailureConnectivity, report.Checks[0].Class)
+}
+
+// TestClassifyRouteFailure tests the failure classes of route validation errors.
+func TestClassifyRouteFailure(t *testing.T) {
+	route := func(err error) error { return errors.Join(fmt.Errorf("account route %q: %w", "sec", err)) }
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"rejected license key", route(&util.LogAPIError{StatusCode: 403, Class: util.ErrorClassAuth}), FailureCredentials},
+		{"wrong key type", route(&util.KeyTypeError{KeyType: util.KeyTypeUser}), FailureCredentials},
+		{"server error", route(&util.LogAPIError{StatusCode: 503, Class: util.ErrorClassServer}), FailureConnectivity},
+		{"unreachable", route(errors.New("dial tcp: i/o timeout")), FailureConnectivity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, classifyRouteFailure(tt.err))
+		})
+	}
+}
+
+// TestCheckStartup tests that the report is written to STARTUP_REPORT_FILE and a failed startup exits with its code.
+func TestCheckStartup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.json")
+	t.Setenv(common.StartupReportFile, path)
+	var code int
+	exit = func(c int) { code = c }
+	t.Cleanup(func() { exit, startupReport = os.Exit, nil })
+
+	checkStartup([]startupCheck{{name: "configPreset", class: FailureConfig, run: func() error { return nil }}})
+	assert.Zero(t, code)
+	require.NotNil(t, startupReport)
+	assert.Equal(t, startupOK, startupReport.Status)
+
+	checkStartup([]startupCheck{{name: "hostResolution", class: FailureConfig, run: func() error {
+		return errors.New("invalid NEW_RELIC_HOST_OVERRIDES")
+	}}})
+	assert.Equal(t, 78, code)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var report StartupReport
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, startupFailed, report.Status)
+	assert.Equal(t, FailureConfig, report.Class)
+	assert.Equal(t, "hostResolution", report.Checks[0].Name)
+}
+
+// TestStartupChecks tests that the Log API connectivity check only runs when enabled.
+func TestStartupChecks(t *testing.T) {
+	names := func() []string {
+		var names []string
+		for _, check := range startupChecks() {
+			names = append(names, check.name)
+		}
+		return names
+	}
+	assert.NotContains(t, names(), "logApi")
+	t.Setenv(common.StartupConnectivityCheck, "true")
+	assert.Contains(t, names(), "logApi")
+}
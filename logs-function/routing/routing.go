@@ -0,0 +1,135 @@
+// Package routing selects the New Relic account each OCI log record is forwarded to.
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/transform"
+)
+
+// DefaultAlias is the alias of the account configured through the SECRET_OCID environment variable.
+const DefaultAlias = "default"
+
+// Route maps records from a set of compartments, log groups or custom log subjects to the New Relic account
+// whose license key is stored in the referenced Vault secret.
+type Route struct {
+	Alias        string   `json:"alias"`                  // Alias names the account in logs and in the accountAlias attribute.
+	SecretOCID   string   `json:"secretOcid"`             // SecretOCID is the Vault secret holding the account's license key.
+	Compartments []string `json:"compartments,omitempty"` // Compartments lists the compartment OCIDs routed to this account.
+	LogGroups    []string `json:"logGroups,omitempty"`    // LogGroups lists the log group OCIDs routed to this account.
+	Subjects     []string `json:"subjects,omitempty"`     // Subjects lists path.Match patterns of the subjects of custom logs routed to this account.
+}
+
+// LoadRoutes reads the routing table from the function environment.
+// It returns no routes when the environment variable is unset.
+func LoadRoutes() ([]Route, error) {
+	return ParseRoutes(os.Getenv(common.AccountRoutes))
+}
+
+// ParseRoutes parses and validates a JSON routing table.
+func ParseRoutes(value string) ([]Route, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var routes []Route
+	if err := json.Unmarshal([]byte(value), &routes); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", common.AccountRoutes, err)
+	}
+	if err := validateRoutes(routes); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", common.AccountRoutes, err)
+	}
+	return routes, nil
+}
+
+// Override replaces the routing table and transform profile of a single invocation.
+type Override struct {
+	Routes  []Route `json:"routes"`            // Routes is the routing table used for the invocation.
+	Profile string  `json:"profile,omitempty"` // Profile, when set, names the transform profile applied to every record.
+}
+
+// ParseOverride parses and validates the JSON value of the routing override header.
+func ParseOverride(value string) (Override, error) {
+	var override Override
+	if err := json.Unmarshal([]byte(value), &override); err != nil {
+		return Override{}, fmt.Errorf("invalid %s header: %w", common.RoutingOverrideHeader, err)
+	}
+	if err := validateRoutes(override.Routes); err != nil {
+		return Override{}, fmt.Errorf("invalid %s header: %w", common.RoutingOverrideHeader, err)
+	}
+	if override.Profile != "" && override.Profile != transform.ProfileStable && override.Profile != transform.ProfileCanary {
+		return Override{}, fmt.Errorf("invalid %s header: unknown transform profile %q", common.RoutingOverrideHeader, override.Profile)
+	}
+	return override, nil
+}
+
+// validateRoutes checks that every route has a unique, non-reserved alias, a secret and valid subject patterns.
+func validateRoutes(routes []Route) error {
+	seen := make(map[string]bool, len(routes))
+	for i, route := range routes {
+		switch {
+		case route.Alias == "":
+			return fmt.Errorf("route %d has no alias", i)
+		case route.Alias == DefaultAlias:
+			return fmt.Errorf("alias %q is reserved", DefaultAlias)
+		case seen[route.Alias]:
+			return fmt.Errorf("duplicate alias %q", route.Alias)
+		case route.SecretOCID == "":
+			return fmt.Errorf("route %q has no secretOcid", route.Alias)
+		}
+		for _, pattern := range route.Subjects {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("route %q has an invalid subject pattern %q: %w", route.Alias, pattern, err)
+			}
+		}
+		seen[route.Alias] = true
+	}
+	return nil
+}
+
+// Match returns the alias of the first route matching the record, or DefaultAlias when none does.
+func Match(routes []Route, record map[string]interface{}) string {
+	if len(routes) == 0 {
+		return DefaultAlias
+	}
+
+	compartmentID := common.CompartmentID(record)
+	logGroupID := common.LogGroupID(record)
+	subject := common.Subject(record)
+	for _, route := range routes {
+		if contains(route.LogGroups, logGroupID) || contains(route.Compartments, compartmentID) || matchesAny(route.Subjects, subject) {
+			return route.Alias
+		}
+	}
+	return DefaultAlias
+}
+
+// matchesAny reports whether value is non-empty and matches one of the path.Match patterns.
+func matchesAny(patterns []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether value is a non-empty member of items.
+func contains(items []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
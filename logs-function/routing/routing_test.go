@@ -0,0 +1,132 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseRoutes tests parsing and validation of the routing table.
+func TestParseRoutes(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expectedCount int
+		expectedError string
+	}{
+		{"unset", "", 0, ""},
+		{"valid routes", `[{"alias":"sec","secretOcid":"ocid1.vaultsecret.a","compartments":["c1"]},{"alias":"app","secretOcid":"ocid1.vaultsecret.b","logGroups":["lg1"]}]`, 2, ""},
+		{"invalid json", `[{"alias":`, 0, "invalid ACCOUNT_ROUTES"},
+		{"missing alias", `[{"secretOcid":"ocid1.vaultsecret.a"}]`, 0, "has no alias"},
+		{"reserved alias", `[{"alias":"default","secretOcid":"ocid1.vaultsecret.a"}]`, 0, "is reserved"},
+		{"duplicate alias", `[{"alias":"a","secretOcid":"s1"},{"alias":"a","secretOcid":"s2"}]`, 0, "duplicate alias"},
+		{"missing secret", `[{"alias":"a"}]`, 0, "has no secretOcid"},
+		{"subject routes", `[{"alias":"pay","secretOcid":"s1","subjects":["/var/log/payments/*"]}]`, 1, ""},
+		{"invalid subject pattern", `[{"alias":"pay","secretOcid":"s1","subjects":["/var/log/["]}]`, 0, "invalid subject pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := ParseRoutes(tt.value)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, routes, tt.expectedCount)
+		})
+	}
+}
+
+// TestMatch tests record matching against compartments, log groups and subjects.
+func TestMatch(t *testing.T) {
+	routes := []Route{
+		{Alias: "sec", SecretOCID: "s1", Compartments: []string{"ocid1.compartment.sec"}},
+		{Alias: "app", SecretOCID: "s2", LogGroups: []string{"ocid1.loggroup.app"}},
+		{Alias: "pay", SecretOCID: "s3", Subjects: []string{"/var/log/payments/*.log"}},
+	}
+
+	tests := []struct {
+		name     string
+		routes   []Route
+		record   map[string]interface{}
+		expected string
+	}{
+		{"no routes", nil, map[string]interface{}{}, DefaultAlias},
+		{
+			"compartment match",
+			routes,
+			map[string]interface{}{"oracle": map[string]interface{}{"compartmentid": "ocid1.compartment.sec"}},
+			"sec",
+		},
+		{
+			"audit compartment match",
+			routes,
+			map[string]interface{}{"data": map[string]interface{}{"compartmentId": "ocid1.compartment.sec"}},
+			"sec",
+		},
+		{
+			"log group match",
+			routes,
+			map[string]interface{}{"oracle": map[string]interface{}{"loggroupid": "ocid1.loggroup.app", "compartmentid": "other"}},
+			"app",
+		},
+		{
+			"subject match",
+			routes,
+			map[string]interface{}{"subject": "/var/log/payments/api.log", "oracle": map[string]interface{}{"compartmentid": "other"}},
+			"pay",
+		},
+		{
+			"subject pattern does not cross directories",
+			routes,
+			map[string]interface{}{"subject": "/var/log/payments/old/api.log"},
+			DefaultAlias,
+		},
+		{
+			"no match falls back to default",
+			routes,
+			map[string]interface{}{"oracle": map[string]interface{}{"compartmentid": "other"}},
+			DefaultAlias,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Match(tt.routes, tt.record))
+		})
+	}
+}
+
+// TestParseOverride tests parsing and validation of the routing override header.
+func TestParseOverride(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expected      Override
+		expectedError string
+	}{
+		{
+			"routes and profile",
+			`{"routes":[{"alias":"sec","secretOcid":"s1","compartments":["c1"]}],"profile":"canary"}`,
+			Override{Routes: []Route{{Alias: "sec", SecretOCID: "s1", Compartments: []string{"c1"}}}, Profile: "canary"},
+			"",
+		},
+		{"profile only", `{"profile":"stable"}`, Override{Profile: "stable"}, ""},
+		{"invalid json", `{"routes":`, Override{}, "invalid X-NR-Routing header"},
+		{"invalid route", `{"routes":[{"alias":"default","secretOcid":"s1"}]}`, Override{}, "is reserved"},
+		{"unknown profile", `{"profile":"beta"}`, Override{}, "unknown transform profile"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			override, err := ParseOverride(tt.value)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, override)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package transform
+
+import (
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// parseAuditHeaderAllowlist parses the AUDIT_HEADER_ALLOWLIST value into lower-case header names, using the
+// default allowlist when it is unset and returning nil, which keeps every header, for "*".
+func parseAuditHeaderAllowlist(value string) map[string]bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		value = common.DefaultAuditHeaderAllowlist
+	}
+	if value == "*" {
+		return nil
+	}
+	return toSet(splitList(strings.ToLower(value)))
+}
+
+// applyAuditHeaderAllowlist drops the request and response headers of _Audit records that are not allowlisted,
+// since the full header arrays repeated on every audit event make up much of its size. The number of dropped
+// headers is kept on the record.
+func applyAuditHeaderAllowlist(record map[string]interface{}, opts Options) {
+	if opts.AuditHeaderAllowlist == nil || !isAuditRecord(record) {
+		return
+	}
+
+	dropped := 0
+	for _, section := range []string{"request", "response"} {
+		if headers, ok := common.LookupValue(record, "data", section, "headers"); ok {
+			if headers, ok := headers.(map[string]interface{}); ok {
+				for name := range headers {
+					if !opts.AuditHeaderAllowlist[strings.ToLower(name)] {
+						delete(headers, name)
+						dropped++
+					}
+				}
+			}
+		}
+	}
+	if dropped > 0 {
+		record[common.AuditDroppedHeadersAttribute] = dropped
+		metrics.Default.Counter("audit.headers.dropped").Add(int64(dropped))
+	}
+}
@@ -0,0 +1,70 @@
+package transform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// TestApplyAuditHeaderAllowlist tests that only allowlisted audit headers are kept and the others are counted.
+func TestApplyAuditHeaderAllowlist(t *testing.T) {
+	tests := []struct {
+		name             string
+		allowlist        string
+		expectedRequest  map[string]interface{}
+		expectedResponse map[string]interface{}
+		expectedDropped  interface{}
+	}{
+		{
+			name:             "default allowlist",
+			allowlist:        "",
+			expectedRequest:  map[string]interface{}{"User-Agent": []interface{}{"oci-cli/3.0"}},
+			expectedResponse: map[string]interface{}{"opc-request-id": []interface{}{"abc"}},
+			expectedDropped:  3,
+		},
+		{
+			name:             "custom allowlist",
+			allowlist:        "Set-Cookie, authorization",
+			expectedRequest:  map[string]interface{}{"Authorization": []interface{}{"Signature version=1"}},
+			expectedResponse: map[string]interface{}{"Set-Cookie": []interface{}{"session=1"}},
+			expectedDropped:  3,
+		},
+		{
+			name:      "all headers kept",
+			allowlist: "*",
+			expectedRequest: map[string]interface{}{
+				"Authorization": []interface{}{"Signature version=1"},
+				"opc-obo-token": []interface{}{"token"},
+				"User-Agent":    []interface{}{"oci-cli/3.0"},
+			},
+			expectedResponse: map[string]interface{}{"Set-Cookie": []interface{}{"session=1"}, "opc-request-id": []interface{}{"abc"}},
+			expectedDropped:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(common.AuditHeaderAllowlist, tt.allowlist)
+			record := auditRecord()
+			Apply(context.Background(), record, LoadOptions())
+
+			data := record["data"].(map[string]interface{})
+			assert.Equal(t, tt.expectedRequest, data["request"].(map[string]interface{})["headers"])
+			assert.Equal(t, tt.expectedResponse, data["response"].(map[string]interface{})["headers"])
+			assert.Equal(t, tt.expectedDropped, record[common.AuditDroppedHeadersAttribute])
+		})
+	}
+}
+
+// TestApplyAuditHeaderAllowlistSkipsOtherRecords tests that headers of non-audit records are left alone.
+func TestApplyAuditHeaderAllowlistSkipsOtherRecords(t *testing.T) {
+	headers := map[string]interface{}{"X-Custom": "1"}
+	record := map[string]interface{}{"message": "hello", "data": map[string]interface{}{"request": map[string]interface{}{"headers": headers}}}
+
+	Apply(context.Background(), record, LoadOptions())
+
+	assert.Equal(t, map[string]interface{}{"X-Custom": "1"}, headers)
+}
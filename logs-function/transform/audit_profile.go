@@ -0,0 +1,143 @@
+package transform
+
+import (
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/parser"
+)
+
+// AuditProfileStrict is the curated audit profile for security teams.
+const AuditProfileStrict = "strict"
+
+// auditLogType is the logtype set on audit records by the strict profile.
+const auditLogType = "oci_audit"
+
+// auditIdentityAttributes maps audit identity fields to the normalized attributes set by the strict profile.
+var auditIdentityAttributes = map[string]string{
+	"principalId":   "enduser.id",
+	"principalName": "enduser.name",
+	"authType":      "enduser.authType",
+	"ipAddress":     "client.address",
+	"userAgent":     "user_agent.original",
+	"tenantId":      "enduser.tenantId",
+	"callerId":      "caller.id",
+	"callerName":    "caller.name",
+}
+
+// auditDelegatedAttribute flags audit records of calls made by one actor on behalf of another.
+const auditDelegatedAttribute = "audit.delegated"
+
+// sensitiveAuditHeaders lists, in lower case, the request and response headers removed by the strict profile.
+var sensitiveAuditHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"opc-obo-token":       true,
+	"opc-principal":       true,
+	"x-content-sha256":    true,
+	"cookie":              true,
+	"set-cookie":          true,
+}
+
+// isAuditRecord reports whether the record comes from the OCI _Audit log.
+func isAuditRecord(record map[string]interface{}) bool {
+	if logGroupID := common.LogGroupID(record); logGroupID == "_Audit" {
+		return true
+	}
+	_, hasIdentity := common.LookupValue(record, "data", "identity")
+	_, hasEventName := common.LookupString(record, "data", "eventName")
+	return hasIdentity && hasEventName
+}
+
+// applyAuditProfile applies the configured audit profile to _Audit records.
+func applyAuditProfile(record map[string]interface{}, opts Options) {
+	if opts.AuditProfile != AuditProfileStrict || !isAuditRecord(record) {
+		return
+	}
+
+	if _, ok := record["logtype"]; !ok {
+		record["logtype"] = auditLogType
+	}
+
+	if audit, ok := parser.DecodeAudit(record); ok {
+		applyAuditIdentity(record, audit.Identity)
+		removeSensitiveHeaders(audit.Request.Headers)
+		removeSensitiveHeaders(audit.Response.Headers)
+		return
+	}
+
+	// The record does not fit the audit schema: read the fields of the expected types from the generic record
+	if identity, ok := common.LookupValue(record, "data", "identity"); ok {
+		if identity, ok := identity.(map[string]interface{}); ok {
+			for field, attribute := range auditIdentityAttributes {
+				if value, ok := identity[field].(string); ok && value != "" {
+					record[attribute] = value
+				}
+			}
+			principalID, _ := identity["principalId"].(string)
+			callerID, _ := identity["callerId"].(string)
+			applyAuditActors(record, principalID, callerID)
+		}
+	}
+
+	for _, section := range []string{"request", "response"} {
+		if headers, ok := common.LookupValue(record, "data", section, "headers"); ok {
+			if headers, ok := headers.(map[string]interface{}); ok {
+				removeSensitiveHeaders(headers)
+			}
+		}
+	}
+}
+
+// applyAuditIdentity sets the normalized attributes of a decoded audit identity.
+func applyAuditIdentity(record map[string]interface{}, identity parser.AuditIdentity) {
+	if identity == (parser.AuditIdentity{}) {
+		return
+	}
+	for attribute, value := range map[string]string{
+		"enduser.id":          identity.PrincipalID,
+		"enduser.name":        identity.PrincipalName,
+		"enduser.authType":    identity.AuthType,
+		"client.address":      identity.IPAddress,
+		"user_agent.original": identity.UserAgent,
+		"enduser.tenantId":    identity.TenantID,
+		"caller.id":           identity.CallerID,
+		"caller.name":         identity.CallerName,
+	} {
+		if value != "" {
+			record[attribute] = value
+		}
+	}
+	applyAuditActors(record, identity.PrincipalID, identity.CallerID)
+}
+
+// applyAuditActors models the two actors of an audit event: the principal whose authority the call used
+// (enduser.*) and the caller that made it (caller.*), which differ for on-behalf-of calls such as a service
+// acting for a user. The actor types are derived from their OCIDs, and delegated calls are flagged.
+func applyAuditActors(record map[string]interface{}, principalID, callerID string) {
+	if kind := ocidType(principalID); kind != "" {
+		record["enduser.type"] = kind
+	}
+	if kind := ocidType(callerID); kind != "" {
+		record["caller.type"] = kind
+	}
+	record[auditDelegatedAttribute] = callerID != "" && principalID != "" && callerID != principalID
+}
+
+// ocidType returns the resource type of an OCID, e.g. "user" for "ocid1.user.oc1..aaaa", or "" when id is not an OCID.
+func ocidType(id string) string {
+	parts := strings.SplitN(id, ".", 3)
+	if len(parts) < 3 || parts[0] != "ocid1" {
+		return ""
+	}
+	return parts[1]
+}
+
+// removeSensitiveHeaders deletes credential-bearing headers, matching names case-insensitively.
+func removeSensitiveHeaders(headers map[string]interface{}) {
+	for name := range headers {
+		if sensitiveAuditHeaders[strings.ToLower(name)] {
+			delete(headers, name)
+		}
+	}
+}
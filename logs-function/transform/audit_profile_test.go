@@ -0,0 +1,126 @@
+package transform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// auditRecord returns an OCI audit event as delivered by Connector Hub.
+func auditRecord() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "com.oraclecloud.ComputeApi.GetInstance",
+		"oracle": map[string]interface{}{
+			"loggroupid": "_Audit",
+		},
+		"data": map[string]interface{}{
+			"eventName": "GetInstance",
+			"identity": map[string]interface{}{
+				"principalId":   "ocid1.user.oc1..alice",
+				"principalName": "alice",
+				"ipAddress":     "10.0.0.1",
+				"userAgent":     "oci-cli/3.0",
+				"authType":      "natv",
+			},
+			"request": map[string]interface{}{
+				"headers": map[string]interface{}{
+					"Authorization": []interface{}{"Signature version=1"},
+					"opc-obo-token": []interface{}{"token"},
+					"User-Agent":    []interface{}{"oci-cli/3.0"},
+				},
+			},
+			"response": map[string]interface{}{
+				"headers": map[string]interface{}{
+					"Set-Cookie":     []interface{}{"session=1"},
+					"opc-request-id": []interface{}{"abc"},
+				},
+			},
+		},
+	}
+}
+
+// TestApplyAuditProfileStrict tests the strict audit profile.
+func TestApplyAuditProfileStrict(t *testing.T) {
+	record := auditRecord()
+	Apply(context.Background(), record, Options{AuditProfile: AuditProfileStrict})
+
+	assert.Equal(t, "oci_audit", record["logtype"])
+	assert.Equal(t, "ocid1.user.oc1..alice", record["enduser.id"])
+	assert.Equal(t, "alice", record["enduser.name"])
+	assert.Equal(t, "10.0.0.1", record["client.address"])
+	assert.Equal(t, "oci-cli/3.0", record["user_agent.original"])
+	assert.Equal(t, "natv", record["enduser.authType"])
+	assert.Equal(t, "user", record["enduser.type"])
+	assert.Equal(t, false, record["audit.delegated"])
+
+	data := record["data"].(map[string]interface{})
+	requestHeaders := data["request"].(map[string]interface{})["headers"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"User-Agent": []interface{}{"oci-cli/3.0"}}, requestHeaders)
+	responseHeaders := data["response"].(map[string]interface{})["headers"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"opc-request-id": []interface{}{"abc"}}, responseHeaders)
+}
+
+// TestApplyAuditProfileActors tests that the principal and caller of on-behalf-of calls are modeled separately.
+func TestApplyAuditProfileActors(t *testing.T) {
+	tests := []struct {
+		name               string
+		callerID           string
+		callerName         string
+		expectedCallerType interface{}
+		expectedDelegated  bool
+	}{
+		{"service on behalf of user", "ocid1.instance.oc1.iad.worker", "worker", "instance", true},
+		{"caller is principal", "ocid1.user.oc1..alice", "alice", "user", false},
+		{"no caller", "", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := auditRecord()
+			identity := record["data"].(map[string]interface{})["identity"].(map[string]interface{})
+			if tt.callerID != "" {
+				identity["callerId"] = tt.callerID
+				identity["callerName"] = tt.callerName
+			}
+			Apply(context.Background(), record, Options{AuditProfile: AuditProfileStrict})
+
+			assert.Equal(t, "ocid1.user.oc1..alice", record["enduser.id"])
+			assert.Equal(t, tt.expectedCallerType, record["caller.type"])
+			assert.Equal(t, tt.expectedDelegated, record["audit.delegated"])
+			if tt.callerID != "" {
+				assert.Equal(t, tt.callerID, record["caller.id"])
+				assert.Equal(t, tt.callerName, record["caller.name"])
+			} else {
+				assert.NotContains(t, record, "caller.id")
+			}
+		})
+	}
+}
+
+// TestApplyAuditProfileSkipped tests that the profile leaves other records and unset profiles alone.
+func TestApplyAuditProfileSkipped(t *testing.T) {
+	record := auditRecord()
+	Apply(context.Background(), record, Options{})
+	assert.NotContains(t, record, "logtype")
+
+	appLog := map[string]interface{}{"message": "hello", "oracle": map[string]interface{}{"loggroupid": "ocid1.loggroup.app"}}
+	Apply(context.Background(), appLog, Options{AuditProfile: AuditProfileStrict})
+	assert.NotContains(t, appLog, "logtype")
+}
+
+// TestApplyAuditProfileStrictFallback tests that an audit record not fitting the audit schema is still
+// normalized from its fields of the expected types.
+func TestApplyAuditProfileStrictFallback(t *testing.T) {
+	record := auditRecord()
+	data := record["data"].(map[string]interface{})
+	data["response"].(map[string]interface{})["status"] = "unknown"
+	data["identity"].(map[string]interface{})["userAgent"] = []interface{}{"oci-cli/3.0"}
+	Apply(context.Background(), record, Options{AuditProfile: AuditProfileStrict})
+
+	assert.Equal(t, "ocid1.user.oc1..alice", record["enduser.id"])
+	assert.NotContains(t, record, "user_agent.original")
+	assert.Equal(t, "user", record["enduser.type"])
+	requestHeaders := data["request"].(map[string]interface{})["headers"].(map[string]interface{})
+	assert.NotContains(t, requestHeaders, "Authorization")
+}
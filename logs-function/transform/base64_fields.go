@@ -0,0 +1,137 @@
+package transform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+)
+
+// Supported policies for large base64-encoded field values.
+const (
+	Base64PolicyKeep     = "keep"     // Base64PolicyKeep forwards the value unchanged.
+	Base64PolicyDrop     = "drop"     // Base64PolicyDrop removes the field from the record.
+	Base64PolicyHash     = "hash"     // Base64PolicyHash replaces the value with its SHA-256 digest.
+	Base64PolicyTruncate = "truncate" // Base64PolicyTruncate keeps only the first Base64MaxBytes of the value.
+)
+
+// truncatedSuffix marks values shortened by the truncate policy.
+const truncatedSuffix = "...[truncated]"
+
+// messageField is the field holding the log line, never subject to the policy however it looks.
+const messageField = "message"
+
+// applyBase64Policy walks the record and applies the configured policy to every string value
+// larger than the threshold that looks like base64-encoded binary data.
+func applyBase64Policy(ctx context.Context, record map[string]interface{}, opts Options) {
+	if opts.Base64Policy == "" || opts.Base64Policy == Base64PolicyKeep {
+		return
+	}
+	applyBase64PolicyToMap(ctx, record, opts)
+}
+
+// applyBase64PolicyToMap applies the policy to the string values of the map, other than message fields, and
+// recurses into its nested maps and arrays.
+func applyBase64PolicyToMap(ctx context.Context, node map[string]interface{}, opts Options) {
+	for key, value := range node {
+		switch v := value.(type) {
+		case string:
+			if key == messageField || !isLargeBase64(v, opts.Base64MaxBytes) {
+				continue
+			}
+			if replacement, keep := replaceBase64(ctx, v, opts); keep {
+				node[key] = replacement
+			} else {
+				delete(node, key)
+			}
+		case map[string]interface{}:
+			applyBase64PolicyToMap(ctx, v, opts)
+		case []interface{}:
+			applyBase64PolicyToSlice(ctx, v, opts)
+		}
+	}
+}
+
+// applyBase64PolicyToSlice applies the policy to the string elements of the array and recurses into its nested
+// maps and arrays.
+func applyBase64PolicyToSlice(ctx context.Context, node []interface{}, opts Options) {
+	for i, value := range node {
+		switch v := value.(type) {
+		case string:
+			if !isLargeBase64(v, opts.Base64MaxBytes) {
+				continue
+			}
+			// Array elements cannot be removed without shifting indices, so drop leaves an empty string.
+			node[i], _ = replaceBase64(ctx, v, opts)
+		case map[string]interface{}:
+			applyBase64PolicyToMap(ctx, v, opts)
+		case []interface{}:
+			applyBase64PolicyToSlice(ctx, v, opts)
+		}
+	}
+}
+
+// replaceBase64 returns the replacement value for a large base64 string and whether the field should be kept.
+func replaceBase64(ctx context.Context, value string, opts Options) (string, bool) {
+	switch opts.Base64Policy {
+	case Base64PolicyDrop:
+		return "", false
+	case Base64PolicyHash:
+		sum := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(sum[:]), true
+	case Base64PolicyTruncate:
+		return value[:opts.Base64MaxBytes] + truncatedSuffix, true
+	default:
+		logger.FromContext(ctx).Warnf("Ignoring unknown base64 field policy: %s", opts.Base64Policy)
+		return value, true
+	}
+}
+
+// base64ChunkSize is the number of encoded characters isLargeBase64 decodes at a time, a multiple of 4.
+const base64ChunkSize = 256
+
+// isLargeBase64 reports whether value exceeds maxBytes and is valid standard base64: optionally wrapped over
+// lines, a length that is a multiple of 4 with padding only at the end, and content that decodes. Values made
+// only of hex digits are valid base64 but rejected, as they are far more likely digests or identifiers than
+// encoded binary data. The value is decoded chunk by chunk into a fixed buffer, so large values are checked
+// without allocating.
+func isLargeBase64(value string, maxBytes int) bool {
+	if len(value) <= maxBytes {
+		return false
+	}
+	var chunk [base64ChunkSize]byte
+	var decoded [base64ChunkSize / 4 * 3]byte
+	n, hexOnly, padded := 0, true, false
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '\r' || c == '\n' {
+			continue
+		}
+		if padded {
+			return false
+		}
+		hexOnly = hexOnly && isHexDigit(c)
+		chunk[n] = c
+		n++
+		if n == len(chunk) {
+			if _, err := base64.StdEncoding.Decode(decoded[:], chunk[:n]); err != nil {
+				return false
+			}
+			padded = chunk[n-1] == '='
+			n = 0
+		}
+	}
+	if n > 0 {
+		if _, err := base64.StdEncoding.Decode(decoded[:], chunk[:n]); err != nil {
+			return false
+		}
+	}
+	return !hexOnly
+}
+
+// isHexDigit reports whether c is one of [0-9a-fA-F].
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
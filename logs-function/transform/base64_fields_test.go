@@ -0,0 +1,125 @@
+package transform
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestApplyBase64Policy tests each policy against large base64 values at the top level and nested in arrays.
+func TestApplyBase64Policy(t *testing.T) {
+	payload := strings.Repeat("QUJD", 8) // 32 bytes of base64
+	record := func() map[string]interface{} {
+		return map[string]interface{}{
+			"message": "short text value that is not base64 because it has spaces in it",
+			"data": map[string]interface{}{
+				"request": map[string]interface{}{"payload": payload},
+				"parts":   []interface{}{payload, "ok"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		policy          string
+		expectedPayload interface{}
+		expectedPresent bool
+		expectedPart    interface{}
+	}{
+		{
+			name:            "keep leaves values unchanged",
+			policy:          Base64PolicyKeep,
+			expectedPayload: payload,
+			expectedPresent: true,
+			expectedPart:    payload,
+		},
+		{
+			name:            "drop removes the field",
+			policy:          Base64PolicyDrop,
+			expectedPresent: false,
+			expectedPart:    "",
+		},
+		{
+			name:            "hash replaces the value with its digest",
+			policy:          Base64PolicyHash,
+			expectedPayload: "sha256:7b39df2009f9f4d1a7fbd60c4fd3cecbc983a573a6eaaeb787ae6bb55989b961",
+			expectedPresent: true,
+			expectedPart:    "sha256:7b39df2009f9f4d1a7fbd60c4fd3cecbc983a573a6eaaeb787ae6bb55989b961",
+		},
+		{
+			name:            "truncate keeps the first max bytes",
+			policy:          Base64PolicyTruncate,
+			expectedPayload: payload[:16] + truncatedSuffix,
+			expectedPresent: true,
+			expectedPart:    payload[:16] + truncatedSuffix,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := record()
+			applyBase64Policy(context.Background(), r, Options{Base64Policy: tt.policy, Base64MaxBytes: 16})
+
+			data := r["data"].(map[string]interface{})
+			value, present := data["request"].(map[string]interface{})["payload"]
+			assert.Equal(t, tt.expectedPresent, present)
+			if tt.expectedPresent {
+				assert.Equal(t, tt.expectedPayload, value)
+			}
+			assert.Equal(t, tt.expectedPart, data["parts"].([]interface{})[0])
+			assert.Equal(t, "ok", data["parts"].([]interface{})[1])
+			assert.Contains(t, r["message"], "short text")
+		})
+	}
+}
+
+// TestIsLargeBase64 tests base64 detection.
+func TestIsLargeBase64(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		maxBytes int
+		expected bool
+	}{
+		{"padded base64", "QUJDREVGR0g=", 4, true},
+		{"unpadded length", "QUJDREVGR0g", 4, false},
+		{"wrapped over lines", "QUJDREVG\nR0hJ", 4, true},
+		{"below threshold", "QUJDREVGR0g=", 64, false},
+		{"plain text", "not base64 at all", 4, false},
+		{"long word", "Supercalifragilisticexpialidocious", 4, false},
+		{"URL-safe alphabet", "QUJD-_VGR0hJ", 4, false},
+		{"misplaced padding", "QUJD=EVGR0hJ", 4, false},
+		{"excess padding", "QUJDREVGR===", 4, false},
+		{"hex digest", "7b39df2009f9f4d1a7fbd60c4fd3cecbc983a573a6eaaeb787ae6bb55989b961", 4, false},
+		{"upper case hex", "7B39DF2009F9F4D1", 4, false},
+		{"only line breaks", "\r\n\r\n\r\n", 4, false},
+		{"spans several chunks", strings.Repeat("QUJD", base64ChunkSize) + "QUI=", 4, true},
+		{"padding before the last chunk", strings.Repeat("QUJD", base64ChunkSize/4-1) + "QUI=" + "QUJD", 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isLargeBase64(tt.value, tt.maxBytes))
+		})
+	}
+}
+
+// TestIsLargeBase64Allocations tests that large values are checked without allocating.
+func TestIsLargeBase64Allocations(t *testing.T) {
+	value := strings.Repeat("QUJD", 16*base64ChunkSize)
+	assert.Zero(t, testing.AllocsPerRun(10, func() { isLargeBase64(value, 4) }))
+}
+
+// TestApplyBase64PolicyMessage tests that message fields are left untouched even when they look like base64.
+func TestApplyBase64PolicyMessage(t *testing.T) {
+	payload := strings.Repeat("QUJD", 8)
+	record := map[string]interface{}{
+		"message": payload,
+		"data":    map[string]interface{}{"message": payload, "payload": payload},
+	}
+	applyBase64Policy(context.Background(), record, Options{Base64Policy: Base64PolicyDrop, Base64MaxBytes: 16})
+
+	assert.Equal(t, payload, record["message"])
+	assert.Equal(t, map[string]interface{}{"message": payload}, record["data"])
+}
@@ -0,0 +1,83 @@
+package transform
+
+import (
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// Categories stamped as source.category, giving the same high-level facets across all OCI sources.
+const (
+	CategoryAudit       = "audit"       // CategoryAudit is the category of the events of the OCI _Audit log.
+	CategoryNetwork     = "network"     // CategoryNetwork is the category of flow, load balancer, DNS and proxy logs.
+	CategoryApplication = "application" // CategoryApplication is the category of custom, function and API gateway logs.
+	CategorySecurity    = "security"    // CategorySecurity is the category of Cloud Guard, Bastion, WAF and Vault access records.
+	CategoryPlatform    = "platform"    // CategoryPlatform is the category of the other OCI service logs.
+)
+
+// oracleTypePrefix is the prefix of the record types of OCI service logs.
+const oracleTypePrefix = "com.oraclecloud."
+
+// parserCategories maps the built-in parsers to the category of the records they recognize.
+var parserCategories = map[string]string{
+	"cloudGuard":       CategorySecurity,
+	"bastion":          CategorySecurity,
+	"vault":            CategorySecurity,
+	"flowLogs":         CategoryNetwork,
+	"loadBalancer":     CategoryNetwork,
+	"envoy":            CategoryNetwork,
+	"alarm":            CategoryPlatform,
+	"goldenGate":       CategoryPlatform,
+	"dataIntegration":  CategoryPlatform,
+	"loggingAnalytics": CategoryApplication,
+}
+
+// typeCategories maps record type prefixes to categories, for the records no parser recognized. The first
+// matching prefix wins.
+var typeCategories = []struct {
+	prefix   string
+	category string
+}{
+	{"com.oraclecloud.logging.custom.", CategoryApplication},
+	{"com.oraclecloud.functions.", CategoryApplication},
+	{"com.oraclecloud.apigateway.", CategoryApplication},
+	{"com.oraclecloud.cloudguard.", CategorySecurity},
+	{"com.oraclecloud.bastion.", CategorySecurity},
+	{"com.oraclecloud.waf.", CategorySecurity},
+	{"com.oraclecloud.networkfirewall.", CategorySecurity},
+	{"com.oraclecloud.vcn.", CategoryNetwork},
+	{"com.oraclecloud.loadbalancer.", CategoryNetwork},
+	{"com.oraclecloud.networkloadbalancer.", CategoryNetwork},
+	{"com.oraclecloud.dns.", CategoryNetwork},
+}
+
+// applyCategory stamps source.category on the record from the parser that recognized it, whether it is an
+// audit event and its type. Records already carrying source.category are left untouched.
+func applyCategory(record map[string]interface{}, parserName string) {
+	if _, ok := record[common.SourceCategoryAttribute]; ok {
+		return
+	}
+	record[common.SourceCategoryAttribute] = category(record, parserName)
+}
+
+// category classifies the record. Records of OCI services without a more specific category are platform
+// records, and records without an OCI type, e.g. raw application output, are application records.
+func category(record map[string]interface{}, parserName string) string {
+	if category, ok := parserCategories[parserName]; ok {
+		return category
+	}
+	if isAuditRecord(record) {
+		return CategoryAudit
+	}
+	recordType, _ := common.LookupString(record, "type")
+	recordType = strings.ToLower(recordType)
+	for _, rule := range typeCategories {
+		if strings.HasPrefix(recordType, rule.prefix) {
+			return rule.category
+		}
+	}
+	if strings.HasPrefix(recordType, oracleTypePrefix) {
+		return CategoryPlatform
+	}
+	return CategoryApplication
+}
@@ -0,0 +1,48 @@
+package transform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// TestApplyCategory tests the classification of records by parser, audit detection and type.
+func TestApplyCategory(t *testing.T) {
+	tests := []struct {
+		name       string
+		parserName string
+		record     map[string]interface{}
+		expected   string
+	}{
+		{"security parser", "cloudGuard", map[string]interface{}{"type": "com.oraclecloud.cloudguard.problemdetected"}, CategorySecurity},
+		{"vault audit event", "vault", map[string]interface{}{"oracle": map[string]interface{}{"loggroupid": "_Audit"}}, CategorySecurity},
+		{"network parser", "loadBalancer", map[string]interface{}{"type": "com.oraclecloud.loadbalancer.access"}, CategoryNetwork},
+		{"platform parser", "alarm", map[string]interface{}{"type": "OK_TO_FIRING"}, CategoryPlatform},
+		{"audit log group", "", map[string]interface{}{"oracle": map[string]interface{}{"loggroupid": "_Audit"}}, CategoryAudit},
+		{"audit event", "", map[string]interface{}{"data": map[string]interface{}{"eventName": "LaunchInstance", "identity": map[string]interface{}{}}}, CategoryAudit},
+		{"custom log", "", map[string]interface{}{"type": "com.oraclecloud.logging.custom.application"}, CategoryApplication},
+		{"function log", "", map[string]interface{}{"type": "com.oraclecloud.functions.application.functioninvoke"}, CategoryApplication},
+		{"WAF log", "", map[string]interface{}{"type": "com.oraclecloud.waf.access"}, CategorySecurity},
+		{"type case insensitive", "", map[string]interface{}{"type": "com.oraclecloud.VCN.flowlogs.DataEvent"}, CategoryNetwork},
+		{"other service log", "", map[string]interface{}{"type": "com.oraclecloud.objectstorage.getobject"}, CategoryPlatform},
+		{"custom parser", "nginx", map[string]interface{}{"type": "com.oraclecloud.objectstorage.getobject"}, CategoryPlatform},
+		{"raw record", "", map[string]interface{}{"message": "started"}, CategoryApplication},
+		{"existing category", "", map[string]interface{}{common.SourceCategoryAttribute: "billing"}, "billing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applyCategory(tt.record, tt.parserName)
+			assert.Equal(t, tt.expected, tt.record[common.SourceCategoryAttribute])
+		})
+	}
+}
+
+// TestApplyCategoryParsed tests that records are classified after being parsed.
+func TestApplyCategoryParsed(t *testing.T) {
+	record := map[string]interface{}{"type": "com.oraclecloud.vcn.flowlogs.DataEvent", "data": map[string]interface{}{"action": "ACCEPT"}}
+	assert.True(t, Apply(context.Background(), record, Options{}))
+	assert.Equal(t, CategoryNetwork, record[common.SourceCategoryAttribute])
+}
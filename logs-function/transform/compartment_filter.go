@@ -0,0 +1,22 @@
+package transform
+
+import (
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// allowCompartment reports whether the record passes the compartment allowlist and denylist.
+// The denylist takes precedence; with an allowlist configured, records without a compartment are dropped.
+func allowCompartment(record map[string]interface{}, opts Options) bool {
+	if len(opts.CompartmentAllowlist) == 0 && len(opts.CompartmentDenylist) == 0 {
+		return true
+	}
+
+	id := common.CompartmentID(record)
+	if opts.CompartmentDenylist[id] {
+		return false
+	}
+	if len(opts.CompartmentAllowlist) > 0 && !opts.CompartmentAllowlist[id] {
+		return false
+	}
+	return true
+}
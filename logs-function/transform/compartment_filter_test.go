@@ -0,0 +1,48 @@
+package transform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAllowCompartment tests the compartment allowlist and denylist evaluation.
+func TestAllowCompartment(t *testing.T) {
+	loggingRecord := map[string]interface{}{
+		"oracle": map[string]interface{}{"compartmentid": "ocid1.compartment.oc1..prod"},
+	}
+	auditRecord := map[string]interface{}{
+		"data": map[string]interface{}{"compartmentId": "ocid1.compartment.oc1..sandbox"},
+	}
+	noCompartment := map[string]interface{}{"message": "hello"}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		record   map[string]interface{}
+		expected bool
+	}{
+		{"no lists configured", Options{}, noCompartment, true},
+		{"allowlisted compartment", Options{CompartmentAllowlist: toSet([]string{"ocid1.compartment.oc1..prod"})}, loggingRecord, true},
+		{"compartment outside allowlist", Options{CompartmentAllowlist: toSet([]string{"ocid1.compartment.oc1..prod"})}, auditRecord, false},
+		{"missing compartment with allowlist", Options{CompartmentAllowlist: toSet([]string{"ocid1.compartment.oc1..prod"})}, noCompartment, false},
+		{"denylisted audit compartment", Options{CompartmentDenylist: toSet([]string{"ocid1.compartment.oc1..sandbox"})}, auditRecord, false},
+		{"compartment outside denylist", Options{CompartmentDenylist: toSet([]string{"ocid1.compartment.oc1..sandbox"})}, loggingRecord, true},
+		{
+			"denylist takes precedence",
+			Options{
+				CompartmentAllowlist: toSet([]string{"ocid1.compartment.oc1..sandbox"}),
+				CompartmentDenylist:  toSet([]string{"ocid1.compartment.oc1..sandbox"}),
+			},
+			auditRecord,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Apply(context.Background(), tt.record, tt.opts))
+		})
+	}
+}
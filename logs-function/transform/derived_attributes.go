@@ -0,0 +1,74 @@
+package transform
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// Derived boolean attributes, set consistently across sources so common NRQL filters need no source-specific logic.
+const (
+	isErrorAttribute       = "isError"       // isErrorAttribute flags requests answered with a status of 400 or above.
+	isWriteAttribute       = "isWrite"       // isWriteAttribute flags requests with a modifying HTTP method.
+	isCrossTenantAttribute = "isCrossTenant" // isCrossTenantAttribute flags audit events of a principal from another tenancy.
+)
+
+// writeMethods lists the HTTP methods that modify resources.
+var writeMethods = map[string]bool{
+	"POST":   true,
+	"PUT":    true,
+	"PATCH":  true,
+	"DELETE": true,
+}
+
+// applyDerivedAttributes sets isError, isWrite and isCrossTenant from the HTTP attributes of parsed access
+// logs and from the request, response and identity of audit events. An attribute is only set when the
+// record holds the fields it is derived from.
+func applyDerivedAttributes(record map[string]interface{}) {
+	audit := isAuditRecord(record)
+
+	status, ok := record["http.statusCode"]
+	if !ok && audit {
+		status, ok = common.LookupValue(record, "data", "response", "status")
+	}
+	if code, valid := statusCode(status); ok && valid {
+		record[isErrorAttribute] = code >= 400
+	}
+
+	method, ok := record["http.method"].(string)
+	if !ok && audit {
+		method, ok = common.LookupString(record, "data", "request", "action")
+	}
+	if ok && method != "" {
+		record[isWriteAttribute] = writeMethods[strings.ToUpper(method)]
+	}
+
+	if audit {
+		principalTenant, hasPrincipal := common.LookupString(record, "data", "identity", "tenantId")
+		resourceTenant, hasResource := common.LookupString(record, "oracle", "tenantid")
+		if hasPrincipal && hasResource {
+			record[isCrossTenantAttribute] = principalTenant != resourceTenant
+		}
+	}
+}
+
+// statusCode converts an HTTP status held as a number or string.
+func statusCode(value interface{}) (int64, bool) {
+	switch value := value.(type) {
+	case int64:
+		return value, true
+	case int:
+		return int64(value), true
+	case float64:
+		return int64(value), true
+	case json.Number:
+		code, err := value.Int64()
+		return code, err == nil
+	case string:
+		code, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		return code, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,73 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestApplyDerivedAttributes tests the isError, isWrite and isCrossTenant attributes across sources.
+func TestApplyDerivedAttributes(t *testing.T) {
+	audit := func(status interface{}, action string, principalTenant string) map[string]interface{} {
+		record := auditRecord()
+		record["oracle"].(map[string]interface{})["tenantid"] = "ocid1.tenancy.oc1..home"
+		data := record["data"].(map[string]interface{})
+		data["response"].(map[string]interface{})["status"] = status
+		data["request"].(map[string]interface{})["action"] = action
+		data["identity"].(map[string]interface{})["tenantId"] = principalTenant
+		return record
+	}
+
+	tests := []struct {
+		name        string
+		record      map[string]interface{}
+		isError     interface{}
+		isWrite     interface{}
+		crossTenant interface{}
+	}{
+		{
+			name:        "audit read in home tenancy",
+			record:      audit("200", "GET", "ocid1.tenancy.oc1..home"),
+			isError:     false,
+			isWrite:     false,
+			crossTenant: false,
+		},
+		{
+			name:        "failed cross-tenant audit write",
+			record:      audit("404", "DELETE", "ocid1.tenancy.oc1..other"),
+			isError:     true,
+			isWrite:     true,
+			crossTenant: true,
+		},
+		{
+			name: "load balancer access log",
+			record: map[string]interface{}{
+				"type": "com.oraclecloud.loadbalancer.access",
+				"data": map[string]interface{}{"lbStatusCode": "503", "request": "put https://example.com/a HTTP/1.1"},
+			},
+			isError: true,
+			isWrite: true,
+		},
+		{
+			name:    "json access log status",
+			record:  map[string]interface{}{"http.statusCode": json.Number("201"), "http.method": "POST"},
+			isError: false,
+			isWrite: true,
+		},
+		{
+			name:   "application log",
+			record: map[string]interface{}{"message": "hello", "data": map[string]interface{}{"response": map[string]interface{}{"status": "500"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Apply(context.Background(), tt.record, Options{})
+			assert.Equal(t, tt.isError, tt.record["isError"])
+			assert.Equal(t, tt.isWrite, tt.record["isWrite"])
+			assert.Equal(t, tt.crossTenant, tt.record["isCrossTenant"])
+		})
+	}
+}
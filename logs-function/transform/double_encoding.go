@@ -0,0 +1,132 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// errTrailingData is returned for a string holding more than one JSON value.
+var errTrailingData = errors.New("unexpected data after JSON value")
+
+// parseFieldPaths parses comma-separated dot paths, e.g. "message,data.message".
+func parseFieldPaths(value string) [][]string {
+	var paths [][]string
+	for _, item := range splitList(value) {
+		paths = append(paths, strings.Split(item, "."))
+	}
+	return paths
+}
+
+// applyDoubleEncodingRepair decodes the configured fields holding JSON encoded more than once, so producers that
+// stringify JSON inside stringified JSON are forwarded structured. The top-level message is kept as a string of
+// the repaired JSON, encoded once. The number of decodes is recorded on the record and counted as
+// records.doubleEncoded.
+func applyDoubleEncodingRepair(record map[string]interface{}, opts Options) {
+	decodes := 0
+	for _, path := range opts.DoubleEncodedFields {
+		parent, ok := fieldParent(record, path)
+		if !ok {
+			continue
+		}
+		field := path[len(path)-1]
+		value, ok := parent[field].(string)
+		if !ok {
+			continue
+		}
+
+		repaired, n := decodeNested(value, opts.DoubleEncodingMaxDepth)
+		// A single decode of a string is regular JSON, which needs no repair
+		if n < 2 {
+			continue
+		}
+		if len(path) == 1 && field == "message" {
+			if encoded, err := json.Marshal(repaired); err == nil {
+				repaired = string(encoded)
+			}
+		}
+		parent[field] = repaired
+		decodes += n
+	}
+	if decodes > 0 {
+		record[common.DoubleEncodingAttribute] = int64(decodes)
+		metrics.Default.Counter("records.doubleEncoded").Inc()
+	}
+}
+
+// fieldParent returns the object holding the last element of the path.
+func fieldParent(record map[string]interface{}, path []string) (map[string]interface{}, bool) {
+	parent := record
+	for _, key := range path[:len(path)-1] {
+		next, ok := parent[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		parent = next
+	}
+	return parent, true
+}
+
+// decodeNested decodes a string holding JSON, then the JSON strings nested in the decoded value, with at most
+// budget decodes along any path. It returns the decoded value and the number of decodes.
+func decodeNested(value string, budget int) (interface{}, int) {
+	if budget <= 0 || !looksLikeJSON(value) {
+		return value, 0
+	}
+	decoded, err := decodeJSONString(value)
+	if err != nil {
+		return value, 0
+	}
+
+	decodes := 1
+	switch v := decoded.(type) {
+	case string:
+		inner, n := decodeNested(v, budget-1)
+		return inner, decodes + n
+	case map[string]interface{}:
+		for key, item := range v {
+			if s, ok := item.(string); ok {
+				var n int
+				v[key], n = decodeNested(s, budget-1)
+				decodes += n
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if s, ok := item.(string); ok {
+				var n int
+				v[i], n = decodeNested(s, budget-1)
+				decodes += n
+			}
+		}
+	}
+	return decoded, decodes
+}
+
+// looksLikeJSON reports whether the string holds a JSON object, array or string, the only values worth decoding.
+func looksLikeJSON(value string) bool {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return false
+	}
+	first, last := value[0], value[len(value)-1]
+	return (first == '{' && last == '}') || (first == '[' && last == ']') || (first == '"' && last == '"')
+}
+
+// decodeJSONString decodes a single JSON value, keeping numbers exact.
+func decodeJSONString(value string) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errTrailingData
+	}
+	return decoded, nil
+}
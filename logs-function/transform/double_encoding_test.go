@@ -0,0 +1,86 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestApplyDoubleEncodingRepair tests the repair of fields holding JSON encoded more than once.
+func TestApplyDoubleEncodingRepair(t *testing.T) {
+	doubleEncoded, _ := json.Marshal(`{"user":"jdoe","status":403}`)
+	nestedArray, _ := json.Marshal([]string{`{"step":1}`, `{"step":2}`})
+
+	tests := []struct {
+		name     string
+		record   map[string]interface{}
+		maxDepth int
+		expected map[string]interface{}
+	}{
+		{
+			name:   "stringified string of json",
+			record: map[string]interface{}{"data": map[string]interface{}{"message": string(doubleEncoded)}},
+			expected: map[string]interface{}{
+				"data":                         map[string]interface{}{"message": map[string]interface{}{"user": "jdoe", "status": json.Number("403")}},
+				common.DoubleEncodingAttribute: int64(2),
+			},
+		},
+		{
+			name:   "stringified array of stringified json",
+			record: map[string]interface{}{"data": map[string]interface{}{"message": string(nestedArray)}},
+			expected: map[string]interface{}{
+				"data": map[string]interface{}{"message": []interface{}{
+					map[string]interface{}{"step": json.Number("1")},
+					map[string]interface{}{"step": json.Number("2")},
+				}},
+				common.DoubleEncodingAttribute: int64(3),
+			},
+		},
+		{
+			name:   "top-level message stays a string encoded once",
+			record: map[string]interface{}{"message": string(doubleEncoded)},
+			expected: map[string]interface{}{
+				"message":                      `{"status":403,"user":"jdoe"}`,
+				common.DoubleEncodingAttribute: int64(2),
+			},
+		},
+		{
+			name:     "json encoded once is left alone",
+			record:   map[string]interface{}{"message": `{"user":"jdoe"}`},
+			expected: map[string]interface{}{"message": `{"user":"jdoe"}`},
+		},
+		{
+			name:     "plain text is left alone",
+			record:   map[string]interface{}{"message": "GET /health 200", "data": map[string]interface{}{"message": "[INFO] ok"}},
+			expected: map[string]interface{}{"message": "GET /health 200", "data": map[string]interface{}{"message": "[INFO] ok"}},
+		},
+		{
+			name:     "depth is bounded",
+			record:   map[string]interface{}{"data": map[string]interface{}{"message": string(doubleEncoded)}},
+			maxDepth: 1,
+			expected: map[string]interface{}{"data": map[string]interface{}{"message": string(doubleEncoded)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{DoubleEncodedFields: parseFieldPaths("message,data.message"), DoubleEncodingMaxDepth: 4}
+			if tt.maxDepth > 0 {
+				opts.DoubleEncodingMaxDepth = tt.maxDepth
+			}
+			applyDoubleEncodingRepair(tt.record, opts)
+			assert.Equal(t, tt.expected, tt.record)
+		})
+	}
+}
+
+// TestApplyDoubleEncodingRepairDisabled tests that no field is repaired unless configured.
+func TestApplyDoubleEncodingRepairDisabled(t *testing.T) {
+	doubleEncoded, _ := json.Marshal(`{"user":"jdoe"}`)
+	record := map[string]interface{}{"message": string(doubleEncoded)}
+
+	applyDoubleEncodingRepair(record, LoadOptions())
+	assert.Equal(t, map[string]interface{}{"message": string(doubleEncoded)}, record)
+}
@@ -0,0 +1,147 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// Supported treatments of records without a message.
+const (
+	EmptyMessageKeep   = "keep"   // EmptyMessageKeep forwards the record with a blank message.
+	EmptyMessageDrop   = "drop"   // EmptyMessageDrop drops the record.
+	EmptyMessageKeys   = "keys"   // EmptyMessageKeys synthesizes the message from the first scalar fields of the record.
+	EmptyMessageSource = "source" // EmptyMessageSource synthesizes the message from the record source and event name.
+)
+
+// maxMessageKeys is the number of fields a message synthesized by EmptyMessageKeys is made of.
+const maxMessageKeys = 5
+
+// envelopeFields lists the record fields describing the envelope rather than the event, skipped by EmptyMessageKeys
+// for records without data.
+var envelopeFields = map[string]bool{
+	"id":                       true,
+	"oracle":                   true,
+	"source":                   true,
+	"specversion":              true,
+	"subject":                  true,
+	"time":                     true,
+	"type":                     true,
+	common.LogSubjectAttribute: true,
+}
+
+// EmptyMessagePolicy selects the treatment of records without a message whose type starts with Source.
+type EmptyMessagePolicy struct {
+	Source string // Source is the record type prefix the policy applies to, "subject:<glob>" or "*" for any record.
+	Policy string // Policy is one of the EmptyMessage treatments.
+}
+
+// parseEmptyMessagePolicies parses the per-source policies, ignoring malformed entries.
+func parseEmptyMessagePolicies(value string) []EmptyMessagePolicy {
+	var policies []EmptyMessagePolicy
+	for _, item := range splitList(value) {
+		source, policy, found := strings.Cut(item, "=")
+		source = strings.TrimSpace(source)
+		policy = strings.ToLower(strings.TrimSpace(policy))
+		if !found || source == "" {
+			logger.FromContext(context.Background()).Warnf("Ignoring invalid empty message policy: %s", item)
+			continue
+		}
+		switch policy {
+		case EmptyMessageKeep, EmptyMessageDrop, EmptyMessageKeys, EmptyMessageSource:
+			policies = append(policies, EmptyMessagePolicy{Source: source, Policy: policy})
+		default:
+			logger.FromContext(context.Background()).Warnf("Ignoring empty message policy with unknown treatment: %s", item)
+		}
+	}
+	return policies
+}
+
+// applyEmptyMessagePolicy applies the first policy matching the record to a record whose message, read
+// from the record or its data, is missing or blank. It returns false when the record should be dropped.
+func applyEmptyMessagePolicy(record map[string]interface{}, opts Options) bool {
+	if len(opts.EmptyMessagePolicies) == 0 || hasMessage(record) {
+		return true
+	}
+
+	for _, policy := range opts.EmptyMessagePolicies {
+		if !matchesSource(record, policy.Source) {
+			continue
+		}
+		metrics.Default.Counter("message.empty." + policy.Policy).Inc()
+		switch policy.Policy {
+		case EmptyMessageDrop:
+			return false
+		case EmptyMessageKeys:
+			if message := keysMessage(record); message != "" {
+				record["message"] = message
+			}
+		case EmptyMessageSource:
+			if message := sourceMessage(record); message != "" {
+				record["message"] = message
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// hasMessage reports whether the record, or its data, holds a non-blank message.
+func hasMessage(record map[string]interface{}) bool {
+	for _, path := range [][]string{{"message"}, {"data", "message"}} {
+		if message, ok := common.LookupString(record, path...); ok && strings.TrimSpace(message) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// keysMessage returns "key=value" pairs of the first scalar fields of the record data, or of the record itself
+// without its envelope fields, in key order.
+func keysMessage(record map[string]interface{}) string {
+	fields, ok := record["data"].(map[string]interface{})
+	skip := map[string]bool{"message": true}
+	if !ok {
+		fields, skip = record, envelopeFields
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if !skip[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		switch value := fields[key].(type) {
+		case string:
+			if value == "" {
+				continue
+			}
+		case map[string]interface{}, []interface{}, nil:
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, fields[key]))
+		if len(pairs) == maxMessageKeys {
+			break
+		}
+	}
+	return strings.Join(pairs, " ")
+}
+
+// sourceMessage returns the record source, or its type when it has none, followed by the event name of the data.
+func sourceMessage(record map[string]interface{}) string {
+	source, ok := common.LookupString(record, "source")
+	if !ok {
+		source, _ = common.LookupString(record, "type")
+	}
+	eventName, _ := common.LookupString(record, "data", "eventName")
+	return strings.TrimSpace(source + " " + eventName)
+}
@@ -0,0 +1,89 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseEmptyMessagePolicies tests parsing of per-source policies and that malformed entries are ignored.
+func TestParseEmptyMessagePolicies(t *testing.T) {
+	policies := parseEmptyMessagePolicies("com.oraclecloud.audit=Source, *=keys, bad, x=blank, =drop")
+
+	assert.Equal(t, []EmptyMessagePolicy{
+		{Source: "com.oraclecloud.audit", Policy: EmptyMessageSource},
+		{Source: "*", Policy: EmptyMessageKeys},
+	}, policies)
+}
+
+// TestApplyEmptyMessagePolicy tests source matching and each treatment.
+func TestApplyEmptyMessagePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies string
+		record   map[string]interface{}
+		keep     bool
+		message  interface{}
+	}{
+		{
+			name:     "records with a nested message are left alone",
+			policies: "*=drop",
+			record:   map[string]interface{}{"data": map[string]interface{}{"message": "hello"}},
+			keep:     true,
+		},
+		{
+			name:     "blank messages are dropped",
+			policies: "*=drop",
+			record:   map[string]interface{}{"message": "  "},
+			keep:     false,
+			message:  "  ",
+		},
+		{
+			name:     "keep forwards the record as is",
+			policies: "com.oraclecloud.vcn=drop,*=keep",
+			record:   map[string]interface{}{"type": "com.oraclecloud.logging.custom.app"},
+			keep:     true,
+		},
+		{
+			name:     "keys uses the first scalar data fields",
+			policies: "*=keys",
+			record: map[string]interface{}{"data": map[string]interface{}{
+				"status": 200.0, "action": "GET", "empty": "", "nested": map[string]interface{}{"a": 1}, "message": "",
+			}},
+			keep:    true,
+			message: "action=GET status=200",
+		},
+		{
+			name:     "keys skips envelope fields of records without data",
+			policies: "*=keys",
+			record:   map[string]interface{}{"type": "custom", "time": "2024-01-01T00:00:00Z", "user": "alice", "ok": true},
+			keep:     true,
+			message:  "ok=true user=alice",
+		},
+		{
+			name:     "source uses the source and event name",
+			policies: "com.oraclecloud.computeapi=source",
+			record: map[string]interface{}{
+				"type": "com.oraclecloud.computeapi.launchinstance.end", "source": "ComputeApi",
+				"data": map[string]interface{}{"eventName": "LaunchInstance"},
+			},
+			keep:    true,
+			message: "ComputeApi LaunchInstance",
+		},
+		{
+			name:     "source falls back to the type",
+			policies: "*=source",
+			record:   map[string]interface{}{"type": "com.oraclecloud.objectstorage.createbucket"},
+			keep:     true,
+			message:  "com.oraclecloud.objectstorage.createbucket",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{EmptyMessagePolicies: parseEmptyMessagePolicies(tt.policies)}
+			assert.Equal(t, tt.keep, applyEmptyMessagePolicy(tt.record, opts))
+			assert.Equal(t, tt.message, tt.record["message"])
+		})
+	}
+}
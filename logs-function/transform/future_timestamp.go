@@ -0,0 +1,47 @@
+package transform
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+)
+
+// parseFutureTimestampThreshold parses the threshold in seconds. It returns the default when unset or invalid,
+// and 0, disabling the check, for "0".
+func parseFutureTimestampThreshold(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "0" {
+		return 0
+	}
+	return time.Duration(getEnvInt(value, common.DefaultFutureTimestampThreshold)) * time.Second
+}
+
+// applyFutureTimestamp re-stamps records dated further in the future than the threshold with the current time,
+// keeping the original value and flagging the record, so that sources with bad clocks do not distort dashboards.
+func applyFutureTimestamp(ctx context.Context, record map[string]interface{}, opts Options) {
+	if opts.FutureTimestampThreshold <= 0 {
+		return
+	}
+	field, recordTime, ok := common.RecordTimeField(record)
+	if !ok {
+		return
+	}
+	now := clock.Now()
+	if !recordTime.After(now.Add(opts.FutureTimestampThreshold)) {
+		return
+	}
+
+	original := record[field]
+	if _, isString := original.(string); isString {
+		record[field] = now.UTC().Format(time.RFC3339Nano)
+	} else {
+		record[field] = now.UnixMilli()
+	}
+	record[common.FutureTimestampAttribute] = true
+	record[common.OriginalTimestampAttribute] = original
+	logger.FromContext(ctx).Debugf("Re-stamped record dated %s ahead", recordTime.Sub(now).Round(time.Second))
+}
@@ -0,0 +1,79 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedClock is a Clock always returning the same time.
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+// TestParseFutureTimestampThreshold tests the threshold default and the disabling value.
+func TestParseFutureTimestampThreshold(t *testing.T) {
+	assert.Equal(t, time.Hour, parseFutureTimestampThreshold(""))
+	assert.Equal(t, 2*time.Minute, parseFutureTimestampThreshold("120"))
+	assert.Equal(t, time.Duration(0), parseFutureTimestampThreshold("0"))
+	assert.Equal(t, time.Hour, parseFutureTimestampThreshold("soon"))
+}
+
+// TestApplyFutureTimestamp tests that only records dated beyond the threshold are re-stamped and flagged.
+func TestApplyFutureTimestamp(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	defer clock.Set(fixedClock{now: now})()
+	opts := Options{FutureTimestampThreshold: time.Hour}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		record   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "within threshold",
+			opts:     opts,
+			record:   map[string]interface{}{"time": "2024-01-01T12:30:00Z"},
+			expected: map[string]interface{}{"time": "2024-01-01T12:30:00Z"},
+		},
+		{
+			name:   "future string time",
+			opts:   opts,
+			record: map[string]interface{}{"time": "2024-01-01T18:00:00Z"},
+			expected: map[string]interface{}{
+				"time":                            "2024-01-01T12:00:00Z",
+				common.FutureTimestampAttribute:   true,
+				common.OriginalTimestampAttribute: "2024-01-01T18:00:00Z",
+			},
+		},
+		{
+			name:   "future epoch milliseconds",
+			opts:   opts,
+			record: map[string]interface{}{"timestamp": json.Number("1704132000000")},
+			expected: map[string]interface{}{
+				"timestamp":                       now.UnixMilli(),
+				common.FutureTimestampAttribute:   true,
+				common.OriginalTimestampAttribute: json.Number("1704132000000"),
+			},
+		},
+		{
+			name:     "disabled",
+			opts:     Options{},
+			record:   map[string]interface{}{"time": "2030-01-01T00:00:00Z"},
+			expected: map[string]interface{}{"time": "2030-01-01T00:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applyFutureTimestamp(context.Background(), tt.record, tt.opts)
+			assert.Equal(t, tt.expected, tt.record)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package transform
+
+import (
+	"context"
+	"math/rand"
+	"regexp"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// heartbeatPattern recognizes one kind of OCI heartbeat or synthetic record.
+type heartbeatPattern struct {
+	name    string
+	matches func(record map[string]interface{}) bool
+}
+
+var (
+	// connectorTestMessage matches the test messages sent when a Service Connector is created or tested.
+	connectorTestMessage = regexp.MustCompile(`(?i)^\s*(oci )?(service )?connector( hub)? test (message|event|log)\b`)
+	// healthProbeRequest matches the access log lines of health check requests.
+	healthProbeRequest = regexp.MustCompile(`(?i)\b(GET|HEAD) /(health|healthz|healthcheck|ready|readyz|live|livez|ping|status)(\?\S*)? HTTP/`)
+	// healthProbeAgents lists, in lower case, the user agent fragments of health checkers.
+	healthProbeAgents = []string{"oci-healthcheck", "oracle-cloud-health-check", "elb-healthchecker", "kube-probe", "googlehc"}
+)
+
+// heartbeatPatterns is the built-in list of heartbeat patterns, matched in order.
+var heartbeatPatterns = []heartbeatPattern{
+	{name: "connector-test", matches: func(record map[string]interface{}) bool {
+		return connectorTestMessage.MatchString(recordMessage(record))
+	}},
+	{name: "health-probe", matches: func(record map[string]interface{}) bool {
+		if agent, ok := common.LookupString(record, "data", "userAgent"); ok {
+			agent = strings.ToLower(agent)
+			for _, fragment := range healthProbeAgents {
+				if strings.Contains(agent, fragment) {
+					return true
+				}
+			}
+		}
+		return healthProbeRequest.MatchString(recordMessage(record))
+	}},
+}
+
+// recordMessage returns the message of the record, read from the record or its data.
+func recordMessage(record map[string]interface{}) string {
+	for _, path := range [][]string{{"message"}, {"data", "message"}} {
+		if message, ok := common.LookupString(record, path...); ok {
+			return message
+		}
+	}
+	return ""
+}
+
+// parseHeartbeatFilter returns the HEARTBEAT_FILTER mode, or the default when unset or unknown.
+func parseHeartbeatFilter(value string) string {
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case "":
+		return common.DefaultHeartbeatFilter
+	case common.HeartbeatFilterDrop, common.HeartbeatFilterSample, common.HeartbeatFilterKeep:
+		return mode
+	default:
+		logger.FromContext(context.Background()).Warnf("Ignoring unknown %s value %q", common.HeartbeatFilter, value)
+		return common.DefaultHeartbeatFilter
+	}
+}
+
+// matchHeartbeat returns the name of the heartbeat pattern the record matches, or "" for other records.
+func matchHeartbeat(record map[string]interface{}) string {
+	for _, pattern := range heartbeatPatterns {
+		if pattern.matches(record) {
+			return pattern.name
+		}
+	}
+	return ""
+}
+
+// applyHeartbeatFilter drops or samples heartbeat records according to HEARTBEAT_FILTER, counting them per pattern.
+// Forwarded heartbeat records are flagged with the name of their pattern. It returns false when the record should
+// be dropped.
+func applyHeartbeatFilter(record map[string]interface{}, opts Options) bool {
+	if opts.HeartbeatFilter == common.HeartbeatFilterKeep {
+		return true
+	}
+	name := matchHeartbeat(record)
+	if name == "" {
+		return true
+	}
+	metrics.Default.Counter("heartbeat." + name + ".records").Inc()
+	if opts.HeartbeatFilter == common.HeartbeatFilterSample && rand.Intn(100) < opts.HeartbeatSamplePercent {
+		record[common.HeartbeatAttribute] = name
+		return true
+	}
+	metrics.Default.Counter("heartbeat.dropped").Inc()
+	return false
+}
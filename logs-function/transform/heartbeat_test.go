@@ -0,0 +1,60 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMatchHeartbeat tests the built-in heartbeat patterns.
+func TestMatchHeartbeat(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   map[string]interface{}
+		expected string
+	}{
+		{"connector test message", map[string]interface{}{"message": "Service Connector Hub test message"}, "connector-test"},
+		{"connector test event in data", map[string]interface{}{"data": map[string]interface{}{"message": "connector test event"}}, "connector-test"},
+		{"health check request", map[string]interface{}{"message": `10.0.0.4 - - "GET /healthz HTTP/1.1" 200 2`}, "health-probe"},
+		{"health checker user agent", map[string]interface{}{"data": map[string]interface{}{"userAgent": "OCI-HealthCheck/1.0"}}, "health-probe"},
+		{"application request", map[string]interface{}{"message": `10.0.0.4 - - "GET /orders/42 HTTP/1.1" 200 812`}, ""},
+		{"message mentioning a test", map[string]interface{}{"message": "integration test message sent by the connector"}, ""},
+		{"no message", map[string]interface{}{"data": map[string]interface{}{}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matchHeartbeat(tt.record))
+		})
+	}
+}
+
+// TestApplyHeartbeatFilter tests that heartbeat records are dropped, sampled or kept and counted per pattern.
+func TestApplyHeartbeatFilter(t *testing.T) {
+	heartbeat := func() map[string]interface{} {
+		return map[string]interface{}{"message": "HEAD /ping HTTP/1.1"}
+	}
+
+	metrics.Default.Reset()
+	assert.False(t, applyHeartbeatFilter(heartbeat(), Options{HeartbeatFilter: common.HeartbeatFilterDrop}))
+	assert.True(t, applyHeartbeatFilter(map[string]interface{}{"message": "hello"}, Options{HeartbeatFilter: common.HeartbeatFilterDrop}))
+	assert.Equal(t, int64(1), metrics.Default.Counter("heartbeat.health-probe.records").Value())
+	assert.Equal(t, int64(1), metrics.Default.Counter("heartbeat.dropped").Value())
+
+	kept := heartbeat()
+	assert.True(t, applyHeartbeatFilter(kept, Options{HeartbeatFilter: common.HeartbeatFilterKeep}))
+	assert.NotContains(t, kept, common.HeartbeatAttribute)
+
+	sampled := heartbeat()
+	assert.True(t, applyHeartbeatFilter(sampled, Options{HeartbeatFilter: common.HeartbeatFilterSample, HeartbeatSamplePercent: 100}))
+	assert.Equal(t, "health-probe", sampled[common.HeartbeatAttribute])
+}
+
+// TestParseHeartbeatFilter tests that unset and unknown modes fall back to the default.
+func TestParseHeartbeatFilter(t *testing.T) {
+	assert.Equal(t, common.HeartbeatFilterDrop, parseHeartbeatFilter(""))
+	assert.Equal(t, common.HeartbeatFilterSample, parseHeartbeatFilter(" Sample "))
+	assert.Equal(t, common.HeartbeatFilterKeep, parseHeartbeatFilter("keep"))
+	assert.Equal(t, common.HeartbeatFilterDrop, parseHeartbeatFilter("all"))
+}
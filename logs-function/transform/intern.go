@@ -0,0 +1,57 @@
+package transform
+
+// Bounds of an intern table, so values that never repeat cannot grow it without limit.
+const (
+	maxInternLength  = 256   // maxInternLength is the length of the longest string interned.
+	maxInternEntries = 10000 // maxInternEntries is the number of distinct strings an intern table holds.
+)
+
+// Interner deduplicates the string values of the records of one invocation. The values repeated on most records,
+// such as OCIDs, regions and levels, are decoded into a separate string per record; replacing them with a single
+// shared copy lets the duplicates be collected while the invocation still holds its records. It is not safe for
+// concurrent use.
+type Interner struct {
+	// values maps each interned string to the record value first holding it, so replacing a duplicate reuses
+	// that value instead of allocating a new interface value
+	values map[string]interface{}
+}
+
+// NewInterner returns an empty intern table.
+func NewInterner() *Interner {
+	return &Interner{values: map[string]interface{}{}}
+}
+
+// InternRecord replaces the string values of the record, at any depth, with their shared copies.
+func (in *Interner) InternRecord(record map[string]interface{}) {
+	for key, value := range record {
+		if shared, replace := in.intern(value); replace {
+			record[key] = shared
+		}
+	}
+}
+
+// intern interns the strings of a decoded value. It returns the shared value to store in place of a string value
+// and whether it differs from value; objects and arrays are interned in place.
+func (in *Interner) intern(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		if len(v) > maxInternLength {
+			return nil, false
+		}
+		if shared, ok := in.values[v]; ok {
+			return shared, true
+		}
+		if len(in.values) < maxInternEntries {
+			in.values[v] = value
+		}
+	case map[string]interface{}:
+		in.InternRecord(v)
+	case []interface{}:
+		for i, item := range v {
+			if shared, replace := in.intern(item); replace {
+				v[i] = shared
+			}
+		}
+	}
+	return nil, false
+}
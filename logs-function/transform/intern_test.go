@@ -0,0 +1,113 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInternRecord tests that equal string values of different records share one copy, at any depth.
+func TestInternRecord(t *testing.T) {
+	first := auditRecords(1)[0]
+	second := auditRecords(1)[0]
+	interner := NewInterner()
+	interner.InternRecord(first)
+	interner.InternRecord(second)
+
+	firstID := first["data"].(map[string]interface{})["compartmentId"].(string)
+	secondID := second["data"].(map[string]interface{})["compartmentId"].(string)
+	assert.Equal(t, firstID, secondID)
+	assert.Equal(t, unsafe.StringData(firstID), unsafe.StringData(secondID))
+
+	firstHeader := first["data"].(map[string]interface{})["request"].(map[string]interface{})["headers"].(map[string]interface{})["User-Agent"].([]interface{})[0].(string)
+	secondHeader := second["data"].(map[string]interface{})["request"].(map[string]interface{})["headers"].(map[string]interface{})["User-Agent"].([]interface{})[0].(string)
+	assert.Equal(t, unsafe.StringData(firstHeader), unsafe.StringData(secondHeader))
+}
+
+// TestInternBounds tests that long values and values beyond the table size are not interned.
+func TestInternBounds(t *testing.T) {
+	interner := NewInterner()
+	long := strings.Repeat("a", maxInternLength+1)
+	interner.InternRecord(map[string]interface{}{"message": long})
+	assert.Empty(t, interner.values)
+
+	for i := 0; i < maxInternEntries+10; i++ {
+		interner.InternRecord(map[string]interface{}{"id": fmt.Sprint(i)})
+	}
+	assert.Len(t, interner.values, maxInternEntries)
+}
+
+// auditRecords decodes count _Audit records of the same principal and compartment, as a payload of an
+// audit-heavy tenancy holds.
+func auditRecords(count int) []map[string]interface{} {
+	var payload bytes.Buffer
+	payload.WriteString("[")
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			payload.WriteString(",")
+		}
+		fmt.Fprintf(&payload, `{"id":"event-%d","type":"com.oraclecloud.objectstorage.getobject","source":"backups",`+
+			`"time":"2024-01-01T00:00:00Z","oracle":{"compartmentid":"ocid1.compartment.oc1..aaaaaaaacompartmentexample",`+
+			`"loggroupid":"_Audit","tenantid":"ocid1.tenancy.oc1..aaaaaaaatenancyexample"},"data":{"eventName":"GetObject",`+
+			`"compartmentId":"ocid1.compartment.oc1..aaaaaaaacompartmentexample","compartmentName":"prod",`+
+			`"identity":{"principalId":"ocid1.user.oc1..aaaaaaaauserexample","principalName":"jdoe","ipAddress":"192.0.2.10",`+
+			`"tenantId":"ocid1.tenancy.oc1..aaaaaaaatenancyexample","authType":"natv"},"request":{"action":"GET",`+
+			`"headers":{"User-Agent":["Oracle-JavaSDK/3.0.0 (Linux/5.15; Java/17)"]},"path":"/n/ns/b/backups/o/object-%d"},`+
+			`"response":{"status":"200"},"resourceId":"ocid1.bucket.oc1.iad.aaaaaaaabucketexample","availabilityDomain":"AD3"}}`, i, i)
+	}
+	payload.WriteString("]")
+
+	var records []map[string]interface{}
+	decoder := json.NewDecoder(&payload)
+	decoder.UseNumber()
+	if err := decoder.Decode(&records); err != nil {
+		panic(err)
+	}
+	return records
+}
+
+// retainedHeap returns the bytes of heap in use after a collection.
+func retainedHeap() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// BenchmarkInternAuditRecords compares the heap retained by decoded _Audit records with and without interning,
+// reported as retained-B/record, and the cost of interning.
+func BenchmarkInternAuditRecords(b *testing.B) {
+	const count = 1000
+	for _, intern := range []bool{false, true} {
+		name := "plain"
+		if intern {
+			name = "interned"
+		}
+		b.Run(name, func(b *testing.B) {
+			var retained uint64
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				before := retainedHeap()
+				records := auditRecords(count)
+				b.StartTimer()
+				if intern {
+					interner := NewInterner()
+					for _, record := range records {
+						interner.InternRecord(record)
+					}
+				}
+				b.StopTimer()
+				retained += retainedHeap() - before
+				runtime.KeepAlive(records)
+				b.StartTimer()
+			}
+			b.ReportMetric(float64(retained)/float64(b.N*count), "retained-B/record")
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package transform
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+)
+
+// Supported retention strategies for messages longer than their source's limit.
+const (
+	TruncateHead     = "head"     // TruncateHead keeps the beginning of the message.
+	TruncateTail     = "tail"     // TruncateTail keeps the end of the message.
+	TruncateHeadTail = "headtail" // TruncateHeadTail keeps the beginning and the end, dropping the middle.
+)
+
+// anySource is the source pattern matching every record.
+const anySource = "*"
+
+// truncatedPrefix marks messages whose beginning was removed by the tail strategy.
+const truncatedPrefix = "[truncated]..."
+
+// truncatedMiddle marks messages whose middle was removed by the headtail strategy.
+const truncatedMiddle = "...[truncated]..."
+
+// MessageLengthLimit caps the message length of records whose type starts with Source.
+type MessageLengthLimit struct {
+	Source   string // Source is the record type prefix the limit applies to, "subject:<glob>" or "*" for any record.
+	MaxBytes int    // MaxBytes is the number of message bytes retained.
+	Strategy string // Strategy selects which part of the message is retained.
+}
+
+// parseMessageLengthLimits parses the per-source limits, ignoring malformed entries.
+func parseMessageLengthLimits(value string) []MessageLengthLimit {
+	var limits []MessageLengthLimit
+	for _, item := range splitList(value) {
+		source, spec, found := strings.Cut(item, "=")
+		size, strategy, _ := strings.Cut(spec, ":")
+		maxBytes, err := strconv.Atoi(strings.TrimSpace(size))
+		strategy = strings.ToLower(strings.TrimSpace(strategy))
+		if strategy == "" {
+			strategy = TruncateHead
+		}
+
+		if !found || strings.TrimSpace(source) == "" || err != nil || maxBytes <= 0 {
+			logger.FromContext(context.Background()).Warnf("Ignoring invalid message length limit: %s", item)
+			continue
+		}
+		if strategy != TruncateHead && strategy != TruncateTail && strategy != TruncateHeadTail {
+			logger.FromContext(context.Background()).Warnf("Ignoring message length limit with unknown strategy: %s", item)
+			continue
+		}
+		limits = append(limits, MessageLengthLimit{Source: strings.TrimSpace(source), MaxBytes: maxBytes, Strategy: strategy})
+	}
+	return limits
+}
+
+// applyMessageLength truncates the record message, and the message nested under data, to the first limit
+// matching the record.
+func applyMessageLength(record map[string]interface{}, opts Options) {
+	if len(opts.MessageLengthLimits) == 0 {
+		return
+	}
+
+	for _, limit := range opts.MessageLengthLimits {
+		if !matchesSource(record, limit.Source) {
+			continue
+		}
+		truncateField(record, "message", limit)
+		if data, ok := record["data"].(map[string]interface{}); ok {
+			truncateField(data, "message", limit)
+		}
+		return
+	}
+}
+
+// truncateField truncates the string value of key when it exceeds the limit.
+func truncateField(node map[string]interface{}, key string, limit MessageLengthLimit) {
+	if message, ok := node[key].(string); ok && len(message) > limit.MaxBytes {
+		node[key] = truncateMessage(message, limit)
+	}
+}
+
+// truncateMessage retains MaxBytes of the message according to the strategy and marks where content was removed.
+// Cuts are moved to rune boundaries so the result stays valid UTF-8.
+func truncateMessage(message string, limit MessageLengthLimit) string {
+	switch limit.Strategy {
+	case TruncateTail:
+		return truncatedPrefix + message[tailStart(message, limit.MaxBytes):]
+	case TruncateHeadTail:
+		head := limit.MaxBytes / 2
+		return message[:headEnd(message, head)] + truncatedMiddle + message[tailStart(message, limit.MaxBytes-head):]
+	default:
+		return message[:headEnd(message, limit.MaxBytes)] + truncatedSuffix
+	}
+}
+
+// headEnd returns the largest rune boundary of s not after n.
+func headEnd(s string, n int) int {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return n
+}
+
+// tailStart returns the index of the shortest suffix of s holding at most n bytes on a rune boundary.
+func tailStart(s string, n int) int {
+	i := len(s) - n
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return i
+}
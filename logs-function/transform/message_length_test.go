@@ -0,0 +1,77 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseMessageLengthLimits tests parsing of per-source limits and that malformed entries are ignored.
+func TestParseMessageLengthLimits(t *testing.T) {
+	limits := parseMessageLengthLimits("com.oraclecloud.vcn.flowlogs=4096, *=32768:HeadTail, bad, x=0, y=10:middle, z=abc")
+
+	assert.Equal(t, []MessageLengthLimit{
+		{Source: "com.oraclecloud.vcn.flowlogs", MaxBytes: 4096, Strategy: TruncateHead},
+		{Source: "*", MaxBytes: 32768, Strategy: TruncateHeadTail},
+	}, limits)
+}
+
+// TestApplyMessageLength tests source matching and each retention strategy.
+func TestApplyMessageLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		limits   string
+		record   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "head keeps the beginning",
+			limits:   "com.oraclecloud.vcn.flowlogs=4",
+			record:   map[string]interface{}{"type": "com.oraclecloud.vcn.flowlogs.DataEvent", "message": "abcdefgh"},
+			expected: map[string]interface{}{"type": "com.oraclecloud.vcn.flowlogs.DataEvent", "message": "abcd" + truncatedSuffix},
+		},
+		{
+			name:     "tail keeps the end of the nested message",
+			limits:   "com.oraclecloud.logging.custom=3:tail",
+			record:   map[string]interface{}{"type": "com.oraclecloud.logging.custom.app", "data": map[string]interface{}{"message": "abcdefgh"}},
+			expected: map[string]interface{}{"type": "com.oraclecloud.logging.custom.app", "data": map[string]interface{}{"message": truncatedPrefix + "fgh"}},
+		},
+		{
+			name:     "headtail keeps both ends",
+			limits:   "*=4:headtail",
+			record:   map[string]interface{}{"message": "abcdefgh"},
+			expected: map[string]interface{}{"message": "ab" + truncatedMiddle + "gh"},
+		},
+		{
+			name:     "first matching source wins",
+			limits:   "com.oraclecloud.vcn=2,*=6",
+			record:   map[string]interface{}{"type": "com.oraclecloud.vcn.flowlogs", "message": "abcdefgh"},
+			expected: map[string]interface{}{"type": "com.oraclecloud.vcn.flowlogs", "message": "ab" + truncatedSuffix},
+		},
+		{
+			name:     "unmatched source is left untouched",
+			limits:   "com.oraclecloud.vcn=2",
+			record:   map[string]interface{}{"type": "com.oraclecloud.objectstorage", "message": "abcdefgh"},
+			expected: map[string]interface{}{"type": "com.oraclecloud.objectstorage", "message": "abcdefgh"},
+		},
+		{
+			name:     "short message is left untouched",
+			limits:   "*=16",
+			record:   map[string]interface{}{"message": "abcdefgh"},
+			expected: map[string]interface{}{"message": "abcdefgh"},
+		},
+		{
+			name:     "cuts on rune boundaries",
+			limits:   "*=4:headtail",
+			record:   map[string]interface{}{"message": "ééxéé"},
+			expected: map[string]interface{}{"message": "é" + truncatedMiddle + "é"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applyMessageLength(tt.record, Options{MessageLengthLimits: parseMessageLengthLimits(tt.limits)})
+			assert.Equal(t, tt.expected, tt.record)
+		})
+	}
+}
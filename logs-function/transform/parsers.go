@@ -0,0 +1,78 @@
+package transform
+
+import (
+	"context"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/parser"
+)
+
+// parseParsersEnabled parses the enabled parsers, returning nil when every parser is enabled. Unknown parser
+// names are ignored with a warning.
+func parseParsersEnabled(value string) map[string]bool {
+	items := splitList(value)
+	if len(items) == 0 {
+		return nil
+	}
+
+	// Lists of disabled parsers only, like "-vault", start from every parser as "*" does
+	all := true
+	for _, item := range items {
+		if item == "*" {
+			all = true
+			break
+		}
+		if !strings.HasPrefix(item, "-") {
+			all = false
+		}
+	}
+	enabled := map[string]bool{}
+	if all {
+		for _, name := range parser.Names() {
+			enabled[name] = true
+		}
+	}
+	disabled := false
+	for _, item := range items {
+		name, disable := strings.CutPrefix(item, "-")
+		switch {
+		case item == "*":
+		case !isParser(name):
+			logger.FromContext(context.Background()).Warnf("Ignoring unknown parser in PARSERS_ENABLED: %s", item)
+		case disable:
+			delete(enabled, name)
+			disabled = true
+		default:
+			enabled[name] = true
+		}
+	}
+	if !disabled && len(enabled) == len(parser.Names()) {
+		return nil
+	}
+	return enabled
+}
+
+// parseParserOverrides parses the parsers forced per log group, ignoring malformed entries and unknown parsers.
+func parseParserOverrides(value string) map[string]string {
+	var forced map[string]string
+	for _, item := range splitList(value) {
+		logGroup, name, found := strings.Cut(item, "=")
+		logGroup, name = strings.TrimSpace(logGroup), strings.TrimSpace(name)
+		if !found || logGroup == "" || (name != parser.ParserNone && !isParser(name)) {
+			logger.FromContext(context.Background()).Warnf("Ignoring invalid parser override: %s", item)
+			continue
+		}
+		if forced == nil {
+			forced = map[string]string{}
+		}
+		forced[logGroup] = name
+	}
+	return forced
+}
+
+// isParser reports whether a parser of that name is registered.
+func isParser(name string) bool {
+	_, ok := parser.Lookup(name)
+	return ok
+}
@@ -0,0 +1,77 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/parser"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseParsersEnabled tests the parsing of PARSERS_ENABLED.
+func TestParseParsersEnabled(t *testing.T) {
+	allBut := func(disabled string) map[string]bool {
+		enabled := map[string]bool{}
+		for _, name := range parser.Names() {
+			if name != disabled {
+				enabled[name] = true
+			}
+		}
+		return enabled
+	}
+	tests := []struct {
+		name     string
+		value    string
+		expected map[string]bool
+	}{
+		{"unset", "", nil},
+		{"all", "*", nil},
+		{"listed", "vault, alarm", map[string]bool{"vault": true, "alarm": true}},
+		{"all but one", "*,-vault", allBut("vault")},
+		{"disabled only", "-vault", allBut("vault")},
+		{"unknown names are ignored", "vault,bogus", map[string]bool{"vault": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseParsersEnabled(tt.value))
+		})
+	}
+}
+
+// TestParseParserOverrides tests the parsing of PARSER_OVERRIDES.
+func TestParseParserOverrides(t *testing.T) {
+	assert.Nil(t, parseParserOverrides(""))
+	assert.Equal(t, map[string]string{"ocid1.loggroup.a": "vault", "ocid1.loggroup.b": "none"},
+		parseParserOverrides("ocid1.loggroup.a=vault, ocid1.loggroup.b=none, ocid1.loggroup.c=bogus, malformed"))
+}
+
+// TestLoadOptionsParsers tests that the parser selection is read with the other transform settings.
+func TestLoadOptionsParsers(t *testing.T) {
+	t.Setenv("PARSERS_ENABLED", "-alarm")
+	t.Setenv("PARSER_OVERRIDES", "ocid1.loggroup.a=alarm")
+
+	opts := LoadOptions()
+	assert.False(t, opts.Parsers.Enabled["alarm"])
+	assert.True(t, opts.Parsers.Enabled["vault"])
+	assert.Equal(t, "alarm", opts.Parsers.Forced["ocid1.loggroup.a"])
+}
+
+// TestApplyParserLogField tests that log lines of the transformations following a parser are tagged with its name.
+func TestApplyParserLogField(t *testing.T) {
+	var out bytes.Buffer
+	l := logger.NewLogrusLogger(logger.WithLogLevel("info"))
+	l.SetOutput(&out)
+	l.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true, DisableColors: true})
+	ctx := logger.WithStage(logger.WithLogger(context.Background(), l), logger.StageTransform)
+	opts := Options{ServiceNameRules: []string{"bogus"}}
+
+	Apply(ctx, map[string]interface{}{"type": "com.oraclecloud.vcn.flowlogs.DataEvent", "data": map[string]interface{}{"action": "ACCEPT"}}, opts)
+	Apply(ctx, map[string]interface{}{"message": "raw"}, opts)
+
+	assert.Equal(t, "level=warning msg=\"Ignoring unknown service name rule: bogus\" parser=flowLogs stage=transform\n"+
+		"level=warning msg=\"Ignoring unknown service name rule: bogus\" stage=transform\n", out.String())
+}
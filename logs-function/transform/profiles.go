@@ -0,0 +1,125 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/config"
+)
+
+// Names of the transform profiles.
+const (
+	ProfileStable = "stable" // ProfileStable is the profile configured by the regular environment variables.
+	ProfileCanary = "canary" // ProfileCanary is the profile configured by the CANARY_-prefixed environment variables.
+)
+
+// Profiles holds the stable transform profile and the canary profile receiving a share of the records.
+type Profiles struct {
+	Stable        Options
+	Canary        Options
+	CanaryPercent int // CanaryPercent is the percentage of records, 0 to 100, processed with the canary profile.
+}
+
+// LoadProfiles reads the stable and canary transform profiles from the function environment.
+func LoadProfiles() Profiles {
+	percent := getEnvInt(os.Getenv(common.CanaryPercent), 0)
+	if percent > 100 {
+		percent = 100
+	}
+
+	return Profiles{
+		Stable:        LoadOptions(),
+		Canary:        loadOptions(ProfileCanary, canaryGetenv),
+		CanaryPercent: percent,
+	}
+}
+
+// profiles is the snapshot of the transform profiles read by invocations.
+var profiles = config.NewSnapshot(LoadProfiles)
+
+// CurrentProfiles returns the published transform profiles without locking, loading them from the function
+// environment on first use. Every stage of an invocation reads the same profiles, even while they are reloaded.
+func CurrentProfiles() Profiles {
+	return profiles.Load()
+}
+
+// ReloadProfiles reads the transform profiles from the function environment and publishes them to the
+// invocations starting afterwards. It is safe to call while other invocations are processing records.
+func ReloadProfiles() Profiles {
+	return profiles.Reload()
+}
+
+// Result describes how a record was processed.
+type Result struct {
+	Keep    bool   // Keep is false when the record should be dropped instead of forwarded.
+	Profile string // Profile is the name of the transform profile applied to the record.
+	Parser  string // Parser is the name of the parser that recognized the record, or empty.
+}
+
+// Apply transforms the record with the profile selected for it. When a canary is configured the
+// chosen profile is recorded on the record so both paths can be compared in New Relic.
+// It returns false when the record should be dropped instead of forwarded.
+func (p Profiles) Apply(ctx context.Context, record map[string]interface{}) bool {
+	return p.Process(ctx, record).Keep
+}
+
+// Process transforms the record like Apply and reports the profile and parser that shaped it. Log lines are
+// tagged with the fields carried by ctx.
+func (p Profiles) Process(ctx context.Context, record map[string]interface{}) Result {
+	unwrap(record)
+	opts := p.Stable
+	if p.CanaryPercent > 0 {
+		if inCanary(record, p.CanaryPercent) {
+			opts = p.Canary
+		}
+		record[common.TransformProfileAttribute] = opts.Profile
+	}
+
+	parserName, keep := apply(ctx, record, opts)
+	return Result{Keep: keep, Profile: opts.Profile, Parser: parserName}
+}
+
+// Version returns a short digest of the transform configuration, which changes whenever any setting of
+// either profile or the canary share changes.
+func (p Profiles) Version() string {
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "%+v", p)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// Force returns the profiles set to process every record with the named profile.
+func (p Profiles) Force(name string) (Profiles, error) {
+	switch name {
+	case ProfileStable:
+		p.CanaryPercent = 0
+	case ProfileCanary:
+		p.CanaryPercent = 100
+	default:
+		return p, fmt.Errorf("unknown transform profile %q", name)
+	}
+	return p, nil
+}
+
+// inCanary reports whether the record belongs to the canary share. Records carrying an OCI event id
+// are assigned deterministically so retried deliveries take the same path.
+func inCanary(record map[string]interface{}, percent int) bool {
+	id, ok := common.LookupString(record, "id")
+	if !ok {
+		return rand.Intn(100) < percent
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return int(h.Sum32()%100) < percent
+}
+
+// canaryGetenv reads the CANARY_-prefixed variant of a setting, falling back to the regular value.
+func canaryGetenv(name string) string {
+	if value, ok := os.LookupEnv(common.CanaryPrefix + name); ok {
+		return value
+	}
+	return os.Getenv(name)
+}
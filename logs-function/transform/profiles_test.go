@@ -0,0 +1,120 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadProfiles tests that the canary profile overrides only its prefixed settings.
+func TestLoadProfiles(t *testing.T) {
+	t.Setenv(common.CanaryPercent, "25")
+	t.Setenv(common.ServiceNameDefault, "stable-service")
+	t.Setenv(common.Base64FieldPolicy, "hash")
+	t.Setenv(common.CanaryPrefix+common.Base64FieldPolicy, "drop")
+
+	profiles := LoadProfiles()
+
+	assert.Equal(t, 25, profiles.CanaryPercent)
+	assert.Equal(t, ProfileStable, profiles.Stable.Profile)
+	assert.Equal(t, ProfileCanary, profiles.Canary.Profile)
+	assert.Equal(t, "hash", profiles.Stable.Base64Policy)
+	assert.Equal(t, "drop", profiles.Canary.Base64Policy)
+	assert.Equal(t, "stable-service", profiles.Canary.ServiceNameDefault)
+}
+
+// TestProfilesApply tests the canary share and the profile attribute.
+func TestProfilesApply(t *testing.T) {
+	profiles := Profiles{
+		Stable: Options{Profile: ProfileStable, ServiceNameDefault: "stable"},
+		Canary: Options{Profile: ProfileCanary, ServiceNameDefault: "canary"},
+	}
+
+	record := map[string]interface{}{"id": "event-1"}
+	profiles.Apply(context.Background(), record)
+	assert.NotContains(t, record, common.TransformProfileAttribute, "No attribute without a canary")
+	assert.Equal(t, "stable", record[common.ServiceNameAttribute])
+
+	profiles.CanaryPercent = 100
+	record = map[string]interface{}{"id": "event-1"}
+	profiles.Apply(context.Background(), record)
+	assert.Equal(t, ProfileCanary, record[common.TransformProfileAttribute])
+	assert.Equal(t, "canary", record[common.ServiceNameAttribute])
+
+	profiles.CanaryPercent = 30
+	canary := 0
+	for i := 0; i < 1000; i++ {
+		record := map[string]interface{}{"id": fmt.Sprintf("event-%d", i)}
+		profiles.Apply(context.Background(), record)
+		if record[common.TransformProfileAttribute] == ProfileCanary {
+			canary++
+		}
+	}
+	assert.InDelta(t, 300, canary, 60)
+}
+
+// TestInCanaryIsDeterministic tests that the same event id always takes the same path.
+func TestInCanaryIsDeterministic(t *testing.T) {
+	record := map[string]interface{}{"id": "ocid1.event.abc"}
+	first := inCanary(record, 50)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, inCanary(record, 50))
+	}
+}
+
+// TestProfilesForce tests that forcing a profile sends every record through it.
+func TestProfilesForce(t *testing.T) {
+	profiles := Profiles{CanaryPercent: 30}
+
+	canary, err := profiles.Force(ProfileCanary)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, canary.CanaryPercent)
+
+	stable, err := profiles.Force(ProfileStable)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stable.CanaryPercent)
+
+	_, err = profiles.Force("beta")
+	assert.ErrorContains(t, err, "unknown transform profile")
+}
+
+// TestProfilesVersion tests that the configuration version changes with the settings.
+func TestProfilesVersion(t *testing.T) {
+	profiles := Profiles{Stable: Options{Profile: ProfileStable}}
+	version := profiles.Version()
+	assert.Len(t, version, 8)
+	assert.Equal(t, version, profiles.Version())
+
+	profiles.Stable.ServiceNameDefault = "app"
+	assert.NotEqual(t, version, profiles.Version())
+}
+
+// TestCurrentProfilesConcurrentReload tests, under the race detector, that records are processed with the
+// current profiles while they are reloaded.
+func TestCurrentProfilesConcurrentReload(t *testing.T) {
+	t.Cleanup(func() { ReloadProfiles() })
+	t.Setenv(common.CanaryPercent, "50")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				profiles := CurrentProfiles()
+				result := profiles.Process(context.Background(), map[string]interface{}{"id": strconv.Itoa(j), "message": "hello"})
+				assert.True(t, result.Keep)
+				assert.Contains(t, []string{ProfileStable, ProfileCanary}, result.Profile)
+			}
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		assert.Equal(t, 50, ReloadProfiles().CanaryPercent)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,64 @@
+package transform
+
+import (
+	"context"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+)
+
+// Supported service.name derivation rules.
+const (
+	ServiceNameRuleResource = "resource" // ServiceNameRuleResource uses the display name of the resource that emitted the log.
+	ServiceNameRuleLogGroup = "logGroup" // ServiceNameRuleLogGroup uses the log group the record was collected from.
+	ServiceNameRuleTag      = "tag:"     // ServiceNameRuleTag is the prefix of rules reading a freeform tag, e.g. "tag:app".
+)
+
+// applyServiceName stamps service.name on the record using the first rule that yields a value,
+// falling back to the configured default. Records already carrying service.name are left untouched.
+func applyServiceName(ctx context.Context, record map[string]interface{}, opts Options) {
+	if len(opts.ServiceNameRules) == 0 && opts.ServiceNameDefault == "" {
+		return
+	}
+	if _, ok := record[common.ServiceNameAttribute]; ok {
+		return
+	}
+
+	if name := deriveServiceName(ctx, record, opts.ServiceNameRules); name != "" {
+		record[common.ServiceNameAttribute] = name
+	} else if opts.ServiceNameDefault != "" {
+		record[common.ServiceNameAttribute] = opts.ServiceNameDefault
+	}
+}
+
+// deriveServiceName evaluates the rules in order and returns the first non-empty value.
+func deriveServiceName(ctx context.Context, record map[string]interface{}, rules []string) string {
+	for _, rule := range rules {
+		var name string
+		var ok bool
+
+		switch {
+		case rule == ServiceNameRuleResource:
+			if name, ok = common.LookupString(record, "data", "resourceName"); !ok {
+				name, ok = common.LookupString(record, "source")
+			}
+		case rule == ServiceNameRuleSubject:
+			name, ok = subjectServiceName(record)
+		case rule == ServiceNameRuleLogGroup:
+			name, ok = common.LookupString(record, "oracle", "loggroupid")
+		case strings.HasPrefix(rule, ServiceNameRuleTag):
+			tag := strings.TrimPrefix(rule, ServiceNameRuleTag)
+			if name, ok = common.LookupString(record, "data", "freeformTags", tag); !ok {
+				name, ok = common.LookupString(record, "freeformTags", tag)
+			}
+		default:
+			logger.FromContext(ctx).Warnf("Ignoring unknown service name rule: %s", rule)
+		}
+
+		if ok {
+			return name
+		}
+	}
+	return ""
+}
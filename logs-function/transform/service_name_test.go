@@ -0,0 +1,81 @@
+package transform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestApplyServiceName tests the service.name derivation rules and the default fallback.
+func TestApplyServiceName(t *testing.T) {
+	record := func() map[string]interface{} {
+		return map[string]interface{}{
+			"source": "my-instance",
+			"oracle": map[string]interface{}{
+				"loggroupid": "ocid1.loggroup.oc1..aaaa",
+			},
+			"data": map[string]interface{}{
+				"freeformTags": map[string]interface{}{"app": "checkout"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		record   map[string]interface{}
+		expected interface{}
+	}{
+		{
+			name:     "tag rule wins when listed first",
+			opts:     Options{ServiceNameRules: []string{"tag:app", "resource"}},
+			record:   record(),
+			expected: "checkout",
+		},
+		{
+			name:     "resource rule uses source",
+			opts:     Options{ServiceNameRules: []string{"resource"}},
+			record:   record(),
+			expected: "my-instance",
+		},
+		{
+			name:     "log group rule",
+			opts:     Options{ServiceNameRules: []string{"logGroup"}},
+			record:   record(),
+			expected: "ocid1.loggroup.oc1..aaaa",
+		},
+		{
+			name:     "missing tag falls through to next rule",
+			opts:     Options{ServiceNameRules: []string{"tag:team", "resource"}},
+			record:   record(),
+			expected: "my-instance",
+		},
+		{
+			name:     "default used when no rule matches",
+			opts:     Options{ServiceNameRules: []string{"tag:team"}, ServiceNameDefault: "oci-logs"},
+			record:   record(),
+			expected: "oci-logs",
+		},
+		{
+			name:     "existing service.name is preserved",
+			opts:     Options{ServiceNameRules: []string{"resource"}},
+			record:   map[string]interface{}{"source": "my-instance", common.ServiceNameAttribute: "custom"},
+			expected: "custom",
+		},
+		{
+			name:     "no rules and no default leave the record untouched",
+			opts:     Options{},
+			record:   record(),
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Apply(context.Background(), tt.record, tt.opts)
+			assert.Equal(t, tt.expected, tt.record[common.ServiceNameAttribute])
+		})
+	}
+}
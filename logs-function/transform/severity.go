@@ -0,0 +1,92 @@
+package transform
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// severityFields are the record fields the severity is read from, in order.
+var severityFields = [][]string{
+	{common.LevelAttribute},
+	{"severity"},
+	{"data", "level"},
+	{"data", "severity"},
+	{"data", "logLevel"},
+	{"data", "loglevel"},
+}
+
+// severity is a normalized log level and its OpenTelemetry severity number, the first of its range.
+type severity struct {
+	level  string
+	number int64
+}
+
+// Normalized severities, in OpenTelemetry severity number order.
+var (
+	severityTrace = severity{"trace", 1}
+	severityDebug = severity{"debug", 5}
+	severityInfo  = severity{"info", 9}
+	severityWarn  = severity{"warn", 13}
+	severityError = severity{"error", 17}
+	severityFatal = severity{"fatal", 21}
+)
+
+// severityNames maps the lower-case level names used by syslog, Java, Python and OCI services to their severity.
+var severityNames = map[string]severity{
+	"trace": severityTrace, "finest": severityTrace, "finer": severityTrace, "verbose": severityTrace,
+	"debug": severityDebug, "fine": severityDebug, "config": severityDebug,
+	"info": severityInfo, "information": severityInfo, "informational": severityInfo, "notice": severityInfo,
+	"warn": severityWarn, "warning": severityWarn,
+	"error": severityError, "err": severityError, "severe": severityError,
+	"fatal": severityFatal, "critical": severityFatal, "crit": severityFatal, "alert": severityFatal,
+	"emerg": severityFatal, "emergency": severityFatal, "panic": severityFatal,
+}
+
+// applySeverity sets severity.number from the first severity field holding a known level name or an
+// OpenTelemetry severity number, and sets level to the normalized name when the record has none.
+func applySeverity(record map[string]interface{}, opts Options) {
+	if !opts.SeverityConversion {
+		return
+	}
+	for _, path := range severityFields {
+		value, ok := common.LookupValue(record, path...)
+		if !ok {
+			continue
+		}
+		if s, ok := parseSeverity(value); ok {
+			record[common.SeverityNumberAttribute] = s.number
+			if _, ok := record[common.LevelAttribute]; !ok {
+				record[common.LevelAttribute] = s.level
+			}
+			return
+		}
+	}
+}
+
+// parseSeverity converts a level name, or a severity number from 1 to 24, into a severity. Numbers keep their
+// exact value, so TRACE2 to FATAL4 survive a round trip.
+func parseSeverity(value interface{}) (severity, bool) {
+	var number int64
+	switch v := value.(type) {
+	case string:
+		s, ok := severityNames[strings.ToLower(strings.TrimSpace(v))]
+		return s, ok
+	case float64:
+		number = int64(v)
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return severity{}, false
+		}
+		number = n
+	default:
+		return severity{}, false
+	}
+	if number < 1 || number > 24 {
+		return severity{}, false
+	}
+	levels := []severity{severityTrace, severityDebug, severityInfo, severityWarn, severityError, severityFatal}
+	return severity{level: levels[(number-1)/4].level, number: number}, true
+}
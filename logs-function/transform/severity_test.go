@@ -0,0 +1,67 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestApplySeverity tests the conversion of severities to level and severity.number.
+func TestApplySeverity(t *testing.T) {
+	tests := []struct {
+		name   string
+		record map[string]interface{}
+		level  interface{}
+		number interface{}
+	}{
+		{
+			name:   "existing level is kept",
+			record: map[string]interface{}{"level": "WARNING"},
+			level:  "WARNING",
+			number: int64(13),
+		},
+		{
+			name:   "nested severity sets the level",
+			record: map[string]interface{}{"data": map[string]interface{}{"severity": "Critical"}},
+			level:  "fatal",
+			number: int64(21),
+		},
+		{
+			name:   "unknown names fall through to the next field",
+			record: map[string]interface{}{"severity": "custom", "data": map[string]interface{}{"logLevel": "debug"}},
+			level:  "debug",
+			number: int64(5),
+		},
+		{
+			name:   "severity numbers are kept exactly",
+			record: map[string]interface{}{"severity": json.Number("18")},
+			level:  "error",
+			number: int64(18),
+		},
+		{
+			name:   "out of range numbers are ignored",
+			record: map[string]interface{}{"severity": 99.0},
+		},
+		{
+			name:   "records without severity are left alone",
+			record: map[string]interface{}{"message": "hello"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applySeverity(tt.record, Options{SeverityConversion: true})
+			assert.Equal(t, tt.level, tt.record[common.LevelAttribute])
+			assert.Equal(t, tt.number, tt.record[common.SeverityNumberAttribute])
+		})
+	}
+}
+
+// TestApplySeverityDisabled tests that records are untouched unless SEVERITY_CONVERSION is enabled.
+func TestApplySeverityDisabled(t *testing.T) {
+	record := map[string]interface{}{"severity": "info"}
+	applySeverity(record, Options{})
+	assert.Equal(t, map[string]interface{}{"severity": "info"}, record)
+}
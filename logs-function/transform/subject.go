@@ -0,0 +1,52 @@
+package transform
+
+import (
+	"path"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// subjectSourcePrefix starts the source patterns matched against the record subject instead of its type,
+// e.g. "subject:/var/log/payments/*".
+const subjectSourcePrefix = "subject:"
+
+// ServiceNameRuleSubject uses the application name encoded in the subject of custom logs: the last element
+// of the log object path without its extension.
+const ServiceNameRuleSubject = "subject"
+
+// matchesSource reports whether the record matches the source pattern of a per-source setting: "*" matches
+// any record, "subject:<glob>" matches the record subject with path.Match, and any other pattern is a prefix
+// of the record type.
+func matchesSource(record map[string]interface{}, source string) bool {
+	if source == anySource {
+		return true
+	}
+	if pattern, ok := strings.CutPrefix(source, subjectSourcePrefix); ok {
+		subject := common.Subject(record)
+		matched, _ := path.Match(pattern, subject)
+		return subject != "" && matched
+	}
+	recordType, _ := common.LookupString(record, "type")
+	return strings.HasPrefix(recordType, source)
+}
+
+// applySubject forwards the CloudEvent subject of the record, the path of the log object custom-log producers
+// write to, as log.subject.
+func applySubject(record map[string]interface{}) {
+	if subject := common.Subject(record); subject != "" {
+		record[common.LogSubjectAttribute] = subject
+	}
+}
+
+// subjectServiceName returns the application name encoded in the subject, e.g. "payments" for
+// "/var/log/app/payments.log".
+func subjectServiceName(record map[string]interface{}) (string, bool) {
+	subject := common.Subject(record)
+	if subject == "" {
+		return "", false
+	}
+	name := path.Base(strings.TrimRight(subject, "/"))
+	name = strings.TrimSuffix(name, path.Ext(name))
+	return name, name != "" && name != "." && name != "/"
+}
@@ -0,0 +1,53 @@
+package transform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMatchesSource tests matching of per-source settings against the record type and subject.
+func TestMatchesSource(t *testing.T) {
+	record := map[string]interface{}{
+		"type":    "com.oraclecloud.logging.custom.app",
+		"subject": "/var/log/payments/api.log",
+	}
+
+	assert.True(t, matchesSource(record, "*"))
+	assert.True(t, matchesSource(record, "com.oraclecloud.logging.custom"))
+	assert.False(t, matchesSource(record, "com.oraclecloud.audit"))
+	assert.True(t, matchesSource(record, "subject:/var/log/payments/*"))
+	assert.False(t, matchesSource(record, "subject:/var/log/orders/*"))
+	assert.False(t, matchesSource(map[string]interface{}{}, "subject:*"))
+}
+
+// TestApplySubject tests that the subject is forwarded as log.subject and used as a service name.
+func TestApplySubject(t *testing.T) {
+	record := map[string]interface{}{"subject": "/var/log/payments/api.log"}
+	Apply(context.Background(), record, Options{ServiceNameRules: []string{ServiceNameRuleSubject}})
+	assert.Equal(t, "/var/log/payments/api.log", record[common.LogSubjectAttribute])
+	assert.Equal(t, "api", record[common.ServiceNameAttribute])
+
+	record = map[string]interface{}{"message": "no subject"}
+	Apply(context.Background(), record, Options{})
+	assert.NotContains(t, record, common.LogSubjectAttribute)
+
+	name, ok := subjectServiceName(map[string]interface{}{"subject": "checkout"})
+	assert.True(t, ok)
+	assert.Equal(t, "checkout", name)
+}
+
+// TestApplyMessageLengthBySubject tests that message length limits can target the subject of custom logs.
+func TestApplyMessageLengthBySubject(t *testing.T) {
+	opts := Options{MessageLengthLimits: parseMessageLengthLimits("subject:/var/log/debug/*=4,*=100")}
+
+	record := map[string]interface{}{"subject": "/var/log/debug/trace.log", "message": "verbose output"}
+	applyMessageLength(record, opts)
+	assert.Equal(t, "verb...[truncated]", record["message"])
+
+	record = map[string]interface{}{"subject": "/var/log/app.log", "message": "verbose output"}
+	applyMessageLength(record, opts)
+	assert.Equal(t, "verbose output", record["message"])
+}
@@ -0,0 +1,153 @@
+// Package transform provides record-level transformations applied to OCI log records
+// before they are batched and forwarded to New Relic.
+package transform
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/parser"
+)
+
+// Options holds the transformation settings for a single invocation. A named set of Options is a transform profile.
+type Options struct {
+	Profile string // Profile is the name of the transform profile these options belong to.
+
+	ServiceNameRules   []string // ServiceNameRules is the ordered list of rules used to derive service.name.
+	ServiceNameDefault string   // ServiceNameDefault is the service.name used when no rule matches.
+	Base64Policy       string   // Base64Policy selects how large base64-encoded field values are handled.
+	Base64MaxBytes     int      // Base64MaxBytes is the size above which a base64 value is subject to Base64Policy.
+	AuditProfile       string   // AuditProfile selects the curated transformations applied to _Audit records.
+
+	AuditHeaderAllowlist map[string]bool // AuditHeaderAllowlist holds the lower-case header names kept on _Audit records; nil keeps all.
+
+	MessageLengthLimits  []MessageLengthLimit // MessageLengthLimits caps message length per source, first match wins.
+	EmptyMessagePolicies []EmptyMessagePolicy // EmptyMessagePolicies selects the treatment of records without a message per source, first match wins.
+
+	SeverityConversion bool // SeverityConversion sets level and severity.number from the severity of the record.
+
+	DoubleEncodedFields    [][]string // DoubleEncodedFields holds the paths of the fields repaired when encoded more than once.
+	DoubleEncodingMaxDepth int        // DoubleEncodingMaxDepth bounds the decodes of the value of one field.
+
+	Parsers parser.Selection // Parsers selects the parsers applied to records.
+
+	FutureTimestampThreshold time.Duration // FutureTimestampThreshold is how far ahead a record time may be before it is re-stamped; 0 disables it.
+
+	HeartbeatFilter        string // HeartbeatFilter selects the treatment of heartbeat records.
+	HeartbeatSamplePercent int    // HeartbeatSamplePercent is the percentage of heartbeat records forwarded when sampling.
+
+	CompartmentAllowlist map[string]bool // CompartmentAllowlist holds the only compartment OCIDs forwarded, when non-empty.
+	CompartmentDenylist  map[string]bool // CompartmentDenylist holds the compartment OCIDs whose records are dropped.
+}
+
+// LoadOptions reads the transformation settings from the function environment.
+func LoadOptions() Options {
+	return loadOptions(ProfileStable, os.Getenv)
+}
+
+// loadOptions builds the named profile from the settings returned by getenv.
+func loadOptions(profile string, getenv func(string) string) Options {
+	return Options{
+		Profile:            profile,
+		ServiceNameRules:   splitList(getenv(common.ServiceNameRules)),
+		ServiceNameDefault: strings.TrimSpace(getenv(common.ServiceNameDefault)),
+		Base64Policy:       strings.ToLower(strings.TrimSpace(getenv(common.Base64FieldPolicy))),
+		Base64MaxBytes:     getEnvInt(getenv(common.Base64FieldMaxBytes), common.DefaultBase64FieldMaxBytes),
+		AuditProfile:       strings.ToLower(strings.TrimSpace(getenv(common.AuditProfile))),
+
+		AuditHeaderAllowlist: parseAuditHeaderAllowlist(getenv(common.AuditHeaderAllowlist)),
+
+		MessageLengthLimits:  parseMessageLengthLimits(getenv(common.MessageLengthLimits)),
+		EmptyMessagePolicies: parseEmptyMessagePolicies(getenv(common.EmptyMessagePolicy)),
+
+		SeverityConversion: strings.TrimSpace(getenv(common.SeverityConversion)) == "true",
+
+		DoubleEncodedFields:    parseFieldPaths(getenv(common.DoubleEncodedFields)),
+		DoubleEncodingMaxDepth: getEnvInt(getenv(common.DoubleEncodingMaxDepth), common.DefaultDoubleEncodingMaxDepth),
+
+		Parsers: parser.Selection{
+			Enabled: parseParsersEnabled(getenv(common.ParsersEnabled)),
+			Forced:  parseParserOverrides(getenv(common.ParserOverrides)),
+		},
+
+		FutureTimestampThreshold: parseFutureTimestampThreshold(getenv(common.FutureTimestampThreshold)),
+
+		HeartbeatFilter:        parseHeartbeatFilter(getenv(common.HeartbeatFilter)),
+		HeartbeatSamplePercent: getEnvInt(getenv(common.HeartbeatSamplePercent), common.DefaultHeartbeatSamplePercent),
+
+		CompartmentAllowlist: toSet(splitList(getenv(common.CompartmentAllowlist))),
+		CompartmentDenylist:  toSet(splitList(getenv(common.CompartmentDenylist))),
+	}
+}
+
+// Apply unwraps the record and runs all configured transformations on it in place.
+// It returns false when the record should be dropped instead of forwarded.
+func Apply(ctx context.Context, record map[string]interface{}, opts Options) bool {
+	unwrap(record)
+	_, keep := apply(ctx, record, opts)
+	return keep
+}
+
+// apply runs the configured transformations on an unwrapped record. It returns the name of the
+// parser that recognized the record and whether the record should be forwarded. Log lines of the
+// transformations following a parser are tagged with its name.
+func apply(ctx context.Context, record map[string]interface{}, opts Options) (string, bool) {
+	if !allowCompartment(record, opts) || !applyHeartbeatFilter(record, opts) {
+		return "", false
+	}
+
+	applyDoubleEncodingRepair(record, opts)
+	parserName := opts.Parsers.Apply(record)
+	if parserName != "" {
+		ctx = logger.WithParser(ctx, parserName)
+	}
+	applyAuditProfile(record, opts)
+	applyAuditHeaderAllowlist(record, opts)
+	applyDerivedAttributes(record)
+	applySubject(record)
+	applySeverity(record, opts)
+	applyServiceName(ctx, record, opts)
+	applyCategory(record, parserName)
+	applyBase64Policy(ctx, record, opts)
+	if !applyEmptyMessagePolicy(record, opts) {
+		return parserName, false
+	}
+	applyMessageLength(record, opts)
+	applyFutureTimestamp(ctx, record, opts)
+	return parserName, true
+}
+
+// getEnvInt returns the positive integer held by an environment value, or defaultValue when it is unset or invalid.
+func getEnvInt(envValue string, defaultValue int) int {
+	if envValue != "" {
+		if parsed, err := strconv.Atoi(envValue); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// splitList splits a comma-separated environment value into its trimmed, non-empty elements.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// toSet converts the items into a lookup set.
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
@@ -0,0 +1,51 @@
+package transform
+
+// maxUnwrapDepth bounds how many wrapper levels are removed from a single record.
+const maxUnwrapDepth = 4
+
+// unwrap hoists OCI log records delivered inside wrappers to the record root, so transforms, filters and
+// routing see the same shape regardless of how the record reached the function. The wrappers removed are:
+//
+//   - logContent, as written by Logging search exports: {"datetime":..., "logContent":{"data":..., "oracle":...}}
+//   - data.logContent, as relayed by Connector Hub from a stream: {"data":{"logContent":{...}}}
+//   - data.data, a data object holding nothing but another data object: {"data":{"data":{...}}}
+//
+// Fields of the inner record take precedence over wrapper fields with the same name.
+func unwrap(record map[string]interface{}) {
+	for depth := 0; depth < maxUnwrapDepth; depth++ {
+		if !unwrapOnce(record) {
+			return
+		}
+	}
+}
+
+// unwrapOnce removes the outermost wrapper of the record, reporting whether one was found.
+func unwrapOnce(record map[string]interface{}) bool {
+	if inner, ok := record["logContent"].(map[string]interface{}); ok {
+		delete(record, "logContent")
+		hoist(record, inner)
+		return true
+	}
+
+	data, ok := record["data"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if inner, ok := data["logContent"].(map[string]interface{}); ok && len(data) == 1 {
+		delete(record, "data")
+		hoist(record, inner)
+		return true
+	}
+	if inner, ok := data["data"].(map[string]interface{}); ok && len(data) == 1 {
+		record["data"] = inner
+		return true
+	}
+	return false
+}
+
+// hoist copies the fields of inner onto the record, overwriting fields with the same name.
+func hoist(record map[string]interface{}, inner map[string]interface{}) {
+	for key, value := range inner {
+		record[key] = value
+	}
+}
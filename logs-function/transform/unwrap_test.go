@@ -0,0 +1,74 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUnwrap tests hoisting of each wrapper permutation delivered to the function.
+func TestUnwrap(t *testing.T) {
+	inner := func() map[string]interface{} {
+		return map[string]interface{}{
+			"data":   map[string]interface{}{"message": "hello"},
+			"oracle": map[string]interface{}{"compartmentid": "ocid1.compartment.a"},
+			"type":   "com.oraclecloud.logging.custom.app",
+		}
+	}
+
+	tests := []struct {
+		name     string
+		record   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "unwrapped record is unchanged",
+			record:   inner(),
+			expected: inner(),
+		},
+		{
+			name:   "logContent",
+			record: map[string]interface{}{"datetime": 1704067200000, "logContent": inner()},
+			expected: map[string]interface{}{
+				"datetime": 1704067200000,
+				"data":     map[string]interface{}{"message": "hello"},
+				"oracle":   map[string]interface{}{"compartmentid": "ocid1.compartment.a"},
+				"type":     "com.oraclecloud.logging.custom.app",
+			},
+		},
+		{
+			name:     "data.logContent",
+			record:   map[string]interface{}{"data": map[string]interface{}{"logContent": inner()}},
+			expected: inner(),
+		},
+		{
+			name: "data.logContent.data",
+			record: map[string]interface{}{"data": map[string]interface{}{"logContent": map[string]interface{}{
+				"data": map[string]interface{}{"data": map[string]interface{}{"message": "hello"}},
+			}}},
+			expected: map[string]interface{}{"data": map[string]interface{}{"message": "hello"}},
+		},
+		{
+			name:     "data.data",
+			record:   map[string]interface{}{"type": "t", "data": map[string]interface{}{"data": map[string]interface{}{"message": "hello"}}},
+			expected: map[string]interface{}{"type": "t", "data": map[string]interface{}{"message": "hello"}},
+		},
+		{
+			name:     "data with sibling fields is not a wrapper",
+			record:   map[string]interface{}{"data": map[string]interface{}{"data": map[string]interface{}{"k": "v"}, "message": "m"}},
+			expected: map[string]interface{}{"data": map[string]interface{}{"data": map[string]interface{}{"k": "v"}, "message": "m"}},
+		},
+		{
+			name:     "inner fields take precedence",
+			record:   map[string]interface{}{"type": "wrapper", "logContent": map[string]interface{}{"type": "inner"}},
+			expected: map[string]interface{}{"type": "inner"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unwrap(tt.record)
+			assert.Equal(t, tt.expected, tt.record)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package unmarshal
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// strictSchemaName is the input schema declaring the top-level fields of OCI Logging records.
+const strictSchemaName = "oci-logging"
+
+// maxListedViolations bounds the problems listed in a SchemaViolation.
+const maxListedViolations = 10
+
+// SchemaViolation is the error returned by Unmarshal, with STRICT_SCHEMA enabled, for an OCI Logging payload with
+// unexpected top-level structures. The event still holds the decoded records so the payload can be dead-lettered.
+type SchemaViolation struct {
+	Problems []string // Problems describes each unexpected structure, by record index.
+}
+
+// Error lists the first problems of the payload.
+func (v *SchemaViolation) Error() string {
+	problems := v.Problems
+	more := ""
+	if len(problems) > maxListedViolations {
+		more = fmt.Sprintf("; and %d more", len(problems)-maxListedViolations)
+		problems = problems[:maxListedViolations]
+	}
+	return fmt.Sprintf("payload violates the %s schema: %s%s", strictSchemaName, strings.Join(problems, "; "), more)
+}
+
+// knownRecordFields holds the top-level record fields declared by the oci-logging input schema.
+var knownRecordFields = recordFields(strictSchemaName)
+
+// recordFields returns the properties of the records of the named input schema.
+func recordFields(name string) map[string]bool {
+	fields := map[string]bool{}
+	for _, input := range inputSchemas {
+		if input.name == name && input.schema.Items != nil {
+			for field := range input.schema.Items.Properties {
+				fields[field] = true
+			}
+		}
+	}
+	return fields
+}
+
+// checkStrictSchema returns a SchemaViolation for the records that are not objects or have undeclared fields when
+// STRICT_SCHEMA is "true", and nil otherwise.
+func checkStrictSchema(elements []interface{}) error {
+	if os.Getenv(common.StrictSchema) != "true" {
+		return nil
+	}
+	var problems []string
+	for i, element := range elements {
+		record, ok := element.(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("record %d: not an object", i))
+			continue
+		}
+		var unknown []string
+		for field := range record {
+			if !knownRecordFields[field] {
+				unknown = append(unknown, field)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			problems = append(problems, fmt.Sprintf("record %d: unexpected fields %s", i, strings.Join(unknown, ", ")))
+		}
+	}
+	if problems == nil {
+		return nil
+	}
+	metrics.Default.Counter("input.rejected").Inc()
+	return &SchemaViolation{Problems: problems}
+}
@@ -0,0 +1,61 @@
+package unmarshal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// strictRecord is a record with only the fields declared by the oci-logging schema.
+const strictRecord = `{"data":{"message":"hello"},"id":"a","oracle":{"compartmentid":"c","loggroupid":"g"},"source":"s","specversion":"1.0","time":"2026-03-02T10:00:00Z","type":"com.oraclecloud.logging.custom.app"}`
+
+// TestUnmarshalStrictSchema tests that STRICT_SCHEMA rejects unexpected top-level structures but still decodes them.
+func TestUnmarshalStrictSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		strict   string
+		payload  string
+		records  int
+		problems []string
+	}{
+		{"declared fields", "true", `[` + strictRecord + `]`, 1, nil},
+		{"undeclared fields", "true", `[` + strictRecord + `,{"message":"hi","tags":{}}]`, 2, []string{"record 1: unexpected fields message, tags"}},
+		{"scalar element", "true", `[` + strictRecord + `,"plain text"]`, 2, []string{"record 1: not an object"}},
+		{"disabled", "", `[{"message":"hi"},42]`, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(common.StrictSchema, tt.strict)
+			for _, passthrough := range []string{"", "true"} {
+				t.Setenv(common.RawMessagePassthrough, passthrough)
+				event := Event{}
+				err := event.Unmarshal(context.Background(), bytes.NewBufferString(tt.payload))
+				assert.Equal(t, OCI_LOGGING, event.EventType)
+				assert.Len(t, event.OCILoggingEvent, tt.records)
+				if tt.problems == nil {
+					assert.NoError(t, err)
+					continue
+				}
+				var violation *SchemaViolation
+				assert.True(t, errors.As(err, &violation))
+				assert.Equal(t, tt.problems, violation.Problems)
+			}
+		})
+	}
+}
+
+// TestSchemaViolationError tests that long lists of problems are shortened.
+func TestSchemaViolationError(t *testing.T) {
+	violation := &SchemaViolation{}
+	for i := 0; i < maxListedViolations+2; i++ {
+		violation.Problems = append(violation.Problems, fmt.Sprintf("record %d: not an object", i))
+	}
+	assert.True(t, strings.HasSuffix(violation.Error(), "record 9: not an object; and 2 more"))
+	assert.True(t, knownRecordFields["oracle"])
+}
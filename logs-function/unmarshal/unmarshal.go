@@ -2,40 +2,203 @@
 package unmarshal
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io"
+	"os"
+	"strings"
 
 	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/dlq"
 	"github.com/newrelic/oci-log-integration/logs-function/logger"
 )
 
 // Defines the event types
 const (
-	OCI_LOGGING = "ociLogging" // OCI_LOGGING represents the event type for Oracle Cloud Infrastructure logging events.
+	OCI_LOGGING  = "ociLogging"  // OCI_LOGGING represents the event type for Oracle Cloud Infrastructure logging events.
+	RETRY_STREAM = "retryStream" // RETRY_STREAM represents batches that failed delivery, replayed from the retry stream.
 )
 
-var log = logger.NewLogrusLogger(logger.WithDebugLevel())
-
 // Event represents the unified event structure.
 type Event struct {
 	EventType       string                 // EventType represents the type of the event.
 	OCILoggingEvent common.OCILoggingEvent // OCILoggingEvent represents the Oracle Cloud Infrastructure logging events.
+	PayloadSize     int                    // PayloadSize is the size in bytes of the raw incoming payload.
+	RawRecords      []json.RawMessage      // RawRecords holds the original bytes of each record when raw message passthrough is enabled.
+	ContentEncoding string                 // ContentEncoding is the Content-Encoding of the incoming payload, when known.
+	RetryEnvelopes  []dlq.Envelope         // RetryEnvelopes holds the replayed batches of a retry stream event.
 }
 
-// Unmarshal unmarshals the JSON data into the Event struct.
-func (event *Event) Unmarshal(in io.Reader) error {
+// gzipMagic is the header of gzip compressed data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Unmarshal unmarshals the JSON data into the Event struct. With STRICT_SCHEMA enabled, an OCI Logging payload with
+// unexpected top-level structures is decoded and reported with a *SchemaViolation. Log lines are tagged with the
+// fields carried by ctx.
+func (event *Event) Unmarshal(ctx context.Context, in io.Reader) error {
+	ulog := logger.FromContext(ctx)
 	payloadBytes, err := io.ReadAll(in)
 	if err != nil {
-		log.Panicf("Error reading incoming payload: %v\n", err)
+		ulog.Panicf("Error reading incoming payload: %v\n", err)
+	}
+
+	payloadBytes, err = event.decompress(ctx, payloadBytes)
+	if err != nil {
+		ulog.Panicf("Error decompressing incoming payload: %v", err)
 	}
+	event.PayloadSize = len(payloadBytes)
+	validateInput(ctx, payloadBytes)
 
-	var incomingLogEvent common.OCILoggingEvent
-	if err := json.Unmarshal(payloadBytes, &incomingLogEvent); err == nil {
+	if os.Getenv(common.RawMessagePassthrough) == "true" {
+		return event.unmarshalRaw(ctx, payloadBytes)
+	}
+
+	var elements []interface{}
+	if err := decodeJSON(payloadBytes, &elements); err == nil {
+		incomingLogEvent := make(common.OCILoggingEvent, len(elements))
+		for i, element := range elements {
+			incomingLogEvent[i], _ = toRecord(element)
+		}
+
+		if envelopes, ok := retryEnvelopes(incomingLogEvent); ok {
+			event.EventType = RETRY_STREAM
+			event.RetryEnvelopes = envelopes
+			return nil
+		}
 		event.EventType = OCI_LOGGING
 		event.OCILoggingEvent = incomingLogEvent
+		return checkStrictSchema(elements)
+	} else if notification, ok := alarmNotification(payloadBytes); ok {
+		event.EventType = OCI_LOGGING
+		event.OCILoggingEvent = common.OCILoggingEvent{notification}
 	} else {
-		log.Panicf("Error decoding incoming log events payload: %v", err)
+		ulog.Panicf("Error decoding incoming log events payload: %v", err)
+	}
+
+	return nil
+}
+
+// decompress returns the gzip-decompressed payload when it is declared or detected as gzip compressed,
+// since some Connector Hub configurations deliver compressed bodies. Other payloads are returned unchanged.
+func (event *Event) decompress(ctx context.Context, payloadBytes []byte) ([]byte, error) {
+	compressed := bytes.HasPrefix(payloadBytes, gzipMagic)
+	if strings.EqualFold(event.ContentEncoding, "gzip") && !compressed {
+		return nil, errors.New("payload declared as gzip is not gzip compressed")
+	}
+	if !compressed {
+		return payloadBytes, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(payloadBytes))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
 	}
+	logger.FromContext(ctx).Debugf("Decompressed gzip payload from %d to %d bytes", len(payloadBytes), len(decompressed))
+	return decompressed, nil
+}
+
+// retryEnvelopes decodes the envelopes of a payload delivered by a connector from the retry stream: stream
+// messages whose base64 value is a dlq envelope. It reports false for any other payload.
+func retryEnvelopes(records common.OCILoggingEvent) ([]dlq.Envelope, bool) {
+	if len(records) == 0 {
+		return nil, false
+	}
+	envelopes := make([]dlq.Envelope, 0, len(records))
+	for _, record := range records {
+		if _, ok := record["stream"]; !ok {
+			return nil, false
+		}
+		value, ok := record["value"].(string)
+		if !ok {
+			return nil, false
+		}
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, false
+		}
+		envelope, err := dlq.Decode(data)
+		if err != nil {
+			return nil, false
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	return envelopes, true
+}
+
+// alarmNotification decodes a payload delivered by a Notifications topic subscription: a single OCI Monitoring
+// alarm notification object rather than an array of records. It reports false for any other payload.
+func alarmNotification(payloadBytes []byte) (map[string]interface{}, bool) {
+	var notification map[string]interface{}
+	if err := decodeJSON(payloadBytes, &notification); err != nil {
+		return nil, false
+	}
+	if _, ok := notification["alarmMetaData"].([]interface{}); !ok {
+		return nil, false
+	}
+	return notification, true
+}
+
+// unmarshalRaw keeps the original bytes of every record next to its decoded form, so the record can be
+// forwarded verbatim while filtering and routing still see its fields.
+func (event *Event) unmarshalRaw(ctx context.Context, payloadBytes []byte) error {
+	ulog := logger.FromContext(ctx)
+	var rawRecords []json.RawMessage
+	if err := decodeJSON(payloadBytes, &rawRecords); err != nil {
+		ulog.Panicf("Error decoding incoming log events payload: %v", err)
+	}
+
+	incomingLogEvent := make(common.OCILoggingEvent, len(rawRecords))
+	elements := make([]interface{}, len(rawRecords))
+	for i, raw := range rawRecords {
+		var element interface{}
+		if err := decodeJSON(raw, &element); err != nil {
+			ulog.Panicf("Error decoding incoming log record %d: %v", i, err)
+		}
+		elements[i] = element
+		record, wrapped := toRecord(element)
+		if wrapped {
+			// A scalar is not a record on its own, so the wrapped record is forwarded in its place.
+			if raw, err := json.Marshal(record); err == nil {
+				rawRecords[i] = raw
+			}
+		}
+		incomingLogEvent[i] = record
+	}
+
+	event.EventType = OCI_LOGGING
+	event.OCILoggingEvent = incomingLogEvent
+	event.RawRecords = rawRecords
+	return checkStrictSchema(elements)
+}
 
+// toRecord returns the array element as a log record. Null and scalar elements, which some connectors mix
+// in with regular records, are wrapped as {"message": <value>} and reported as wrapped.
+func toRecord(element interface{}) (map[string]interface{}, bool) {
+	if record, ok := element.(map[string]interface{}); ok {
+		return record, false
+	}
+	return map[string]interface{}{"message": element}, true
+}
+
+// decodeJSON decodes a single JSON document, keeping numbers as json.Number so large integers
+// (ports, epoch nanoseconds, IDs) are re-emitted exactly instead of going through float64.
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("unexpected data after top-level JSON value")
+	}
 	return nil
 }
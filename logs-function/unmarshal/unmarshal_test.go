@@ -2,6 +2,10 @@ package unmarshal
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"testing"
 
 	"github.com/newrelic/oci-log-integration/logs-function/common"
@@ -38,7 +42,7 @@ func TestUnmarshalJSONOCILoggingData(t *testing.T) {
 	}
 
 	var event Event
-	err := event.Unmarshal(bytes.NewReader(input))
+	err := event.Unmarshal(context.Background(), bytes.NewReader(input))
 	assert.NoError(t, err)
 
 	assert.Equal(t, expected.EventType, event.EventType)
@@ -66,7 +70,7 @@ func TestUnmarshalSingleOCILoggingEvent(t *testing.T) {
 	}
 
 	var event Event
-	err := event.Unmarshal(bytes.NewReader(input))
+	err := event.Unmarshal(context.Background(), bytes.NewReader(input))
 	assert.NoError(t, err)
 
 	assert.Equal(t, expected.EventType, event.EventType)
@@ -152,7 +156,7 @@ func TestUnmarshalComplexOCILoggingEvent(t *testing.T) {
 				},
 				"cache": map[string]interface{}{
 					"key": "user-profile-123",
-					"ttl": float64(3600),
+					"ttl": json.Number("3600"),
 				},
 				"metadata": map[string]interface{}{
 					"size":        "2.4MB",
@@ -163,9 +167,146 @@ func TestUnmarshalComplexOCILoggingEvent(t *testing.T) {
 	}
 
 	var event Event
-	err := event.Unmarshal(bytes.NewReader(input))
+	err := event.Unmarshal(context.Background(), bytes.NewReader(input))
 	assert.NoError(t, err)
 
 	assert.Equal(t, expected.EventType, event.EventType)
 	assert.Equal(t, expected.OCILoggingEvent, event.OCILoggingEvent)
 }
+
+// TestUnmarshalPreservesNumberPrecision tests that large integers survive a decode/encode round trip unchanged.
+func TestUnmarshalPreservesNumberPrecision(t *testing.T) {
+	input := []byte(`[{"epochNanos":1704067200123456789,"port":443,"ratio":0.25}]`)
+
+	var event Event
+	assert.NoError(t, event.Unmarshal(context.Background(), bytes.NewReader(input)))
+
+	encoded, err := json.Marshal(event.OCILoggingEvent)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(input), string(encoded))
+	assert.Contains(t, string(encoded), "1704067200123456789")
+}
+
+// TestUnmarshalTrailingData tests that data after the payload array is rejected.
+func TestUnmarshalTrailingData(t *testing.T) {
+	var event Event
+	assert.Panics(t, func() {
+		_ = event.Unmarshal(context.Background(), bytes.NewReader([]byte(`[{"message":"a"}] [{"message":"b"}]`)))
+	})
+}
+
+// TestUnmarshalRawPassthrough tests that raw message passthrough keeps each record's original bytes.
+func TestUnmarshalRawPassthrough(t *testing.T) {
+	t.Setenv(common.RawMessagePassthrough, "true")
+	input := []byte(`[{"z":1,"a":"x"},
+		{"b" : 2.50}]`)
+
+	var event Event
+	assert.NoError(t, event.Unmarshal(context.Background(), bytes.NewReader(input)))
+
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`{"z":1,"a":"x"}`), json.RawMessage(`{"b" : 2.50}`)}, event.RawRecords)
+	assert.Equal(t, common.OCILoggingEvent{
+		{"z": json.Number("1"), "a": "x"},
+		{"b": json.Number("2.50")},
+	}, event.OCILoggingEvent)
+}
+
+// TestUnmarshalScalarElements tests that null and scalar array elements are wrapped as message records.
+func TestUnmarshalScalarElements(t *testing.T) {
+	tests := []struct {
+		name        string
+		passthrough string
+	}{
+		{"decoded", ""},
+		{"raw passthrough", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(common.RawMessagePassthrough, tt.passthrough)
+			input := []byte(`[{"message":"a"}, null, 42, "plain text", true]`)
+
+			var event Event
+			assert.NoError(t, event.Unmarshal(context.Background(), bytes.NewReader(input)))
+
+			assert.Equal(t, OCI_LOGGING, event.EventType)
+			assert.Equal(t, common.OCILoggingEvent{
+				{"message": "a"},
+				{"message": nil},
+				{"message": json.Number("42")},
+				{"message": "plain text"},
+				{"message": true},
+			}, event.OCILoggingEvent)
+			if tt.passthrough == "true" {
+				assert.Equal(t, json.RawMessage(`{"message":42}`), event.RawRecords[2])
+			}
+		})
+	}
+}
+
+// TestUnmarshalGzipPayload tests that gzip compressed payloads are detected and decompressed before decoding.
+func TestUnmarshalGzipPayload(t *testing.T) {
+	input := []byte(`[{"message":"compressed"}]`)
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, _ = writer.Write(input)
+	_ = writer.Close()
+
+	tests := []struct {
+		name     string
+		encoding string
+	}{
+		{"detected by magic bytes", ""},
+		{"declared by header", "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := Event{ContentEncoding: tt.encoding}
+			assert.NoError(t, event.Unmarshal(context.Background(), bytes.NewReader(compressed.Bytes())))
+			assert.Equal(t, "compressed", event.OCILoggingEvent[0]["message"])
+			assert.Equal(t, len(input), event.PayloadSize)
+		})
+	}
+
+	assert.Panics(t, func() {
+		event := Event{ContentEncoding: "gzip"}
+		_ = event.Unmarshal(context.Background(), bytes.NewReader(input))
+	})
+}
+
+// TestUnmarshalRetryStream tests that messages delivered from the retry stream are decoded as envelopes,
+// while other stream payloads stay log records.
+func TestUnmarshalRetryStream(t *testing.T) {
+	envelope := base64.StdEncoding.EncodeToString([]byte(`{"version":1,"transformed":[{"common":{"attributes":{}},"logs":[{"message":"retry me"}]}],"attempts":2}`))
+	input := `[{"stream":"retry","partition":"0","key":null,"value":"` + envelope + `","offset":7,"timestamp":1700000000000}]`
+
+	var event Event
+	assert.NoError(t, event.Unmarshal(context.Background(), bytes.NewReader([]byte(input))))
+	assert.Equal(t, RETRY_STREAM, event.EventType)
+	assert.Len(t, event.RetryEnvelopes, 1)
+	assert.Equal(t, 2, event.RetryEnvelopes[0].Attempts)
+	assert.Equal(t, "retry me", event.RetryEnvelopes[0].Transformed[0].Entries[0]["message"])
+
+	other := `[{"stream":"app","value":"` + base64.StdEncoding.EncodeToString([]byte(`hello`)) + `"}]`
+	event = Event{}
+	assert.NoError(t, event.Unmarshal(context.Background(), bytes.NewReader([]byte(other))))
+	assert.Equal(t, OCI_LOGGING, event.EventType)
+}
+
+// TestUnmarshalAlarmNotification tests that a single alarm notification delivered by a Notifications topic is
+// unmarshaled as one record.
+func TestUnmarshalAlarmNotification(t *testing.T) {
+	input := []byte(`{"dedupeKey":"k","title":"HighCpu","type":"OK_TO_FIRING","alarmMetaData":[{"id":"ocid1.alarm.a"}]}`)
+
+	var event Event
+	assert.NoError(t, event.Unmarshal(context.Background(), bytes.NewReader(input)))
+	assert.Equal(t, OCI_LOGGING, event.EventType)
+	assert.Len(t, event.OCILoggingEvent, 1)
+	assert.Equal(t, "HighCpu", event.OCILoggingEvent[0]["title"])
+
+	assert.Panics(t, func() {
+		var other Event
+		_ = other.Unmarshal(context.Background(), bytes.NewReader([]byte(`{"message":"not an alarm"}`)))
+	})
+}
@@ -0,0 +1,228 @@
+package unmarshal
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// schemaFiles holds the JSON Schemas of the supported input formats, one <name>.schema.json file each.
+//
+//go:embed schemas/*.schema.json
+var schemaFiles embed.FS
+
+// schemaSuffix ends the file name of every input schema.
+const schemaSuffix = ".schema.json"
+
+// jsonSchema is the subset of JSON Schema the input schemas are written in: type, required, properties, items
+// and minItems. Other keywords are documentation only.
+type jsonSchema struct {
+	Type       schemaTypes            `json:"type"`
+	Required   []string               `json:"required"`
+	Properties map[string]*jsonSchema `json:"properties"`
+	Items      *jsonSchema            `json:"items"`
+	MinItems   int                    `json:"minItems"`
+}
+
+// schemaTypes are the types a value may have, given in the schema as a single type or an array of types.
+type schemaTypes []string
+
+// UnmarshalJSON accepts a single type name or an array of type names.
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var several []string
+	if err := json.Unmarshal(data, &several); err != nil {
+		return err
+	}
+	*t = several
+	return nil
+}
+
+// inputSchema is a parsed input schema.
+type inputSchema struct {
+	name   string
+	schema *jsonSchema
+}
+
+// inputSchemas are the parsed input schemas, sorted by name.
+var inputSchemas = loadInputSchemas()
+
+// loadInputSchemas parses the embedded input schemas. A schema that does not parse is a build defect.
+func loadInputSchemas() []inputSchema {
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		panic(err)
+	}
+	schemas := make([]inputSchema, 0, len(entries))
+	for _, entry := range entries {
+		data, err := schemaFiles.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var schema jsonSchema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			panic(fmt.Sprintf("input schema %s: %v", entry.Name(), err))
+		}
+		schemas = append(schemas, inputSchema{name: strings.TrimSuffix(entry.Name(), schemaSuffix), schema: &schema})
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].name < schemas[j].name })
+	return schemas
+}
+
+// InputSchemas returns the JSON Schemas of the supported input formats by name.
+func InputSchemas() map[string]json.RawMessage {
+	schemas := make(map[string]json.RawMessage, len(inputSchemas))
+	for _, schema := range inputSchemas {
+		data, _ := schemaFiles.ReadFile(path.Join("schemas", schema.name+schemaSuffix))
+		schemas[schema.name] = data
+	}
+	return schemas
+}
+
+// SchemaFailure is the first violation of an input schema found in a payload.
+type SchemaFailure struct {
+	Schema string // Schema is the name of the input schema.
+	Path   string // Path locates the offending value, e.g. $[0].oracle.
+	Reason string // Reason describes the violation.
+}
+
+// String returns the schema, path and reason of the failure.
+func (f SchemaFailure) String() string {
+	return fmt.Sprintf("%s: %s %s", f.Schema, f.Path, f.Reason)
+}
+
+// ValidatePayload checks the payload against every input schema. It returns the name of the first schema the
+// payload matches, or the failure against each schema when it matches none.
+func ValidatePayload(payloadBytes []byte) (string, []SchemaFailure) {
+	var payload interface{}
+	if err := decodeJSON(payloadBytes, &payload); err != nil {
+		return "", []SchemaFailure{{Schema: "json", Path: "$", Reason: err.Error()}}
+	}
+	var failures []SchemaFailure
+	for _, schema := range inputSchemas {
+		path, reason, ok := schema.schema.validate(payload, "$")
+		if ok {
+			return schema.name, nil
+		}
+		failures = append(failures, SchemaFailure{Schema: schema.name, Path: path, Reason: reason})
+	}
+	return "", failures
+}
+
+// validate returns the path and reason of the first violation of the schema by the value, or true when the
+// value matches.
+func (s *jsonSchema) validate(value interface{}, at string) (string, string, bool) {
+	if len(s.Type) > 0 && !s.Type.match(value) {
+		return at, fmt.Sprintf("is %s, expected %s", typeOf(value), strings.Join(s.Type, " or ")), false
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return at, fmt.Sprintf("is missing required property %q", name), false
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := v[name]; ok {
+				if path, reason, ok := s.Properties[name].validate(property, at+"."+name); !ok {
+					return path, reason, false
+				}
+			}
+		}
+	case []interface{}:
+		if len(v) < s.MinItems {
+			return at, fmt.Sprintf("has %d items, expected at least %d", len(v), s.MinItems), false
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				if path, reason, ok := s.Items.validate(item, fmt.Sprintf("%s[%d]", at, i)); !ok {
+					return path, reason, false
+				}
+			}
+		}
+	}
+	return "", "", true
+}
+
+// match reports whether the value has one of the types.
+func (t schemaTypes) match(value interface{}) bool {
+	actual := typeOf(value)
+	for _, expected := range t {
+		if expected == actual || (expected == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON Schema type of a decoded value.
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+// validateInput checks the payload against the input schemas as selected by VALIDATE_INPUT. A payload matching
+// no schema is counted as input.invalid and logged with its failure against each schema, and rejected in strict
+// mode.
+func validateInput(ctx context.Context, payloadBytes []byte) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv(common.ValidateInput)))
+	if mode == "" || mode == common.ValidateInputOff {
+		return
+	}
+
+	vlog := logger.FromContext(ctx)
+	matched, failures := ValidatePayload(payloadBytes)
+	if failures == nil {
+		vlog.Debugf("Payload matches the %s input schema", matched)
+		return
+	}
+	metrics.Default.Counter("input.invalid").Inc()
+	reasons := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		reasons = append(reasons, failure.String())
+	}
+	if mode == common.ValidateInputStrict {
+		vlog.Panicf("Payload matches no input schema: %s", strings.Join(reasons, "; "))
+	}
+	vlog.Warnf("Payload matches no input schema: %s", strings.Join(reasons, "; "))
+}
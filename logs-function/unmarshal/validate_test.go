@@ -0,0 +1,99 @@
+package unmarshal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// ociLoggingRecord is a record delivered by a Service Connector with a Logging source.
+const ociLoggingRecord = `{"data":{"message":"hello"},"id":"e1","oracle":{"compartmentid":"ocid1.compartment.a",` +
+	`"loggroupid":"ocid1.loggroup.a"},"source":"app","specversion":"1.0","time":"2024-01-01T00:00:00Z","type":"com.oraclecloud.logging.custom.app"}`
+
+// TestValidatePayload tests which input schema payloads match and why they fail the others.
+func TestValidatePayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		matched  string
+		failures []string
+	}{
+		{name: "oci logging", payload: "[" + ociLoggingRecord + "]", matched: "oci-logging"},
+		{name: "retry stream", payload: `[{"stream":"retry","key":null,"value":"e30=","offset":12}]`, matched: "retry-stream"},
+		{
+			name:    "alarm notification",
+			payload: `{"dedupeKey":"k","type":"OK_TO_FIRING","alarmMetaData":[{"id":"ocid1.alarm.a","status":"FIRING"}]}`,
+			matched: "alarm-notification",
+		},
+		{
+			name:    "record without envelope",
+			payload: `[{"data":{},"id":"e1","source":"app","specversion":"1.0","time":"t","type":"x","oracle":{"compartmentid":"c"}}]`,
+			failures: []string{
+				"alarm-notification: $ is array, expected object",
+				`oci-logging: $[0].oracle is missing required property "loggroupid"`,
+				`retry-stream: $[0] is missing required property "stream"`,
+			},
+		},
+		{
+			name:    "empty array",
+			payload: `[]`,
+			failures: []string{
+				"alarm-notification: $ is array, expected object",
+				"oci-logging: $ has 0 items, expected at least 1",
+				"retry-stream: $ has 0 items, expected at least 1",
+			},
+		},
+		{
+			name:     "not json",
+			payload:  `[{`,
+			failures: []string{"json: $ unexpected EOF"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, failures := ValidatePayload([]byte(tt.payload))
+			assert.Equal(t, tt.matched, matched)
+			var reasons []string
+			for _, failure := range failures {
+				reasons = append(reasons, failure.String())
+			}
+			assert.Equal(t, tt.failures, reasons)
+		})
+	}
+}
+
+// TestInputSchemas tests that every input schema is published as valid JSON.
+func TestInputSchemas(t *testing.T) {
+	schemas := InputSchemas()
+	assert.Len(t, schemas, 3)
+	for name, schema := range schemas {
+		assert.True(t, json.Valid(schema), name)
+	}
+}
+
+// TestUnmarshalValidateInput tests that invalid payloads are counted in warn mode and rejected in strict mode.
+func TestUnmarshalValidateInput(t *testing.T) {
+	metrics.Default.Reset()
+	payload := []byte(`[{"message":"generic record"}]`)
+
+	t.Setenv("VALIDATE_INPUT", "warn")
+	var event Event
+	assert.NoError(t, event.Unmarshal(context.Background(), bytes.NewReader(payload)))
+	assert.Len(t, event.OCILoggingEvent, 1)
+	assert.Equal(t, int64(1), metrics.Default.Counter("input.invalid").Value())
+
+	t.Setenv("VALIDATE_INPUT", "strict")
+	assert.Panics(t, func() {
+		var strict Event
+		_ = strict.Unmarshal(context.Background(), bytes.NewReader(payload))
+	})
+	assert.NotPanics(t, func() {
+		var valid Event
+		_ = valid.Unmarshal(context.Background(), bytes.NewReader([]byte("["+ociLoggingRecord+"]")))
+	})
+}
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// Adaptive timeout settings.
+const (
+	latencyWindow     = 200 // latencyWindow is the number of most recent Log API latencies the p99 is computed over.
+	minLatencySamples = 20  // minLatencySamples is the number of latencies observed before the timeout adapts.
+	timeoutHeadroom   = 3   // timeoutHeadroom multiplies the p99 latency into the request timeout.
+)
+
+// logAPILatency tracks Log API latencies for the lifetime of the warm container.
+var logAPILatency = &latencyTracker{}
+
+// latencyTracker keeps a rolling window of request latencies.
+type latencyTracker struct {
+	mu      sync.Mutex
+	samples [latencyWindow]time.Duration
+	count   int
+	next    int
+}
+
+// Observe records the latency of a request that received a response.
+func (t *latencyTracker) Observe(latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.samples[t.next] = latency
+	t.next = (t.next + 1) % latencyWindow
+	t.count = min(t.count+1, latencyWindow)
+}
+
+// P99 returns the 99th percentile of the window, and false until minLatencySamples latencies were observed.
+func (t *latencyTracker) P99() (time.Duration, bool) {
+	t.mu.Lock()
+	sorted := append([]time.Duration(nil), t.samples[:t.count]...)
+	t.mu.Unlock()
+	if len(sorted) < minLatencySamples {
+		return 0, false
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(len(sorted)*99-1)/100], true
+}
+
+// Timeout returns the request timeout: a multiple of the p99 latency bounded by LOG_API_TIMEOUT_MIN_SECONDS and
+// LOG_API_TIMEOUT_MAX_SECONDS. Until enough latencies are known the upper bound is used, so a cold container
+// never times out earlier than a fixed timeout would.
+func (t *latencyTracker) Timeout() time.Duration {
+	lower := timeoutBound(common.LogAPITimeoutMin, common.DefaultLogAPITimeoutMin)
+	upper := max(timeoutBound(common.LogAPITimeoutMax, common.DefaultLogAPITimeoutMax), lower)
+
+	p99, ok := t.P99()
+	if !ok {
+		return upper
+	}
+	return min(max(p99*timeoutHeadroom, lower), upper)
+}
+
+// timeoutBound returns the positive number of seconds in the environment variable, or the default.
+func timeoutBound(name string, defaultValue time.Duration) time.Duration {
+	if seconds, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return defaultValue
+}
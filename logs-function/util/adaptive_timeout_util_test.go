@@ -0,0 +1,48 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLatencyTrackerTimeout tests that the timeout follows the p99 latency within its bounds.
+func TestLatencyTrackerTimeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		latencies []time.Duration
+		min       string
+		max       string
+		expected  time.Duration
+	}{
+		{"too few samples uses upper bound", repeat(time.Second, minLatencySamples-1), "", "", 30 * time.Second},
+		{"p99 with headroom", repeat(2*time.Second, latencyWindow), "", "", 6 * time.Second},
+		{"bounded below", repeat(10*time.Millisecond, latencyWindow), "", "", 5 * time.Second},
+		{"bounded above", repeat(20*time.Second, latencyWindow), "", "", 30 * time.Second},
+		{"configured bounds", repeat(10*time.Millisecond, latencyWindow), "0.5", "10", 500 * time.Millisecond},
+		{"outliers above p99 ignored", append(repeat(2*time.Second, 99), 25*time.Second), "", "", 6 * time.Second},
+		{"window keeps recent latencies", append(repeat(20*time.Second, latencyWindow), repeat(3*time.Second, latencyWindow)...), "", "", 9 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LOG_API_TIMEOUT_MIN_SECONDS", tt.min)
+			t.Setenv("LOG_API_TIMEOUT_MAX_SECONDS", tt.max)
+			tracker := &latencyTracker{}
+			for _, latency := range tt.latencies {
+				tracker.Observe(latency)
+			}
+			assert.Equal(t, tt.expected, tracker.Timeout())
+		})
+	}
+}
+
+// repeat returns n copies of latency.
+func repeat(latency time.Duration, n int) []time.Duration {
+	latencies := make([]time.Duration, n)
+	for i := range latencies {
+		latencies[i] = latency
+	}
+	return latencies
+}
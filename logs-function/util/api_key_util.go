@@ -0,0 +1,118 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/newrelic-client-go/v2/pkg/region"
+
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+)
+
+// Types of New Relic API keys, detected from the format of the key.
+const (
+	KeyTypeLicense = "license" // KeyTypeLicense is an Ingest - License key, the only key accepted by the Log API.
+	KeyTypeUser    = "user"    // KeyTypeUser is a User API key, used for NerdGraph.
+	KeyTypeBrowser = "browser" // KeyTypeBrowser is an Ingest - Browser key, used by the browser agent.
+	KeyTypeInsert  = "insert"  // KeyTypeInsert is an Insights insert key.
+	KeyTypeQuery   = "query"   // KeyTypeQuery is an Insights query key.
+	KeyTypeUnknown = "unknown" // KeyTypeUnknown is a key of no known format.
+)
+
+// licenseKeyLength is the length of every Ingest - License key.
+const licenseKeyLength = 40
+
+// euLicenseKeyPrefix starts the license keys of accounts in the EU data center.
+const euLicenseKeyPrefix = "eu01xx"
+
+// keyTypePrefixes maps the prefixes of the New Relic keys that are not license keys to their type.
+var keyTypePrefixes = map[string]string{
+	"NRAK-": KeyTypeUser,
+	"NRJS-": KeyTypeBrowser,
+	"NRII-": KeyTypeInsert,
+	"NRIQ-": KeyTypeQuery,
+}
+
+// keyTypeNames are the names of the key types as shown in the New Relic API keys UI.
+var keyTypeNames = map[string]string{
+	KeyTypeUser:    "a User API key (NRAK-)",
+	KeyTypeBrowser: "an Ingest - Browser key (NRJS-)",
+	KeyTypeInsert:  "an Insights insert key (NRII-)",
+	KeyTypeQuery:   "an Insights query key (NRIQ-)",
+}
+
+// DetectKeyType returns the type of a New Relic key from its format. License keys are 40 characters long and
+// either end with NRAL or, for older keys, are hexadecimal after the optional EU prefix.
+func DetectKeyType(key string) string {
+	key = strings.TrimSpace(key)
+	for prefix, keyType := range keyTypePrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return keyType
+		}
+	}
+	if len(key) != licenseKeyLength {
+		return KeyTypeUnknown
+	}
+	if strings.HasSuffix(key, "NRAL") || isHex(strings.TrimPrefix(key, euLicenseKeyPrefix)) {
+		return KeyTypeLicense
+	}
+	return KeyTypeUnknown
+}
+
+// isHex reports whether s is made of hexadecimal digits only.
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// KeyTypeError is returned when a secret expected to hold a license key holds another kind of New Relic key.
+type KeyTypeError struct {
+	KeyType string // KeyType is the type of the key found in the secret.
+}
+
+// Error explains which key was found and how to get the right one.
+func (e *KeyTypeError) Error() string {
+	return fmt.Sprintf("the Vault secret holds %s, but the Log API only accepts an Ingest - License key: "+
+		"create one in New Relic under API keys > Create a key > Ingest - License and store it in the secret",
+		keyTypeNames[e.KeyType])
+}
+
+// checkLicenseKey returns a KeyTypeError when the key is recognizably not a license key. Keys of unknown
+// format are accepted with a warning, so a new key format does not stop the function.
+func checkLicenseKey(key string) error {
+	switch keyType := DetectKeyType(key); keyType {
+	case KeyTypeLicense:
+		return nil
+	case KeyTypeUnknown:
+		logger.FromContext(context.Background()).Warn("the license key has an unexpected format, check that the Vault secret holds an Ingest - License key")
+		return nil
+	default:
+		return &KeyTypeError{KeyType: keyType}
+	}
+}
+
+// authErrorHint explains the likely cause of a license key rejected by the Log API: a key of the wrong type,
+// whitespace around the key, or a key of an account in the other data center.
+func authErrorHint(key string, nrRegion *region.Region) string {
+	keyType := DetectKeyType(key)
+	eu := strings.HasPrefix(strings.TrimSpace(key), euLicenseKeyPrefix)
+	switch {
+	case key == "":
+		return "the license key is empty, check the Vault secret referenced by the function configuration"
+	case keyType != KeyTypeLicense && keyType != KeyTypeUnknown:
+		return (&KeyTypeError{KeyType: keyType}).Error()
+	case key != strings.TrimSpace(key):
+		return "the license key has leading or trailing whitespace, remove it from the Vault secret"
+	case eu && nrRegion.String() == string(region.US):
+		return "the license key belongs to an EU account, set NEW_RELIC_REGION to EU"
+	case !eu && keyType == KeyTypeLicense && nrRegion.String() == string(region.EU):
+		return "the license key belongs to a US account, set NEW_RELIC_REGION to US"
+	default:
+		return "check that the license key belongs to the account the logs are sent to and has not been deleted"
+	}
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/newrelic/newrelic-client-go/v2/pkg/region"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testLicenseKey   = "0123456789abcdef0123456789abcdef0123NRAL"
+	testEULicenseKey = "eu01xx0123456789abcdef0123456789abcdef01"
+)
+
+// TestDetectKeyType tests that each key format is recognized.
+func TestDetectKeyType(t *testing.T) {
+	tests := map[string]string{
+		testLicenseKey:   KeyTypeLicense,
+		testEULicenseKey: KeyTypeLicense,
+		"0123456789abcdef0123456789abcdef01234567": KeyTypeLicense,
+		" " + testLicenseKey + "\n":                KeyTypeLicense,
+		"NRAK-ABCDEFGHIJKLMNOPQRSTUVWXYZ0":         KeyTypeUser,
+		"NRJS-0123456789abcdef012":                 KeyTypeBrowser,
+		"NRII-abcdefghijklmnopqrstuvwxyz012345":    KeyTypeInsert,
+		"NRIQ-abcdefghijklmnopqrstuvwxyz012345":    KeyTypeQuery,
+		"0123456789zzzzzz0123456789abcdef01234567": KeyTypeUnknown,
+		"key": KeyTypeUnknown,
+	}
+	for key, expected := range tests {
+		assert.Equal(t, expected, DetectKeyType(key), key)
+	}
+}
+
+// TestCheckLicenseKey tests that other kinds of keys are rejected with an actionable error.
+func TestCheckLicenseKey(t *testing.T) {
+	assert.NoError(t, checkLicenseKey(testLicenseKey))
+	assert.NoError(t, checkLicenseKey("key"))
+
+	err := checkLicenseKey("NRAK-ABCDEFGHIJKLMNOPQRSTUVWXYZ0")
+	var keyErr *KeyTypeError
+	assert.ErrorAs(t, err, &keyErr)
+	assert.Equal(t, KeyTypeUser, keyErr.KeyType)
+	assert.Contains(t, err.Error(), "holds a User API key (NRAK-)")
+	assert.Contains(t, err.Error(), "Ingest - License")
+}
+
+// TestAuthErrorHint tests the likely cause suggested for a rejected license key.
+func TestAuthErrorHint(t *testing.T) {
+	us, _ := region.Get(region.US)
+	eu, _ := region.Get(region.EU)
+
+	tests := []struct {
+		name     string
+		key      string
+		region   *region.Region
+		expected string
+	}{
+		{name: "empty key", key: "", region: us, expected: "the license key is empty"},
+		{name: "browser key", key: "NRJS-0123456789abcdef012", region: us, expected: "holds an Ingest - Browser key (NRJS-)"},
+		{name: "whitespace", key: testLicenseKey + "\n", region: us, expected: "leading or trailing whitespace"},
+		{name: "EU key in US", key: testEULicenseKey, region: us, expected: "set NEW_RELIC_REGION to EU"},
+		{name: "US key in EU", key: testLicenseKey, region: eu, expected: "set NEW_RELIC_REGION to US"},
+		{name: "matching region", key: testEULicenseKey, region: eu, expected: "has not been deleted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Contains(t, authErrorHint(tt.key, tt.region), tt.expected)
+		})
+	}
+}
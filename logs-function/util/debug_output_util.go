@@ -0,0 +1,73 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/payload"
+)
+
+// Debug output modes.
+const (
+	DebugOutputTee  = "tee"  // DebugOutputTee writes each payload to the function output and posts it.
+	DebugOutputOnly = "only" // DebugOutputOnly writes each payload to the function output without posting it.
+)
+
+// debugOutputClient writes the final Log API payload of every batch, scrubbed and indented, to the function
+// output, so that `fn invoke` shows exactly what would be sent.
+type debugOutputClient struct {
+	next    NewRelicClientAPI
+	builder payload.Builder
+	post    bool
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewDebugOutputClient wraps the client so that payloads are also, or only when mode is DebugOutputOnly,
+// written to out. It returns the client unchanged for an empty mode.
+func NewDebugOutputClient(next NewRelicClientAPI, out io.Writer, mode string) (NewRelicClientAPI, error) {
+	if mode == "" {
+		return next, nil
+	}
+	if mode != DebugOutputTee && mode != DebugOutputOnly {
+		return nil, fmt.Errorf("unknown %s %q, expected %s or %s", common.DebugOutput, mode, DebugOutputTee, DebugOutputOnly)
+	}
+	builder, err := payload.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &debugOutputClient{next: next, builder: builder, post: mode == DebugOutputTee, out: out}, nil
+}
+
+// CreateLogEntry writes the payload of the batch and posts it unless posting is disabled.
+func (c *debugOutputClient) CreateLogEntry(logEntry interface{}) error {
+	body := logEntry
+	if batch, ok := logEntry.(common.DetailedLogsBatch); ok {
+		built, err := c.builder.Build(batch)
+		if err != nil {
+			return fmt.Errorf("error building %s payload: %w", c.builder.Format(), err)
+		}
+		body = built
+	}
+
+	data, err := json.MarshalIndent(logger.ScrubPayload(body), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding debug output: %w", err)
+	}
+	c.mu.Lock()
+	_, err = fmt.Fprintf(c.out, "%s\n", data)
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("error writing debug output: %w", err)
+	}
+
+	if !c.post {
+		return nil
+	}
+	return c.next.CreateLogEntry(logEntry)
+}
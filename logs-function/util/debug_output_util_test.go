@@ -0,0 +1,52 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// TestDebugOutputClient tests that payloads are written scrubbed to the output and only posted in tee mode.
+func TestDebugOutputClient(t *testing.T) {
+	batch := common.DetailedLogsBatch{{Entries: common.LogData{{"message": "hello", "password": "hunter2"}}}}
+
+	tests := []struct {
+		mode          string
+		expectedPosts int
+	}{
+		{DebugOutputTee, 1},
+		{DebugOutputOnly, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			next := new(MockNRClient)
+			next.On("CreateLogEntry", mock.Anything).Return(nil)
+			var out bytes.Buffer
+
+			client, err := NewDebugOutputClient(next, &out, tt.mode)
+			assert.NoError(t, err)
+			assert.NoError(t, client.CreateLogEntry(batch))
+
+			assert.Contains(t, out.String(), "\"message\": \"hello\"")
+			assert.NotContains(t, out.String(), "hunter2")
+			next.AssertNumberOfCalls(t, "CreateLogEntry", tt.expectedPosts)
+		})
+	}
+}
+
+// TestNewDebugOutputClientModes tests that the client is left unchanged when disabled and unknown modes are rejected.
+func TestNewDebugOutputClientModes(t *testing.T) {
+	next := new(MockNRClient)
+
+	client, err := NewDebugOutputClient(next, &bytes.Buffer{}, "")
+	assert.NoError(t, err)
+	assert.Same(t, next, client)
+
+	_, err = NewDebugOutputClient(next, &bytes.Buffer{}, "stdout")
+	assert.ErrorContains(t, err, `unknown DEBUG_OUTPUT "stdout"`)
+}
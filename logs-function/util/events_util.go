@@ -0,0 +1,81 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/newrelic/newrelic-client-go/v2/pkg/config"
+	"github.com/newrelic/newrelic-client-go/v2/pkg/events"
+	"github.com/newrelic/newrelic-client-go/v2/pkg/region"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// EventSender posts custom events to the New Relic Event API.
+type EventSender interface {
+	CreateEvents(events []map[string]interface{}) error
+}
+
+// eventsClient posts custom events to a single New Relic account.
+type eventsClient struct {
+	client    events.Events
+	accountID int
+}
+
+// Cached EventSender shared by warm invocations, with the same TTL as the NewRelic client
+var (
+	cachedEventSender EventSender
+	eventSenderError  error
+	eventSenderTime   time.Time
+)
+
+// CreateEvents posts the events in a single request.
+func (c *eventsClient) CreateEvents(events []map[string]interface{}) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return c.client.CreateEvent(c.accountID, events)
+}
+
+// NewEventSender returns the EventSender of the account configured through NEW_RELIC_ACCOUNT_ID, authenticated
+// with the license key referenced by SECRET_OCID. Senders are cached like NewRelic clients.
+func NewEventSender() (EventSender, error) {
+	if !eventSenderTime.IsZero() && time.Since(eventSenderTime) < getClientTTL() {
+		return cachedEventSender, eventSenderError
+	}
+
+	cachedEventSender, eventSenderError = createEventSender()
+	eventSenderTime = time.Now()
+	return cachedEventSender, eventSenderError
+}
+
+// createEventSender creates a new Event API client.
+func createEventSender() (EventSender, error) {
+	accountID, err := strconv.Atoi(os.Getenv(common.NewRelicAccountID))
+	if err != nil || accountID <= 0 {
+		return nil, fmt.Errorf("%s must be set to the New Relic account ID", common.NewRelicAccountID)
+	}
+
+	nrRegion, _ := region.Get(NewRelicRegion())
+	cfg := config.Config{
+		Compression:   config.Compression.Gzip,
+		HTTPTransport: &skewTrackingTransport{base: logsTransport},
+		LogLevel:      "info",
+	}
+	if err := cfg.SetRegion(nrRegion); err != nil {
+		return nil, err
+	}
+
+	licenseKey, err := GetLicenseKey(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLicenseKey(licenseKey); err != nil {
+		return nil, err
+	}
+	cfg.LicenseKey = licenseKey
+	return &eventsClient{client: events.New(cfg), accountID: accountID}, nil
+}
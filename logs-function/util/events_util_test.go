@@ -0,0 +1,47 @@
+package util
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/newrelic/newrelic-client-go/v2/pkg/config"
+	"github.com/newrelic/newrelic-client-go/v2/pkg/events"
+	"github.com/newrelic/newrelic-client-go/v2/pkg/region"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEventsClientCreateEvents tests that events are posted as a single array to the account's Event API endpoint.
+func TestEventsClientCreateEvents(t *testing.T) {
+	var path string
+	var posted []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &posted)
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("NEW_RELIC_INSIGHTS_BASE_URL", server.URL+"/v1")
+	nrRegion, _ := region.Get(region.Name("US"))
+	cfg := config.Config{LicenseKey: "key", HTTPTransport: http.DefaultTransport}
+	assert.NoError(t, cfg.SetRegion(nrRegion))
+	client := &eventsClient{client: events.New(cfg), accountID: 42}
+
+	assert.NoError(t, client.CreateEvents(nil))
+	assert.NoError(t, client.CreateEvents([]map[string]interface{}{{"eventType": "OciBastionSession", "bastion.name": "b"}}))
+	assert.Equal(t, "/v1/accounts/42/events", path)
+	assert.Equal(t, []map[string]interface{}{{"eventType": "OciBastionSession", "bastion.name": "b"}}, posted)
+}
+
+// TestNewEventSenderRequiresAccountID tests that a missing account ID is reported without fetching the license key.
+func TestNewEventSenderRequiresAccountID(t *testing.T) {
+	t.Setenv(common.NewRelicAccountID, "")
+
+	_, err := createEventSender()
+	assert.ErrorContains(t, err, common.NewRelicAccountID)
+}
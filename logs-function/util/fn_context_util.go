@@ -0,0 +1,82 @@
+package util
+
+import (
+	"context"
+	"os"
+
+	"github.com/fnproject/fdk-go"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// fnHTTPHeaderPrefix is prepended by Fn to the headers of invocations made through an HTTP gateway.
+const fnHTTPHeaderPrefix = "Fn-Http-H-"
+
+// FnContext returns the Fn invocation context carried by ctx. It reports false outside an Fn
+// invocation, where fdk.GetContext would panic.
+func FnContext(ctx context.Context) (fnCtx fdk.Context, ok bool) {
+	defer func() {
+		if recover() != nil {
+			fnCtx, ok = nil, false
+		}
+	}()
+	return fdk.GetContext(ctx), true
+}
+
+// InvocationHeader returns the named header of the Fn invocation, whether it was invoked directly
+// or through an HTTP gateway. It returns an empty string outside an Fn invocation.
+func InvocationHeader(ctx context.Context, name string) string {
+	fnCtx, ok := FnContext(ctx)
+	if !ok || fnCtx.Header() == nil {
+		return ""
+	}
+	if value := fnCtx.Header().Get(name); value != "" {
+		return value
+	}
+	return fnCtx.Header().Get(fnHTTPHeaderPrefix + name)
+}
+
+// FunctionInfo identifies the function, its application and an invocation.
+type FunctionInfo struct {
+	ID      string // ID is the OCID of the function.
+	Name    string // Name is the name of the function.
+	AppID   string // AppID is the OCID of the application.
+	AppName string // AppName is the name of the application.
+	CallID  string // CallID is the ID of the invocation.
+}
+
+// InvocationFunction returns the function of the Fn invocation. Outside an Fn invocation, the function and
+// application are read from the FN_* environment variables set by Fn, and the call ID is empty.
+func InvocationFunction(ctx context.Context) FunctionInfo {
+	if fnCtx, ok := FnContext(ctx); ok {
+		return FunctionInfo{
+			ID:      fnCtx.FnID(),
+			Name:    fnCtx.FnName(),
+			AppID:   fnCtx.AppID(),
+			AppName: fnCtx.AppName(),
+			CallID:  fnCtx.CallID(),
+		}
+	}
+	return FunctionInfo{
+		ID:      os.Getenv("FN_FN_ID"),
+		Name:    os.Getenv("FN_FN_NAME"),
+		AppID:   os.Getenv("FN_APP_ID"),
+		AppName: os.Getenv("FN_APP_NAME"),
+	}
+}
+
+// Attributes returns the faas.* attributes of the set fields.
+func (f FunctionInfo) Attributes() map[string]string {
+	attributes := map[string]string{}
+	for name, value := range map[string]string{
+		common.FaasIDAttribute:           f.ID,
+		common.FaasNameAttribute:         f.Name,
+		common.FaasAppIDAttribute:        f.AppID,
+		common.FaasAppNameAttribute:      f.AppName,
+		common.FaasInvocationIDAttribute: f.CallID,
+	} {
+		if value != "" {
+			attributes[name] = value
+		}
+	}
+	return attributes
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/fnproject/fdk-go"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFnContext is an Fn invocation context with the given headers.
+type fakeFnContext struct {
+	fdk.Context
+	header http.Header
+}
+
+func (c fakeFnContext) Header() http.Header { return c.header }
+
+// TestInvocationHeader tests reading direct and HTTP gateway headers, and that a missing Fn context is tolerated.
+func TestInvocationHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected string
+	}{
+		{"no fn context", context.Background(), ""},
+		{"direct header", fdk.WithContext(context.Background(), fakeFnContext{header: http.Header{"X-Nr-Routing": {"direct"}}}), "direct"},
+		{"gateway header", fdk.WithContext(context.Background(), fakeFnContext{header: http.Header{"Fn-Http-H-X-Nr-Routing": {"gateway"}}}), "gateway"},
+		{"missing header", fdk.WithContext(context.Background(), fakeFnContext{header: http.Header{}}), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, InvocationHeader(tt.ctx, "X-NR-Routing"))
+		})
+	}
+}
+
+// fakeFunctionContext is an Fn invocation context of a function.
+type fakeFunctionContext struct {
+	fdk.Context
+}
+
+func (fakeFunctionContext) FnID() string    { return "ocid1.fnfunc.oc1..f" }
+func (fakeFunctionContext) FnName() string  { return "nr-logs" }
+func (fakeFunctionContext) AppID() string   { return "ocid1.fnapp.oc1..a" }
+func (fakeFunctionContext) AppName() string { return "nr-forwarders" }
+func (fakeFunctionContext) CallID() string  { return "01CALL" }
+
+// TestInvocationFunction tests reading the function from the Fn context, and from the environment outside of one.
+func TestInvocationFunction(t *testing.T) {
+	t.Setenv("FN_FN_ID", "ocid1.fnfunc.oc1..env")
+	t.Setenv("FN_FN_NAME", "")
+	t.Setenv("FN_APP_ID", "")
+	t.Setenv("FN_APP_NAME", "nr-env")
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected map[string]string
+	}{
+		{"fn context", fdk.WithContext(context.Background(), fakeFunctionContext{}), map[string]string{
+			common.FaasIDAttribute:           "ocid1.fnfunc.oc1..f",
+			common.FaasNameAttribute:         "nr-logs",
+			common.FaasAppIDAttribute:        "ocid1.fnapp.oc1..a",
+			common.FaasAppNameAttribute:      "nr-forwarders",
+			common.FaasInvocationIDAttribute: "01CALL",
+		}},
+		{"environment", context.Background(), map[string]string{
+			common.FaasIDAttribute:      "ocid1.fnfunc.oc1..env",
+			common.FaasAppNameAttribute: "nr-env",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, InvocationFunction(tt.ctx).Attributes())
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+	"github.com/oracle/oci-go-sdk/v65/functions"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/ociclient"
+)
+
+// OCIFunctionsAPI is the subset of the OCI Functions management client used to read the function's tags.
+type OCIFunctionsAPI interface {
+	GetFunction(ctx context.Context, request functions.GetFunctionRequest) (functions.GetFunctionResponse, error)
+}
+
+// ApplyConfigProfile selects the configuration profile named by the function's own tag configured in
+// CONFIG_PROFILE_TAG, and returns its name. Every environment variable prefixed with the upper-cased
+// profile name and an underscore (e.g. PROD_SECRET_OCID for the "prod" profile) then replaces the
+// unprefixed variable, so one set of function configuration can serve every environment.
+func ApplyConfigProfile(ctx context.Context) (string, error) {
+	tag := os.Getenv(common.ConfigProfileTag)
+	if tag == "" {
+		return "", nil
+	}
+
+	provider, err := auth.ResourcePrincipalConfigurationProvider()
+	if err != nil {
+		return "", fmt.Errorf("failed to create resource principal configuration provider: %w", err)
+	}
+	client, err := functions.NewFunctionsManagementClientWithConfigurationProvider(provider)
+	if err != nil {
+		return "", fmt.Errorf("failed to create OCI functions client: %w", err)
+	}
+	ociclient.Configure(&client.BaseClient, "Functions")
+	return applyConfigProfile(ctx, &client, os.Getenv(common.FnFunctionID), tag)
+}
+
+// applyConfigProfile reads the profile from the tag of the function and applies it to the environment.
+func applyConfigProfile(ctx context.Context, client OCIFunctionsAPI, functionID string, tag string) (string, error) {
+	if functionID == "" {
+		return "", fmt.Errorf("cannot read function tags: %s is not set", common.FnFunctionID)
+	}
+	response, err := client.GetFunction(ctx, functions.GetFunctionRequest{FunctionId: ociCommon.String(functionID)})
+	if err != nil {
+		return "", fmt.Errorf("failed to read function %s: %w", functionID, err)
+	}
+
+	profile, ok := tagValue(response.Function, tag)
+	if !ok {
+		return "", fmt.Errorf("function %s has no %q tag", functionID, tag)
+	}
+	prefix := strings.ToUpper(profile) + "_"
+	applied := 0
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if target := strings.TrimPrefix(name, prefix); target != name && target != "" {
+			if err := os.Setenv(target, value); err != nil {
+				return "", err
+			}
+			applied++
+		}
+	}
+	logger.FromContext(ctx).Infof("Applied configuration profile %q with %d settings", profile, applied)
+	return profile, nil
+}
+
+// tagValue returns the value of a defined tag given as "<namespace>.<key>", or of a freeform tag given as "<key>".
+func tagValue(function functions.Function, tag string) (string, bool) {
+	if namespace, key, defined := strings.Cut(tag, "."); defined {
+		value, ok := function.DefinedTags[namespace][key].(string)
+		return value, ok && value != ""
+	}
+	value, ok := function.FreeformTags[tag]
+	return value, ok && value != ""
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/functions"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFunctionsClient returns a function with the given tags.
+type fakeFunctionsClient struct {
+	function functions.Function
+}
+
+func (c *fakeFunctionsClient) GetFunction(_ context.Context, _ functions.GetFunctionRequest) (functions.GetFunctionResponse, error) {
+	return functions.GetFunctionResponse{Function: c.function}, nil
+}
+
+// TestApplyConfigProfile tests that the tagged profile's prefixed variables override the unprefixed ones.
+func TestApplyConfigProfile(t *testing.T) {
+	client := &fakeFunctionsClient{function: functions.Function{
+		DefinedTags:  map[string]map[string]interface{}{"Operations": {"env": "prod"}},
+		FreeformTags: map[string]string{"stage": "dev"},
+	}}
+
+	tests := []struct {
+		name           string
+		tag            string
+		expectedSecret string
+		expectedError  string
+	}{
+		{"defined tag", "Operations.env", "ocid1.vaultsecret.prod", ""},
+		{"freeform tag", "stage", "ocid1.vaultsecret.dev", ""},
+		{"missing tag", "Operations.tier", "", `has no "Operations.tier" tag`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SECRET_OCID", "ocid1.vaultsecret.default")
+			t.Setenv("PROD_SECRET_OCID", "ocid1.vaultsecret.prod")
+			t.Setenv("DEV_SECRET_OCID", "ocid1.vaultsecret.dev")
+
+			_, err := applyConfigProfile(context.Background(), client, "ocid1.fnfunc.a", tt.tag)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedSecret, os.Getenv("SECRET_OCID"))
+		})
+	}
+}
+
+// TestApplyConfigProfileDisabled tests that nothing is read unless a profile tag is configured.
+func TestApplyConfigProfileDisabled(t *testing.T) {
+	t.Setenv("CONFIG_PROFILE_TAG", "")
+	profile, err := ApplyConfigProfile(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, profile)
+}
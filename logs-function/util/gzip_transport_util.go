@@ -0,0 +1,88 @@
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// minGzipBytes is the body size below which requests are sent uncompressed, as by the New Relic client.
+const minGzipBytes = 150
+
+// gzipTransport compresses request bodies at a configured level in place of the New Relic client, whose level
+// is fixed, and reports the compression ratio and time as gzip.ratio and gzip.ms.
+type gzipTransport struct {
+	base  http.RoundTripper
+	level int
+}
+
+// RoundTrip compresses the body of a copy of the request and performs it with the base transport.
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return t.base.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading request body to compress: %w", err)
+	}
+
+	compressed := req.Clone(req.Context())
+	if len(body) >= minGzipBytes {
+		if body, err = t.compress(body); err != nil {
+			return nil, err
+		}
+		compressed.Header.Set("Content-Encoding", "gzip")
+	}
+	compressed.Body = io.NopCloser(bytes.NewReader(body))
+	compressed.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	compressed.ContentLength = int64(len(body))
+	return t.base.RoundTrip(compressed)
+}
+
+// compress gzips the body and records the compression metrics.
+func (t *gzipTransport) compress(body []byte) ([]byte, error) {
+	start := time.Now()
+	var buffer bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buffer, t.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(body); err != nil {
+		return nil, fmt.Errorf("error compressing request body: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing request body: %w", err)
+	}
+
+	metrics.Default.Gauge("gzip.level").Set(float64(t.level))
+	metrics.Default.Counter("gzip.bytes.in").Add(int64(len(body)))
+	metrics.Default.Counter("gzip.bytes.out").Add(int64(buffer.Len()))
+	metrics.Default.Histogram("gzip.ratio", 1, 2, 4, 8, 16, 32).Observe(float64(len(body)) / float64(buffer.Len()))
+	metrics.Default.Histogram("gzip.ms").Observe(float64(time.Since(start).Microseconds()) / 1000)
+	return buffer.Bytes(), nil
+}
+
+// gzipLevel returns the compression level set by GZIP_LEVEL, 6 by default.
+func gzipLevel() int {
+	value := os.Getenv(common.GzipLevel)
+	if value == "" {
+		return common.DefaultGzipLevel
+	}
+	level, err := strconv.Atoi(value)
+	if err != nil || level < gzip.BestSpeed || level > gzip.BestCompression {
+		logger.FromContext(context.Background()).Warnf("Ignoring invalid %s value %q, expected 1 to 9", common.GzipLevel, value)
+		return common.DefaultGzipLevel
+	}
+	return level
+}
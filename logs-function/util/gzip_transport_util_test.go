@@ -0,0 +1,64 @@
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// TestGzipTransport tests that large bodies are gzipped at the configured level and small ones sent as is.
+func TestGzipTransport(t *testing.T) {
+	type received struct {
+		encoding string
+		body     []byte
+	}
+	var requests []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, received{encoding: r.Header.Get("Content-Encoding"), body: body})
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	metrics.Default.Reset()
+
+	client := &http.Client{Transport: &gzipTransport{base: http.DefaultTransport, level: 9}}
+	large := strings.Repeat(`{"message":"audit event"}`, 100)
+	for _, body := range []string{large, "small"} {
+		resp, err := client.Post(server.URL, "application/json", strings.NewReader(body))
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "gzip", requests[0].encoding)
+	reader, err := gzip.NewReader(bytes.NewReader(requests[0].body))
+	assert.NoError(t, err)
+	decompressed, _ := io.ReadAll(reader)
+	assert.Equal(t, large, string(decompressed))
+
+	assert.Empty(t, requests[1].encoding)
+	assert.Equal(t, "small", string(requests[1].body))
+
+	assert.Equal(t, 9.0, metrics.Default.Gauge("gzip.level").Value())
+	assert.Equal(t, int64(len(large)), metrics.Default.Counter("gzip.bytes.in").Value())
+	assert.Equal(t, int64(len(requests[0].body)), metrics.Default.Counter("gzip.bytes.out").Value())
+	assert.Greater(t, metrics.Default.Histogram("gzip.ratio").Snapshot().Min, 10.0)
+}
+
+// TestGzipLevel tests the parsing of GZIP_LEVEL.
+func TestGzipLevel(t *testing.T) {
+	tests := map[string]int{"": 6, "1": 1, "9": 9, "0": 6, "10": 6, "best": 6}
+	for value, expected := range tests {
+		t.Setenv(common.GzipLevel, value)
+		assert.Equal(t, expected, gzipLevel(), value)
+	}
+}
@@ -0,0 +1,68 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/newrelic/newrelic-client-go/v2/pkg/region"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+)
+
+// logsTransport is shared by every NewRelic client so that TLS connections to the Log API
+// survive client refreshes and are reused across warm invocations.
+var logsTransport = newLogsTransport()
+
+// skewTrackingTransport records the clock skew against New Relic from the Date header of every response.
+type skewTrackingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip performs the request with the base transport and observes the response Date header.
+func (t *skewTrackingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if serverDate, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		clock.ObserveServerDate(serverDate, time.Now())
+	}
+	return resp, nil
+}
+
+// newLogsTransport creates an HTTP/2-enabled transport keeping one idle connection per worker.
+func newLogsTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConnsPerHost = common.NumberOfWorkers
+	transport.IdleConnTimeout = 90 * time.Second
+	return transport
+}
+
+// WarmUpConnection opens the TLS connection to the Log API ahead of the first payload,
+// so the handshake cost is paid during container startup instead of the first invocation.
+func WarmUpConnection(ctx context.Context) error {
+	nrRegion, _ := region.Get(NewRelicRegion())
+
+	ctx, cancel := context.WithTimeout(ctx, common.ConnectionWarmUpTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, nrRegion.LogsURL(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create warm-up request: %w", err)
+	}
+
+	start := time.Now()
+	resp, err := (&http.Client{Transport: &skewTrackingTransport{base: logsTransport}}).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to warm up Log API connection: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	logger.FromContext(ctx).Debugf("Warmed up Log API connection over %s in %v", resp.Proto, time.Since(start))
+	return nil
+}
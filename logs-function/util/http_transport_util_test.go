@@ -0,0 +1,53 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewLogsTransport tests that the shared transport attempts HTTP/2 and keeps connections per worker.
+func TestNewLogsTransport(t *testing.T) {
+	transport := newLogsTransport()
+	assert.True(t, transport.ForceAttemptHTTP2)
+	assert.Greater(t, transport.MaxIdleConnsPerHost, 1)
+}
+
+// TestWarmUpConnection tests that the warm-up request reaches the Log API endpoint.
+func TestWarmUpConnection(t *testing.T) {
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	t.Setenv(common.NewRelicRegion, "US")
+	t.Setenv("NEW_RELIC_LOGS_BASE_URL", server.URL)
+
+	assert.NoError(t, WarmUpConnection(context.Background()))
+	assert.Equal(t, http.MethodHead, method)
+}
+
+// TestSkewTrackingTransport tests that the skew is observed from the response Date header.
+func TestSkewTrackingTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(10*time.Minute).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	defer clock.ObserveServerDate(time.Now(), time.Now())
+
+	client := &http.Client{Transport: &skewTrackingTransport{base: http.DefaultTransport}}
+	resp, err := client.Post(server.URL, "application/json", nil)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.InDelta(t, (10 * time.Minute).Seconds(), clock.Skew().Seconds(), 2)
+}
@@ -0,0 +1,67 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// keyGraceRetryInterval is how long a cached client is reused during the grace period before Vault is tried again.
+const keyGraceRetryInterval = time.Minute
+
+// vaultError marks a failure to fetch a license key from OCI Vault, as opposed to a rejected or misconfigured key.
+type vaultError struct {
+	err error
+}
+
+func (e vaultError) Error() string { return e.err.Error() }
+
+func (e vaultError) Unwrap() error { return e.err }
+
+// IsVaultError reports whether err is, or wraps, a failure to fetch a license key from OCI Vault.
+func IsVaultError(err error) bool {
+	var vaultErr vaultError
+	return errors.As(err, &vaultErr)
+}
+
+// licenseKeyGracePeriod returns the LICENSE_KEY_GRACE_PERIOD_SECONDS grace period, or 0 when disabled.
+func licenseKeyGracePeriod() time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(os.Getenv(common.LicenseKeyGracePeriod)))
+	if err != nil || seconds < 0 {
+		seconds = common.DefaultLicenseKeyGracePeriod
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// keepCachedKey reports whether a cached client should be kept after refreshing it failed with err: the cached
+// client must be healthy, the refresh must have failed to reach Vault, and the license key must have been fetched
+// within the grace period. Every use of the grace period is logged and counted.
+func keepCachedKey(cached NewRelicClientAPI, cachedErr error, fetchedAt time.Time, err error) bool {
+	var vaultErr vaultError
+	if err == nil || cached == nil || cachedErr != nil || fetchedAt.IsZero() || !errors.As(err, &vaultErr) {
+		return false
+	}
+	age := time.Since(fetchedAt)
+	grace := licenseKeyGracePeriod()
+	if age >= grace {
+		logger.FromContext(context.Background()).Errorf("Cached license key fetched %v ago is past its grace period of %v: %v", age.Round(time.Second), grace, err)
+		return false
+	}
+	metrics.Default.Counter("vault.grace.used").Inc()
+	logger.FromContext(context.Background()).Warnf("Vault is unreachable, using the cached license key fetched %v ago for up to %v: %v",
+		age.Round(time.Second), (grace - age).Round(time.Second), err)
+	return true
+}
+
+// graceRetryTime returns the creation time to stamp on a client kept during the grace period, so that Vault is
+// tried again after keyGraceRetryInterval rather than a full client TTL.
+func graceRetryTime() time.Time {
+	return time.Now().Add(keyGraceRetryInterval - getClientTTL())
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestKeepCachedKey tests that only a healthy cached client is kept, after a Vault failure, within the grace period.
+func TestKeepCachedKey(t *testing.T) {
+	t.Setenv(common.LicenseKeyGracePeriod, "600")
+	client := new(MockNRClient)
+	unreachable := vaultError{err: errors.New("failed to fetch secret bundle: timeout")}
+	recent := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		cached    NewRelicClientAPI
+		cachedErr error
+		fetchedAt time.Time
+		err       error
+		expected  bool
+	}{
+		{"vault unreachable", client, nil, recent, unreachable, true},
+		{"refresh succeeded", client, nil, recent, nil, false},
+		{"invalid license key", client, nil, recent, errors.New("license key is not an ingest key"), false},
+		{"no cached client", nil, nil, time.Time{}, unreachable, false},
+		{"cached client failed", client, errors.New("secret not found"), recent, unreachable, false},
+		{"past grace period", client, nil, time.Now().Add(-time.Hour), unreachable, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, keepCachedKey(tt.cached, tt.cachedErr, tt.fetchedAt, tt.err))
+		})
+	}
+
+	t.Setenv(common.LicenseKeyGracePeriod, "0")
+	assert.False(t, keepCachedKey(client, nil, recent, unreachable))
+}
+
+// TestRouteClientGracePeriod tests that a routed client survives a Vault outage and Vault is retried sooner.
+func TestRouteClientGracePeriod(t *testing.T) {
+	client := new(MockNRClient)
+	var fetchErr error
+	createRouteClient = func(string) (NewRelicClientAPI, error) {
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return client, nil
+	}
+	routeClientCache = map[string]*routeClientEntry{}
+	t.Cleanup(func() {
+		createRouteClient = createNRClient
+		routeClientCache = map[string]*routeClientEntry{}
+	})
+	metrics.Default.Reset()
+
+	got, err := newRouteNRClient("secret")
+	assert.NoError(t, err)
+	assert.Same(t, client, got)
+
+	fetchErr = vaultError{err: errors.New("failed to fetch secret bundle: timeout")}
+	routeClientCache["secret"].createdAt = time.Now().Add(-2 * getClientTTL())
+	got, err = newRouteNRClient("secret")
+	assert.NoError(t, err)
+	assert.Same(t, client, got)
+	assert.Equal(t, int64(1), metrics.Default.Counter("vault.grace.used").Value())
+	assert.WithinDuration(t, time.Now().Add(keyGraceRetryInterval-getClientTTL()), routeClientCache["secret"].createdAt, time.Second)
+
+	routeClientCache["secret"].createdAt = time.Now().Add(-2 * getClientTTL())
+	routeClientCache["secret"].fetchedAt = time.Now().Add(-2 * licenseKeyGracePeriod())
+	_, err = newRouteNRClient("secret")
+	assert.ErrorContains(t, err, "timeout")
+}
@@ -0,0 +1,241 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/newrelic/newrelic-client-go/v2/pkg/config"
+	logging "github.com/newrelic/newrelic-client-go/v2/pkg/logs"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/newrelic/oci-log-integration/logs-function/payload"
+)
+
+// Classes of Log API errors, telling whether a rejected batch is worth retrying.
+const (
+	ErrorClassAuth      = "auth"      // ErrorClassAuth is a rejected or missing license key.
+	ErrorClassQuota     = "quota"     // ErrorClassQuota is a valid license key of an account over its ingest limit or without the logs entitlement.
+	ErrorClassPayload   = "payload"   // ErrorClassPayload is a malformed or oversized batch that will never be accepted.
+	ErrorClassThrottled = "throttled" // ErrorClassThrottled is a rate limited or timed out request.
+	ErrorClassServer    = "server"    // ErrorClassServer is a transient failure on the New Relic side.
+	ErrorClassUnknown   = "unknown"   // ErrorClassUnknown is any other unsuccessful response.
+)
+
+// quotaErrorMarkers are found, ignoring case, in the error bodies of forbidden requests refused for the ingest quota
+// or entitlement of the account rather than for its license key.
+var quotaErrorMarkers = []string{"quota", "limit exceeded", "ingest limit", "data limit", "entitlement"}
+
+// quotaHint suggests the fix of a quota error.
+const quotaHint = "the account has reached its data ingest limit or is not entitled to logs, review its data management " +
+	"settings in New Relic; the license key is valid"
+
+// maxErrorBodyBytes bounds the response body read when a request fails.
+const maxErrorBodyBytes = 64 * 1024
+
+// LogAPIError is an unsuccessful Log API response, parsed from its error body.
+type LogAPIError struct {
+	StatusCode int                 // StatusCode is the HTTP status of the response.
+	Class      string              // Class is the error class derived from the status.
+	Details    []LogAPIErrorDetail // Details lists the errors reported in the response body.
+	Hint       string              // Hint suggests the likely fix of an auth error.
+
+	cause error
+}
+
+// LogAPIErrorDetail is a single error reported by the Log API, optionally naming the offending field.
+type LogAPIErrorDetail struct {
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error returns the status, class and field-level details of the error.
+func (e *LogAPIError) Error() string {
+	msg := fmt.Sprintf("log api returned %d (%s)", e.StatusCode, e.Class)
+	var details []string
+	for _, detail := range e.Details {
+		switch {
+		case detail.Field != "":
+			details = append(details, fmt.Sprintf("%s: %s", detail.Field, detail.Message))
+		case detail.Message != "":
+			details = append(details, detail.Message)
+		}
+	}
+	if len(details) > 0 {
+		msg += ": " + strings.Join(details, "; ")
+	}
+	if e.Hint != "" {
+		msg += " (" + e.Hint + ")"
+	}
+	return msg
+}
+
+// Unwrap returns the error reported by the New Relic client.
+func (e *LogAPIError) Unwrap() error {
+	return e.cause
+}
+
+// Retryable reports whether posting the same batch again may succeed.
+func (e *LogAPIError) Retryable() bool {
+	return e.Class == ErrorClassThrottled || e.Class == ErrorClassServer
+}
+
+// classifyStatus returns the error class of an unsuccessful HTTP status.
+func classifyStatus(statusCode int) string {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrorClassAuth
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests:
+		return ErrorClassThrottled
+	case statusCode >= 500:
+		return ErrorClassServer
+	case statusCode >= 400:
+		return ErrorClassPayload
+	default:
+		return ErrorClassUnknown
+	}
+}
+
+// parseLogAPIError builds a LogAPIError from an unsuccessful response. It understands an errors array,
+// a single error object with a title and messages, and a plain error string; any other body is kept as
+// a single message. A forbidden request whose body mentions the quota or entitlement of the account is a
+// quota error rather than an auth error.
+func parseLogAPIError(statusCode int, body []byte) *LogAPIError {
+	apiErr := &LogAPIError{StatusCode: statusCode, Class: classifyStatus(statusCode)}
+
+	var parsed struct {
+		Errors []LogAPIErrorDetail `json:"errors"`
+		Error  json.RawMessage     `json:"error"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		if text := strings.TrimSpace(string(body)); text != "" {
+			apiErr.Details = []LogAPIErrorDetail{{Message: text}}
+		}
+		return apiErr
+	}
+
+	apiErr.Details = parsed.Errors
+	var message string
+	var titled struct {
+		Title    string   `json:"title"`
+		Messages []string `json:"messages"`
+	}
+	switch {
+	case len(parsed.Error) == 0:
+	case json.Unmarshal(parsed.Error, &message) == nil:
+		apiErr.Details = append(apiErr.Details, LogAPIErrorDetail{Message: message})
+	case json.Unmarshal(parsed.Error, &titled) == nil:
+		for _, m := range titled.Messages {
+			apiErr.Details = append(apiErr.Details, LogAPIErrorDetail{Code: titled.Title, Message: m})
+		}
+		if len(titled.Messages) == 0 && titled.Title != "" {
+			apiErr.Details = append(apiErr.Details, LogAPIErrorDetail{Message: titled.Title})
+		}
+	}
+	if apiErr.Class == ErrorClassAuth && mentionsQuota(apiErr.Details) {
+		apiErr.Class = ErrorClassQuota
+	}
+	return apiErr
+}
+
+// mentionsQuota reports whether the code or message of a detail carries a quota error marker.
+func mentionsQuota(details []LogAPIErrorDetail) bool {
+	for _, detail := range details {
+		text := strings.ToLower(detail.Code + " " + detail.Message)
+		for _, marker := range quotaErrorMarkers {
+			if strings.Contains(text, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// errorCapturingTransport keeps the parsed body of the last unsuccessful response, which the
+// New Relic client otherwise reduces to a status code.
+type errorCapturingTransport struct {
+	base http.RoundTripper
+
+	mu   sync.Mutex
+	last *LogAPIError
+}
+
+// RoundTrip performs the request with the base transport, reading and restoring the body of unsuccessful responses.
+func (t *errorCapturingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 400 {
+		return resp, err
+	}
+
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		return resp, nil
+	}
+
+	t.mu.Lock()
+	t.last = parseLogAPIError(resp.StatusCode, body)
+	t.mu.Unlock()
+	return resp, nil
+}
+
+// lastError returns the error parsed from the last unsuccessful response, if any.
+func (t *errorCapturingTransport) lastError() *LogAPIError {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.last
+}
+
+// logAPIClient posts batches with the New Relic client and reports failures as LogAPIError.
+type logAPIClient struct {
+	cfg     config.Config
+	builder payload.Builder
+}
+
+// CreateLogEntry posts the batch. Each call uses its own New Relic client so the captured error body
+// belongs to this batch even while other workers post concurrently; connections are still shared
+// through the underlying transport. The request timeout adapts to the latencies of earlier responses.
+func (c *logAPIClient) CreateLogEntry(logEntry interface{}) error {
+	transport := &errorCapturingTransport{base: c.cfg.HTTPTransport}
+	cfg := c.cfg
+	cfg.HTTPTransport = transport
+	timeout := logAPILatency.Timeout()
+	cfg.Timeout = &timeout
+	client := logging.New(cfg)
+
+	if batch, ok := logEntry.(common.DetailedLogsBatch); ok && c.builder != nil {
+		body, err := c.builder.Build(batch)
+		if err != nil {
+			return fmt.Errorf("error building %s payload: %w", c.builder.Format(), err)
+		}
+		logEntry = body
+	}
+
+	start := time.Now()
+	err := client.CreateLogEntry(logEntry)
+	metrics.Default.Gauge("sink.timeout.ms").Set(float64(timeout.Milliseconds()))
+	if err == nil {
+		logAPILatency.Observe(time.Since(start))
+		return nil
+	}
+	if apiErr := transport.lastError(); apiErr != nil {
+		logAPILatency.Observe(time.Since(start))
+		apiErr.cause = err
+		switch apiErr.Class {
+		case ErrorClassAuth:
+			apiErr.Hint = authErrorHint(c.cfg.LicenseKey, c.cfg.Region())
+		case ErrorClassQuota:
+			apiErr.Hint = quotaHint
+		}
+		return apiErr
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+package util
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/newrelic/newrelic-client-go/v2/pkg/config"
+	"github.com/newrelic/newrelic-client-go/v2/pkg/region"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseLogAPIError tests parsing of each error body format and the status classification.
+func TestParseLogAPIError(t *testing.T) {
+	tests := []struct {
+		name            string
+		statusCode      int
+		body            string
+		expectedClass   string
+		expectedDetails []LogAPIErrorDetail
+	}{
+		{
+			name:          "errors array with fields",
+			statusCode:    http.StatusBadRequest,
+			body:          `{"errors":[{"field":"logs[0].timestamp","code":"INVALID","message":"invalid timestamp"}]}`,
+			expectedClass: ErrorClassPayload,
+			expectedDetails: []LogAPIErrorDetail{
+				{Field: "logs[0].timestamp", Code: "INVALID", Message: "invalid timestamp"},
+			},
+		},
+		{
+			name:            "error string",
+			statusCode:      http.StatusForbidden,
+			body:            `{"error":"invalid license key"}`,
+			expectedClass:   ErrorClassAuth,
+			expectedDetails: []LogAPIErrorDetail{{Message: "invalid license key"}},
+		},
+		{
+			name:            "quota error string",
+			statusCode:      http.StatusForbidden,
+			body:            `{"error":"Ingest quota exceeded for this account"}`,
+			expectedClass:   ErrorClassQuota,
+			expectedDetails: []LogAPIErrorDetail{{Message: "Ingest quota exceeded for this account"}},
+		},
+		{
+			name:            "entitlement error code",
+			statusCode:      http.StatusForbidden,
+			body:            `{"errors":[{"code":"NO_LOGS_ENTITLEMENT","message":"forbidden"}]}`,
+			expectedClass:   ErrorClassQuota,
+			expectedDetails: []LogAPIErrorDetail{{Code: "NO_LOGS_ENTITLEMENT", Message: "forbidden"}},
+		},
+		{
+			name:            "error object",
+			statusCode:      http.StatusRequestEntityTooLarge,
+			body:            `{"error":{"title":"PayloadTooLarge","messages":["max 1MB"]}}`,
+			expectedClass:   ErrorClassPayload,
+			expectedDetails: []LogAPIErrorDetail{{Code: "PayloadTooLarge", Message: "max 1MB"}},
+		},
+		{
+			name:            "plain text body",
+			statusCode:      http.StatusServiceUnavailable,
+			body:            "upstream unavailable\n",
+			expectedClass:   ErrorClassServer,
+			expectedDetails: []LogAPIErrorDetail{{Message: "upstream unavailable"}},
+		},
+		{
+			name:          "empty body",
+			statusCode:    http.StatusTooManyRequests,
+			expectedClass: ErrorClassThrottled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := parseLogAPIError(tt.statusCode, []byte(tt.body))
+			assert.Equal(t, tt.statusCode, apiErr.StatusCode)
+			assert.Equal(t, tt.expectedClass, apiErr.Class)
+			assert.Equal(t, tt.expectedDetails, apiErr.Details)
+		})
+	}
+}
+
+// TestLogAPIClientError tests that a rejected batch is reported with the details of the response body.
+func TestLogAPIClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors":[{"field":"logs[0].message","message":"too long"}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("NEW_RELIC_LOGS_BASE_URL", server.URL)
+	nrRegion, _ := region.Get(region.Name("US"))
+	cfg := config.Config{LicenseKey: "key", HTTPTransport: http.DefaultTransport}
+	assert.NoError(t, cfg.SetRegion(nrRegion))
+
+	err := (&logAPIClient{cfg: cfg}).CreateLogEntry(common.DetailedLogsBatch{{Entries: common.LogData{{"message": "a"}}}})
+
+	var apiErr *LogAPIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, ErrorClassPayload, apiErr.Class)
+	assert.False(t, apiErr.Retryable())
+	assert.Equal(t, "log api returned 400 (payload): logs[0].message: too long", err.Error())
+	assert.NotNil(t, errors.Unwrap(err))
+}
+
+// TestLogAPIClientAuthHint tests that a rejected license key is reported with the likely fix.
+func TestLogAPIClientAuthHint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	t.Setenv("NEW_RELIC_LOGS_BASE_URL", server.URL)
+	nrRegion, _ := region.Get(region.Name("US"))
+	cfg := config.Config{LicenseKey: "NRAK-ABCDEFGHIJKLMNOPQRSTUVWXYZ0", HTTPTransport: http.DefaultTransport}
+	assert.NoError(t, cfg.SetRegion(nrRegion))
+
+	err := (&logAPIClient{cfg: cfg}).CreateLogEntry(common.DetailedLogsBatch{{Entries: common.LogData{{"message": "a"}}}})
+
+	var apiErr *LogAPIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, ErrorClassAuth, apiErr.Class)
+	assert.Contains(t, err.Error(), "log api returned 403 (auth) (the Vault secret holds a User API key (NRAK-)")
+}
+
+// wrappingBuilder is a payload builder wrapping the batch in an object.
+type wrappingBuilder struct{}
+
+func (wrappingBuilder) Format() string { return "wrapped" }
+
+func (wrappingBuilder) Build(batch common.DetailedLogsBatch) (interface{}, error) {
+	return map[string]interface{}{"wrapped": batch}, nil
+}
+
+// TestLogAPIClientPayloadBuilder tests that batches are posted in the format of the configured builder.
+func TestLogAPIClientPayloadBuilder(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	t.Setenv("NEW_RELIC_LOGS_BASE_URL", server.URL)
+	nrRegion, _ := region.Get(region.Name("US"))
+	cfg := config.Config{LicenseKey: "key", HTTPTransport: http.DefaultTransport}
+	assert.NoError(t, cfg.SetRegion(nrRegion))
+
+	err := (&logAPIClient{cfg: cfg, builder: wrappingBuilder{}}).CreateLogEntry(common.DetailedLogsBatch{{Entries: common.LogData{{"message": "a"}}}})
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `{"wrapped":[{"common"`)
+}
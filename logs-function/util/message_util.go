@@ -2,15 +2,47 @@
 package util
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/newrelic/oci-log-integration/logs-function/common"
 )
 
 // ProduceMessageToChannel sends a log batch to a channel for further processing.
+// When its records carry timestamps, the batch timestamp is set to the earliest of them and the
+// batch time bounds are added to a copy of the attributes.
 func ProduceMessageToChannel(channel chan common.DetailedLogsBatch, currentBatch common.LogData, attributes common.LogAttributes) {
+	commonData := common.Common{Attributes: attributes}
+	if start, end, ok := timeRange(currentBatch); ok {
+		commonData.Attributes = make(common.LogAttributes, len(attributes)+2)
+		for key, value := range attributes {
+			commonData.Attributes[key] = value
+		}
+		commonData.Attributes[common.BatchTimeRangeStart] = start.UnixMilli()
+		commonData.Attributes[common.BatchTimeRangeEnd] = end.UnixMilli()
+		commonData.Timestamp = strconv.FormatInt(start.UnixMilli(), 10)
+	}
+
 	channel <- []common.DetailedLog{{
-		CommonData: common.Common{
-			Attributes: attributes,
-		},
-		Entries: currentBatch,
+		CommonData: commonData,
+		Entries:    currentBatch,
 	}}
 }
+
+// timeRange returns the earliest and latest record times of the batch.
+func timeRange(batch common.LogData) (start time.Time, end time.Time, ok bool) {
+	for _, record := range batch {
+		t, found := common.RecordTime(record)
+		if !found {
+			continue
+		}
+		if !ok || t.Before(start) {
+			start = t
+		}
+		if !ok || t.After(end) {
+			end = t
+		}
+		ok = true
+	}
+	return start, end, ok
+}
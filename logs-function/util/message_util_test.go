@@ -1,6 +1,7 @@
 package util
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/newrelic/oci-log-integration/logs-function/common"
@@ -45,3 +46,24 @@ func TestProduceMessageToChannel(t *testing.T) {
 
 	close(channel)
 }
+
+// TestProduceMessageToChannelTimeRange tests that the batch timestamp and time bounds follow the record times
+// without changing the shared attributes.
+func TestProduceMessageToChannelTimeRange(t *testing.T) {
+	channel := make(chan common.DetailedLogsBatch, 1)
+	attributes := common.LogAttributes{"instrumentation.provider": common.InstrumentationProvider}
+	currentBatch := common.LogData{
+		{"time": "2024-01-01T00:00:02.500Z"},
+		{"timestamp": json.Number("1704067200000")},
+		{"timestamp": 1704067205.0},
+		{"message": "no time"},
+	}
+
+	ProduceMessageToChannel(channel, currentBatch, attributes)
+	batch := <-channel
+
+	assert.Equal(t, "1704067200000", batch[0].CommonData.Timestamp)
+	assert.Equal(t, int64(1704067200000), batch[0].CommonData.Attributes[common.BatchTimeRangeStart])
+	assert.Equal(t, int64(1704067205000), batch[0].CommonData.Attributes[common.BatchTimeRangeEnd])
+	assert.NotContains(t, attributes, common.BatchTimeRangeStart)
+}
@@ -0,0 +1,83 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	mathrand "math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/clock"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// MigrationSinkName is the name of the migration sink in logs and metrics.
+const MigrationSinkName = "migration"
+
+// MigrationSink dual-ships batches to a second New Relic account during a blue/green account migration:
+// a share of the batches, or all of them, is also posted with the migration license key until the end
+// of the overlap window. Its sink.migration.* metrics count the batches sent, skipped and failed.
+type MigrationSink struct {
+	client  func() (NewRelicClientAPI, error)
+	percent float64
+	until   time.Time
+}
+
+// NewMigrationSinkFromEnv returns the MigrationSink configured through MIGRATION_SECRET_OCID, MIGRATION_PERCENT
+// and MIGRATION_UNTIL, or nil without error when no migration is configured.
+func NewMigrationSinkFromEnv() (*MigrationSink, error) {
+	secretOCID := os.Getenv(common.MigrationSecretOCID)
+	if secretOCID == "" {
+		return nil, nil
+	}
+
+	percent := 100.0
+	if value := strings.TrimSpace(os.Getenv(common.MigrationPercent)); value != "" && !strings.EqualFold(value, "all") {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 100 {
+			return nil, fmt.Errorf("%s must be a percentage or \"all\", got %q", common.MigrationPercent, value)
+		}
+		percent = parsed
+	}
+
+	var until time.Time
+	if value := strings.TrimSpace(os.Getenv(common.MigrationUntil)); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an RFC 3339 time: %w", common.MigrationUntil, err)
+		}
+		until = parsed
+	}
+
+	client := func() (NewRelicClientAPI, error) { return newRouteNRClient(secretOCID) }
+	return NewMigrationSink(client, percent, until), nil
+}
+
+// NewMigrationSink creates a MigrationSink posting percent of the batches with the client until the given
+// time, or indefinitely when until is zero.
+func NewMigrationSink(client func() (NewRelicClientAPI, error), percent float64, until time.Time) *MigrationSink {
+	return &MigrationSink{client: client, percent: percent, until: until}
+}
+
+// Name returns MigrationSinkName.
+func (s *MigrationSink) Name() string {
+	return MigrationSinkName
+}
+
+// Send posts the batch to the migration account, unless the overlap window ended or the batch is not sampled.
+func (s *MigrationSink) Send(_ context.Context, batch common.DetailedLogsBatch) error {
+	if !s.until.IsZero() && !clock.Now().Before(s.until) {
+		return ErrSinkSkipped
+	}
+	if s.percent < 100 && mathrand.Float64()*100 >= s.percent {
+		return ErrSinkSkipped
+	}
+
+	client, err := s.client()
+	if err != nil {
+		return fmt.Errorf("error creating migration client: %w", err)
+	}
+	return client.CreateLogEntry(batch)
+}
@@ -0,0 +1,92 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// TestMigrationSinkSend tests that batches are dual-shipped within the overlap window and sampled by percentage.
+func TestMigrationSinkSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		percent  float64
+		until    time.Time
+		posts    int
+		expected error
+	}{
+		{"all within window", 100, time.Now().Add(time.Hour), 1, nil},
+		{"no window end", 100, time.Time{}, 1, nil},
+		{"window ended", 100, time.Now().Add(-time.Minute), 0, ErrSinkSkipped},
+		{"not sampled", 0, time.Time{}, 0, ErrSinkSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := new(MockNRClient)
+			client.On("CreateLogEntry", mock.Anything).Return(nil)
+			sink := NewMigrationSink(func() (NewRelicClientAPI, error) { return client, nil }, tt.percent, tt.until)
+
+			err := sink.Send(context.Background(), common.DetailedLogsBatch{})
+
+			assert.ErrorIs(t, err, tt.expected)
+			client.AssertNumberOfCalls(t, "CreateLogEntry", tt.posts)
+		})
+	}
+}
+
+// TestMigrationSinkMetrics tests that skipped and failed migration posts are counted separately from sent ones.
+func TestMigrationSinkMetrics(t *testing.T) {
+	defer func(registered []Sink) { sinks = registered }(sinks)
+	metrics.Default.Reset()
+	failing := func() (NewRelicClientAPI, error) { return nil, errors.New("secret unavailable") }
+	RegisterSink(NewMigrationSink(failing, 100, time.Time{}))
+	RegisterSink(NewMigrationSink(failing, 0, time.Time{}))
+
+	sendToSinks(context.Background(), common.DetailedLogsBatch{})
+
+	assert.Equal(t, int64(1), metrics.Default.Counter("sink.migration.failed").Value())
+	assert.Equal(t, int64(1), metrics.Default.Counter("sink.migration.skipped").Value())
+	assert.Equal(t, int64(0), metrics.Default.Counter("sink.migration.sent").Value())
+}
+
+// TestNewMigrationSinkFromEnv tests the migration settings.
+func TestNewMigrationSinkFromEnv(t *testing.T) {
+	tests := []struct {
+		name          string
+		secret        string
+		percent       string
+		until         string
+		expectedNil   bool
+		expectedError string
+	}{
+		{"not configured", "", "", "", true, ""},
+		{"all", "ocid1.vaultsecret.new", "all", "2030-01-01T00:00:00Z", false, ""},
+		{"percentage", "ocid1.vaultsecret.new", "25", "", false, ""},
+		{"invalid percentage", "ocid1.vaultsecret.new", "150", "", true, "MIGRATION_PERCENT"},
+		{"invalid window end", "ocid1.vaultsecret.new", "", "tomorrow", true, "MIGRATION_UNTIL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(common.MigrationSecretOCID, tt.secret)
+			t.Setenv(common.MigrationPercent, tt.percent)
+			t.Setenv(common.MigrationUntil, tt.until)
+
+			sink, err := NewMigrationSinkFromEnv()
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedNil, sink == nil)
+		})
+	}
+}
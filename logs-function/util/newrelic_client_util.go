@@ -10,74 +10,102 @@ import (
 	"time"
 
 	"github.com/newrelic/newrelic-client-go/v2/pkg/config"
-	logging "github.com/newrelic/newrelic-client-go/v2/pkg/logs"
 	"github.com/newrelic/newrelic-client-go/v2/pkg/region"
 
 	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/payload"
 )
 
-// Global variables for caching the NewRelic client with TTL support
+// Global variables for caching the NewRelic client with TTL support. The lock is held while the client is
+// created, so an invocation arriving during the startup prefetch waits for it instead of fetching again.
 var (
+	nrClientMu      sync.Mutex
 	cachedNRClient  NewRelicClientAPI
 	nrClientError   error
 	clientCacheTime time.Time
+	keyFetchedAt    time.Time // keyFetchedAt is when the license key of the cached client was last fetched.
 )
 
+// routeClientCache holds the NewRelic clients of the routed accounts, keyed by secret OCID. It is also
+// used by sinks running on the workers, hence the lock. Each entry has its own lock, held while its client
+// is created, so the license keys of different accounts are fetched concurrently but never twice at once.
+var (
+	routeClientCacheMu sync.Mutex
+	routeClientCache   = map[string]*routeClientEntry{}
+)
+
+// routeClientEntry is the cached client of one routed account.
+type routeClientEntry struct {
+	mu sync.Mutex
+	cachedClient
+}
+
+// createRouteClient creates the NewRelic client of a routed account.
+var createRouteClient = createNRClient
+
+// cachedClient is a NewRelic client together with its initialization error and creation time.
+type cachedClient struct {
+	client    NewRelicClientAPI
+	err       error
+	createdAt time.Time
+	fetchedAt time.Time // fetchedAt is when the license key of the client was last fetched.
+}
+
 // NewRelicClientAPI is an interface that defines the methods for interacting with the New Relic Logs API.
 type NewRelicClientAPI interface {
 	CreateLogEntry(logEntry interface{}) error
 }
 
-// ConsumeLogBatches consumes log batches from a channel and creates log entries using the provided NewRelicClientAPI.
-// The function returns when the channel is closed or the context is cancelled.
-func ConsumeLogBatches(ctx context.Context, channel <-chan common.DetailedLogsBatch, wg *sync.WaitGroup, nrClientAPI NewRelicClientAPI) {
-	// Defer the Done() method of the WaitGroup to indicate that the goroutine has finished processing
-	defer wg.Done()
-
-	for {
-		select {
-		case batch, ok := <-channel:
-			if !ok {
-				return
-			}
-			if err := nrClientAPI.CreateLogEntry(batch); err != nil {
-				log.Errorf("error posting Log entry: %v", err)
-				// Continue processing other batches instead of terminating
-				continue
-			}
-		case <-ctx.Done():
-			// Context has been cancelled, exit the goroutine
-			return
-		}
-	}
-}
-
 // NewNRClient Initializes a new NRClient with debug level and region
 // It returns a NewRelicClientAPI interface and an error if there is a problem setting the region.
 // Uses TTL-based caching for performance in OCI Function environment.
 func NewNRClient() (NewRelicClientAPI, error) {
+	nrClientMu.Lock()
+	defer nrClientMu.Unlock()
+
 	// Check if cache is still valid
 	if cachedNRClient != nil {
 		ttl := getClientTTL()
 		if time.Since(clientCacheTime) < ttl {
 			// Return cached client (even if there was an error before)
-			log.Debug("Returning cached New Relic client")
+			logger.FromContext(context.Background()).Debug("Returning cached New Relic client")
 			return cachedNRClient, nrClientError
 		}
 	}
 
 	// Cache is invalid, expired, or doesn't exist - create new client
-	log.Debug("Initializing/refreshing New Relic client")
-	cachedNRClient, nrClientError = createNRClient()
+	logger.FromContext(context.Background()).Debug("Initializing/refreshing New Relic client")
+	client, err := createNRClient(os.Getenv(common.SecretOCID))
+	if keepCachedKey(cachedNRClient, nrClientError, keyFetchedAt, err) {
+		clientCacheTime = graceRetryTime()
+		return cachedNRClient, nil
+	}
+	cachedNRClient, nrClientError = client, err
 	clientCacheTime = time.Now()
 
 	if nrClientError == nil {
-		log.Debug("New Relic client initialized successfully")
+		keyFetchedAt = clientCacheTime
+		logger.FromContext(context.Background()).Debug("New Relic client initialized successfully")
 	}
 
 	return cachedNRClient, nrClientError
 }
 
+// PrefetchNRClient creates the cached NewRelic client, fetching the license key from Vault, ahead of the
+// first invocation of a new container. A failed prefetch is not cached, so the first invocation tries again.
+func PrefetchNRClient() error {
+	start := time.Now()
+	if _, err := NewNRClient(); err != nil {
+		nrClientMu.Lock()
+		cachedNRClient, nrClientError, clientCacheTime, keyFetchedAt = nil, nil, time.Time{}, time.Time{}
+		nrClientMu.Unlock()
+		return err
+	}
+	logger.FromContext(context.Background()).Debugf("Prefetched license key and New Relic client in %v", time.Since(start))
+	return nil
+}
+
 // getClientTTL returns the TTL for the client cache from environment variable or default (600 seconds = 10 minutes)
 func getClientTTL() time.Duration {
 	ttlSeconds := common.DefaultClientTTL // Default TTL in seconds
@@ -91,12 +119,41 @@ func getClientTTL() time.Duration {
 	return time.Duration(ttlSeconds) * time.Second
 }
 
-// createNRClient creates a new NewRelic client instance
-func createNRClient() (NewRelicClientAPI, error) {
-	nrRegion, _ := region.Get(region.Name(os.Getenv(common.NewRelicRegion)))
-	var nrClient logging.Logs
+// newRouteNRClient returns the NewRelic client using the license key stored in the given secret,
+// sharing the TTL-based caching of NewNRClient.
+func newRouteNRClient(secretOCID string) (NewRelicClientAPI, error) {
+	routeClientCacheMu.Lock()
+	entry, ok := routeClientCache[secretOCID]
+	if !ok {
+		entry = &routeClientEntry{}
+		routeClientCache[secretOCID] = entry
+	}
+	routeClientCacheMu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if !entry.createdAt.IsZero() && time.Since(entry.createdAt) < getClientTTL() {
+		return entry.client, entry.err
+	}
+
+	client, err := createRouteClient(secretOCID)
+	if keepCachedKey(entry.client, entry.err, entry.fetchedAt, err) {
+		entry.createdAt = graceRetryTime()
+		return entry.client, nil
+	}
+	fetchedAt := entry.fetchedAt
+	if err == nil {
+		fetchedAt = time.Now()
+	}
+	entry.cachedClient = cachedClient{client: client, err: err, createdAt: time.Now(), fetchedAt: fetchedAt}
+	return client, err
+}
+
+// createNRClient creates a new NewRelic client instance using the license key stored in the given secret
+func createNRClient(secretOCID string) (NewRelicClientAPI, error) {
+	nrRegion, _ := region.Get(NewRelicRegion())
 	cfg := config.Config{
-		Compression: config.Compression.Gzip,
+		HTTPTransport: &skewTrackingTransport{base: logsTransport},
 	}
 
 	if os.Getenv(common.DebugEnabled) == "true" {
@@ -106,11 +163,26 @@ func createNRClient() (NewRelicClientAPI, error) {
 	}
 
 	if err := cfg.SetRegion(nrRegion); err != nil {
-		return &nrClient, err
+		return &logAPIClient{cfg: cfg}, err
+	}
+
+	builder, err := payload.FromEnv()
+	if err != nil {
+		return &logAPIClient{cfg: cfg}, err
 	}
 
-	licenseKey, err := GetLicenseKey()
+	if cfg.HTTPTransport, err = newSigningTransport(cfg.HTTPTransport); err != nil {
+		return &logAPIClient{cfg: cfg}, err
+	}
+	// Bodies are gzipped by the transport at GZIP_LEVEL, before they are signed
+	cfg.HTTPTransport = &gzipTransport{base: cfg.HTTPTransport, level: gzipLevel()}
+
+	licenseKey, err := GetLicenseKeyForSecret(context.Background(), secretOCID)
+	if err != nil {
+		err = vaultError{err: err}
+	} else {
+		err = checkLicenseKey(licenseKey)
+	}
 	cfg.LicenseKey = licenseKey
-	nrClient = logging.New(cfg)
-	return &nrClient, err
+	return &logAPIClient{cfg: cfg, builder: builder}, err
 }
@@ -3,9 +3,7 @@
 package util
 
 import (
-	"context"
 	"os"
-	"sync"
 	"testing"
 	"time"
 
@@ -19,6 +17,7 @@ func resetNRClient() {
 	cachedNRClient = nil
 	nrClientError = nil
 	clientCacheTime = time.Time{}
+	keyFetchedAt = time.Time{}
 }
 
 // MockNRClient is a mock type for the Logs interface.
@@ -32,34 +31,6 @@ func (m *MockNRClient) CreateLogEntry(batch interface{}) error {
 	return args.Error(0)
 }
 
-// TestConsumeLogBatches tests the ConsumeLogBatches function.
-func TestConsumeLogBatches(t *testing.T) {
-	mockNRClient := new(MockNRClient)
-	mockNRClient.On("CreateLogEntry", mock.Anything).Return(nil)
-
-	channel := make(chan common.DetailedLogsBatch, 1)
-	wg := new(sync.WaitGroup)
-
-	logBatch := []common.DetailedLog{{
-		CommonData: common.Common{
-			Attributes: common.LogAttributes{
-				"compartmentId": "ocid1.compartment.oc1..aaaaaaaa",
-				"tenantId":      "ocid1.tenancy.oc1..bbbbbbbbb",
-				"region":        "us-ashburn-1",
-			},
-		},
-	}}
-
-	channel <- logBatch
-
-	ctx := context.TODO()
-	wg.Add(1)
-	go ConsumeLogBatches(ctx, channel, wg, mockNRClient)
-	close(channel)
-	wg.Wait()
-	mockNRClient.AssertNumberOfCalls(t, "CreateLogEntry", 1)
-}
-
 // TestGetClientTTL tests the getClientTTL function
 func TestGetClientTTL(t *testing.T) {
 	tests := []struct {
@@ -186,39 +157,16 @@ func TestNewNRClient_CacheExpiration(t *testing.T) {
 	assert.True(t, secondCacheTime.After(firstCacheTime), "Cache should have been refreshed after TTL expiration")
 }
 
-// TestConsumeLogBatches_ErrorHandling tests error handling in log processing
-func TestConsumeLogBatches_ErrorHandling(t *testing.T) {
-	mockNRClient := new(MockNRClient)
-	
-	mockNRClient.On("CreateLogEntry", mock.Anything).Return(assert.AnError)
-
-	channel := make(chan common.DetailedLogsBatch, 2)
-	wg := new(sync.WaitGroup)
-
-	// Send two batches
-	logBatch1 := []common.DetailedLog{{
-		CommonData: common.Common{
-			Attributes: common.LogAttributes{
-				"compartmentId": "ocid1.compartment.oc1..aaaaaaaa",
-			},
-		},
-	}}
-	logBatch2 := []common.DetailedLog{{
-		CommonData: common.Common{
-			Attributes: common.LogAttributes{
-				"compartmentId": "ocid1.compartment.oc1..bbbbbbbbb",
-			},
-		},
-	}}
-
-	channel <- logBatch1
-	channel <- logBatch2
-
-	ctx := context.TODO()
-	wg.Add(1)
-	go ConsumeLogBatches(ctx, channel, wg, mockNRClient)
-	close(channel)
-	wg.Wait()
-	
-	mockNRClient.AssertNumberOfCalls(t, "CreateLogEntry", 2)
-}
\ No newline at end of file
+// TestPrefetchNRClient tests that a failed prefetch leaves the cache empty for the first invocation to retry.
+func TestPrefetchNRClient(t *testing.T) {
+	resetNRClient()
+	defer resetNRClient()
+	t.Setenv(common.SecretOCID, "ocid1.vaultsecret.test")
+	t.Setenv(common.VaultRegion, "us-ashburn-1")
+
+	err := PrefetchNRClient()
+
+	assert.Error(t, err)
+	assert.Nil(t, cachedNRClient)
+	assert.True(t, clientCacheTime.IsZero())
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"os"
+	"strings"
+
+	"github.com/newrelic/newrelic-client-go/v2/pkg/region"
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// europeanRegionPrefixes are the prefixes of the OCI regions located in Europe.
+var europeanRegionPrefixes = []string{"eu-", "uk-"}
+
+// OCIRegion returns the OCI region the function runs in, read from the resource principal environment, and the
+// realm the region belongs to. Short region keys such as "iad" are expanded. Either value is empty when unknown.
+func OCIRegion() (string, string) {
+	name := strings.TrimSpace(os.Getenv(auth.ResourcePrincipalRegionEnvVar))
+	if name == "" {
+		return "", ""
+	}
+	ociRegion := ociCommon.StringToRegion(name)
+	realm, _ := ociRegion.RealmID()
+	return string(ociRegion), realm
+}
+
+// vaultRegion returns the region of the Vault holding the secrets, set by VAULT_REGION or, when it is unset,
+// the region of the function.
+func vaultRegion() string {
+	if vault := os.Getenv(common.VaultRegion); vault != "" {
+		return vault
+	}
+	ociRegion, _ := OCIRegion()
+	return ociRegion
+}
+
+// NewRelicRegion returns the New Relic region named by NEW_RELIC_REGION. With "auto" the region is chosen from
+// the OCI region of the function, EU for the European OCI regions and US otherwise, so the data is sent to the
+// endpoints closest to it.
+func NewRelicRegion() region.Name {
+	value := os.Getenv(common.NewRelicRegion)
+	if !strings.EqualFold(value, common.NewRelicRegionAuto) {
+		return region.Name(value)
+	}
+	ociRegion, _ := OCIRegion()
+	for _, prefix := range europeanRegionPrefixes {
+		if strings.HasPrefix(ociRegion, prefix) {
+			return region.EU
+		}
+	}
+	return region.US
+}
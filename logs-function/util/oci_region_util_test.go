@@ -0,0 +1,69 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/newrelic/newrelic-client-go/v2/pkg/region"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// TestOCIRegion tests the detection of the region and realm of the function.
+func TestOCIRegion(t *testing.T) {
+	tests := []struct {
+		name           string
+		env            string
+		expectedRegion string
+		expectedRealm  string
+	}{
+		{name: "unset"},
+		{name: "region identifier", env: "eu-frankfurt-1", expectedRegion: "eu-frankfurt-1", expectedRealm: "oc1"},
+		{name: "region key", env: "IAD", expectedRegion: "us-ashburn-1", expectedRealm: "oc1"},
+		{name: "government realm", env: "us-langley-1", expectedRegion: "us-langley-1", expectedRealm: "oc2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(auth.ResourcePrincipalRegionEnvVar, tt.env)
+			ociRegion, realm := OCIRegion()
+			assert.Equal(t, tt.expectedRegion, ociRegion)
+			assert.Equal(t, tt.expectedRealm, realm)
+		})
+	}
+}
+
+// TestVaultRegion tests that the Vault region defaults to the region of the function.
+func TestVaultRegion(t *testing.T) {
+	t.Setenv(auth.ResourcePrincipalRegionEnvVar, "us-phoenix-1")
+	t.Setenv(common.VaultRegion, "")
+	assert.Equal(t, "us-phoenix-1", vaultRegion())
+
+	t.Setenv(common.VaultRegion, "us-ashburn-1")
+	assert.Equal(t, "us-ashburn-1", vaultRegion())
+}
+
+// TestNewRelicRegion tests the selection of the New Relic region.
+func TestNewRelicRegion(t *testing.T) {
+	tests := []struct {
+		name      string
+		nrRegion  string
+		ociRegion string
+		expected  region.Name
+	}{
+		{name: "configured region", nrRegion: "EU", ociRegion: "us-ashburn-1", expected: region.EU},
+		{name: "unset", nrRegion: "", ociRegion: "eu-frankfurt-1", expected: ""},
+		{name: "auto in Europe", nrRegion: "auto", ociRegion: "uk-london-1", expected: region.EU},
+		{name: "auto elsewhere", nrRegion: "AUTO", ociRegion: "ap-tokyo-1", expected: region.US},
+		{name: "auto without region", nrRegion: "auto", ociRegion: "", expected: region.US},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(common.NewRelicRegion, tt.nrRegion)
+			t.Setenv(auth.ResourcePrincipalRegionEnvVar, tt.ociRegion)
+			assert.Equal(t, tt.expected, NewRelicRegion())
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// quotaAlertInterval is the minimum time between two quota alerts of a warm container.
+const quotaAlertInterval = 15 * time.Minute
+
+// quotaAlerter limits quota alerts to one per quotaAlertInterval, as every batch of an account over its limit fails.
+type quotaAlerter struct {
+	mu        sync.Mutex
+	alertedAt time.Time
+}
+
+// quotaAlert is the quota alerter of the warm container.
+var quotaAlert quotaAlerter
+
+// due reports whether an alert is due at now, and records it as sent.
+func (a *quotaAlerter) due(now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.alertedAt.IsZero() && now.Sub(a.alertedAt) < quotaAlertInterval {
+		return false
+	}
+	a.alertedAt = now
+	return true
+}
+
+// checkQuotaError counts a quota error of the Log API as sink.quota.exceeded and alerts on it, with an error log
+// and a QuotaExceededEventType event when NEW_RELIC_ACCOUNT_ID is set. Batches refused for the quota are kept
+// in the DLQ like other failures, to be replayed once the limit is raised or reset.
+func checkQuotaError(ctx context.Context, err error) {
+	var apiErr *LogAPIError
+	if !errors.As(err, &apiErr) || apiErr.Class != ErrorClassQuota {
+		return
+	}
+	metrics.Default.Counter("sink.quota.exceeded").Inc()
+	if !quotaAlert.due(time.Now()) {
+		return
+	}
+	logger.FromContext(ctx).Errorf("New Relic refused log batches for the ingest quota of the account: %v", err)
+	if os.Getenv(common.NewRelicAccountID) == "" {
+		return
+	}
+
+	sender, senderErr := NewEventSender()
+	if senderErr == nil {
+		senderErr = sender.CreateEvents([]map[string]interface{}{{
+			"eventType":               common.QuotaExceededEventType,
+			"priority":                "high",
+			"statusCode":              apiErr.StatusCode,
+			"error":                   apiErr.Error(),
+			"instrumentation.version": common.InstrumentationVersion,
+		}})
+	}
+	if senderErr != nil {
+		logger.FromContext(ctx).Warnf("error posting quota exceeded event: %v", senderErr)
+	}
+}
@@ -0,0 +1,34 @@
+package util
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestQuotaAlerterDue tests that quota alerts are sent at most once per interval.
+func TestQuotaAlerterDue(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var a quotaAlerter
+
+	assert.True(t, a.due(start))
+	assert.False(t, a.due(start.Add(time.Minute)))
+	assert.False(t, a.due(start.Add(quotaAlertInterval-time.Second)))
+	assert.True(t, a.due(start.Add(quotaAlertInterval)))
+}
+
+// TestCheckQuotaError tests that only quota errors are counted.
+func TestCheckQuotaError(t *testing.T) {
+	metrics.Default.Reset()
+	t.Setenv("NEW_RELIC_ACCOUNT_ID", "")
+
+	checkQuotaError(context.Background(), assert.AnError)
+	checkQuotaError(context.Background(), &LogAPIError{StatusCode: 403, Class: ErrorClassAuth})
+	checkQuotaError(context.Background(), &LogAPIError{StatusCode: 403, Class: ErrorClassQuota})
+	checkQuotaError(context.Background(), &LogAPIError{StatusCode: 403, Class: ErrorClassQuota})
+
+	assert.Equal(t, int64(2), metrics.Default.Counter("sink.quota.exceeded").Value())
+}
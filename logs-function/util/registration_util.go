@@ -0,0 +1,200 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+)
+
+// NerdStorage location of integration records, one document per function keyed by its OCID.
+const (
+	RegistrationPackageID  = "5b6e8c7a-2f4d-4c1e-9a3b-0c1d2e3f4a5b"
+	RegistrationCollection = "ociLogIntegrations"
+)
+
+// DocumentStore reads and writes account-scoped NerdStorage documents of the integration collection.
+type DocumentStore interface {
+	GetDocument(ctx context.Context, accountID int, documentID string) (json.RawMessage, error)
+	WriteDocument(ctx context.Context, accountID int, documentID string, document interface{}) error
+}
+
+// nerdStorageClient is a DocumentStore calling NerdGraph directly, since NerdStorage requests must carry
+// the NewRelic-Package-Id header of the collection's package.
+type nerdStorageClient struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+const getDocumentQuery = `query($accountId: Int!, $collection: String!, $documentId: String!) {
+  actor { account(id: $accountId) { nerdStorage { document(collection: $collection, documentId: $documentId) } } }
+}`
+
+const writeDocumentMutation = `mutation($scopeId: String!, $collection: String!, $documentId: String!, $document: NerdStorageDocument!) {
+  nerdStorageWriteDocument(scope: {name: ACCOUNT, id: $scopeId}, collection: $collection, documentId: $documentId, document: $document)
+}`
+
+// GetDocument returns the stored document, or nil when there is none.
+func (c *nerdStorageClient) GetDocument(ctx context.Context, accountID int, documentID string) (json.RawMessage, error) {
+	var response struct {
+		Actor struct {
+			Account struct {
+				NerdStorage struct {
+					Document json.RawMessage `json:"document"`
+				} `json:"nerdStorage"`
+			} `json:"account"`
+		} `json:"actor"`
+	}
+	err := c.query(ctx, getDocumentQuery, map[string]interface{}{
+		"accountId":  accountID,
+		"collection": RegistrationCollection,
+		"documentId": documentID,
+	}, &response)
+	return response.Actor.Account.NerdStorage.Document, err
+}
+
+// WriteDocument creates or replaces the document.
+func (c *nerdStorageClient) WriteDocument(ctx context.Context, accountID int, documentID string, document interface{}) error {
+	return c.query(ctx, writeDocumentMutation, map[string]interface{}{
+		"scopeId":    strconv.Itoa(accountID),
+		"collection": RegistrationCollection,
+		"documentId": documentID,
+		"document":   document,
+	}, nil)
+}
+
+// query posts a GraphQL request and decodes its data into response.
+func (c *nerdStorageClient) query(ctx context.Context, query string, variables map[string]interface{}, response interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("API-Key", c.apiKey)
+	req.Header.Set("NewRelic-Package-Id", RegistrationPackageID)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("nerdgraph request failed with status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid nerdgraph response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("nerdgraph error: %s", result.Errors[0].Message)
+	}
+	if response == nil || len(result.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(result.Data, response)
+}
+
+// IntegrationRecord describes a deployed forwarder function to New Relic.
+type IntegrationRecord struct {
+	FunctionID    string   `json:"functionId"`
+	FunctionName  string   `json:"functionName,omitempty"`
+	AppID         string   `json:"appId,omitempty"`
+	AppName       string   `json:"appName,omitempty"`
+	Region        string   `json:"region,omitempty"`
+	AccountID     int      `json:"accountId"`
+	Version       string   `json:"version"`
+	RoutedAliases []string `json:"routedAliases,omitempty"` // RoutedAliases lists the aliases of the accounts logs are also routed to.
+	UpdatedAt     string   `json:"updatedAt,omitempty"`
+}
+
+// RegisterIntegration records the running function in NerdStorage when SELF_REGISTRATION is enabled.
+func RegisterIntegration(ctx context.Context, routedAliases []string) error {
+	if os.Getenv(common.SelfRegistration) != "true" {
+		return nil
+	}
+	accountID, err := strconv.Atoi(os.Getenv(common.NewRelicAccountID))
+	if err != nil || accountID <= 0 {
+		return fmt.Errorf("%s must be set to the New Relic account ID", common.NewRelicAccountID)
+	}
+	cfg, err := nerdGraphConfig()
+	if err != nil {
+		return err
+	}
+	store := &nerdStorageClient{
+		url:    cfg.Region().NerdGraphURL(),
+		apiKey: cfg.PersonalAPIKey,
+		client: &http.Client{Transport: logsTransport, Timeout: 30 * time.Second},
+	}
+	return registerIntegration(ctx, store, currentIntegration(accountID, routedAliases))
+}
+
+// currentIntegration describes the running function from its environment.
+func currentIntegration(accountID int, routedAliases []string) IntegrationRecord {
+	return IntegrationRecord{
+		FunctionID:    os.Getenv(common.FnFunctionID),
+		FunctionName:  os.Getenv(common.FnFunctionName),
+		AppID:         os.Getenv(common.FnAppID),
+		AppName:       os.Getenv(common.FnAppName),
+		Region:        os.Getenv(common.OCIRegion),
+		AccountID:     accountID,
+		Version:       common.InstrumentationVersion,
+		RoutedAliases: routedAliases,
+	}
+}
+
+// registerIntegration writes the record unless an identical one is already stored, so that warm
+// restarts of an unchanged deployment do not rewrite it.
+func registerIntegration(ctx context.Context, store DocumentStore, record IntegrationRecord) error {
+	if record.FunctionID == "" {
+		return fmt.Errorf("cannot register integration: %s is not set", common.FnFunctionID)
+	}
+
+	existing, err := store.GetDocument(ctx, record.AccountID, record.FunctionID)
+	if err != nil {
+		logger.FromContext(ctx).Debugf("no integration record read for %s: %v", record.FunctionID, err)
+	} else if stored, ok := decodeIntegration(existing); ok {
+		stored.UpdatedAt = ""
+		if reflect.DeepEqual(stored, record) {
+			logger.FromContext(ctx).Debug("integration record is up to date")
+			return nil
+		}
+	}
+
+	record.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := store.WriteDocument(ctx, record.AccountID, record.FunctionID, record); err != nil {
+		return fmt.Errorf("error writing integration record: %w", err)
+	}
+	logger.FromContext(ctx).Infof("Registered integration %s in account %d", record.FunctionID, record.AccountID)
+	return nil
+}
+
+// decodeIntegration converts a stored NerdStorage document back into a record.
+func decodeIntegration(document json.RawMessage) (IntegrationRecord, bool) {
+	var record IntegrationRecord
+	if len(document) == 0 || string(document) == "null" {
+		return record, false
+	}
+	if err := json.Unmarshal(document, &record); err != nil {
+		return IntegrationRecord{}, false
+	}
+	return record, true
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryDocumentStore keeps NerdStorage documents in memory as their JSON form, like NerdGraph returns them.
+type memoryDocumentStore struct {
+	documents map[string]json.RawMessage
+	writes    int
+}
+
+func (s *memoryDocumentStore) GetDocument(_ context.Context, _ int, documentID string) (json.RawMessage, error) {
+	return s.documents[documentID], nil
+}
+
+func (s *memoryDocumentStore) WriteDocument(_ context.Context, _ int, documentID string, document interface{}) error {
+	data, err := json.Marshal(document)
+	s.documents[documentID] = data
+	s.writes++
+	return err
+}
+
+// TestRegisterIntegration tests that the record is written on first run and only rewritten when it changes.
+func TestRegisterIntegration(t *testing.T) {
+	store := &memoryDocumentStore{documents: map[string]json.RawMessage{}}
+	record := IntegrationRecord{FunctionID: "ocid1.fnfunc.a", Region: "us-ashburn-1", AccountID: 1, Version: "1.0.0"}
+
+	assert.NoError(t, registerIntegration(context.Background(), store, record))
+	assert.Equal(t, 1, store.writes)
+	assert.Contains(t, string(store.documents["ocid1.fnfunc.a"]), `"region":"us-ashburn-1"`)
+
+	assert.NoError(t, registerIntegration(context.Background(), store, record))
+	assert.Equal(t, 1, store.writes)
+
+	record.Version = "1.1.0"
+	assert.NoError(t, registerIntegration(context.Background(), store, record))
+	assert.Equal(t, 2, store.writes)
+
+	assert.ErrorContains(t, registerIntegration(context.Background(), store, IntegrationRecord{AccountID: 1}), "FN_FN_ID")
+}
+
+// TestNerdStorageClient tests the NerdGraph requests and response decoding of the NerdStorage client.
+func TestNerdStorageClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "user-key", r.Header.Get("API-Key"))
+		assert.Equal(t, RegistrationPackageID, r.Header.Get("NewRelic-Package-Id"))
+		var request struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request.Variables["documentId"] == "missing" {
+			_, _ = w.Write([]byte(`{"errors":[{"message":"denied"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"actor":{"account":{"nerdStorage":{"document":{"functionId":"ocid1.fnfunc.a"}}}}}}`))
+	}))
+	defer server.Close()
+	client := &nerdStorageClient{url: server.URL, apiKey: "user-key", client: server.Client()}
+
+	document, err := client.GetDocument(context.Background(), 1, "ocid1.fnfunc.a")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"functionId":"ocid1.fnfunc.a"}`, string(document))
+
+	assert.ErrorContains(t, client.WriteDocument(context.Background(), 1, "missing", IntegrationRecord{}), "denied")
+}
+
+// TestRegisterIntegrationDisabled tests that nothing is registered unless self-registration is enabled.
+func TestRegisterIntegrationDisabled(t *testing.T) {
+	t.Setenv("SELF_REGISTRATION", "")
+	assert.NoError(t, RegisterIntegration(context.Background(), nil))
+}
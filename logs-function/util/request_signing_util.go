@@ -0,0 +1,71 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+)
+
+// signatureScheme prefixes the hex-encoded signature in SignatureHeader.
+const signatureScheme = "sha256="
+
+// signingTransport signs the body of every request, as sent on the wire after compression, so a log gateway
+// holding the same key can authenticate that payloads come from this forwarder.
+type signingTransport struct {
+	base http.RoundTripper
+	key  []byte
+}
+
+// RoundTrip sets SignatureHeader on a copy of the request and performs it with the base transport.
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading request body to sign: %w", err)
+		}
+	}
+
+	signed := req.Clone(req.Context())
+	signed.Body = io.NopCloser(bytes.NewReader(body))
+	signed.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	signed.Header.Set(common.SignatureHeader, SignBody(t.key, body))
+	return t.base.RoundTrip(signed)
+}
+
+// SignBody returns the SignatureHeader value of a body: its HMAC-SHA256 under key, as sha256=<hex>.
+func SignBody(key []byte, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return signatureScheme + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newSigningTransport wraps base with a signingTransport when SIGNING_KEY_SECRET_OCID is set and the Log API
+// endpoint is a custom one. Requests to New Relic are never signed.
+func newSigningTransport(base http.RoundTripper) (http.RoundTripper, error) {
+	secretOCID := os.Getenv(common.SigningKeySecretOCID)
+	if secretOCID == "" {
+		return base, nil
+	}
+	if os.Getenv(common.LogsBaseURL) == "" {
+		logger.FromContext(context.Background()).Warnf("Ignoring %s: requests are only signed for a custom %s", common.SigningKeySecretOCID, common.LogsBaseURL)
+		return base, nil
+	}
+
+	key, err := GetLicenseKeyForSecret(context.Background(), secretOCID)
+	if err != nil {
+		return base, fmt.Errorf("error fetching request signing key: %w", err)
+	}
+	return &signingTransport{base: base, key: []byte(key)}, nil
+}
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/newrelic/newrelic-client-go/v2/pkg/config"
+	"github.com/newrelic/newrelic-client-go/v2/pkg/region"
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSigningTransport tests that the compressed body received by the gateway matches its signature.
+func TestSigningTransport(t *testing.T) {
+	key := []byte("gateway-key")
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(common.SignatureHeader)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	t.Setenv(common.LogsBaseURL, server.URL)
+	nrRegion, _ := region.Get(region.Name("US"))
+	cfg := config.Config{
+		LicenseKey:    "key",
+		Compression:   config.Compression.Gzip,
+		HTTPTransport: &signingTransport{base: http.DefaultTransport, key: key},
+	}
+	assert.NoError(t, cfg.SetRegion(nrRegion))
+
+	err := (&logAPIClient{cfg: cfg}).CreateLogEntry(common.DetailedLogsBatch{{Entries: common.LogData{{"message": "a"}}}})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, body)
+	assert.Equal(t, SignBody(key, body), signature)
+	assert.NotEqual(t, SignBody([]byte("other-key"), body), signature)
+}
+
+// TestNewSigningTransport tests that requests are only signed for a custom endpoint.
+func TestNewSigningTransport(t *testing.T) {
+	base := http.DefaultTransport
+
+	t.Setenv(common.SigningKeySecretOCID, "")
+	transport, err := newSigningTransport(base)
+	assert.NoError(t, err)
+	assert.Equal(t, base, transport)
+
+	t.Setenv(common.SigningKeySecretOCID, "ocid1.vaultsecret.signing")
+	t.Setenv(common.LogsBaseURL, "")
+	transport, err = newSigningTransport(base)
+	assert.NoError(t, err)
+	assert.Equal(t, base, transport)
+}
+
+// TestSignBody tests the signature format against a known HMAC-SHA256 value.
+func TestSignBody(t *testing.T) {
+	assert.Equal(t, "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		SignBody([]byte("key"), []byte("The quick brown fox jumps over the lazy dog")))
+}
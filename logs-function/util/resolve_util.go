@@ -0,0 +1,107 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+)
+
+// defaultDNSPort is the port of a NEW_RELIC_DNS_RESOLVER server given without one.
+const defaultDNSPort = "53"
+
+// ConfigureHostResolution makes the shared New Relic transport resolve hostnames with the static addresses of
+// NEW_RELIC_HOST_OVERRIDES and the DNS server of NEW_RELIC_DNS_RESOLVER, for functions whose only path to New Relic
+// is a Service Gateway or another private connection. It must be called before the first request is made.
+func ConfigureHostResolution() error {
+	overrides, err := parseHostOverrides(os.Getenv(common.HostOverrides))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", common.HostOverrides, err)
+	}
+	resolver, err := newResolver(os.Getenv(common.DNSResolver))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", common.DNSResolver, err)
+	}
+	if len(overrides) == 0 && resolver == nil {
+		return nil
+	}
+	logsTransport.DialContext = overrideDialer(overrides, resolver).DialContext
+	logger.FromContext(context.Background()).Infof("Resolving New Relic hostnames with %d static addresses and custom DNS resolver %t", len(overrides), resolver != nil)
+	return nil
+}
+
+// parseHostOverrides parses a comma-separated list of <hostname>=<ip> entries into a map keyed by the lower-cased hostname.
+func parseHostOverrides(value string) (map[string]string, error) {
+	overrides := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		host, ip, ok := strings.Cut(entry, "=")
+		host, ip = strings.ToLower(strings.TrimSpace(host)), strings.TrimSpace(ip)
+		if !ok || host == "" {
+			return nil, fmt.Errorf("entry %q is not <hostname>=<ip>", entry)
+		}
+		if net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("entry %q has an invalid IP address", entry)
+		}
+		overrides[host] = ip
+	}
+	return overrides, nil
+}
+
+// newResolver returns a resolver querying the DNS server at address, or nil when address is empty.
+func newResolver(address string) (*net.Resolver, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return nil, nil
+	}
+	if net.ParseIP(address) != nil {
+		address = net.JoinHostPort(address, defaultDNSPort)
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) == nil || port == "" {
+		return nil, fmt.Errorf("%q is not <ip> or <ip>:<port>", address)
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+	}, nil
+}
+
+// hostOverrideDialer dials the static address of an overridden hostname, and any other hostname with its resolver.
+type hostOverrideDialer struct {
+	dialer    *net.Dialer
+	overrides map[string]string
+}
+
+// overrideDialer returns a dialer with the same timeouts as the default transport. A nil resolver is the system one.
+func overrideDialer(overrides map[string]string, resolver *net.Resolver) *hostOverrideDialer {
+	return &hostOverrideDialer{
+		dialer:    &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Resolver: resolver},
+		overrides: overrides,
+	}
+}
+
+// DialContext connects to addr, replacing an overridden hostname with its static address.
+func (d *hostOverrideDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err == nil {
+		if ip, ok := d.overrides[strings.ToLower(host)]; ok {
+			addr = net.JoinHostPort(ip, port)
+		}
+	}
+	return d.dialer.DialContext(ctx, network, addr)
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseHostOverrides tests the parsing of the hostname to IP address entries.
+func TestParseHostOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{name: "empty", value: "", expected: map[string]string{}},
+		{name: "entries", value: "Log-API.newrelic.com=10.0.0.5, insights-collector.newrelic.com=fd00::1",
+			expected: map[string]string{"log-api.newrelic.com": "10.0.0.5", "insights-collector.newrelic.com": "fd00::1"}},
+		{name: "missing ip", value: "log-api.newrelic.com", wantErr: true},
+		{name: "invalid ip", value: "log-api.newrelic.com=gateway", wantErr: true},
+		{name: "missing hostname", value: "=10.0.0.5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overrides, err := parseHostOverrides(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, overrides)
+		})
+	}
+}
+
+// TestNewResolver tests that the DNS server address is validated.
+func TestNewResolver(t *testing.T) {
+	resolver, err := newResolver("")
+	assert.NoError(t, err)
+	assert.Nil(t, resolver)
+
+	for _, address := range []string{"10.0.0.2", "10.0.0.2:5353", "[fd00::2]:53"} {
+		resolver, err := newResolver(address)
+		assert.NoError(t, err, address)
+		assert.NotNil(t, resolver, address)
+	}
+	for _, address := range []string{"dns.internal", "dns.internal:53", "10.0.0.2:"} {
+		_, err := newResolver(address)
+		assert.Error(t, err, address)
+	}
+}
+
+// TestHostOverrideDialer tests that requests to an overridden hostname reach its static address.
+func TestHostOverrideDialer(t *testing.T) {
+	var host string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	transport := newLogsTransport()
+	transport.DialContext = overrideDialer(map[string]string{"log-api.newrelic.invalid": "127.0.0.1"}, nil).DialContext
+	resp, err := (&http.Client{Transport: transport}).Get("http://log-api.newrelic.invalid:" + port + "/log/v1")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "log-api.newrelic.invalid:"+port, host)
+
+	_, err = overrideDialer(nil, nil).DialContext(context.Background(), "tcp", "unknown.newrelic.invalid:"+port)
+	assert.Error(t, err)
+}
+
+// TestConfigureHostResolution tests that the shared transport dials through the override dialer only when configured.
+func TestConfigureHostResolution(t *testing.T) {
+	defer func(dial func(context.Context, string, string) (net.Conn, error)) { logsTransport.DialContext = dial }(logsTransport.DialContext)
+	logsTransport.DialContext = nil
+
+	assert.NoError(t, ConfigureHostResolution())
+	assert.Nil(t, logsTransport.DialContext)
+
+	t.Setenv(common.HostOverrides, "log-api.newrelic.com=gateway")
+	assert.ErrorContains(t, ConfigureHostResolution(), common.HostOverrides)
+
+	t.Setenv(common.HostOverrides, "log-api.newrelic.com=10.0.0.5")
+	t.Setenv(common.DNSResolver, "10.0.0.2")
+	assert.NoError(t, ConfigureHostResolution())
+	assert.NotNil(t, logsTransport.DialContext)
+}
@@ -0,0 +1,137 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/routing"
+)
+
+// routeFetchConcurrency is the number of license keys of routed accounts fetched from Vault at the same time.
+const routeFetchConcurrency = 8
+
+// routedNRClient posts each batch with the client of the account the batch was routed to.
+type routedNRClient struct {
+	clients map[string]NewRelicClientAPI
+}
+
+// NewRoutedNRClient returns a NewRelicClientAPI that forwards each batch to the account named by its
+// accountAlias attribute. Without routes it is equivalent to NewNRClient.
+func NewRoutedNRClient(routes []routing.Route) (NewRelicClientAPI, error) {
+	defaultClient, err := NewNRClient()
+	if err != nil || len(routes) == 0 {
+		return defaultClient, err
+	}
+
+	clients := map[string]NewRelicClientAPI{routing.DefaultAlias: defaultClient}
+	bySecret := routeClients(routes)
+	for _, route := range routes {
+		entry := bySecret[route.SecretOCID]
+		if entry.err != nil {
+			return nil, fmt.Errorf("error initializing newrelic client for account route %q: %w", route.Alias, entry.err)
+		}
+		clients[route.Alias] = entry.client
+	}
+	return &routedNRClient{clients: clients}, nil
+}
+
+// routeClients returns the clients of the routes keyed by secret OCID. The clients of distinct secrets are
+// created concurrently, with at most routeFetchConcurrency license keys fetched from Vault at a time, and
+// cached individually so a broken secret does not affect the others.
+func routeClients(routes []routing.Route) map[string]cachedClient {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		clients = make(map[string]cachedClient, len(routes))
+		seen    = make(map[string]bool, len(routes))
+		slots   = make(chan struct{}, routeFetchConcurrency)
+	)
+	for _, route := range routes {
+		if seen[route.SecretOCID] {
+			continue
+		}
+		seen[route.SecretOCID] = true
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(secretOCID string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			client, err := newRouteNRClient(secretOCID)
+			mu.Lock()
+			clients[secretOCID] = cachedClient{client: client, err: err}
+			mu.Unlock()
+		}(route.SecretOCID)
+	}
+	wg.Wait()
+	return clients
+}
+
+// CreateLogEntry posts the batch with the client of the account it was routed to.
+func (c *routedNRClient) CreateLogEntry(logEntry interface{}) error {
+	alias := batchAlias(logEntry)
+	client, ok := c.clients[alias]
+	if !ok {
+		return fmt.Errorf("no newrelic client for account route %q", alias)
+	}
+	return client.CreateLogEntry(logEntry)
+}
+
+// batchAlias returns the account alias stamped on the batch, or the default alias.
+func batchAlias(logEntry interface{}) string {
+	batch, ok := logEntry.(common.DetailedLogsBatch)
+	if !ok || len(batch) == 0 {
+		return routing.DefaultAlias
+	}
+	if alias, ok := batch[0].CommonData.Attributes[common.AccountAliasAttribute].(string); ok && alias != "" {
+		return alias
+	}
+	return routing.DefaultAlias
+}
+
+// ValidateRoutes checks every routed account by posting an empty batch with its license key.
+// It logs the result of each account and returns the errors of all broken routes joined, each naming its route,
+// so that callers can tell a Vault outage from a rejected license key.
+func ValidateRoutes(routes []routing.Route) error {
+	var errs []error
+	rlog := logger.FromContext(context.Background())
+	check := func(alias string, client NewRelicClientAPI, err error) {
+		if err == nil {
+			err = client.CreateLogEntry(validationBatch(alias))
+		}
+		if err != nil {
+			rlog.Errorf("account route %q: license key validation failed: %v", alias, err)
+			errs = append(errs, fmt.Errorf("account route %q: %w", alias, err))
+			return
+		}
+		rlog.Infof("account route %q: license key validated", alias)
+	}
+
+	client, err := NewNRClient()
+	check(routing.DefaultAlias, client, err)
+	bySecret := routeClients(routes)
+	for _, route := range routes {
+		entry := bySecret[route.SecretOCID]
+		check(route.Alias, entry.client, entry.err)
+	}
+	return errors.Join(errs...)
+}
+
+// validationBatch is the empty batch posted to check a license key.
+func validationBatch(alias string) common.DetailedLogsBatch {
+	return common.DetailedLogsBatch{{
+		CommonData: common.Common{
+			Attributes: common.LogAttributes{
+				"instrumentation.provider":   common.InstrumentationProvider,
+				"instrumentation.name":       common.InstrumentationName,
+				"instrumentation.version":    common.InstrumentationVersion,
+				common.AccountAliasAttribute: alias,
+			},
+		},
+		Entries: common.LogData{},
+	}}
+}
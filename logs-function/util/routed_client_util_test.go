@@ -0,0 +1,136 @@
+package util
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestRoutedNRClient tests that batches are posted with the client of their account alias.
+func TestRoutedNRClient(t *testing.T) {
+	defaultClient := new(MockNRClient)
+	defaultClient.On("CreateLogEntry", mock.Anything).Return(nil)
+	securityClient := new(MockNRClient)
+	securityClient.On("CreateLogEntry", mock.Anything).Return(nil)
+
+	client := &routedNRClient{clients: map[string]NewRelicClientAPI{
+		routing.DefaultAlias: defaultClient,
+		"sec":                securityClient,
+	}}
+
+	aliased := func(alias string) common.DetailedLogsBatch {
+		return common.DetailedLogsBatch{{CommonData: common.Common{Attributes: common.LogAttributes{common.AccountAliasAttribute: alias}}}}
+	}
+
+	assert.NoError(t, client.CreateLogEntry(aliased("sec")))
+	assert.NoError(t, client.CreateLogEntry(aliased(routing.DefaultAlias)))
+	assert.NoError(t, client.CreateLogEntry(common.DetailedLogsBatch{{}}))
+	assert.ErrorContains(t, client.CreateLogEntry(aliased("unknown")), `no newrelic client for account route "unknown"`)
+
+	securityClient.AssertNumberOfCalls(t, "CreateLogEntry", 1)
+	defaultClient.AssertNumberOfCalls(t, "CreateLogEntry", 2)
+}
+
+// TestValidationBatch tests the payload used to validate license keys.
+func TestValidationBatch(t *testing.T) {
+	batch := validationBatch("sec")
+	assert.Len(t, batch, 1)
+	assert.Empty(t, batch[0].Entries)
+	assert.Equal(t, "sec", batch[0].CommonData.Attributes[common.AccountAliasAttribute])
+}
+
+// TestRouteClients tests that the clients of distinct secrets are created concurrently within the bound, once
+// per secret, and that a failing secret does not affect the others.
+func TestRouteClients(t *testing.T) {
+	var inFlight, maxInFlight, calls atomic.Int64
+	var mu sync.Mutex
+	created := map[string]int{}
+	createRouteClient = func(secretOCID string) (NewRelicClientAPI, error) {
+		calls.Add(1)
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if current <= peak || maxInFlight.CompareAndSwap(peak, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		created[secretOCID]++
+		mu.Unlock()
+		if secretOCID == "broken" {
+			return nil, errors.New("secret not found")
+		}
+		return new(MockNRClient), nil
+	}
+	routeClientCache = map[string]*routeClientEntry{}
+	t.Cleanup(func() {
+		createRouteClient = createNRClient
+		routeClientCache = map[string]*routeClientEntry{}
+	})
+
+	var routes []routing.Route
+	for _, secret := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "a", "b", "broken"} {
+		routes = append(routes, routing.Route{Alias: "alias-" + secret, SecretOCID: secret})
+	}
+
+	clients := routeClients(routes)
+	assert.Len(t, clients, 11)
+	assert.EqualError(t, clients["broken"].err, "secret not found")
+	assert.NotNil(t, clients["a"].client)
+	assert.Equal(t, int64(11), calls.Load())
+	assert.Greater(t, maxInFlight.Load(), int64(1))
+	assert.LessOrEqual(t, maxInFlight.Load(), int64(routeFetchConcurrency))
+
+	routeClients(routes)
+	assert.Equal(t, int64(11), calls.Load(), "cached clients are not created again")
+	assert.Equal(t, 1, created["a"])
+}
+
+// TestValidateRoutes tests that the errors of all broken routes are returned with their causes.
+func TestValidateRoutes(t *testing.T) {
+	resetNRClient()
+	defaultClient := new(MockNRClient)
+	defaultClient.On("CreateLogEntry", mock.Anything).Return(nil)
+	cachedNRClient, clientCacheTime = defaultClient, time.Now()
+	rejected := new(MockNRClient)
+	rejected.On("CreateLogEntry", mock.Anything).Return(&LogAPIError{StatusCode: 403, Class: ErrorClassAuth})
+	createRouteClient = func(secretOCID string) (NewRelicClientAPI, error) {
+		switch secretOCID {
+		case "unreachable":
+			return nil, vaultError{err: errors.New("failed to fetch secret bundle: timeout")}
+		case "rejected":
+			return rejected, nil
+		}
+		return defaultClient, nil
+	}
+	routeClientCache = map[string]*routeClientEntry{}
+	t.Cleanup(func() {
+		resetNRClient()
+		createRouteClient = createNRClient
+		routeClientCache = map[string]*routeClientEntry{}
+	})
+
+	assert.NoError(t, ValidateRoutes([]routing.Route{{Alias: "ok", SecretOCID: "ok"}}))
+
+	err := ValidateRoutes([]routing.Route{
+		{Alias: "ok", SecretOCID: "ok"},
+		{Alias: "vault", SecretOCID: "unreachable"},
+		{Alias: "sec", SecretOCID: "rejected"},
+	})
+	assert.ErrorContains(t, err, `account route "vault": failed to fetch secret bundle`)
+	assert.NotContains(t, err.Error(), `"ok"`)
+	assert.True(t, IsVaultError(err))
+	var apiErr *LogAPIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, ErrorClassAuth, apiErr.Class)
+	assert.False(t, IsVaultError(apiErr))
+}
@@ -3,8 +3,12 @@ package util
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 
 	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/common/auth"
@@ -12,27 +16,43 @@ import (
 
 	"github.com/newrelic/oci-log-integration/logs-function/common"
 	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/ociclient"
 )
 
-var log = logger.NewLogrusLogger(logger.WithDebugLevel())
-
 // OCISecretsManagerAPI is an interface for interacting with OCI Secrets Manager.
 type OCISecretsManagerAPI interface {
 	GetSecretBundle(ctx context.Context, request secrets.GetSecretBundleRequest) (secrets.GetSecretBundleResponse, error)
 	SetRegion(regionId string)
 }
 
+// newSecretsClient creates the client secrets are fetched with, replaced in tests with UseSecretsClient.
+var newSecretsClient = newOCISecretsManagerClient
+
+// UseSecretsClient makes secrets be fetched with the clients created by factory, e.g. the client of a
+// vaulttest.Server, until restore is called.
+func UseSecretsClient(factory func() (OCISecretsManagerAPI, error)) (restore func()) {
+	previous := newSecretsClient
+	newSecretsClient = factory
+	return func() { newSecretsClient = previous }
+}
+
+// Versions of the secrets fetched by the warm container, used to report rotations and rollbacks.
+var (
+	secretVersionsMu sync.Mutex
+	secretVersions   = map[string]int64{}
+)
+
 // getSecretFromOCIVault retrieves a secret from OCI Vault.
 // It returns the secret string and an error if any.
 func getSecretFromOCIVault(ctx context.Context, secretsClient OCISecretsManagerAPI, secretOCID string, vaultRegion string) (string, error) {
 	// Check if the passed secret OCID is empty
 	if secretOCID == "" {
-		log.Panicf("secret OCID is empty")
+		logger.FromContext(ctx).Panicf("secret OCID is empty")
 	}
 
 	// Check if the vault region is empty
 	if vaultRegion == "" {
-		log.Panicf("vault region is empty")
+		logger.FromContext(ctx).Panicf("vault region is empty")
 	}
 
 	// Set the region for secrets client
@@ -41,33 +61,103 @@ func getSecretFromOCIVault(ctx context.Context, secretsClient OCISecretsManagerA
 	getSecretBundleRequest := secrets.GetSecretBundleRequest{
 		SecretId: ociCommon.String(secretOCID),
 	}
+	pinned := pinnedSecretVersion(ctx, secretOCID)
+	if pinned > 0 {
+		getSecretBundleRequest.VersionNumber = ociCommon.Int64(pinned)
+	}
 
-	scResponse, err := secretsClient.GetSecretBundle(ctx, getSecretBundleRequest)
+	scResponse, err := getSecretBundle(ctx, secretsClient, getSecretBundleRequest)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch secret bundle: %w", err)
 	}
-	log.Debug("successfully fetched secret from OCI vault")
+	logger.FromContext(ctx).Debug("successfully fetched secret from OCI vault")
+	if scResponse.VersionNumber != nil {
+		if event, changed := secretVersionChange(ctx, secretOCID, *scResponse.VersionNumber, pinned > 0); changed {
+			reportSecretVersionChange(ctx, event)
+		}
+	}
 
 	secretContent, ok := scResponse.SecretBundleContent.(secrets.Base64SecretBundleContentDetails)
 	if !ok {
-		log.WithField("secretOCID", secretOCID).Error("unexpected secret content type")
+		logger.FromContext(ctx).WithField("secretOCID", secretOCID).Error("unexpected secret content type")
 		return "", fmt.Errorf("unexpected secret content type")
 	}
 
 	if secretContent.Content == nil {
-		log.WithField("secretOCID", secretOCID).Error("secret content is nil")
+		logger.FromContext(ctx).WithField("secretOCID", secretOCID).Error("secret content is nil")
 		return "", fmt.Errorf("secret content is nil")
 	}
 
 	decodedSecret, err := base64.StdEncoding.DecodeString(*secretContent.Content)
 	if err != nil {
-		log.WithField("error", err).WithField("secretOCID", secretOCID).Error("failed to base64 decode secret content")
+		logger.FromContext(ctx).WithField("error", err).WithField("secretOCID", secretOCID).Error("failed to base64 decode secret content")
 		return "", fmt.Errorf("failed to decode secret content: %w", err)
 	}
 
 	return string(decodedSecret), nil
 }
 
+// pinnedSecretVersion returns the version pinned with SECRET_VERSION when secretOCID is the license key
+// secret referenced by SECRET_OCID, or 0 when the current version should be fetched.
+func pinnedSecretVersion(ctx context.Context, secretOCID string) int64 {
+	if secretOCID != os.Getenv(common.SecretOCID) {
+		return 0
+	}
+	value := os.Getenv(common.SecretVersion)
+	if value == "" {
+		return 0
+	}
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version <= 0 {
+		logger.FromContext(ctx).Warnf("Ignoring invalid %s value %q", common.SecretVersion, value)
+		return 0
+	}
+	return version
+}
+
+// secretVersionChange records the fetched version of the secret and, when it differs from the version fetched
+// before, returns a SecretVersionEventType event describing the change. Moving to an older version that was not
+// pinned is flagged as a rollback.
+func secretVersionChange(ctx context.Context, secretOCID string, version int64, pinned bool) (map[string]interface{}, bool) {
+	secretVersionsMu.Lock()
+	previous, seen := secretVersions[secretOCID]
+	secretVersions[secretOCID] = version
+	secretVersionsMu.Unlock()
+
+	if !seen || previous == version {
+		return nil, false
+	}
+	rollback := version < previous && !pinned
+	if rollback {
+		logger.FromContext(ctx).WithField("secretOCID", secretOCID).Warnf("secret version rolled back from %d to %d", previous, version)
+	} else {
+		logger.FromContext(ctx).WithField("secretOCID", secretOCID).Infof("secret version changed from %d to %d", previous, version)
+	}
+	return map[string]interface{}{
+		"eventType":       common.SecretVersionEventType,
+		"secretId":        secretOCID,
+		"previousVersion": previous,
+		"version":         version,
+		"pinned":          pinned,
+		"rollback":        rollback,
+	}, true
+}
+
+// reportSecretVersionChange posts the version change event when an account is configured for events.
+func reportSecretVersionChange(ctx context.Context, event map[string]interface{}) {
+	if os.Getenv(common.NewRelicAccountID) == "" {
+		return
+	}
+	sender, err := NewEventSender()
+	if err != nil {
+		logger.FromContext(ctx).Warnf("error creating event sender for secret version change: %v", err)
+		return
+	}
+	if err := sender.CreateEvents([]map[string]interface{}{event}); err != nil {
+		logger.FromContext(ctx).Warnf("error posting secret version change: %v", err)
+	}
+}
+
 // newOCISecretsManagerClient creates a new OCI Secrets Manager client.
 // It returns an OCISecretsManagerAPI client and an error if any.
 func newOCISecretsManagerClient() (OCISecretsManagerAPI, error) {
@@ -76,37 +166,63 @@ func newOCISecretsManagerClient() (OCISecretsManagerAPI, error) {
 
 	provider, err = auth.ResourcePrincipalConfigurationProvider()
 	if err != nil {
-		log.WithField("error", err).Error("failed to create resource principal configuration provider")
+		logger.FromContext(context.Background()).WithField("error", err).Error("failed to create resource principal configuration provider")
 		return nil, fmt.Errorf("failed to create resource principal configuration provider: %w", err)
 	}
 
 	secretsClient, err := secrets.NewSecretsClientWithConfigurationProvider(provider)
 	if err != nil {
-		log.WithField("error", err).Error("failed to create OCI secrets client")
+		logger.FromContext(context.Background()).WithField("error", err).Error("failed to create OCI secrets client")
 		return nil, fmt.Errorf("failed to create OCI secrets client: %w", err)
 	}
+	ociclient.Configure(&secretsClient.BaseClient, "Secrets")
 
 	return &secretsClient, nil
 }
 
 // GetLicenseKey returns the license key from the OCI Secrets Manager.
 // It returns the New Relic Ingest License key and an error if any.
-func GetLicenseKey() (key string, err error) {
-	ctx := context.Background()
-	log.Debug("fetching license key from OCI vault")
+func GetLicenseKey(ctx context.Context) (key string, err error) {
+	return GetLicenseKeyForSecret(ctx, os.Getenv(common.SecretOCID))
+}
+
+// GetLicenseKeyForSecret returns the license key stored in the given OCI Vault secret, either as the whole secret
+// or as the licenseKey field of a JSON secret.
+// It returns the New Relic Ingest License key and an error if any.
+func GetLicenseKeyForSecret(ctx context.Context, secretOCID string) (key string, err error) {
+	logger.FromContext(ctx).Debug("fetching license key from OCI vault")
 
-	secretOCID := os.Getenv(common.SecretOCID)
-	vaultRegion := os.Getenv(common.VaultRegion)
+	region := vaultRegion()
 
-	secretsClient, err := newOCISecretsManagerClient()
+	secretsClient, err := newSecretsClient()
 	if err != nil {
 		return "", err
 	}
 
-	secretValue, err := getSecretFromOCIVault(ctx, secretsClient, secretOCID, vaultRegion)
+	secretValue, err := getSecretFromOCIVault(ctx, secretsClient, secretOCID, region)
 	if err != nil {
 		return "", err
 	}
 
-	return secretValue, nil
+	return licenseKeyFromSecret(secretValue)
+}
+
+// licenseKeyFromSecret returns the licenseKey field of a JSON object secret, and any other secret as is. An empty
+// secret is an error, so that it is reported as a Vault misconfiguration rather than rejected by the Log API.
+func licenseKeyFromSecret(secretValue string) (string, error) {
+	if strings.TrimSpace(secretValue) == "" {
+		return "", fmt.Errorf("license key secret is empty")
+	}
+	if !strings.HasPrefix(strings.TrimSpace(secretValue), "{") {
+		return secretValue, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secretValue), &fields); err != nil {
+		return secretValue, nil
+	}
+	licenseKey, _ := fields[common.LicenseKey].(string)
+	if licenseKey == "" {
+		return "", fmt.Errorf("license key is empty or not present in the secret")
+	}
+	return licenseKey, nil
 }
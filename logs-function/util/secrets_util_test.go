@@ -3,15 +3,17 @@ package util
 import (
 	"context"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
+	"net/http"
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/oracle/oci-go-sdk/v65/secrets"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/vaulttest"
 )
 
 // Mock OCI Secrets Manager client for testing
@@ -21,9 +23,16 @@ type mockOCISecretsClient struct {
 	region          string
 	forceNilContent bool
 	invalidBase64   bool
+	versionNumber   int64
+	throttled       int
+	requests        []secrets.GetSecretBundleRequest
 }
 
 func (m *mockOCISecretsClient) GetSecretBundle(ctx context.Context, request secrets.GetSecretBundleRequest) (secrets.GetSecretBundleResponse, error) {
+	m.requests = append(m.requests, request)
+	if len(m.requests) <= m.throttled {
+		return secrets.GetSecretBundleResponse{}, throttledError{}
+	}
 	if m.shouldError {
 		return secrets.GetSecretBundleResponse{}, errors.New("mock OCI secrets error")
 	}
@@ -47,6 +56,9 @@ func (m *mockOCISecretsClient) GetSecretBundle(ctx context.Context, request secr
 			},
 		},
 	}
+	if m.versionNumber > 0 {
+		response.VersionNumber = &m.versionNumber
+	}
 
 	return response, nil
 }
@@ -224,7 +236,7 @@ func TestGetLicenseKeyWithMockClient(t *testing.T) {
 				return
 			}
 
-			licenseKey, extractErr := extractLicenseKeyFromSecret(secret)
+			licenseKey, extractErr := licenseKeyFromSecret(secret)
 
 			if tt.expectedError != "" {
 				if extractErr == nil {
@@ -244,24 +256,6 @@ func TestGetLicenseKeyWithMockClient(t *testing.T) {
 	}
 }
 
-// Helper function to extract license key from secret (for testing)
-func extractLicenseKeyFromSecret(secretValue string) (string, error) {
-	if secretValue == "" {
-		return "", errors.New("license key secret is empty")
-	}
-
-	var secretMap map[string]string
-	if err := json.Unmarshal([]byte(secretValue), &secretMap); err != nil {
-		return secretValue, nil
-	}
-
-	if licenseKey, exists := secretMap[common.LicenseKey]; exists && licenseKey != "" {
-		return licenseKey, nil
-	}
-
-	return "", errors.New("license key is empty or not present in the secret")
-}
-
 func TestGetSecretFromOCIVault_EdgeCases(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -399,7 +393,7 @@ func TestGetLicenseKeyError(t *testing.T) {
 				}
 			}
 
-			key, err := GetLicenseKey()
+			key, err := GetLicenseKey(context.Background())
 
 			if err == nil {
 				t.Errorf("Expected error, but got nil")
@@ -426,3 +420,151 @@ func contains(str, substr string) bool {
 					return false
 				}())))
 }
+
+// TestGetSecretFromOCIVaultPinnedVersion tests that SECRET_VERSION pins only the license key secret.
+func TestGetSecretFromOCIVaultPinnedVersion(t *testing.T) {
+	t.Setenv(common.SecretOCID, "ocid1.vaultsecret.license")
+	t.Setenv(common.SecretVersion, "3")
+	mockClient := &mockOCISecretsClient{secretContent: "key"}
+
+	_, err := getSecretFromOCIVault(context.Background(), mockClient, "ocid1.vaultsecret.license", "us-ashburn-1")
+	assert.NoError(t, err)
+	_, err = getSecretFromOCIVault(context.Background(), mockClient, "ocid1.vaultsecret.other", "us-ashburn-1")
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(3), *mockClient.requests[0].VersionNumber)
+	assert.Nil(t, mockClient.requests[1].VersionNumber)
+}
+
+// TestSecretVersionChange tests that version changes are reported and unpinned downgrades are flagged as rollbacks.
+func TestSecretVersionChange(t *testing.T) {
+	tests := []struct {
+		name             string
+		versions         []int64
+		pinned           bool
+		expectedChanged  bool
+		expectedRollback bool
+	}{
+		{"first fetch", []int64{4}, false, false, false},
+		{"same version", []int64{4, 4}, false, false, false},
+		{"rotation", []int64{4, 5}, false, true, false},
+		{"rollback", []int64{5, 4}, false, true, true},
+		{"pinned downgrade", []int64{5, 4}, true, true, false},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secretOCID := "ocid1.vaultsecret.test" + strconv.Itoa(i)
+			var event map[string]interface{}
+			var changed bool
+			for _, version := range tt.versions {
+				event, changed = secretVersionChange(context.Background(), secretOCID, version, tt.pinned)
+			}
+
+			assert.Equal(t, tt.expectedChanged, changed)
+			if changed {
+				assert.Equal(t, common.SecretVersionEventType, event["eventType"])
+				assert.Equal(t, tt.versions[0], event["previousVersion"])
+				assert.Equal(t, tt.expectedRollback, event["rollback"])
+			}
+		})
+	}
+}
+
+// TestLicenseKeyFromSecret tests that the license key is read from the licenseKey field of JSON object secrets
+// and that other secrets are used as is.
+func TestLicenseKeyFromSecret(t *testing.T) {
+	tests := []struct {
+		name          string
+		secret        string
+		expectedKey   string
+		expectedError string
+	}{
+		{"plain key", "plain-license-key", "plain-license-key", ""},
+		{"empty secret", "", "", "license key secret is empty"},
+		{"whitespace secret", " \n", "", "license key secret is empty"},
+		{"JSON object", `{"licenseKey": "json-license-key", "note": "prod"}`, "json-license-key", ""},
+		{"JSON object after whitespace", "\n {\"licenseKey\":\"json-license-key\"}", "json-license-key", ""},
+		{"JSON object without license key", `{"otherKey": "other-value"}`, "", "license key is empty or not present"},
+		{"JSON object with empty license key", `{"licenseKey": ""}`, "", "license key is empty or not present"},
+		{"JSON object with numeric license key", `{"licenseKey": 42}`, "", "license key is empty or not present"},
+		{"invalid JSON", "{not json", "{not json", ""},
+		{"JSON array", `["json-license-key"]`, `["json-license-key"]`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := licenseKeyFromSecret(tt.secret)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedKey, key)
+		})
+	}
+}
+
+// TestGetLicenseKeyForSecretWithVault tests the license key path against a fake OCI Vault, from the SDK request to
+// the decoding of plain and JSON secrets.
+func TestGetLicenseKeyForSecretWithVault(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	var client *vaulttest.Client
+	defer UseSecretsClient(func() (OCISecretsManagerAPI, error) {
+		var err error
+		client, err = server.Client()
+		return client, err
+	})()
+	useFastVaultBackoff(t)
+
+	server.PutSecret("ocid1.vaultsecret.plain", "plain-license-key")
+	server.PutSecret("ocid1.vaultsecret.json", `{"licenseKey": "json-license-key"}`)
+	server.PutSecret("ocid1.vaultsecret.other", `{"otherKey": "other-value"}`)
+	server.PutSecret("ocid1.vaultsecret.pinned", "old-license-key")
+	server.PutSecret("ocid1.vaultsecret.pinned", "new-license-key")
+	server.PutSecret("ocid1.vaultsecret.throttled", "throttled-license-key")
+	server.Fail("ocid1.vaultsecret.throttled", http.StatusTooManyRequests)
+	server.PutSecret("ocid1.vaultsecret.unavailable", "key")
+	server.Fail("ocid1.vaultsecret.unavailable", http.StatusServiceUnavailable)
+
+	tests := []struct {
+		name          string
+		secretOCID    string
+		pinnedVersion string
+		expectedKey   string
+		expectedError string
+		requests      []vaulttest.Request
+	}{
+		{"plain secret", "ocid1.vaultsecret.plain", "", "plain-license-key", "", []vaulttest.Request{{SecretID: "ocid1.vaultsecret.plain"}}},
+		{"JSON secret", "ocid1.vaultsecret.json", "", "json-license-key", "", []vaulttest.Request{{SecretID: "ocid1.vaultsecret.json"}}},
+		{"JSON secret without license key", "ocid1.vaultsecret.other", "", "", "license key is empty or not present", []vaulttest.Request{{SecretID: "ocid1.vaultsecret.other"}}},
+		{"current version", "ocid1.vaultsecret.pinned", "", "new-license-key", "", []vaulttest.Request{{SecretID: "ocid1.vaultsecret.pinned"}}},
+		{"pinned version", "ocid1.vaultsecret.pinned", "1", "old-license-key", "", []vaulttest.Request{{SecretID: "ocid1.vaultsecret.pinned", VersionNumber: 1}}},
+		{"missing version", "ocid1.vaultsecret.pinned", "3", "", "NotAuthorizedOrNotFound", []vaulttest.Request{{SecretID: "ocid1.vaultsecret.pinned", VersionNumber: 3}}},
+		{"missing secret", "ocid1.vaultsecret.missing", "", "", "NotAuthorizedOrNotFound", []vaulttest.Request{{SecretID: "ocid1.vaultsecret.missing"}}},
+		{"throttled", "ocid1.vaultsecret.throttled", "", "", "TooManyRequests", []vaulttest.Request{
+			{SecretID: "ocid1.vaultsecret.throttled"}, {SecretID: "ocid1.vaultsecret.throttled"},
+			{SecretID: "ocid1.vaultsecret.throttled"}, {SecretID: "ocid1.vaultsecret.throttled"},
+		}},
+		{"unavailable", "ocid1.vaultsecret.unavailable", "", "", "Error Code: ServiceUnavailable", []vaulttest.Request{{SecretID: "ocid1.vaultsecret.unavailable"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(common.NewRelicAccountID, "")
+			t.Setenv(common.VaultRegion, "us-phoenix-1")
+			t.Setenv(common.SecretOCID, tt.secretOCID)
+			t.Setenv(common.SecretVersion, tt.pinnedVersion)
+			before := len(server.Requests())
+
+			key, err := GetLicenseKeyForSecret(context.Background(), tt.secretOCID)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedKey, key)
+			assert.Equal(t, tt.requests, server.Requests()[before:])
+			assert.Equal(t, "us-phoenix-1", client.Region)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// Sink receives every batch once delivery to New Relic was attempted, for example to archive or mirror it.
+type Sink interface {
+	// Name identifies the sink in logs and metrics.
+	Name() string
+	// Send delivers the batch. Errors are logged and do not affect delivery to New Relic or to other sinks.
+	Send(ctx context.Context, batch common.DetailedLogsBatch) error
+}
+
+// ErrSinkSkipped is returned by a sink that deliberately did not send a batch, for example when sampling.
+var ErrSinkSkipped = errors.New("batch skipped by sink")
+
+// Registered sinks, in registration order.
+var (
+	sinksMu sync.RWMutex
+	sinks   []Sink
+)
+
+// RegisterSink adds a sink receiving every batch.
+func RegisterSink(sink Sink) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	sinks = append(sinks, sink)
+}
+
+// sendToSinks delivers the batch to every registered sink.
+func sendToSinks(ctx context.Context, batch common.DetailedLogsBatch) {
+	sinksMu.RLock()
+	registered := sinks
+	sinksMu.RUnlock()
+
+	for _, sink := range registered {
+		err := sink.Send(ctx, batch)
+		if errors.Is(err, ErrSinkSkipped) {
+			metrics.Default.Counter("sink." + sink.Name() + ".skipped").Inc()
+			continue
+		}
+		if err != nil {
+			metrics.Default.Counter("sink." + sink.Name() + ".failed").Inc()
+			logger.FromContext(ctx).Errorf("error sending log batch to sink %s: %v", sink.Name(), err)
+			continue
+		}
+		metrics.Default.Counter("sink." + sink.Name() + ".sent").Inc()
+	}
+}
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// recordingSink counts the batches it receives and fails with err.
+type recordingSink struct {
+	name    string
+	batches int
+	err     error
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Send(_ context.Context, _ common.DetailedLogsBatch) error {
+	s.batches++
+	return s.err
+}
+
+// TestRegisterSink tests that every batch reaches every sink, whatever the New Relic and sink outcomes.
+func TestRegisterSink(t *testing.T) {
+	defer func(registered []Sink) { sinks = registered }(sinks)
+	failing := &recordingSink{name: "failing", err: errors.New("unavailable")}
+	archive := &recordingSink{name: "archive"}
+	RegisterSink(failing)
+	RegisterSink(archive)
+
+	pool := NewWorkerPool(1, 1)
+	healthy := new(MockNRClient)
+	healthy.On("CreateLogEntry", mock.Anything).Return(nil)
+	pool.Dispatch(context.Background(), sendBatches(2), healthy, 1)
+
+	rejecting := new(MockNRClient)
+	rejecting.On("CreateLogEntry", mock.Anything).Return(assert.AnError)
+	pool.Dispatch(context.Background(), sendBatches(1), rejecting, 1)
+
+	assert.Equal(t, 3, failing.batches)
+	assert.Equal(t, 3, archive.batches)
+}
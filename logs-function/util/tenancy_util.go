@@ -0,0 +1,93 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+	"github.com/oracle/oci-go-sdk/v65/identity"
+
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/ociclient"
+)
+
+// Refresh intervals of the cached tenancy name.
+const (
+	tenancyNameTTL           = time.Hour       // tenancyNameTTL is how long a resolved tenancy name is used.
+	tenancyNameRetryInterval = 5 * time.Minute // tenancyNameRetryInterval is the pause after a failed lookup.
+	tenancyLookupTimeout     = 5 * time.Second // tenancyLookupTimeout bounds the lookup within an invocation.
+)
+
+// OCIIdentityAPI is the subset of the OCI Identity client used to read the tenancy.
+type OCIIdentityAPI interface {
+	GetTenancy(ctx context.Context, request identity.GetTenancyRequest) (identity.GetTenancyResponse, error)
+}
+
+// tenancyName caches the tenancy name for the lifetime of the warm container.
+var tenancyName struct {
+	mu      sync.Mutex
+	name    string
+	refresh time.Time // refresh is when the name is next looked up.
+}
+
+// newIdentityClient creates the Identity client; replaced in tests.
+var newIdentityClient = defaultIdentityClient
+
+// defaultIdentityClient returns the Identity client and the OCID of the tenancy of the function's resource principal.
+func defaultIdentityClient() (OCIIdentityAPI, string, error) {
+	provider, err := auth.ResourcePrincipalConfigurationProvider()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create resource principal configuration provider: %w", err)
+	}
+	tenancyID, err := provider.TenancyOCID()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read the tenancy of the resource principal: %w", err)
+	}
+	client, err := identity.NewIdentityClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create OCI identity client: %w", err)
+	}
+	ociclient.Configure(&client.BaseClient, "Identity")
+	return &client, tenancyID, nil
+}
+
+// TenancyName returns the display name of the tenancy the function runs in, resolved with the Identity API and
+// cached. A failed lookup keeps the previously resolved name, or returns an empty string, and is retried later.
+func TenancyName(ctx context.Context) string {
+	tenancyName.mu.Lock()
+	defer tenancyName.mu.Unlock()
+	if time.Now().Before(tenancyName.refresh) {
+		return tenancyName.name
+	}
+
+	name, err := lookupTenancyName(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Warnf("error resolving the tenancy name: %v", err)
+		tenancyName.refresh = time.Now().Add(tenancyNameRetryInterval)
+		return tenancyName.name
+	}
+	tenancyName.name = name
+	tenancyName.refresh = time.Now().Add(tenancyNameTTL)
+	return name
+}
+
+// lookupTenancyName reads the name of the tenancy of the function from the Identity API.
+func lookupTenancyName(ctx context.Context) (string, error) {
+	client, tenancyID, err := newIdentityClient()
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, tenancyLookupTimeout)
+	defer cancel()
+	response, err := client.GetTenancy(ctx, identity.GetTenancyRequest{TenancyId: ociCommon.String(tenancyID)})
+	if err != nil {
+		return "", fmt.Errorf("failed to read tenancy %s: %w", tenancyID, err)
+	}
+	if response.Name == nil || *response.Name == "" {
+		return "", fmt.Errorf("tenancy %s has no name", tenancyID)
+	}
+	return *response.Name, nil
+}
@@ -0,0 +1,60 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeIdentityClient returns a tenancy with the given name, or err.
+type fakeIdentityClient struct {
+	name  *string
+	err   error
+	calls int
+}
+
+func (c *fakeIdentityClient) GetTenancy(_ context.Context, request identity.GetTenancyRequest) (identity.GetTenancyResponse, error) {
+	c.calls++
+	if c.err != nil {
+		return identity.GetTenancyResponse{}, c.err
+	}
+	return identity.GetTenancyResponse{Tenancy: identity.Tenancy{Id: request.TenancyId, Name: c.name}}, nil
+}
+
+// TestTenancyName tests that the tenancy name is cached, and kept when a later lookup fails.
+func TestTenancyName(t *testing.T) {
+	name := "acme-prod"
+	client := &fakeIdentityClient{name: &name}
+	newIdentityClient = func() (OCIIdentityAPI, string, error) { return client, "ocid1.tenancy.oc1..a", nil }
+	t.Cleanup(func() {
+		newIdentityClient = defaultIdentityClient
+		tenancyName.name, tenancyName.refresh = "", time.Time{}
+	})
+
+	assert.Equal(t, "acme-prod", TenancyName(context.Background()))
+	assert.Equal(t, "acme-prod", TenancyName(context.Background()))
+	assert.Equal(t, 1, client.calls)
+
+	client.err = errors.New("NotAuthorizedOrNotFound")
+	tenancyName.refresh = time.Time{}
+	assert.Equal(t, "acme-prod", TenancyName(context.Background()))
+	assert.Equal(t, 2, client.calls)
+	assert.True(t, tenancyName.refresh.Before(time.Now().Add(tenancyNameRetryInterval+time.Second)))
+}
+
+// TestLookupTenancyName tests that missing names and client failures are reported.
+func TestLookupTenancyName(t *testing.T) {
+	t.Cleanup(func() { newIdentityClient = defaultIdentityClient })
+
+	newIdentityClient = func() (OCIIdentityAPI, string, error) { return &fakeIdentityClient{}, "ocid1.tenancy.oc1..a", nil }
+	_, err := lookupTenancyName(context.Background())
+	assert.ErrorContains(t, err, "has no name")
+
+	newIdentityClient = func() (OCIIdentityAPI, string, error) { return nil, "", errors.New("no resource principal") }
+	_, err = lookupTenancyName(context.Background())
+	assert.ErrorContains(t, err, "no resource principal")
+}
@@ -0,0 +1,123 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/secrets"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// vaultThrottleAttempts is the number of attempts of a secret request throttled by OCI Vault, on top of the
+// retries of the OCI SDK.
+const vaultThrottleAttempts = 4
+
+// maxVaultThrottleBackoff caps the pause after a throttled secret request.
+const maxVaultThrottleBackoff = 8 * time.Second
+
+// vaultThrottleBackoff is the pause after the first throttled secret request, doubled after each one.
+var vaultThrottleBackoff = 250 * time.Millisecond
+
+// requestLimiter is a token bucket pacing requests, holding up to one second of requests. A pause holds every
+// request until it ends, so one throttled request backs off all of them.
+type requestLimiter struct {
+	mu          sync.Mutex
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// vaultLimiter paces the secret requests of the warm container.
+var vaultLimiter requestLimiter
+
+// wait blocks until a request may be sent at the given rate per second, or ctx is done.
+func (l *requestLimiter) wait(ctx context.Context, rate float64) error {
+	burst := max(rate, 1)
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		if l.last.IsZero() {
+			l.tokens = burst
+		} else {
+			l.tokens = min(burst, l.tokens+now.Sub(l.last).Seconds()*rate)
+		}
+		l.last = now
+
+		var delay time.Duration
+		switch {
+		case now.Before(l.pausedUntil):
+			delay = l.pausedUntil.Sub(now)
+		case l.tokens >= 1:
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		default:
+			delay = time.Duration((1 - l.tokens) / rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// pause holds every request for d.
+func (l *requestLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// getSecretBundle fetches the secret bundle at the shared Vault request rate. A request throttled with a 429 is
+// counted as vault.throttled and retried after an exponential backoff with full jitter, during which every other
+// secret request of the container waits too.
+func getSecretBundle(ctx context.Context, client OCISecretsManagerAPI, request secrets.GetSecretBundleRequest) (secrets.GetSecretBundleResponse, error) {
+	for attempt := 0; ; attempt++ {
+		if err := vaultLimiter.wait(ctx, vaultRequestsPerSecond()); err != nil {
+			return secrets.GetSecretBundleResponse{}, err
+		}
+		response, err := client.GetSecretBundle(ctx, request)
+		if !isThrottled(err) {
+			return response, err
+		}
+		metrics.Default.Counter("vault.throttled").Inc()
+		if attempt+1 >= vaultThrottleAttempts {
+			return response, err
+		}
+		backoff := min(vaultThrottleBackoff<<attempt, maxVaultThrottleBackoff)
+		backoff = time.Duration(rand.Int63n(int64(backoff)) + 1)
+		logger.FromContext(ctx).Warnf("OCI Vault throttled the secret request, retrying in %v", backoff)
+		vaultLimiter.pause(backoff)
+	}
+}
+
+// isThrottled reports whether the error is an OCI service error with status 429.
+func isThrottled(err error) bool {
+	var serviceErr ociCommon.ServiceError
+	return errors.As(err, &serviceErr) && serviceErr.GetHTTPStatusCode() == http.StatusTooManyRequests
+}
+
+// vaultRequestsPerSecond returns the rate of secret requests, 10 by default.
+func vaultRequestsPerSecond() float64 {
+	if rate, err := strconv.ParseFloat(os.Getenv(common.VaultRequestsPerSecond), 64); err == nil && rate > 0 {
+		return rate
+	}
+	return common.DefaultVaultRequestsPerSecond
+}
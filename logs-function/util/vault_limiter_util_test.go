@@ -0,0 +1,86 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// throttledError is the OCI service error returned for a throttled request.
+type throttledError struct{}
+
+func (throttledError) Error() string           { return "Service error:TooManyRequests. http status code: 429" }
+func (throttledError) GetHTTPStatusCode() int  { return http.StatusTooManyRequests }
+func (throttledError) GetMessage() string      { return "Too many requests" }
+func (throttledError) GetCode() string         { return "TooManyRequests" }
+func (throttledError) GetOpcRequestID() string { return "opc-request-id" }
+
+// useFastVaultBackoff shortens the backoff after throttled requests and resets the shared limiter for the test.
+func useFastVaultBackoff(t *testing.T) {
+	backoff := vaultThrottleBackoff
+	vaultThrottleBackoff = time.Millisecond
+	vaultLimiter = requestLimiter{}
+	t.Cleanup(func() {
+		vaultThrottleBackoff = backoff
+		vaultLimiter = requestLimiter{}
+	})
+}
+
+// TestGetSecretBundleThrottled tests that throttled requests are retried, counted and given up after the last attempt.
+func TestGetSecretBundleThrottled(t *testing.T) {
+	useFastVaultBackoff(t)
+	metrics.Default.Reset()
+
+	client := &mockOCISecretsClient{secretContent: "key", throttled: 2}
+	secret, err := getSecretFromOCIVault(context.Background(), client, "ocid1.vaultsecret.a", "us-ashburn-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "key", secret)
+	assert.Len(t, client.requests, 3)
+	assert.Equal(t, int64(2), metrics.Default.Counter("vault.throttled").Value())
+
+	client = &mockOCISecretsClient{secretContent: "key", throttled: 10}
+	_, err = getSecretFromOCIVault(context.Background(), client, "ocid1.vaultsecret.a", "us-ashburn-1")
+	assert.ErrorContains(t, err, "429")
+	assert.Len(t, client.requests, vaultThrottleAttempts)
+}
+
+// TestRequestLimiter tests that requests beyond the burst are paced at the configured rate and that a pause
+// holds every request.
+func TestRequestLimiter(t *testing.T) {
+	var limiter requestLimiter
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, limiter.wait(ctx, 100))
+	}
+	assert.Less(t, time.Since(start), 20*time.Millisecond, "the burst is not paced")
+
+	limiter = requestLimiter{}
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, limiter.wait(ctx, 100))
+	}
+	limiter.pause(50 * time.Millisecond)
+	assert.NoError(t, limiter.wait(ctx, 100))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	limiter.pause(time.Hour)
+	cancelled, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.wait(cancelled, 100), context.DeadlineExceeded)
+}
+
+// TestVaultRequestsPerSecond tests the parsing of VAULT_REQUESTS_PER_SECOND.
+func TestVaultRequestsPerSecond(t *testing.T) {
+	t.Setenv(common.VaultRequestsPerSecond, "2.5")
+	assert.Equal(t, 2.5, vaultRequestsPerSecond())
+	t.Setenv(common.VaultRequestsPerSecond, "-1")
+	assert.Equal(t, common.DefaultVaultRequestsPerSecond, vaultRequestsPerSecond())
+}
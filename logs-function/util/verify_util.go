@@ -0,0 +1,213 @@
+package util
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	mathrand "math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-client-go/v2/pkg/config"
+	"github.com/newrelic/newrelic-client-go/v2/pkg/nrdb"
+	"github.com/newrelic/newrelic-client-go/v2/pkg/region"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// VerificationEventType is the custom event type recording the outcome of each read-your-writes verification.
+const VerificationEventType = "OciLogVerification"
+
+// Verification polling settings.
+var (
+	verificationDelay    = 30 * time.Second // verificationDelay is the wait before the first query, covering ingest latency.
+	verificationAttempts = 4                // verificationAttempts is the number of queries before a batch is reported missing.
+	verificationTimeout  = 4 * time.Minute  // verificationTimeout bounds a verification, within the invocation deadline.
+)
+
+// NRQLQuerier runs NRQL queries through the NerdGraph API.
+type NRQLQuerier interface {
+	QueryWithContext(ctx context.Context, accountID int, query nrdb.NRQL) (*nrdb.NRDBResultContainer, error)
+}
+
+// Verifier confirms that sampled batches of critical log groups arrived in New Relic by querying for them
+// after they were posted, and reports the outcome as a custom event.
+type Verifier struct {
+	querier   NRQLQuerier
+	events    EventSender
+	accountID int
+	logGroups map[string]bool
+	percent   float64
+}
+
+// NewVerifierFromEnv returns a Verifier for the log groups listed in VERIFY_LOG_GROUPS, or nil without error
+// when verification is not configured. Queries are authenticated with the User API key referenced by
+// USER_API_KEY_SECRET_OCID and results are sent with the EventSender.
+func NewVerifierFromEnv() (*Verifier, error) {
+	var logGroups []string
+	for _, id := range strings.Split(os.Getenv(common.VerifyLogGroups), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			logGroups = append(logGroups, id)
+		}
+	}
+	if len(logGroups) == 0 {
+		return nil, nil
+	}
+
+	accountID, err := strconv.Atoi(os.Getenv(common.NewRelicAccountID))
+	if err != nil || accountID <= 0 {
+		return nil, fmt.Errorf("%s must be set to the New Relic account ID", common.NewRelicAccountID)
+	}
+	cfg, err := nerdGraphConfig()
+	if err != nil {
+		return nil, err
+	}
+	events, err := NewEventSender()
+	if err != nil {
+		return nil, err
+	}
+
+	querier := nrdb.New(cfg)
+	return NewVerifier(&querier, events, accountID, logGroups, verifySamplePercent()), nil
+}
+
+// nerdGraphConfig returns the client configuration for NerdGraph requests, authenticated with the
+// User API key referenced by USER_API_KEY_SECRET_OCID. Requests go through the shared transport, so that they
+// follow NEW_RELIC_HOST_OVERRIDES and NEW_RELIC_DNS_RESOLVER like the Log API and events requests.
+func nerdGraphConfig() (config.Config, error) {
+	userKey, err := GetLicenseKeyForSecret(context.Background(), os.Getenv(common.UserAPIKeySecretOCID))
+	if err != nil {
+		return config.Config{}, fmt.Errorf("error fetching User API key: %w", err)
+	}
+	nrRegion, _ := region.Get(NewRelicRegion())
+	cfg := config.Config{PersonalAPIKey: userKey, LogLevel: "info", HTTPTransport: logsTransport}
+	if err := cfg.SetRegion(nrRegion); err != nil {
+		return config.Config{}, err
+	}
+	return cfg, nil
+}
+
+// NewVerifier creates a Verifier sampling percent of the batches holding records of the given log groups.
+func NewVerifier(querier NRQLQuerier, events EventSender, accountID int, logGroups []string, percent float64) *Verifier {
+	critical := make(map[string]bool, len(logGroups))
+	for _, id := range logGroups {
+		critical[id] = true
+	}
+	return &Verifier{querier: querier, events: events, accountID: accountID, logGroups: critical, percent: percent}
+}
+
+// verifySamplePercent returns the percentage of critical batches verified, 10 by default.
+func verifySamplePercent() float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(common.VerifySamplePercent), 64); err == nil && value >= 0 && value <= 100 {
+		return value
+	}
+	return common.DefaultVerifySamplePercent
+}
+
+// Sample decides whether the batch is verified. A sampled batch is returned with a copy of its common
+// attributes stamped with a unique verification ID, together with that ID and the critical log group.
+func (v *Verifier) Sample(batch common.DetailedLogsBatch) (common.DetailedLogsBatch, string, string) {
+	logGroupID := v.criticalLogGroup(batch)
+	if logGroupID == "" || mathrand.Float64()*100 >= v.percent {
+		return batch, "", ""
+	}
+
+	id := verificationID()
+	stamped := make(common.DetailedLogsBatch, len(batch))
+	for i, logs := range batch {
+		attributes := make(common.LogAttributes, len(logs.CommonData.Attributes)+1)
+		for key, value := range logs.CommonData.Attributes {
+			attributes[key] = value
+		}
+		attributes[common.VerificationAttribute] = id
+		logs.CommonData.Attributes = attributes
+		stamped[i] = logs
+	}
+	return stamped, id, logGroupID
+}
+
+// criticalLogGroup returns the first critical log group with records in the batch, read from the records
+// or from the common attributes when the oracle envelope was hoisted.
+func (v *Verifier) criticalLogGroup(batch common.DetailedLogsBatch) string {
+	for _, logs := range batch {
+		if id, _ := logs.CommonData.Attributes[common.OracleEnvelopeKey+".loggroupid"].(string); v.logGroups[id] {
+			return id
+		}
+		for _, entry := range logs.Entries {
+			if id := common.LogGroupID(entry); v.logGroups[id] {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// Verify polls for the records stamped with id until expected records are found or the attempts are exhausted,
+// then posts a VerificationEventType event with the outcome. A verification cut short by ctx, e.g. when the
+// invocation times out, is counted as verification.abandoned without posting an outcome.
+func (v *Verifier) Verify(ctx context.Context, id string, logGroupID string, expected int, postedAt time.Time) {
+	query := nrdb.NRQL(fmt.Sprintf("SELECT count(*) FROM Log WHERE `%s` = '%s' SINCE 1 hour ago", common.VerificationAttribute, id))
+
+	vlog := logger.FromContext(ctx)
+	found := 0
+	delay := verificationDelay
+	for attempt := 1; attempt <= verificationAttempts && found < expected; attempt++ {
+		select {
+		case <-ctx.Done():
+			metrics.Default.Counter("verification.abandoned").Inc()
+			vlog.Warnf("verification %s of log group %s abandoned after %d queries: %v", id, logGroupID, attempt-1, ctx.Err())
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+
+		result, err := v.querier.QueryWithContext(ctx, v.accountID, query)
+		if err != nil {
+			vlog.Warnf("error querying verification %s: %v", id, err)
+			continue
+		}
+		found = resultCount(result)
+	}
+
+	verified := found >= expected
+	if !verified {
+		vlog.Errorf("verification %s of log group %s failed: found %d of %d records", id, logGroupID, found, expected)
+	}
+	if err := v.events.CreateEvents([]map[string]interface{}{{
+		"eventType":      VerificationEventType,
+		"verificationId": id,
+		"logGroupId":     logGroupID,
+		"verified":       verified,
+		"expected":       expected,
+		"found":          found,
+		"latencyMs":      time.Since(postedAt).Milliseconds(),
+	}}); err != nil {
+		vlog.Errorf("error posting verification %s: %v", id, err)
+	}
+}
+
+// resultCount returns the count of a count(*) query result.
+func resultCount(result *nrdb.NRDBResultContainer) int {
+	if result == nil || len(result.Results) == 0 {
+		return 0
+	}
+	if count, ok := result.Results[0]["count"].(float64); ok {
+		return int(math.Round(count))
+	}
+	return 0
+}
+
+// verificationID returns a random identifier for a verified batch.
+func verificationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,132 @@
+package util
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-client-go/v2/pkg/nrdb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+)
+
+// fakeQuerier returns the given counts from successive queries.
+type fakeQuerier struct {
+	counts  []float64
+	queries []nrdb.NRQL
+}
+
+func (q *fakeQuerier) QueryWithContext(_ context.Context, _ int, query nrdb.NRQL) (*nrdb.NRDBResultContainer, error) {
+	q.queries = append(q.queries, query)
+	count := q.counts[0]
+	if len(q.counts) > 1 {
+		q.counts = q.counts[1:]
+	}
+	return &nrdb.NRDBResultContainer{Results: []nrdb.NRDBResult{{"count": count}}}, nil
+}
+
+// recordingEventSender records the events it is asked to send.
+type recordingEventSender struct {
+	events []map[string]interface{}
+}
+
+func (s *recordingEventSender) CreateEvents(events []map[string]interface{}) error {
+	s.events = append(s.events, events...)
+	return nil
+}
+
+// criticalBatch returns a batch with records of the given log group sharing attributes.
+func criticalBatch(logGroupID string, attributes common.LogAttributes) common.DetailedLogsBatch {
+	entry := map[string]interface{}{"oracle": map[string]interface{}{"loggroupid": logGroupID}}
+	return common.DetailedLogsBatch{{CommonData: common.Common{Attributes: attributes}, Entries: common.LogData{entry, entry}}}
+}
+
+// TestVerifierSample tests that only batches of critical log groups are sampled, without mutating shared attributes.
+func TestVerifierSample(t *testing.T) {
+	verifier := NewVerifier(&fakeQuerier{}, &recordingEventSender{}, 1, []string{"ocid1.loggroup.critical"}, 100)
+	shared := common.LogAttributes{"instrumentation.name": "oci"}
+
+	_, id, _ := verifier.Sample(criticalBatch("ocid1.loggroup.other", shared))
+	assert.Empty(t, id)
+
+	stamped, id, logGroupID := verifier.Sample(criticalBatch("ocid1.loggroup.critical", shared))
+	assert.NotEmpty(t, id)
+	assert.Equal(t, "ocid1.loggroup.critical", logGroupID)
+	assert.Equal(t, id, stamped[0].CommonData.Attributes[common.VerificationAttribute])
+	assert.NotContains(t, shared, common.VerificationAttribute)
+
+	hoisted := common.DetailedLogsBatch{{
+		CommonData: common.Common{Attributes: common.LogAttributes{"oracle.loggroupid": "ocid1.loggroup.critical"}},
+		Entries:    common.LogData{{"message": "a"}},
+	}}
+	_, _, logGroupID = verifier.Sample(hoisted)
+	assert.Equal(t, "ocid1.loggroup.critical", logGroupID)
+
+	unsampled := NewVerifier(&fakeQuerier{}, &recordingEventSender{}, 1, []string{"ocid1.loggroup.critical"}, 0)
+	_, id, _ = unsampled.Sample(criticalBatch("ocid1.loggroup.critical", shared))
+	assert.Empty(t, id)
+}
+
+// TestVerifierVerify tests polling until the records arrive and the reported outcome.
+func TestVerifierVerify(t *testing.T) {
+	verificationDelay = time.Millisecond
+
+	tests := []struct {
+		name     string
+		counts   []float64
+		verified bool
+		queries  int
+	}{
+		{"arrives after retry", []float64{0, 2}, true, 2},
+		{"never arrives", []float64{1}, false, verificationAttempts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			querier := &fakeQuerier{counts: tt.counts}
+			events := &recordingEventSender{}
+			verifier := NewVerifier(querier, events, 1, []string{"lg"}, 100)
+
+			verifier.Verify(context.Background(), "abc", "lg", 2, time.Now())
+
+			assert.Len(t, querier.queries, tt.queries)
+			assert.Contains(t, string(querier.queries[0]), "`forwarder.verificationId` = 'abc'")
+			assert.Len(t, events.events, 1)
+			assert.Equal(t, VerificationEventType, events.events[0]["eventType"])
+			assert.Equal(t, tt.verified, events.events[0]["verified"])
+		})
+	}
+}
+
+// TestNerdGraphConfigHostResolution tests that NerdGraph queries follow the New Relic host overrides.
+func TestNerdGraphConfigHostResolution(t *testing.T) {
+	defer func(dial func(context.Context, string, string) (net.Conn, error)) { logsTransport.DialContext = dial }(logsTransport.DialContext)
+	defer UseSecretsClient(func() (OCISecretsManagerAPI, error) {
+		return &mockOCISecretsClient{secretContent: "user-key"}, nil
+	})()
+	var host, apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, apiKey = r.Host, r.Header.Get("Api-Key")
+		_, _ = w.Write([]byte(`{"data": {"actor": {"account": {"nrql": {"results": [{"count": 2}]}}}}}`))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	t.Setenv(common.UserAPIKeySecretOCID, "ocid1.vaultsecret.user")
+	t.Setenv(common.VaultRegion, "us-ashburn-1")
+	t.Setenv(common.HostOverrides, "api.newrelic.invalid=127.0.0.1")
+	assert.NoError(t, ConfigureHostResolution())
+
+	cfg, err := nerdGraphConfig()
+	assert.NoError(t, err)
+	cfg.Region().SetNerdGraphBaseURL("http://api.newrelic.invalid:" + port + "/graphql")
+	querier := nrdb.New(cfg)
+	result, err := querier.QueryWithContext(context.Background(), 1, "SELECT count(*) FROM Log")
+	assert.NoError(t, err)
+	assert.Equal(t, nrdb.NRDBResult{"count": 2.0}, result.Results[0])
+	assert.Equal(t, "api.newrelic.invalid:"+port, host)
+	assert.Equal(t, "user-key", apiKey)
+}
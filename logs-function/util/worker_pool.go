@@ -0,0 +1,311 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/dlq"
+	"github.com/newrelic/oci-log-integration/logs-function/logger"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+)
+
+// WorkerPool is a set of long-lived consumer goroutines that persist across warm invocations.
+// Each invocation dispatches its batches to the shared job queue and waits only for its own batches,
+// so no per-invocation state survives once Dispatch returns. Workers are started on demand, up to
+// the pool's maximum size, as invocations request more concurrency.
+type WorkerPool struct {
+	jobs    chan logBatchJob
+	maxSize int
+
+	mu          sync.Mutex
+	running     int
+	deadLetters DeadLetterWriter
+	verifier    *Verifier
+}
+
+// DeadLetterWriter persists batches that could not be delivered.
+type DeadLetterWriter interface {
+	Write(ctx context.Context, envelope dlq.Envelope) (string, error)
+}
+
+// logBatchJob is a single batch to be posted on behalf of one invocation.
+type logBatchJob struct {
+	ctx      context.Context
+	batch    common.DetailedLogsBatch
+	nrClient NewRelicClientAPI
+	failFast *authFailures
+	done     func()
+}
+
+// authFailures holds the auth error of each account route of one invocation. Once the license key of a route
+// is rejected, the remaining batches of the route are dead-lettered without posting, as they would be rejected too.
+type authFailures struct {
+	mu     sync.Mutex
+	errors map[string]error
+}
+
+// record keeps err when it is an auth error of the Log API.
+func (f *authFailures) record(alias string, err error) {
+	var apiErr *LogAPIError
+	if !errors.As(err, &apiErr) || apiErr.Class != ErrorClassAuth {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errors == nil {
+		f.errors = map[string]error{}
+	}
+	f.errors[alias] = err
+}
+
+// failed returns the auth error of the route, if any.
+func (f *authFailures) failed(alias string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.errors[alias]
+}
+
+// NewWorkerPool creates a WorkerPool with the given maximum number of workers and job queue size.
+// No worker is started until the first call to Dispatch.
+func NewWorkerPool(maxSize int, queueSize int) *WorkerPool {
+	return &WorkerPool{
+		jobs:    make(chan logBatchJob, queueSize),
+		maxSize: maxSize,
+	}
+}
+
+// MaxWorkers returns the maximum number of workers from the environment, or NumberOfWorkers when unset or invalid.
+func MaxWorkers() int {
+	if envWorkers := os.Getenv(common.MaxWorkers); envWorkers != "" {
+		if parsed, err := strconv.Atoi(envWorkers); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return common.NumberOfWorkers
+}
+
+// WorkerCount returns the number of workers worth using for a payload: one per expected batch,
+// bounded by the record count and by maxWorkers.
+func WorkerCount(payloadSize int, recordCount int, maxWorkers int) int {
+	workers := (payloadSize + common.MaxPayloadSize - 1) / common.MaxPayloadSize
+	if workers > recordCount {
+		workers = recordCount
+	}
+	if workers > maxWorkers {
+		workers = maxWorkers
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// Dispatch forwards every batch received on the channel to the pool and blocks until the channel is
+// closed and all of the forwarded batches have been processed. At most concurrency batches of this
+// invocation are posted at the same time. Batches received after the context is cancelled are drained
+// and discarded so the producer never blocks. Once the license key of an account route is rejected, the
+// remaining batches of the route are dead-lettered without posting.
+func (p *WorkerPool) Dispatch(ctx context.Context, channel <-chan common.DetailedLogsBatch, nrClientAPI NewRelicClientAPI, concurrency int) {
+	concurrency = p.ensureWorkers(ctx, concurrency)
+	inFlight := make(chan struct{}, concurrency)
+
+	failFast := &authFailures{}
+	dlog := logger.FromContext(logger.WithStage(ctx, logger.StageDispatch))
+	var wg sync.WaitGroup
+	index := 0
+	for batch := range channel {
+		batchCtx := logger.WithBatch(ctx, index)
+		index++
+		if ctx.Err() != nil {
+			dlog.Warn("context cancelled, discarding log batch")
+			continue
+		}
+
+		select {
+		case inFlight <- struct{}{}:
+		case <-ctx.Done():
+			dlog.Warn("context cancelled, discarding log batch")
+			continue
+		}
+
+		wg.Add(1)
+		done := func() {
+			<-inFlight
+			wg.Done()
+		}
+		select {
+		case p.jobs <- logBatchJob{ctx: batchCtx, batch: batch, nrClient: nrClientAPI, failFast: failFast, done: done}:
+		case <-ctx.Done():
+			dlog.Warn("context cancelled, discarding log batch")
+			done()
+		}
+	}
+	wg.Wait()
+}
+
+// SetDeadLetterWriter sets the writer that batches failing delivery are persisted with.
+func (p *WorkerPool) SetDeadLetterWriter(writer DeadLetterWriter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.deadLetters = writer
+}
+
+// SetVerifier sets the verifier confirming the arrival of sampled batches of critical log groups. A sampled batch
+// is verified by the worker that posted it, before the invocation is released.
+func (p *WorkerPool) SetVerifier(verifier *Verifier) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.verifier = verifier
+}
+
+// ensureWorkers starts workers until at least n are running, bounded by the pool's maximum size.
+// It returns the number of workers the caller may use.
+func (p *WorkerPool) ensureWorkers(ctx context.Context, n int) int {
+	if n > p.maxSize {
+		n = p.maxSize
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for ; p.running < n; p.running++ {
+		go p.work()
+	}
+	logger.FromContext(ctx).Debugf("Dispatching with %d of %d running workers", n, p.running)
+	return n
+}
+
+// work processes jobs from the shared queue for the lifetime of the container.
+func (p *WorkerPool) work() {
+	for job := range p.jobs {
+		p.process(job)
+	}
+}
+
+// process posts a single batch. A panic while posting is recovered so the worker survives
+// and the owning invocation is still released.
+func (p *WorkerPool) process(job logBatchJob) {
+	defer job.done()
+	jlog := logger.FromContext(job.ctx)
+	defer func() {
+		if r := recover(); r != nil {
+			jlog.Errorf("recovered from panic while posting log batch: %v", r)
+		}
+	}()
+
+	if job.ctx.Err() != nil {
+		return
+	}
+	p.mu.Lock()
+	verifier := p.verifier
+	p.mu.Unlock()
+
+	var verificationID, logGroupID string
+	if verifier != nil {
+		job.batch, verificationID, logGroupID = verifier.Sample(job.batch)
+	}
+
+	defer sendToSinks(job.ctx, job.batch)
+
+	alias := batchAlias(job.batch)
+	start := time.Now()
+	if err := job.failFast.failed(alias); err != nil {
+		metrics.Default.Counter("sink.batches.failed").Inc()
+		metrics.Default.Counter("records.failed").Add(int64(entryCount(job.batch)))
+		jlog.Debugf("Skipping log batch of a rejected license key: %v", err)
+		p.deadLetter(job, err, start)
+		return
+	}
+
+	err := job.nrClient.CreateLogEntry(job.batch)
+	metrics.Default.Histogram("sink.post.ms").Observe(float64(time.Since(start).Milliseconds()))
+	if err != nil {
+		metrics.Default.Counter("sink.batches.failed").Inc()
+		metrics.Default.Counter("records.failed").Add(int64(entryCount(job.batch)))
+		jlog.Errorf("error posting Log entry: %v", err)
+		logger.DebugPayload(jlog, "Rejected log batch", job.batch)
+		job.failFast.record(alias, err)
+		checkQuotaError(job.ctx, err)
+		p.deadLetter(job, err, start)
+		return
+	}
+	metrics.Default.Counter("sink.batches.posted").Inc()
+	metrics.Default.Counter("records.sent").Add(int64(entryCount(job.batch)))
+	if verificationID != "" {
+		// The verification holds the worker, and so the invocation, so that it never outlives the invocation
+		verifyCtx, cancel := context.WithTimeout(job.ctx, verificationTimeout)
+		defer cancel()
+		verifier.Verify(verifyCtx, verificationID, logGroupID, entryCount(job.batch), start)
+	}
+}
+
+// entryCount returns the number of log records in the batch.
+func entryCount(batch common.DetailedLogsBatch) int {
+	count := 0
+	for _, logs := range batch {
+		count += len(logs.Entries)
+	}
+	return count
+}
+
+// deadLetter persists a batch that failed delivery when a dead-letter writer is configured.
+func (p *WorkerPool) deadLetter(job logBatchJob, err error, start time.Time) {
+	p.mu.Lock()
+	writer := p.deadLetters
+	p.mu.Unlock()
+	if writer == nil {
+		return
+	}
+
+	jlog := logger.FromContext(job.ctx)
+	// The DLQ write must outlive an invocation that timed out while posting.
+	attempts := retryAttempt(job.batch) + 1
+	name, writeErr := writer.Write(context.WithoutCancel(job.ctx), dlq.NewEnvelope(job.batch, nil, err, attempts, start))
+	if writeErr != nil {
+		metrics.Default.Counter("dlq.failed").Inc()
+		jlog.Errorf("error writing log batch to dlq: %v", writeErr)
+		return
+	}
+	metrics.Default.Counter("dlq.written").Inc()
+	jlog.Warnf("Wrote undelivered log batch after %d attempts to %s", attempts, name)
+}
+
+// DeadLetterPayload persists a payload rejected before it was transformed, when a dead-letter writer is configured,
+// and returns the name it was written as, or "" when no writer is configured.
+func (p *WorkerPool) DeadLetterPayload(ctx context.Context, original json.RawMessage, err error) (string, error) {
+	p.mu.Lock()
+	writer := p.deadLetters
+	p.mu.Unlock()
+	if writer == nil {
+		return "", nil
+	}
+	name, writeErr := writer.Write(context.WithoutCancel(ctx), dlq.NewEnvelope(nil, original, err, 1, time.Now()))
+	if writeErr != nil {
+		metrics.Default.Counter("dlq.failed").Inc()
+		return "", writeErr
+	}
+	metrics.Default.Counter("dlq.written").Inc()
+	return name, nil
+}
+
+// retryAttempt returns the number of failed delivery attempts stamped on a batch replayed from the retry stream.
+func retryAttempt(batch common.DetailedLogsBatch) int {
+	if len(batch) == 0 {
+		return 0
+	}
+	switch attempts := batch[0].CommonData.Attributes[common.RetryAttemptAttribute].(type) {
+	case int:
+		return attempts
+	case float64:
+		return int(attempts)
+	}
+	return 0
+}
@@ -0,0 +1,242 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/newrelic/oci-log-integration/logs-function/common"
+	"github.com/newrelic/oci-log-integration/logs-function/dlq"
+	"github.com/newrelic/oci-log-integration/logs-function/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// sendBatches returns a closed channel holding count empty batches.
+func sendBatches(count int) chan common.DetailedLogsBatch {
+	channel := make(chan common.DetailedLogsBatch, count)
+	for i := 0; i < count; i++ {
+		channel <- common.DetailedLogsBatch{{}}
+	}
+	close(channel)
+	return channel
+}
+
+// TestWorkerPoolReuseAcrossInvocations tests that one pool serves several invocations in sequence.
+func TestWorkerPoolReuseAcrossInvocations(t *testing.T) {
+	pool := NewWorkerPool(2, 4)
+
+	first := new(MockNRClient)
+	first.On("CreateLogEntry", mock.Anything).Return(nil)
+	pool.Dispatch(context.Background(), sendBatches(3), first, 2)
+	first.AssertNumberOfCalls(t, "CreateLogEntry", 3)
+
+	second := new(MockNRClient)
+	second.On("CreateLogEntry", mock.Anything).Return(assert.AnError)
+	pool.Dispatch(context.Background(), sendBatches(2), second, 2)
+	second.AssertNumberOfCalls(t, "CreateLogEntry", 2)
+}
+
+// TestWorkerPoolRecoversFromPanic tests that a panicking client neither kills the worker nor blocks the invocation.
+func TestWorkerPoolRecoversFromPanic(t *testing.T) {
+	pool := NewWorkerPool(1, 1)
+
+	panicking := new(MockNRClient)
+	panicking.On("CreateLogEntry", mock.Anything).Panic("boom")
+	pool.Dispatch(context.Background(), sendBatches(2), panicking, 1)
+
+	healthy := new(MockNRClient)
+	healthy.On("CreateLogEntry", mock.Anything).Return(nil)
+	pool.Dispatch(context.Background(), sendBatches(1), healthy, 1)
+	healthy.AssertNumberOfCalls(t, "CreateLogEntry", 1)
+}
+
+// TestWorkerPoolCancelledContext tests that batches are discarded once the invocation context is cancelled.
+func TestWorkerPoolCancelledContext(t *testing.T) {
+	pool := NewWorkerPool(1, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mockNRClient := new(MockNRClient)
+	pool.Dispatch(ctx, sendBatches(3), mockNRClient, 1)
+	mockNRClient.AssertNotCalled(t, "CreateLogEntry", mock.Anything)
+}
+
+// recordingDeadLetterWriter records the envelopes written to the DLQ.
+type recordingDeadLetterWriter struct {
+	mu        sync.Mutex
+	envelopes []dlq.Envelope
+}
+
+func (w *recordingDeadLetterWriter) Write(_ context.Context, envelope dlq.Envelope) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.envelopes = append(w.envelopes, envelope)
+	return "dlq/object.json", nil
+}
+
+// TestWorkerPoolDeadLetters tests that only batches failing delivery are written to the DLQ.
+func TestWorkerPoolDeadLetters(t *testing.T) {
+	pool := NewWorkerPool(1, 1)
+	writer := &recordingDeadLetterWriter{}
+	pool.SetDeadLetterWriter(writer)
+
+	healthy := new(MockNRClient)
+	healthy.On("CreateLogEntry", mock.Anything).Return(nil)
+	pool.Dispatch(context.Background(), sendBatches(2), healthy, 1)
+	assert.Empty(t, writer.envelopes)
+
+	failing := new(MockNRClient)
+	failing.On("CreateLogEntry", mock.Anything).Return(assert.AnError)
+	pool.Dispatch(context.Background(), sendBatches(2), failing, 1)
+	assert.Len(t, writer.envelopes, 2)
+	assert.Equal(t, []string{assert.AnError.Error()}, writer.envelopes[0].ErrorChain)
+	assert.Equal(t, 1, writer.envelopes[0].Attempts)
+
+	replayed := make(chan common.DetailedLogsBatch, 1)
+	replayed <- common.DetailedLogsBatch{{CommonData: common.Common{Attributes: common.LogAttributes{common.RetryAttemptAttribute: 2}}}}
+	close(replayed)
+	pool.Dispatch(context.Background(), replayed, failing, 1)
+	assert.Equal(t, 3, writer.envelopes[2].Attempts)
+}
+
+// TestWorkerPoolDeadLetterPayload tests that rejected payloads are written untransformed when a DLQ is configured.
+func TestWorkerPoolDeadLetterPayload(t *testing.T) {
+	pool := NewWorkerPool(1, 1)
+	name, err := pool.DeadLetterPayload(context.Background(), json.RawMessage(`[{"x":1}]`), assert.AnError)
+	assert.NoError(t, err)
+	assert.Empty(t, name)
+
+	writer := &recordingDeadLetterWriter{}
+	pool.SetDeadLetterWriter(writer)
+	name, err = pool.DeadLetterPayload(context.Background(), json.RawMessage(`[{"x":1}]`), assert.AnError)
+	assert.NoError(t, err)
+	assert.Equal(t, "dlq/object.json", name)
+	assert.Len(t, writer.envelopes, 1)
+	assert.JSONEq(t, `[{"x":1}]`, string(writer.envelopes[0].Original))
+	assert.Empty(t, writer.envelopes[0].Transformed)
+	assert.Equal(t, []string{assert.AnError.Error()}, writer.envelopes[0].ErrorChain)
+}
+
+// aliasBatches returns a closed channel holding a batch of each account route alias.
+func aliasBatches(aliases ...string) chan common.DetailedLogsBatch {
+	channel := make(chan common.DetailedLogsBatch, len(aliases))
+	for _, alias := range aliases {
+		channel <- common.DetailedLogsBatch{{CommonData: common.Common{Attributes: common.LogAttributes{common.AccountAliasAttribute: alias}}}}
+	}
+	close(channel)
+	return channel
+}
+
+// TestWorkerPoolFailsFastOnAuthErrors tests that the batches of a route whose license key was rejected are
+// dead-lettered without posting, while other routes and later invocations still post.
+func TestWorkerPoolFailsFastOnAuthErrors(t *testing.T) {
+	pool := NewWorkerPool(1, 1)
+	writer := &recordingDeadLetterWriter{}
+	pool.SetDeadLetterWriter(writer)
+	authErr := &LogAPIError{StatusCode: 403, Class: ErrorClassAuth}
+
+	client := new(MockNRClient)
+	client.On("CreateLogEntry", mock.MatchedBy(func(batch common.DetailedLogsBatch) bool {
+		return batchAlias(batch) == "sec"
+	})).Return(authErr)
+	client.On("CreateLogEntry", mock.Anything).Return(nil)
+
+	pool.Dispatch(context.Background(), aliasBatches("sec", "", "sec", "sec"), client, 1)
+	client.AssertNumberOfCalls(t, "CreateLogEntry", 2)
+	assert.Len(t, writer.envelopes, 3)
+
+	pool.Dispatch(context.Background(), aliasBatches("sec"), client, 1)
+	client.AssertNumberOfCalls(t, "CreateLogEntry", 3)
+}
+
+// TestWorkerPoolStartsWorkersOnDemand tests that workers are only started as invocations need them.
+func TestWorkerPoolStartsWorkersOnDemand(t *testing.T) {
+	pool := NewWorkerPool(4, 4)
+	mockNRClient := new(MockNRClient)
+	mockNRClient.On("CreateLogEntry", mock.Anything).Return(nil)
+
+	pool.Dispatch(context.Background(), sendBatches(1), mockNRClient, 1)
+	assert.Equal(t, 1, pool.running)
+
+	pool.Dispatch(context.Background(), sendBatches(3), mockNRClient, 3)
+	assert.Equal(t, 3, pool.running)
+
+	pool.Dispatch(context.Background(), sendBatches(2), mockNRClient, 10)
+	assert.Equal(t, 4, pool.running)
+	mockNRClient.AssertNumberOfCalls(t, "CreateLogEntry", 6)
+}
+
+// TestWorkerPoolVerifies tests that sampled batches are verified before the invocation is released, and that the
+// verification is abandoned with the invocation.
+func TestWorkerPoolVerifies(t *testing.T) {
+	delay, timeout := verificationDelay, verificationTimeout
+	defer func() { verificationDelay, verificationTimeout = delay, timeout }()
+	verificationDelay = time.Millisecond
+	metrics.Default.Reset()
+
+	mockNRClient := new(MockNRClient)
+	mockNRClient.On("CreateLogEntry", mock.Anything).Return(nil)
+	critical := func() chan common.DetailedLogsBatch {
+		channel := make(chan common.DetailedLogsBatch, 1)
+		channel <- criticalBatch("ocid1.loggroup.critical", common.LogAttributes{})
+		close(channel)
+		return channel
+	}
+
+	events := &recordingEventSender{}
+	pool := NewWorkerPool(1, 1)
+	pool.SetVerifier(NewVerifier(&fakeQuerier{counts: []float64{2}}, events, 1, []string{"ocid1.loggroup.critical"}, 100))
+	pool.Dispatch(context.Background(), critical(), mockNRClient, 1)
+	assert.Len(t, events.events, 1)
+	assert.Equal(t, true, events.events[0]["verified"])
+
+	verificationDelay = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	pool.Dispatch(ctx, critical(), mockNRClient, 1)
+	assert.Len(t, events.events, 1)
+	assert.Equal(t, int64(1), metrics.Default.Counter("verification.abandoned").Value())
+
+	verificationTimeout = time.Millisecond
+	pool.Dispatch(context.Background(), critical(), mockNRClient, 1)
+	assert.Len(t, events.events, 1)
+	assert.Equal(t, int64(2), metrics.Default.Counter("verification.abandoned").Value())
+}
+
+// TestWorkerCount tests the worker count chosen for a payload.
+func TestWorkerCount(t *testing.T) {
+	tests := []struct {
+		name        string
+		payloadSize int
+		recordCount int
+		maxWorkers  int
+		expected    int
+	}{
+		{"small payload uses one worker", 2048, 3, 6, 1},
+		{"one worker per megabyte", 3*common.MaxPayloadSize + 1, 1000, 6, 4},
+		{"bounded by max workers", 20 * common.MaxPayloadSize, 1000, 6, 6},
+		{"bounded by record count", 5 * common.MaxPayloadSize, 2, 6, 2},
+		{"empty payload still uses one worker", 0, 0, 6, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, WorkerCount(tt.payloadSize, tt.recordCount, tt.maxWorkers))
+		})
+	}
+}
+
+// TestMaxWorkers tests reading the maximum worker count from the environment.
+func TestMaxWorkers(t *testing.T) {
+	t.Setenv(common.MaxWorkers, "")
+	assert.Equal(t, common.NumberOfWorkers, MaxWorkers())
+
+	t.Setenv(common.MaxWorkers, "12")
+	assert.Equal(t, 12, MaxWorkers())
+
+	t.Setenv(common.MaxWorkers, "invalid")
+	assert.Equal(t, common.NumberOfWorkers, MaxWorkers())
+}
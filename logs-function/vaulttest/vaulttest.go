@@ -0,0 +1,183 @@
+// Package vaulttest provides a fake OCI Secrets service for integration tests. The Server speaks the Secrets REST
+// API, and its Client is the oci-go-sdk secrets client pointed at the server, so that the full license key path,
+// from the SDK request to the decoding of the secret bundle, runs without OCI credentials:
+//
+//	server := vaulttest.NewServer()
+//	defer server.Close()
+//	server.PutSecret("ocid1.vaultsecret.oc1..a", `{"licenseKey":"...NRAL"}`)
+//	client, err := server.Client()
+package vaulttest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/secrets"
+)
+
+// bundlePath is the path prefix of the GetSecretBundle operation.
+const bundlePath = "/20190301/secretbundles/"
+
+// Request is a GetSecretBundle request received by the server.
+type Request struct {
+	SecretID      string
+	VersionNumber int64 // VersionNumber is the requested version, or 0 for the current one.
+}
+
+// Server is a fake OCI Secrets service holding versioned secrets in memory.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	versions map[string][]string // versions holds the values of each secret, version n at index n-1.
+	failures map[string]int      // failures holds the HTTP status the requests of a secret fail with.
+	requests []Request
+}
+
+// NewServer starts a server without secrets. The caller must Close it.
+func NewServer() *Server {
+	s := &Server{versions: map[string][]string{}, failures: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveBundle))
+	return s
+}
+
+// PutSecret adds a version holding value to the secret, creating the secret if needed, and returns the number of
+// the version, which becomes the current one.
+func (s *Server) PutSecret(secretOCID string, value string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[secretOCID] = append(s.versions[secretOCID], value)
+	return int64(len(s.versions[secretOCID]))
+}
+
+// Fail makes the requests of the secret fail with the HTTP status, or succeed again when status is 0.
+func (s *Server) Fail(secretOCID string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == 0 {
+		delete(s.failures, secretOCID)
+		return
+	}
+	s.failures[secretOCID] = status
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// serveBundle answers GetSecretBundle requests as the Secrets service does, with BASE64 content.
+func (s *Server) serveBundle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, bundlePath) {
+		writeError(w, http.StatusNotFound, "NotFound", "unknown operation "+r.Method+" "+r.URL.Path)
+		return
+	}
+	request := Request{SecretID: strings.TrimPrefix(r.URL.Path, bundlePath)}
+	if value := r.URL.Query().Get("versionNumber"); value != "" {
+		request.VersionNumber, _ = strconv.ParseInt(value, 10, 64)
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	w.Header().Set("opc-request-id", fmt.Sprintf("vaulttest-%d", len(s.requests)))
+	status := s.failures[request.SecretID]
+	versions := s.versions[request.SecretID]
+	s.mu.Unlock()
+
+	if status != 0 {
+		writeError(w, status, strings.ReplaceAll(http.StatusText(status), " ", ""), "injected failure")
+		return
+	}
+	version := request.VersionNumber
+	if version == 0 {
+		version = int64(len(versions))
+	}
+	if version < 1 || version > int64(len(versions)) {
+		writeError(w, http.StatusNotFound, "NotAuthorizedOrNotFound", "secret bundle "+request.SecretID+" not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"secretId":      request.SecretID,
+		"versionNumber": version,
+		"stages":        []string{"CURRENT"},
+		"secretBundleContent": map[string]string{
+			"contentType": "BASE64",
+			"content":     base64.StdEncoding.EncodeToString([]byte(versions[version-1])),
+		},
+	})
+}
+
+// writeError writes an OCI error response.
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"code": code, "message": message})
+}
+
+// Client is an oci-go-sdk secrets client sending its requests to a Server. Setting the region records it without
+// moving the client to the regional endpoint.
+type Client struct {
+	secrets.SecretsClient
+	Region string // Region is the region last set on the client.
+}
+
+// SetRegion records the region the caller selected.
+func (c *Client) SetRegion(region string) {
+	c.Region = region
+}
+
+// Client returns a secrets client for the server, signing requests with a throwaway key and without retries, so
+// that failures injected with Fail are seen by the caller.
+func (s *Server) Client() (*Client, error) {
+	key, err := signingKey()
+	if err != nil {
+		return nil, err
+	}
+	provider := ociCommon.NewRawConfigurationProvider("ocid1.tenancy.oc1..vaulttest", "ocid1.user.oc1..vaulttest",
+		"us-ashburn-1", "00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00", key, nil)
+	client, err := secrets.NewSecretsClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secrets client: %w", err)
+	}
+	client.Host = s.URL
+	client.HTTPClient = s.Server.Client()
+	policy := ociCommon.NoRetryPolicy()
+	client.Configuration.RetryPolicy = &policy
+	client.Configuration.CircuitBreaker = nil
+	return &Client{SecretsClient: client}, nil
+}
+
+// Signing key shared by the clients of all servers, as generating one is slow.
+var (
+	keyOnce sync.Once
+	keyPEM  string
+	keyErr  error
+)
+
+// signingKey returns a PEM-encoded RSA private key the requests are signed with.
+func signingKey() (string, error) {
+	keyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			keyErr = fmt.Errorf("failed to generate signing key: %w", err)
+			return
+		}
+		keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	})
+	return keyPEM, keyErr
+}
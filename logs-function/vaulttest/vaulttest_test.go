@@ -0,0 +1,67 @@
+package vaulttest
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	ociCommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/secrets"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestServer tests that the SDK client reads the versions of a secret and the injected failures from the server.
+func TestServer(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client, err := server.Client()
+	assert.NoError(t, err)
+	client.SetRegion("eu-frankfurt-1")
+	assert.Equal(t, "eu-frankfurt-1", client.Region)
+
+	assert.Equal(t, int64(1), server.PutSecret("ocid1.vaultsecret.a", "first"))
+	assert.Equal(t, int64(2), server.PutSecret("ocid1.vaultsecret.a", "second"))
+
+	tests := []struct {
+		name            string
+		secretOCID      string
+		version         int64
+		failure         int
+		expectedContent string
+		expectedVersion int64
+		expectedStatus  int
+	}{
+		{"current version", "ocid1.vaultsecret.a", 0, 0, "second", 2, 0},
+		{"pinned version", "ocid1.vaultsecret.a", 1, 0, "first", 1, 0},
+		{"unknown version", "ocid1.vaultsecret.a", 3, 0, "", 0, http.StatusNotFound},
+		{"unknown secret", "ocid1.vaultsecret.b", 0, 0, "", 0, http.StatusNotFound},
+		{"injected failure", "ocid1.vaultsecret.a", 0, http.StatusInternalServerError, "", 0, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.Fail(tt.secretOCID, tt.failure)
+			defer server.Fail(tt.secretOCID, 0)
+			request := secrets.GetSecretBundleRequest{SecretId: ociCommon.String(tt.secretOCID)}
+			if tt.version > 0 {
+				request.VersionNumber = ociCommon.Int64(tt.version)
+			}
+
+			response, err := client.GetSecretBundle(context.Background(), request)
+			assert.Equal(t, Request{SecretID: tt.secretOCID, VersionNumber: tt.version}, server.Requests()[len(server.Requests())-1])
+			if tt.expectedStatus != 0 {
+				serviceErr, ok := ociCommon.IsServiceError(err)
+				assert.True(t, ok)
+				assert.Equal(t, tt.expectedStatus, serviceErr.GetHTTPStatusCode())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedVersion, *response.VersionNumber)
+			content, ok := response.SecretBundleContent.(secrets.Base64SecretBundleContentDetails)
+			assert.True(t, ok)
+			decoded, err := base64.StdEncoding.DecodeString(*content.Content)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedContent, string(decoded))
+		})
+	}
+}